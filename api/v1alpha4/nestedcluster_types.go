@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha4
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 )
@@ -24,8 +25,37 @@ import (
 // NestedClusterSpec defines the desired state of NestedCluster.
 type NestedClusterSpec struct {
 	// ControlPlaneEndpoint represents the endpoint used to communicate with the control plane.
+	// If it is not set, it is derived from the APIServerEndpoint.
 	// +optional
 	ControlPlaneEndpoint clusterv1.APIEndpoint `json:"controlPlaneEndpoint"`
+
+	// APIServerEndpoint configures how the ControlPlaneEndpoint is derived
+	// if it is not set, defaults to the ClusterIP of the apiserver Service.
+	// +optional
+	APIServerEndpoint *APIServerEndpoint `json:"apiserverEndpoint,omitempty"`
+}
+
+// APIServerEndpoint defines how the apiserver of the NestedCluster is
+// exposed.
+type APIServerEndpoint struct {
+	// ServiceType is the type of the apiserver Service, the endpoint is the
+	// ClusterIP, the address of a node and the node port, or the first
+	// LoadBalancer ingress of the Service.
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	// +kubebuilder:default=ClusterIP
+	// +optional
+	ServiceType corev1.ServiceType `json:"serviceType,omitempty"`
+
+	// Hostname is the hostname of an ingress or a gateway that routes to
+	// the apiserver Service, it takes precedence over the Service.
+	// +optional
+	Hostname string `json:"hostname,omitempty"`
+
+	// Port is the port of the Hostname, defaults to 443.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port int32 `json:"port,omitempty"`
 }
 
 // NestedClusterStatus defines the observed state of NestedCluster.
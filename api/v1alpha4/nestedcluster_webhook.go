@@ -49,7 +49,13 @@ func (r *NestedCluster) ValidateUpdate(old runtime.Object) error {
 	var allErrs field.ErrorList
 	oldNestedcluster := old.(*NestedCluster)
 
-	if !reflect.DeepEqual(r.Spec, oldNestedcluster.Spec) {
+	// the ControlPlaneEndpoint can be populated once by the controller.
+	oldSpec := oldNestedcluster.Spec.DeepCopy()
+	if oldSpec.ControlPlaneEndpoint.IsZero() {
+		oldSpec.ControlPlaneEndpoint = r.Spec.ControlPlaneEndpoint
+	}
+
+	if !reflect.DeepEqual(r.Spec, *oldSpec) {
 		allErrs = append(allErrs,
 			field.Invalid(field.NewPath("spec", "template", "spec"), r, NestedclusterImmutableMsg),
 		)
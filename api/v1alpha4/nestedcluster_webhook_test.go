@@ -20,13 +20,12 @@ import (
 	"testing"
 
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 )
 
 func TestNestedCluster_ValidateUpdate(t *testing.T) {
-	g := NewWithT(t)
-
 	tests := []struct {
 		name    string
 		old     *NestedCluster
@@ -78,10 +77,47 @@ func TestNestedCluster_ValidateUpdate(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "NestedCluster with populated endpoint",
+			old:  &NestedCluster{},
+			new: &NestedCluster{
+				Spec: NestedClusterSpec{
+					ControlPlaneEndpoint: clusterv1.APIEndpoint{
+						Host: "10.96.0.10",
+						Port: 6443,
+					},
+				},
+			},
+		},
+		{
+			name: "NestedCluster with cleared endpoint",
+			old: &NestedCluster{
+				Spec: NestedClusterSpec{
+					ControlPlaneEndpoint: clusterv1.APIEndpoint{
+						Host: "10.96.0.10",
+						Port: 6443,
+					},
+				},
+			},
+			new:     &NestedCluster{},
+			wantErr: true,
+		},
+		{
+			name: "NestedCluster with changed apiserver endpoint",
+			old:  &NestedCluster{},
+			new: &NestedCluster{
+				Spec: NestedClusterSpec{
+					APIServerEndpoint: &APIServerEndpoint{ServiceType: corev1.ServiceTypeLoadBalancer},
+				},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
+		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
+			g := NewWithT(t)
 			err := tt.new.ValidateUpdate(tt.old)
 			if tt.wantErr {
 				g.Expect(err).To(HaveOccurred())
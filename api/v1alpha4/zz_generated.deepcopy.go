@@ -25,6 +25,21 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *APIServerEndpoint) DeepCopyInto(out *APIServerEndpoint) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new APIServerEndpoint.
+func (in *APIServerEndpoint) DeepCopy() *APIServerEndpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(APIServerEndpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NestedCluster) DeepCopyInto(out *NestedCluster) {
 	*out = *in
@@ -88,6 +103,11 @@ func (in *NestedClusterList) DeepCopyObject() runtime.Object {
 func (in *NestedClusterSpec) DeepCopyInto(out *NestedClusterSpec) {
 	*out = *in
 	out.ControlPlaneEndpoint = in.ControlPlaneEndpoint
+	if in.APIServerEndpoint != nil {
+		in, out := &in.APIServerEndpoint, &out.APIServerEndpoint
+		*out = new(APIServerEndpoint)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NestedClusterSpec.
@@ -21,11 +21,15 @@ package controllers
 import (
 	"context"
 	"reflect"
+	"time"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	"sigs.k8s.io/cluster-api/util"
 	"sigs.k8s.io/cluster-api/util/annotations"
@@ -44,12 +48,24 @@ import (
 	controlplanev1 "sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/api/v1alpha4"
 )
 
-// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters,verbs=get;list;watch
+// +kubebuilder:rbac:groups=cluster.x-k8s.io,resources=clusters,verbs=get;list;watch;patch
+//+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=nestedclusters,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=nestedclusters/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=infrastructure.cluster.x-k8s.io,resources=nestedclusters/finalizers,verbs=update
 //+kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=nestedcontrolplanes,verbs=get;list;watch
 
+const (
+	// apiserverPort is the port of the apiserver Service.
+	apiserverPort = 6443
+	// defaultHostnamePort is the port of the ingress or gateway hostname.
+	defaultHostnamePort = 443
+	// endpointRequeueInterval is how often the apiserver Service is checked
+	// while it waits for an address.
+	endpointRequeueInterval = 10 * time.Second
+)
+
 // NestedClusterReconciler reconciles a NestedCluster object.
 type NestedClusterReconciler struct {
 	client.Client
@@ -81,6 +97,7 @@ func (r *NestedClusterReconciler) SetupWithManager(ctx context.Context, mgr ctrl
 			),
 		).
 		Owns(&controlplanev1.NestedControlPlane{}).
+		Owns(&corev1.Service{}).
 		Watches(
 			&source.Kind{Type: &clusterv1.Cluster{}},
 			handler.EnqueueRequestsFromMapFunc(func(o client.Object) []reconcile.Request {
@@ -145,6 +162,34 @@ func (r *NestedClusterReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		return ctrl.Result{}, err
 	}
 
+	// Populate the ControlPlaneEndpoint of the NestedCluster and the Cluster,
+	// the NestedControlPlane waits for it before creating the components.
+	if !nc.Spec.ControlPlaneEndpoint.IsValid() {
+		endpoint := cluster.Spec.ControlPlaneEndpoint
+		if !endpoint.IsValid() {
+			if endpoint, err = r.reconcileControlPlaneEndpoint(ctx, cluster, nc, ncp); err != nil {
+				return ctrl.Result{}, err
+			}
+			if !endpoint.IsValid() {
+				log.Info("waiting for the apiserver Service to get an address")
+				return ctrl.Result{RequeueAfter: endpointRequeueInterval}, nil
+			}
+		}
+		patch := client.MergeFrom(nc.DeepCopy())
+		nc.Spec.ControlPlaneEndpoint = endpoint
+		if err := r.Patch(ctx, nc, patch); err != nil {
+			return ctrl.Result{}, err
+		}
+		log.Info("populated the ControlPlaneEndpoint", "endpoint", endpoint.String())
+	}
+	if !cluster.Spec.ControlPlaneEndpoint.IsValid() {
+		patch := client.MergeFrom(cluster.DeepCopy())
+		cluster.Spec.ControlPlaneEndpoint = nc.Spec.ControlPlaneEndpoint
+		if err := r.Patch(ctx, cluster, patch); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	if !nc.Status.Ready && ncp.Status.Ready && ncp.Status.Initialized {
 		nc.Status.Ready = true
 		if err := r.Status().Update(ctx, nc); err != nil {
@@ -155,3 +200,138 @@ func (r *NestedClusterReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 
 	return ctrl.Result{}, nil
 }
+
+// reconcileControlPlaneEndpoint derives the ControlPlaneEndpoint from the
+// APIServerEndpoint of the NestedCluster. The apiserver Service is created
+// ahead of the NestedAPIServer with the desired type and is owned by the
+// NestedCluster, the NestedAPIServer only reconciles its selector and ports
+// and keeps its type. An empty endpoint is returned if the Service does not
+// have an address yet.
+func (r *NestedClusterReconciler) reconcileControlPlaneEndpoint(ctx context.Context,
+	cluster *clusterv1.Cluster, nc *infrav1.NestedCluster, ncp *controlplanev1.NestedControlPlane) (clusterv1.APIEndpoint, error) {
+	source := nc.Spec.APIServerEndpoint
+	if source == nil {
+		source = &infrav1.APIServerEndpoint{}
+	}
+	if source.Hostname != "" {
+		port := source.Port
+		if port == 0 {
+			port = defaultHostnamePort
+		}
+		return clusterv1.APIEndpoint{Host: source.Hostname, Port: port}, nil
+	}
+	if ncp.Spec.APIServerRef == nil {
+		return clusterv1.APIEndpoint{}, nil
+	}
+
+	svc := &corev1.Service{}
+	err := r.Get(ctx, types.NamespacedName{
+		Namespace: ncp.GetNamespace(),
+		Name:      cluster.GetName() + "-apiserver",
+	}, svc)
+	switch {
+	case apierrors.IsNotFound(err):
+		svc = genAPIServerService(cluster.GetName(), ncp.GetNamespace(), ncp.Spec.APIServerRef.Name, source.ServiceType)
+		if err := ctrl.SetControllerReference(nc, svc, r.Scheme); err != nil {
+			return clusterv1.APIEndpoint{}, err
+		}
+		if err := r.Create(ctx, svc); err != nil {
+			return clusterv1.APIEndpoint{}, err
+		}
+	case err != nil:
+		return clusterv1.APIEndpoint{}, err
+	}
+	var nodes corev1.NodeList
+	if svc.Spec.Type == corev1.ServiceTypeNodePort {
+		if err := r.List(ctx, &nodes); err != nil {
+			return clusterv1.APIEndpoint{}, err
+		}
+	}
+	return serviceEndpoint(svc, nodes.Items), nil
+}
+
+// genAPIServerService generates the apiserver Service the same way as the
+// NestedAPIServer does, except for the type.
+func genAPIServerService(clusterName, namespace, componentName string, serviceType corev1.ServiceType) *corev1.Service {
+	if serviceType == "" {
+		serviceType = corev1.ServiceTypeClusterIP
+	}
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clusterName + "-apiserver",
+			Namespace: namespace,
+			Labels: map[string]string{
+				"component-name": componentName,
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{
+				"component-name": componentName,
+			},
+			Type: serviceType,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "api",
+					Port:       apiserverPort,
+					Protocol:   corev1.ProtocolTCP,
+					TargetPort: intstr.FromString("api"),
+				},
+			},
+		},
+	}
+}
+
+// serviceEndpoint returns the first LoadBalancer ingress of a LoadBalancer
+// Service, the address of a node and the node port of a NodePort Service, or
+// the ClusterIP of other Services.
+func serviceEndpoint(svc *corev1.Service, nodes []corev1.Node) clusterv1.APIEndpoint {
+	port := int32(apiserverPort)
+	nodePort := int32(0)
+	for _, p := range svc.Spec.Ports {
+		if p.Name == "api" {
+			port = p.Port
+			nodePort = p.NodePort
+		}
+	}
+	host := ""
+	switch svc.Spec.Type {
+	case corev1.ServiceTypeLoadBalancer:
+		for _, ingress := range svc.Status.LoadBalancer.Ingress {
+			if ingress.Hostname != "" {
+				host = ingress.Hostname
+				break
+			}
+			if ingress.IP != "" {
+				host = ingress.IP
+				break
+			}
+		}
+	case corev1.ServiceTypeNodePort:
+		port = nodePort
+		if nodePort != 0 {
+			host = nodeAddress(nodes)
+		}
+	default:
+		if svc.Spec.ClusterIP != corev1.ClusterIPNone {
+			host = svc.Spec.ClusterIP
+		}
+	}
+	return clusterv1.APIEndpoint{Host: host, Port: port}
+}
+
+// nodeAddress returns the first external IP of the nodes, or the first
+// internal IP if no node has an external IP.
+func nodeAddress(nodes []corev1.Node) string {
+	internalIP := ""
+	for _, node := range nodes {
+		for _, addr := range node.Status.Addresses {
+			switch {
+			case addr.Type == corev1.NodeExternalIP && addr.Address != "":
+				return addr.Address
+			case addr.Type == corev1.NodeInternalIP && internalIP == "":
+				internalIP = addr.Address
+			}
+		}
+	}
+	return internalIP
+}
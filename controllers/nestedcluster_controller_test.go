@@ -0,0 +1,120 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+func TestServiceEndpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		svc      *corev1.Service
+		nodes    []corev1.Node
+		expected clusterv1.APIEndpoint
+	}{
+		{
+			name:     "ClusterIP",
+			svc:      genServiceWithAddress(corev1.ServiceTypeClusterIP, "10.96.0.10"),
+			expected: clusterv1.APIEndpoint{Host: "10.96.0.10", Port: 6443},
+		},
+		{
+			name:     "ClusterIP not allocated",
+			svc:      genServiceWithAddress(corev1.ServiceTypeClusterIP, ""),
+			expected: clusterv1.APIEndpoint{Port: 6443},
+		},
+		{
+			name: "LoadBalancer with hostname",
+			svc: func() *corev1.Service {
+				svc := genServiceWithAddress(corev1.ServiceTypeLoadBalancer, "10.96.0.10")
+				svc.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{Hostname: "lb.example.com"}}
+				return svc
+			}(),
+			expected: clusterv1.APIEndpoint{Host: "lb.example.com", Port: 6443},
+		},
+		{
+			name: "LoadBalancer with ip",
+			svc: func() *corev1.Service {
+				svc := genServiceWithAddress(corev1.ServiceTypeLoadBalancer, "10.96.0.10")
+				svc.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}}
+				return svc
+			}(),
+			expected: clusterv1.APIEndpoint{Host: "1.2.3.4", Port: 6443},
+		},
+		{
+			name: "NodePort",
+			svc: func() *corev1.Service {
+				svc := genServiceWithAddress(corev1.ServiceTypeNodePort, "10.96.0.10")
+				svc.Spec.Ports[0].NodePort = 30443
+				return svc
+			}(),
+			nodes: []corev1.Node{
+				genNodeWithAddresses(corev1.NodeAddress{Type: corev1.NodeHostName, Address: "node-0"}),
+				genNodeWithAddresses(
+					corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "192.168.0.11"},
+					corev1.NodeAddress{Type: corev1.NodeExternalIP, Address: "1.2.3.5"},
+				),
+			},
+			expected: clusterv1.APIEndpoint{Host: "1.2.3.5", Port: 30443},
+		},
+		{
+			name: "NodePort with internal ip",
+			svc: func() *corev1.Service {
+				svc := genServiceWithAddress(corev1.ServiceTypeNodePort, "10.96.0.10")
+				svc.Spec.Ports[0].NodePort = 30443
+				return svc
+			}(),
+			nodes: []corev1.Node{
+				genNodeWithAddresses(corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "192.168.0.10"}),
+			},
+			expected: clusterv1.APIEndpoint{Host: "192.168.0.10", Port: 30443},
+		},
+		{
+			name:     "NodePort not allocated",
+			svc:      genServiceWithAddress(corev1.ServiceTypeNodePort, "10.96.0.10"),
+			nodes:    []corev1.Node{genNodeWithAddresses(corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "192.168.0.10"})},
+			expected: clusterv1.APIEndpoint{},
+		},
+		{
+			name:     "LoadBalancer pending",
+			svc:      genServiceWithAddress(corev1.ServiceTypeLoadBalancer, "10.96.0.10"),
+			expected: clusterv1.APIEndpoint{Port: 6443},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := serviceEndpoint(tt.svc, tt.nodes); got != tt.expected {
+				t.Errorf("expected endpoint %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func genServiceWithAddress(serviceType corev1.ServiceType, clusterIP string) *corev1.Service {
+	svc := genAPIServerService("c", "default", "c-apiserver", serviceType)
+	svc.Spec.ClusterIP = clusterIP
+	return svc
+}
+
+func genNodeWithAddresses(addresses ...corev1.NodeAddress) corev1.Node {
+	return corev1.Node{Status: corev1.NodeStatus{Addresses: addresses}}
+}
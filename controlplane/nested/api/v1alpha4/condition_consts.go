@@ -0,0 +1,50 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha4
+
+import clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+
+// Conditions and condition Reasons for the NestedControlPlane object.
+
+const (
+	// NestedComponentsDeletedCondition reports the progress of the ordered
+	// teardown of the nested components on the deletion of the
+	// NestedControlPlane.
+	NestedComponentsDeletedCondition clusterv1.ConditionType = "NestedComponentsDeleted"
+
+	// DeletingControllerManagerReason (Severity=Info) documents a
+	// NestedControlPlane stopping the NestedControllerManager.
+	DeletingControllerManagerReason = "DeletingControllerManager"
+
+	// BackingUpEtcdReason (Severity=Info) documents a NestedControlPlane
+	// taking a snapshot of the NestedEtcd before it is deleted.
+	BackingUpEtcdReason = "BackingUpEtcd"
+
+	// EtcdBackupFailedReason (Severity=Error) documents a NestedControlPlane
+	// failing to take a snapshot of the NestedEtcd, the deletion is blocked
+	// until the failed backup Job is deleted to retry, or the
+	// EtcdBackupOnDelete is unset.
+	EtcdBackupFailedReason = "EtcdBackupFailed"
+
+	// DeletingAPIServerReason (Severity=Info) documents a NestedControlPlane
+	// deleting the NestedAPIServer.
+	DeletingAPIServerReason = "DeletingAPIServer"
+
+	// DeletingEtcdReason (Severity=Info) documents a NestedControlPlane
+	// deleting the NestedEtcd.
+	DeletingEtcdReason = "DeletingEtcd"
+)
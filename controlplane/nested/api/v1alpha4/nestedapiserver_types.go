@@ -28,6 +28,66 @@ type NestedAPIServerSpec struct {
 	// required for creating the component.
 	// +optional
 	NestedComponentSpec `json:",inline"`
+
+	// Audit configures the audit logging of the apiserver.
+	// +optional
+	Audit *APIServerAudit `json:"audit,omitempty"`
+}
+
+// APIServerAudit defines the audit policy of the apiserver and the backends
+// the audit events are sent to.
+type APIServerAudit struct {
+	// PolicyRef is the reference to the ConfigMap in the namespace of the
+	// NestedAPIServer that holds the audit policy under the policy.yaml key.
+	PolicyRef corev1.LocalObjectReference `json:"policyRef"`
+
+	// Log writes the audit events to a log file.
+	// +optional
+	Log *AuditLogBackend `json:"log,omitempty"`
+
+	// Webhook sends the audit events to a webhook, e.g. a collector running
+	// in the management cluster.
+	// +optional
+	Webhook *AuditWebhookBackend `json:"webhook,omitempty"`
+}
+
+// AuditLogBackend defines the log file the audit events are written to.
+type AuditLogBackend struct {
+	// Path is the absolute path of the log file in the apiserver container,
+	// "-" writes the events to the standard output. Defaults to "-".
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// MaxAge is the maximum number of days to retain the rotated log files.
+	// +optional
+	MaxAge int32 `json:"maxAge,omitempty"`
+
+	// MaxBackups is the maximum number of rotated log files to retain.
+	// +optional
+	MaxBackups int32 `json:"maxBackups,omitempty"`
+
+	// MaxSize is the maximum size in megabytes of the log file before it
+	// gets rotated.
+	// +optional
+	MaxSize int32 `json:"maxSize,omitempty"`
+}
+
+// AuditWebhookBackend defines the webhook the audit events are sent to.
+type AuditWebhookBackend struct {
+	// URL is the https URL of the webhook, e.g.
+	// https://audit-collector.audit-system.svc:8443/events.
+	URL string `json:"url"`
+
+	// CABundle is the PEM encoded CA bundle used to verify the certificate of
+	// the webhook, the system roots are used if it is empty.
+	// +optional
+	CABundle []byte `json:"caBundle,omitempty"`
+
+	// TagCluster adds the name and the namespace of the cluster to the URL
+	// as the cluster and namespace query parameters, so that a collector
+	// shared by the clusters can tell their audit events apart.
+	// +optional
+	TagCluster bool `json:"tagCluster,omitempty"`
 }
 
 // NestedAPIServerStatus defines the observed state of NestedAPIServer.
@@ -0,0 +1,302 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha4
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/version"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	addonv1alpha1 "sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/apis/v1alpha1"
+
+	"sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/kubeadm"
+	"sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/patch"
+)
+
+// validateNestedComponentSpec validates the NestedComponentSpec of the
+// NestedComponent of the kind.
+func validateNestedComponentSpec(kind ComponentKind, name string, spec NestedComponentSpec) error {
+	return toInvalidError(kind, name, nestedComponentSpecErrors(kind, spec))
+}
+
+// nestedComponentSpecErrors returns the errors of the NestedComponentSpec of
+// the NestedComponent of the kind.
+func nestedComponentSpecErrors(kind ComponentKind, spec NestedComponentSpec) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, raw := range spec.Patches {
+		if _, err := patch.Parse(raw); err != nil {
+			allErrs = append(allErrs,
+				field.Invalid(field.NewPath("spec", "patches").Index(i), string(raw.Raw), err.Error()))
+		}
+	}
+	if spec.Replicas < 0 {
+		allErrs = append(allErrs,
+			field.Invalid(field.NewPath("spec", "replicas"), spec.Replicas, "must be greater than or equal to 0"))
+	}
+	allErrs = append(allErrs, componentVersionErrors(kind, spec.CommonSpec)...)
+	return allErrs
+}
+
+// componentVersionErrors returns the errors of the version and the channel of
+// the NestedComponent of the kind. The version of the etcd is the tag of the
+// etcd image, the others are Kubernetes versions.
+func componentVersionErrors(kind ComponentKind, spec addonv1alpha1.CommonSpec) field.ErrorList {
+	var allErrs field.ErrorList
+	if spec.Channel != "" && spec.Channel != kubeadm.StableChannel {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("spec", "channel"), spec.Channel,
+			[]string{kubeadm.StableChannel}))
+	}
+	if spec.Version == "" {
+		return allErrs
+	}
+	fldPath := field.NewPath("spec", "version")
+	if kind == Etcd {
+		if _, err := version.ParseSemantic(spec.Version); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath, spec.Version,
+				"must be a tag of the etcd image, e.g. 3.4.13-0"))
+		}
+		return allErrs
+	}
+	if _, err := kubeadm.ParseKubernetesVersion(spec.Version); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath, spec.Version, err.Error()))
+	}
+	return allErrs
+}
+
+// validateNestedEtcdSpec validates the NestedEtcdSpec.
+func validateNestedEtcdSpec(name string, spec NestedEtcdSpec) error {
+	allErrs := nestedComponentSpecErrors(Etcd, spec.NestedComponentSpec)
+	if spec.Backup != nil {
+		if spec.Backup.Schedule == "" {
+			allErrs = append(allErrs, field.Required(field.NewPath("spec", "backup", "schedule"), ""))
+		}
+		allErrs = append(allErrs, etcdBackupStorageErrors(field.NewPath("spec", "backup", "storage"), spec.Backup.Storage)...)
+	}
+	if spec.Restore != nil {
+		if spec.Restore.Snapshot == "" {
+			allErrs = append(allErrs, field.Required(field.NewPath("spec", "restore", "snapshot"), ""))
+		}
+		allErrs = append(allErrs, etcdBackupStorageErrors(field.NewPath("spec", "restore", "storage"), spec.Restore.Storage)...)
+	}
+	return toInvalidError(Etcd, name, allErrs)
+}
+
+// etcdBackupStorageErrors returns the errors of the EtcdBackupStorage, which
+// must have exactly one storage.
+func etcdBackupStorageErrors(fldPath *field.Path, storage EtcdBackupStorage) field.ErrorList {
+	var allErrs field.ErrorList
+	switch {
+	case storage.PersistentVolumeClaim == nil && storage.S3 == nil:
+		allErrs = append(allErrs, field.Required(fldPath, "one of persistentVolumeClaim and s3 must be specified"))
+	case storage.PersistentVolumeClaim != nil && storage.S3 != nil:
+		allErrs = append(allErrs, field.Forbidden(fldPath, "only one of persistentVolumeClaim and s3 can be specified"))
+	case storage.S3 != nil:
+		if storage.S3.Bucket == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("s3", "bucket"), ""))
+		}
+		if storage.S3.CredentialsSecretRef.Name == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("s3", "credentialsSecretRef", "name"), ""))
+		}
+	}
+	return allErrs
+}
+
+// validateNestedAPIServerSpec validates the NestedAPIServerSpec.
+func validateNestedAPIServerSpec(name string, spec NestedAPIServerSpec) error {
+	allErrs := nestedComponentSpecErrors(APIServer, spec.NestedComponentSpec)
+	if spec.Audit != nil {
+		allErrs = append(allErrs, apiServerAuditErrors(field.NewPath("spec", "audit"), spec.Audit)...)
+	}
+	return toInvalidError(APIServer, name, allErrs)
+}
+
+// apiServerAuditErrors returns the errors of the APIServerAudit, which must
+// have at least one backend.
+func apiServerAuditErrors(fldPath *field.Path, audit *APIServerAudit) field.ErrorList {
+	var allErrs field.ErrorList
+	if audit.PolicyRef.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("policyRef", "name"), ""))
+	}
+	if audit.Log == nil && audit.Webhook == nil {
+		allErrs = append(allErrs, field.Required(fldPath, "at least one of log and webhook must be specified"))
+	}
+	if log := audit.Log; log != nil {
+		if log.Path != "" && log.Path != "-" && (!path.IsAbs(log.Path) || path.Dir(log.Path) == "/") {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("log", "path"), log.Path,
+				`must be "-" or an absolute path of a file in a directory, e.g. /var/log/kubernetes/audit.log`))
+		}
+		for _, limit := range []struct {
+			name  string
+			value int32
+		}{{"maxAge", log.MaxAge}, {"maxBackups", log.MaxBackups}, {"maxSize", log.MaxSize}} {
+			if limit.value < 0 {
+				allErrs = append(allErrs, field.Invalid(fldPath.Child("log", limit.name), limit.value,
+					"must be greater than or equal to 0"))
+			}
+		}
+	}
+	if webhook := audit.Webhook; webhook != nil {
+		if u, err := url.Parse(webhook.URL); err != nil || u.Scheme != "https" || u.Host == "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("webhook", "url"), webhook.URL,
+				"must be an https URL, e.g. https://audit-collector.audit-system.svc:8443/events"))
+		}
+	}
+	return allErrs
+}
+
+// toInvalidError converts the errors of the NestedComponent of the kind to an
+// Invalid error, or returns nil if there is no error.
+func toInvalidError(kind ComponentKind, name string, allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind(string(kind)).GroupKind(), name, allErrs)
+}
+
+// SetupWebhookWithManager sets up the webhook of the NestedEtcd.
+func (r *NestedEtcd) SetupWebhookWithManager(mgr manager.Manager) error {
+	return builder.WebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-controlplane-cluster-x-k8s-io-v1alpha4-nestedetcd,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,groups=controlplane.cluster.x-k8s.io,resources=nestedetcds,versions=v1alpha4,name=validation.nestedetcds.controlplane.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1beta1
+
+var _ webhook.Validator = &NestedEtcd{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (r *NestedEtcd) ValidateCreate() error {
+	return validateNestedEtcdSpec(r.Name, r.Spec)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (r *NestedEtcd) ValidateUpdate(old runtime.Object) error {
+	if err := validateNestedEtcdSpec(r.Name, r.Spec); err != nil {
+		return err
+	}
+	oldEtcd, ok := old.(*NestedEtcd)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a NestedEtcd but got a %T", old))
+	}
+	// the restore is applied when the etcd StatefulSet is created, along
+	// with the PersistentVolumeClaims of its data directory.
+	if !apiequality.Semantic.DeepEqual(r.Spec.Restore, oldEtcd.Spec.Restore) {
+		return toInvalidError(Etcd, r.Name, field.ErrorList{
+			field.Forbidden(field.NewPath("spec", "restore"), "field is immutable"),
+		})
+	}
+	return nil
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
+func (r *NestedEtcd) ValidateDelete() error {
+	return nil
+}
+
+// SetupWebhookWithManager sets up the webhook of the NestedAPIServer.
+func (r *NestedAPIServer) SetupWebhookWithManager(mgr manager.Manager) error {
+	webhookReader = mgr.GetAPIReader()
+	return builder.WebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-controlplane-cluster-x-k8s-io-v1alpha4-nestedapiserver,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,groups=controlplane.cluster.x-k8s.io,resources=nestedapiservers,versions=v1alpha4,name=validation.nestedapiservers.controlplane.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1beta1
+
+var _ webhook.Validator = &NestedAPIServer{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (r *NestedAPIServer) ValidateCreate() error {
+	return r.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (r *NestedAPIServer) ValidateUpdate(old runtime.Object) error {
+	return r.validate()
+}
+
+// validate validates the NestedAPIServerSpec, and the replicas against the
+// SQLite datastore of the owner NestedControlPlane, if any.
+func (r *NestedAPIServer) validate() error {
+	if err := validateNestedAPIServerSpec(r.Name, r.Spec); err != nil {
+		return err
+	}
+	if webhookReader == nil || r.Spec.Replicas <= 1 {
+		return nil
+	}
+	for _, owner := range r.GetOwnerReferences() {
+		if owner.APIVersion != GroupVersion.String() || owner.Kind != "NestedControlPlane" {
+			continue
+		}
+		var ncp NestedControlPlane
+		if err := webhookReader.Get(context.TODO(),
+			types.NamespacedName{Namespace: r.Namespace, Name: owner.Name}, &ncp); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return apierrors.NewInternalError(err)
+		}
+		if ncp.Spec.Datastore != nil && ncp.Spec.Datastore.SQLite != nil {
+			return toInvalidError(APIServer, r.Name, field.ErrorList{
+				field.Forbidden(field.NewPath("spec", "replicas"),
+					"the SQLite datastore of the NestedControlPlane needs a single replica"),
+			})
+		}
+	}
+	return nil
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
+func (r *NestedAPIServer) ValidateDelete() error {
+	return nil
+}
+
+// SetupWebhookWithManager sets up the webhook of the NestedControllerManager.
+func (r *NestedControllerManager) SetupWebhookWithManager(mgr manager.Manager) error {
+	return builder.WebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-controlplane-cluster-x-k8s-io-v1alpha4-nestedcontrollermanager,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,groups=controlplane.cluster.x-k8s.io,resources=nestedcontrollermanagers,versions=v1alpha4,name=validation.nestedcontrollermanagers.controlplane.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1beta1
+
+var _ webhook.Validator = &NestedControllerManager{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (r *NestedControllerManager) ValidateCreate() error {
+	return validateNestedComponentSpec(ControllerManager, r.Name, r.Spec.NestedComponentSpec)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (r *NestedControllerManager) ValidateUpdate(old runtime.Object) error {
+	return validateNestedComponentSpec(ControllerManager, r.Name, r.Spec.NestedComponentSpec)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
+func (r *NestedControllerManager) ValidateDelete() error {
+	return nil
+}
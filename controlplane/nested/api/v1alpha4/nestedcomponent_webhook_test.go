@@ -0,0 +1,75 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha4
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	addonv1alpha1 "sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/apis/v1alpha1"
+)
+
+func TestNestedComponent_ValidateVersion(t *testing.T) {
+	tests := []struct {
+		name      string
+		component func(spec NestedComponentSpec) webhook.Validator
+		version   string
+		channel   string
+		wantErr   bool
+	}{
+		{name: "NestedEtcd with the default version", component: newTestNestedEtcd},
+		{name: "NestedEtcd with the stable channel", component: newTestNestedEtcd, channel: "stable"},
+		{name: "NestedEtcd with an unknown channel", component: newTestNestedEtcd, channel: "rapid", wantErr: true},
+		{name: "NestedEtcd with an image tag", component: newTestNestedEtcd, version: "3.4.13-0"},
+		{name: "NestedEtcd with an invalid image tag", component: newTestNestedEtcd, version: "3.4", wantErr: true},
+		{name: "NestedAPIServer with a version", component: newTestNestedAPIServer, version: "v1.21.1"},
+		{name: "NestedAPIServer with an invalid version", component: newTestNestedAPIServer, version: "latest", wantErr: true},
+		{name: "NestedAPIServer with an unsupported version", component: newTestNestedAPIServer, version: "v1.18.20", wantErr: true},
+		{name: "NestedAPIServer with an unknown channel", component: newTestNestedAPIServer, channel: "rapid", wantErr: true},
+		{name: "NestedControllerManager with a version", component: newTestNestedControllerManager, version: "1.22.2"},
+		{name: "NestedControllerManager with an invalid version", component: newTestNestedControllerManager, version: "v1.22", wantErr: true},
+		{name: "NestedControllerManager with an unknown channel", component: newTestNestedControllerManager, channel: "rapid", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			component := tt.component(NestedComponentSpec{
+				CommonSpec: addonv1alpha1.CommonSpec{Version: tt.version, Channel: tt.channel},
+			})
+			if tt.wantErr {
+				g.Expect(component.ValidateCreate()).NotTo(Succeed())
+				g.Expect(component.ValidateUpdate(component)).NotTo(Succeed())
+			} else {
+				g.Expect(component.ValidateCreate()).To(Succeed())
+				g.Expect(component.ValidateUpdate(component)).To(Succeed())
+			}
+		})
+	}
+}
+
+func newTestNestedEtcd(spec NestedComponentSpec) webhook.Validator {
+	return &NestedEtcd{Spec: NestedEtcdSpec{NestedComponentSpec: spec}}
+}
+
+func newTestNestedAPIServer(spec NestedComponentSpec) webhook.Validator {
+	return &NestedAPIServer{Spec: NestedAPIServerSpec{NestedComponentSpec: spec}}
+}
+
+func newTestNestedControllerManager(spec NestedComponentSpec) webhook.Validator {
+	return &NestedControllerManager{Spec: NestedControllerManagerSpec{NestedComponentSpec: spec}}
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha4
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/version"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	"sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/kubeadm"
+)
+
+// webhookReader reads the NestedControlPlane and the NestedAPIServer that
+// refer to each other while validating the replicas of the NestedAPIServer
+// the SQLite datastore needs, it is set up along with the webhooks.
+var webhookReader client.Reader
+
+// SetupWebhookWithManager sets up the webhook of the NestedControlPlane.
+func (r *NestedControlPlane) SetupWebhookWithManager(mgr manager.Manager) error {
+	webhookReader = mgr.GetAPIReader()
+	return builder.WebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-controlplane-cluster-x-k8s-io-v1alpha4-nestedcontrolplane,mutating=false,failurePolicy=fail,matchPolicy=Equivalent,groups=controlplane.cluster.x-k8s.io,resources=nestedcontrolplanes,versions=v1alpha4,name=validation.nestedcontrolplanes.controlplane.cluster.x-k8s.io,sideEffects=None,admissionReviewVersions=v1beta1
+
+var _ webhook.Validator = &NestedControlPlane{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type.
+func (r *NestedControlPlane) ValidateCreate() error {
+	return r.validate()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+func (r *NestedControlPlane) ValidateUpdate(old runtime.Object) error {
+	if err := r.validate(); err != nil {
+		return err
+	}
+	oldNCP, ok := old.(*NestedControlPlane)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected a NestedControlPlane but got a %T", old))
+	}
+	var allErrs field.ErrorList
+	// the apiserver can not read the Secrets encrypted at rest once the
+	// encryption is removed, the provider can still be changed.
+	if oldNCP.Spec.SecretEncryption != nil && r.Spec.SecretEncryption == nil {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "secretEncryption"),
+			"can not be removed once it is set"))
+	}
+	allErrs = append(allErrs, versionUpgradeErrors(field.NewPath("spec", "version"),
+		oldNCP.Spec.Version, r.Spec.Version)...)
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("NestedControlPlane").GroupKind(), r.Name, allErrs)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type.
+func (r *NestedControlPlane) ValidateDelete() error {
+	return nil
+}
+
+func (r *NestedControlPlane) validate() error {
+	var allErrs field.ErrorList
+	if r.Spec.Version != "" {
+		if _, err := kubeadm.ParseKubernetesVersion(r.Spec.Version); err != nil {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "version"), r.Spec.Version, err.Error()))
+		}
+	}
+	if r.Spec.ExternalEtcd != nil {
+		fldPath := field.NewPath("spec", "externalEtcd")
+		if r.Spec.EtcdRef != nil {
+			allErrs = append(allErrs, field.Forbidden(fldPath, "can not be set along with etcd"))
+		}
+		if r.Spec.EtcdBackupOnDelete != nil {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "etcdBackupOnDelete"),
+				"the external etcd is not backed up"))
+		}
+		allErrs = append(allErrs, externalEtcdErrors(fldPath, r.Spec.ExternalEtcd)...)
+	}
+	if r.Spec.Datastore != nil {
+		fldPath := field.NewPath("spec", "datastore")
+		if r.Spec.EtcdRef != nil || r.Spec.ExternalEtcd != nil {
+			allErrs = append(allErrs, field.Forbidden(fldPath, "can not be set along with etcd or externalEtcd"))
+		}
+		if r.Spec.EtcdBackupOnDelete != nil {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "etcdBackupOnDelete"),
+				"the kine datastore is not backed up"))
+		}
+		replicas, err := r.apiServerReplicas()
+		if err != nil {
+			return apierrors.NewInternalError(err)
+		}
+		allErrs = append(allErrs, kineDatastoreErrors(fldPath, r.Spec.Datastore, replicas)...)
+	}
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("NestedControlPlane").GroupKind(), r.Name, allErrs)
+}
+
+// versionUpgradeErrors returns the errors of changing the Kubernetes version
+// from oldVersion to newVersion, the nested components can neither be
+// downgraded nor skip a minor version, as the Kubernetes version skew policy
+// requires. The unset versions denote the DefaultKubernetesVersion.
+func versionUpgradeErrors(fldPath *field.Path, oldVersion, newVersion string) field.ErrorList {
+	if oldVersion == "" {
+		oldVersion = kubeadm.DefaultKubernetesVersion
+	}
+	if newVersion == "" {
+		newVersion = kubeadm.DefaultKubernetesVersion
+	}
+	oldV, err := version.ParseSemantic(strings.TrimPrefix(oldVersion, "v"))
+	if err != nil {
+		// the invalid old version can only be corrected.
+		return nil
+	}
+	newV, err := version.ParseSemantic(strings.TrimPrefix(newVersion, "v"))
+	if err != nil {
+		// reported by validate.
+		return nil
+	}
+	var allErrs field.ErrorList
+	switch {
+	case newV.LessThan(oldV):
+		allErrs = append(allErrs, field.Forbidden(fldPath,
+			fmt.Sprintf("can not be downgraded from %s to %s", oldVersion, newVersion)))
+	case newV.Major() != oldV.Major() || newV.Minor() > oldV.Minor()+1:
+		allErrs = append(allErrs, field.Forbidden(fldPath,
+			fmt.Sprintf("can not be upgraded from %s to %s, the minor versions can not be skipped",
+				oldVersion, newVersion)))
+	}
+	return allErrs
+}
+
+// externalEtcdErrors returns the errors of the ExternalEtcd.
+func externalEtcdErrors(fldPath *field.Path, etcd *ExternalEtcd) field.ErrorList {
+	var allErrs field.ErrorList
+	if len(etcd.Endpoints) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("endpoints"), ""))
+	}
+	for i, endpoint := range etcd.Endpoints {
+		if u, err := url.Parse(endpoint); err != nil || u.Scheme != "https" || u.Host == "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("endpoints").Index(i), endpoint,
+				"must be an https URL, e.g. https://etcd-0.example.com:2379"))
+		}
+	}
+	if etcd.CertificatesSecretRef.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("certificatesSecretRef", "name"), ""))
+	}
+	if etcd.KeyPrefix != "" && !strings.HasPrefix(etcd.KeyPrefix, "/") {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("keyPrefix"), etcd.KeyPrefix, "must start with /"))
+	}
+	return allErrs
+}
+
+// apiServerReplicas returns the replicas of the NestedAPIServer the
+// NestedControlPlane refers to, or 0 if it is not found.
+func (r *NestedControlPlane) apiServerReplicas() (int32, error) {
+	ref := r.Spec.APIServerRef
+	if webhookReader == nil || ref == nil || ref.Name == "" {
+		return 0, nil
+	}
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = r.Namespace
+	}
+	var nkas NestedAPIServer
+	if err := webhookReader.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: ref.Name}, &nkas); err != nil {
+		return 0, client.IgnoreNotFound(err)
+	}
+	return nkas.Spec.Replicas, nil
+}
+
+// kineDatastoreErrors returns the errors of the KineDatastore, which must have
+// exactly one database. The SQLite database can only be opened by a single
+// apiserver replica.
+func kineDatastoreErrors(fldPath *field.Path, datastore *KineDatastore, apiServerReplicas int32) field.ErrorList {
+	var allErrs field.ErrorList
+	switch {
+	case datastore.SQLite == nil && datastore.SQL == nil:
+		allErrs = append(allErrs, field.Required(fldPath, "one of sqlite and sql must be specified"))
+	case datastore.SQLite != nil && datastore.SQL != nil:
+		allErrs = append(allErrs, field.Forbidden(fldPath, "only one of sqlite and sql can be specified"))
+	case datastore.SQLite != nil:
+		if size := datastore.SQLite.Size; size != nil && size.Sign() <= 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("sqlite", "size"), size.String(), "must be greater than 0"))
+		}
+		if apiServerReplicas > 1 {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("sqlite"),
+				"the NestedAPIServer needs a single replica"))
+		}
+	case datastore.SQL != nil:
+		ref := datastore.SQL.EndpointSecretRef
+		if ref.Name == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("sql", "endpointSecretRef", "name"), ""))
+		}
+		if ref.Key == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("sql", "endpointSecretRef", "key"), ""))
+		}
+	}
+	return allErrs
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha4
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestNestedControlPlane_ValidateCreate(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		wantErr bool
+	}{
+		{name: "NestedControlPlane with the default version"},
+		{name: "NestedControlPlane with a version", version: "v1.21.1"},
+		{name: "NestedControlPlane with a version without the prefix", version: "1.22.2"},
+		{name: "NestedControlPlane with an invalid version", version: "latest", wantErr: true},
+		{name: "NestedControlPlane with a partial version", version: "v1.21", wantErr: true},
+		{name: "NestedControlPlane with an unsupported version", version: "v1.18.20", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ncp := &NestedControlPlane{Spec: NestedControlPlaneSpec{Version: tt.version}}
+			if tt.wantErr {
+				g.Expect(ncp.ValidateCreate()).NotTo(Succeed())
+			} else {
+				g.Expect(ncp.ValidateCreate()).To(Succeed())
+			}
+		})
+	}
+}
+
+func TestNestedControlPlane_ValidateUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		old     *NestedControlPlane
+		new     *NestedControlPlane
+		wantErr bool
+	}{
+		{
+			name: "NestedControlPlane with an unchanged version",
+			old:  &NestedControlPlane{Spec: NestedControlPlaneSpec{Version: "v1.21.1"}},
+			new:  &NestedControlPlane{Spec: NestedControlPlaneSpec{Version: "v1.21.1"}},
+		},
+		{
+			name: "NestedControlPlane upgraded to a patch version",
+			old:  &NestedControlPlane{Spec: NestedControlPlaneSpec{Version: "v1.21.1"}},
+			new:  &NestedControlPlane{Spec: NestedControlPlaneSpec{Version: "v1.21.5"}},
+		},
+		{
+			name: "NestedControlPlane upgraded to the next minor version",
+			old:  &NestedControlPlane{Spec: NestedControlPlaneSpec{Version: "v1.21.1"}},
+			new:  &NestedControlPlane{Spec: NestedControlPlaneSpec{Version: "v1.22.2"}},
+		},
+		{
+			name: "NestedControlPlane upgraded from the default version",
+			old:  &NestedControlPlane{},
+			new:  &NestedControlPlane{Spec: NestedControlPlaneSpec{Version: "v1.22.2"}},
+		},
+		{
+			name:    "NestedControlPlane upgraded to an invalid version",
+			old:     &NestedControlPlane{Spec: NestedControlPlaneSpec{Version: "v1.21.1"}},
+			new:     &NestedControlPlane{Spec: NestedControlPlaneSpec{Version: "v1.22"}},
+			wantErr: true,
+		},
+		{
+			name:    "NestedControlPlane skipping a minor version",
+			old:     &NestedControlPlane{Spec: NestedControlPlaneSpec{Version: "v1.21.1"}},
+			new:     &NestedControlPlane{Spec: NestedControlPlaneSpec{Version: "v1.23.0"}},
+			wantErr: true,
+		},
+		{
+			name:    "NestedControlPlane downgraded to a patch version",
+			old:     &NestedControlPlane{Spec: NestedControlPlaneSpec{Version: "v1.21.5"}},
+			new:     &NestedControlPlane{Spec: NestedControlPlaneSpec{Version: "v1.21.1"}},
+			wantErr: true,
+		},
+		{
+			name:    "NestedControlPlane downgraded to the previous minor version",
+			old:     &NestedControlPlane{Spec: NestedControlPlaneSpec{Version: "v1.21.1"}},
+			new:     &NestedControlPlane{Spec: NestedControlPlaneSpec{Version: "v1.20.7"}},
+			wantErr: true,
+		},
+		{
+			name:    "NestedControlPlane downgraded by unsetting the version",
+			old:     &NestedControlPlane{Spec: NestedControlPlaneSpec{Version: "v1.22.2"}},
+			new:     &NestedControlPlane{},
+			wantErr: true,
+		},
+		{
+			name:    "NestedControlPlane with the secret encryption removed",
+			old:     &NestedControlPlane{Spec: NestedControlPlaneSpec{SecretEncryption: &SecretEncryption{}}},
+			new:     &NestedControlPlane{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			if tt.wantErr {
+				g.Expect(tt.new.ValidateUpdate(tt.old)).NotTo(Succeed())
+			} else {
+				g.Expect(tt.new.ValidateUpdate(tt.old)).To(Succeed())
+			}
+		})
+	}
+}
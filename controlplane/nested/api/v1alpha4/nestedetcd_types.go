@@ -17,6 +17,8 @@ limitations under the License.
 package v1alpha4
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	addonv1alpha1 "sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/apis/v1alpha1"
 )
@@ -27,6 +29,61 @@ type NestedEtcdSpec struct {
 	// that are required for creating the component.
 	// +optional
 	NestedComponentSpec `json:",inline"`
+
+	// Backup schedules the snapshots of the etcd.
+	// +optional
+	Backup *NestedEtcdBackup `json:"backup,omitempty"`
+
+	// Restore restores the etcd from a snapshot when the StatefulSet of the
+	// NestedEtcd is created. The snapshot is restored once, the restored
+	// snapshot is recorded in the status. It is immutable.
+	// +optional
+	Restore *NestedEtcdRestore `json:"restore,omitempty"`
+}
+
+// NestedEtcdBackup defines the scheduled snapshots of the etcd.
+type NestedEtcdBackup struct {
+	// Schedule is the schedule of the snapshots in the cron format, e.g.
+	// "0 */6 * * *".
+	Schedule string `json:"schedule"`
+
+	// Retention is the number of the scheduled snapshots kept in the
+	// storage, the older ones are removed. Defaults to 7.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Retention int32 `json:"retention,omitempty"`
+
+	// Storage is where the snapshots are stored.
+	Storage EtcdBackupStorage `json:"storage"`
+}
+
+// NestedEtcdRestore defines the snapshot the etcd is restored from.
+type NestedEtcdRestore struct {
+	// Snapshot is the name of the snapshot in the storage.
+	Snapshot string `json:"snapshot"`
+
+	// Storage is where the snapshot is stored.
+	Storage EtcdBackupStorage `json:"storage"`
+
+	// DataVolumeClaim is the PersistentVolumeClaim the data directory of each
+	// etcd member is kept in, so that the restored data survives the restarts
+	// of the members. It is not used if a patch mounts the data directory
+	// from a PersistentVolumeClaim.
+	// +optional
+	DataVolumeClaim *EtcdDataVolumeClaim `json:"dataVolumeClaim,omitempty"`
+}
+
+// EtcdDataVolumeClaim defines the PersistentVolumeClaims of the data
+// directories of the etcd members.
+type EtcdDataVolumeClaim struct {
+	// StorageClassName is the storage class of the PersistentVolumeClaims,
+	// defaults to the default storage class.
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+
+	// Size is the size of the PersistentVolumeClaims, defaults to 1Gi.
+	// +optional
+	Size *resource.Quantity `json:"size,omitempty"`
 }
 
 // NestedEtcdStatus defines the observed state of NestedEtcd.
@@ -34,6 +91,15 @@ type NestedEtcdStatus struct {
 	// EtcdDomain defines how to address the etcd instance.
 	Addresses []NestedEtcdAddress `json:"addresses,omitempty"`
 
+	// LastBackupTime is the time of the last successful scheduled snapshot.
+	// +optional
+	LastBackupTime *metav1.Time `json:"lastBackupTime,omitempty"`
+
+	// RestoredSnapshot is the snapshot of NestedEtcdSpec.Restore the etcd has
+	// been restored from, the snapshot is not restored again once it is set.
+	// +optional
+	RestoredSnapshot string `json:"restoredSnapshot,omitempty"`
+
 	// CommonStatus allows addons status monitoring.
 	addonv1alpha1.CommonStatus `json:",inline"`
 }
@@ -52,6 +118,50 @@ type NestedEtcdAddress struct {
 	Port int32 `json:"port"`
 }
 
+// EtcdBackupStorage defines where the snapshots of the etcd are stored, one
+// of the storages must be set.
+type EtcdBackupStorage struct {
+	// PersistentVolumeClaim stores the snapshots in the persistent volume
+	// claimed by the PersistentVolumeClaim in the namespace of the
+	// NestedControlPlane.
+	// +optional
+	PersistentVolumeClaim *corev1.PersistentVolumeClaimVolumeSource `json:"persistentVolumeClaim,omitempty"`
+
+	// S3 stores the snapshots in a bucket of an S3 compatible object storage.
+	// +optional
+	S3 *S3BackupStorage `json:"s3,omitempty"`
+
+	// Image is the image that copies the snapshots to and from the storage,
+	// it needs a shell and the client of the storage. Defaults to busybox for
+	// the PersistentVolumeClaim and amazon/aws-cli for the S3.
+	// +optional
+	Image string `json:"image,omitempty"`
+}
+
+// S3BackupStorage defines the bucket of an S3 compatible object storage.
+type S3BackupStorage struct {
+	// Endpoint is the URL of the S3 compatible object storage, defaults to
+	// the AWS S3.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Region is the region of the bucket, defaults to us-east-1.
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// Bucket is the name of the bucket.
+	Bucket string `json:"bucket"`
+
+	// Prefix is prepended to the names of the snapshots, e.g. "backups/".
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// CredentialsSecretRef refers to the Secret in the namespace of the
+	// NestedEtcd that holds the AWS_ACCESS_KEY_ID and the
+	// AWS_SECRET_ACCESS_KEY of the bucket.
+	CredentialsSecretRef corev1.LocalObjectReference `json:"credentialsSecretRef"`
+}
+
 //+kubebuilder:object:root=true
 //+kubebuilder:resource:scope=Namespaced,shortName=netcd,categories=capi;capn
 //+kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
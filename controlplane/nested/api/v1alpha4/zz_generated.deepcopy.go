@@ -27,6 +27,163 @@ import (
 	apiv1alpha4 "sigs.k8s.io/cluster-api/api/v1alpha4"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *APIServerAudit) DeepCopyInto(out *APIServerAudit) {
+	*out = *in
+	out.PolicyRef = in.PolicyRef
+	if in.Log != nil {
+		in, out := &in.Log, &out.Log
+		*out = new(AuditLogBackend)
+		**out = **in
+	}
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(AuditWebhookBackend)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new APIServerAudit.
+func (in *APIServerAudit) DeepCopy() *APIServerAudit {
+	if in == nil {
+		return nil
+	}
+	out := new(APIServerAudit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditLogBackend) DeepCopyInto(out *AuditLogBackend) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditLogBackend.
+func (in *AuditLogBackend) DeepCopy() *AuditLogBackend {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditLogBackend)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditWebhookBackend) DeepCopyInto(out *AuditWebhookBackend) {
+	*out = *in
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditWebhookBackend.
+func (in *AuditWebhookBackend) DeepCopy() *AuditWebhookBackend {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditWebhookBackend)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdBackupStorage) DeepCopyInto(out *EtcdBackupStorage) {
+	*out = *in
+	if in.PersistentVolumeClaim != nil {
+		in, out := &in.PersistentVolumeClaim, &out.PersistentVolumeClaim
+		*out = new(v1.PersistentVolumeClaimVolumeSource)
+		**out = **in
+	}
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(S3BackupStorage)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdBackupStorage.
+func (in *EtcdBackupStorage) DeepCopy() *EtcdBackupStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdBackupStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdDataVolumeClaim) DeepCopyInto(out *EtcdDataVolumeClaim) {
+	*out = *in
+	if in.StorageClassName != nil {
+		in, out := &in.StorageClassName, &out.StorageClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Size != nil {
+		in, out := &in.Size, &out.Size
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdDataVolumeClaim.
+func (in *EtcdDataVolumeClaim) DeepCopy() *EtcdDataVolumeClaim {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdDataVolumeClaim)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalEtcd) DeepCopyInto(out *ExternalEtcd) {
+	*out = *in
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.CertificatesSecretRef = in.CertificatesSecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalEtcd.
+func (in *ExternalEtcd) DeepCopy() *ExternalEtcd {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalEtcd)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KineDatastore) DeepCopyInto(out *KineDatastore) {
+	*out = *in
+	if in.SQLite != nil {
+		in, out := &in.SQLite, &out.SQLite
+		*out = new(SQLiteDatastore)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SQL != nil {
+		in, out := &in.SQL, &out.SQL
+		*out = new(SQLDatastore)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KineDatastore.
+func (in *KineDatastore) DeepCopy() *KineDatastore {
+	if in == nil {
+		return nil
+	}
+	out := new(KineDatastore)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NestedAPIServer) DeepCopyInto(out *NestedAPIServer) {
 	*out = *in
@@ -90,6 +247,11 @@ func (in *NestedAPIServerList) DeepCopyObject() runtime.Object {
 func (in *NestedAPIServerSpec) DeepCopyInto(out *NestedAPIServerSpec) {
 	*out = *in
 	in.NestedComponentSpec.DeepCopyInto(&out.NestedComponentSpec)
+	if in.Audit != nil {
+		in, out := &in.Audit, &out.Audit
+		*out = new(APIServerAudit)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NestedAPIServerSpec.
@@ -208,6 +370,16 @@ func (in *NestedControlPlaneSpec) DeepCopyInto(out *NestedControlPlaneSpec) {
 		*out = new(v1.ObjectReference)
 		**out = **in
 	}
+	if in.ExternalEtcd != nil {
+		in, out := &in.ExternalEtcd, &out.ExternalEtcd
+		*out = new(ExternalEtcd)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Datastore != nil {
+		in, out := &in.Datastore, &out.Datastore
+		*out = new(KineDatastore)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.APIServerRef != nil {
 		in, out := &in.APIServerRef, &out.APIServerRef
 		*out = new(v1.ObjectReference)
@@ -218,6 +390,16 @@ func (in *NestedControlPlaneSpec) DeepCopyInto(out *NestedControlPlaneSpec) {
 		*out = new(v1.ObjectReference)
 		**out = **in
 	}
+	if in.EtcdBackupOnDelete != nil {
+		in, out := &in.EtcdBackupOnDelete, &out.EtcdBackupOnDelete
+		*out = new(EtcdBackupStorage)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretEncryption != nil {
+		in, out := &in.SecretEncryption, &out.SecretEncryption
+		*out = new(SecretEncryption)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NestedControlPlaneSpec.
@@ -243,6 +425,11 @@ func (in *NestedControlPlaneStatus) DeepCopyInto(out *NestedControlPlaneStatus)
 		*out = new(NestedControlPlaneStatusAPIServer)
 		**out = **in
 	}
+	if in.Version != nil {
+		in, out := &in.Version, &out.Version
+		*out = new(string)
+		**out = **in
+	}
 	if in.FailureMessage != nil {
 		in, out := &in.FailureMessage, &out.FailureMessage
 		*out = new(string)
@@ -435,6 +622,22 @@ func (in *NestedEtcdAddress) DeepCopy() *NestedEtcdAddress {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NestedEtcdBackup) DeepCopyInto(out *NestedEtcdBackup) {
+	*out = *in
+	in.Storage.DeepCopyInto(&out.Storage)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NestedEtcdBackup.
+func (in *NestedEtcdBackup) DeepCopy() *NestedEtcdBackup {
+	if in == nil {
+		return nil
+	}
+	out := new(NestedEtcdBackup)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NestedEtcdList) DeepCopyInto(out *NestedEtcdList) {
 	*out = *in
@@ -467,10 +670,41 @@ func (in *NestedEtcdList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NestedEtcdRestore) DeepCopyInto(out *NestedEtcdRestore) {
+	*out = *in
+	in.Storage.DeepCopyInto(&out.Storage)
+	if in.DataVolumeClaim != nil {
+		in, out := &in.DataVolumeClaim, &out.DataVolumeClaim
+		*out = new(EtcdDataVolumeClaim)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NestedEtcdRestore.
+func (in *NestedEtcdRestore) DeepCopy() *NestedEtcdRestore {
+	if in == nil {
+		return nil
+	}
+	out := new(NestedEtcdRestore)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NestedEtcdSpec) DeepCopyInto(out *NestedEtcdSpec) {
 	*out = *in
 	in.NestedComponentSpec.DeepCopyInto(&out.NestedComponentSpec)
+	if in.Backup != nil {
+		in, out := &in.Backup, &out.Backup
+		*out = new(NestedEtcdBackup)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Restore != nil {
+		in, out := &in.Restore, &out.Restore
+		*out = new(NestedEtcdRestore)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NestedEtcdSpec.
@@ -491,6 +725,10 @@ func (in *NestedEtcdStatus) DeepCopyInto(out *NestedEtcdStatus) {
 		*out = make([]NestedEtcdAddress, len(*in))
 		copy(*out, *in)
 	}
+	if in.LastBackupTime != nil {
+		in, out := &in.LastBackupTime, &out.LastBackupTime
+		*out = (*in).DeepCopy()
+	}
 	in.CommonStatus.DeepCopyInto(&out.CommonStatus)
 }
 
@@ -503,3 +741,75 @@ func (in *NestedEtcdStatus) DeepCopy() *NestedEtcdStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SQLDatastore) DeepCopyInto(out *SQLDatastore) {
+	*out = *in
+	in.EndpointSecretRef.DeepCopyInto(&out.EndpointSecretRef)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SQLDatastore.
+func (in *SQLDatastore) DeepCopy() *SQLDatastore {
+	if in == nil {
+		return nil
+	}
+	out := new(SQLDatastore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SQLiteDatastore) DeepCopyInto(out *SQLiteDatastore) {
+	*out = *in
+	if in.StorageClassName != nil {
+		in, out := &in.StorageClassName, &out.StorageClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Size != nil {
+		in, out := &in.Size, &out.Size
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SQLiteDatastore.
+func (in *SQLiteDatastore) DeepCopy() *SQLiteDatastore {
+	if in == nil {
+		return nil
+	}
+	out := new(SQLiteDatastore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretEncryption) DeepCopyInto(out *SecretEncryption) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretEncryption.
+func (in *SecretEncryption) DeepCopy() *SecretEncryption {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretEncryption)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3BackupStorage) DeepCopyInto(out *S3BackupStorage) {
+	*out = *in
+	out.CredentialsSecretRef = in.CredentialsSecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3BackupStorage.
+func (in *S3BackupStorage) DeepCopy() *S3BackupStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(S3BackupStorage)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,298 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientcmdv1 "k8s.io/client-go/tools/clientcmd/api/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/yaml"
+
+	controlplanev1 "sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/kubeadm"
+)
+
+const (
+	// auditPolicyKey is the key of the audit policy in the ConfigMap
+	// referenced by the APIServerAudit.
+	auditPolicyKey = "policy.yaml"
+	// auditPolicyDir is where the audit policy is mounted in the apiserver.
+	auditPolicyDir = "/etc/kubernetes/audit"
+	// auditWebhookConfigKey is the key of the kubeconfig of the audit webhook
+	// in the audit webhook config secret.
+	auditWebhookConfigKey = "webhook-config.yaml"
+	// auditWebhookConfigDir is where the audit webhook config secret is
+	// mounted in the apiserver.
+	auditWebhookConfigDir = "/etc/kubernetes/audit-webhook"
+	// auditWebhookName is the name of the cluster, the user and the context
+	// in the kubeconfig of the audit webhook.
+	auditWebhookName = "audit-webhook"
+)
+
+// auditWebhookConfigSecretName returns the name of the secret that holds the
+// kubeconfig of the audit webhook of the cluster.
+func auditWebhookConfigSecretName(clusterName string) string {
+	return clusterName + "-audit-webhook"
+}
+
+// auditLogPath returns the path of the audit log file, which defaults to the
+// standard output.
+func auditLogPath(log *controlplanev1.AuditLogBackend) string {
+	if log.Path == "" {
+		return "-"
+	}
+	return log.Path
+}
+
+// auditWebhookURL returns the URL of the audit webhook, which is tagged with
+// the name and the namespace of the cluster if required.
+func auditWebhookURL(webhook *controlplanev1.AuditWebhookBackend, clusterName, namespace string) (string, error) {
+	u, err := url.Parse(webhook.URL)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid audit webhook URL %s", webhook.URL)
+	}
+	if webhook.TagCluster {
+		query := u.Query()
+		query.Set("cluster", clusterName)
+		query.Set("namespace", namespace)
+		u.RawQuery = query.Encode()
+	}
+	return u.String(), nil
+}
+
+// genAuditWebhookConfig generates the kubeconfig of the audit webhook of the
+// cluster.
+func genAuditWebhookConfig(webhook *controlplanev1.AuditWebhookBackend, clusterName, namespace string) ([]byte, error) {
+	server, err := auditWebhookURL(webhook, clusterName, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(&clientcmdv1.Config{
+		APIVersion: "v1",
+		Kind:       "Config",
+		Clusters: []clientcmdv1.NamedCluster{{
+			Name: auditWebhookName,
+			Cluster: clientcmdv1.Cluster{
+				Server:                   server,
+				CertificateAuthorityData: webhook.CABundle,
+			},
+		}},
+		AuthInfos: []clientcmdv1.NamedAuthInfo{{Name: auditWebhookName}},
+		Contexts: []clientcmdv1.NamedContext{{
+			Name:    auditWebhookName,
+			Context: clientcmdv1.Context{Cluster: auditWebhookName, AuthInfo: auditWebhookName},
+		}},
+		CurrentContext: auditWebhookName,
+	})
+}
+
+// applyAudit mounts the audit policy, and the audit webhook config if any,
+// into the apiserver pod, and sets the flags of the audit backends.
+func applyAudit(ps *corev1.PodSpec, clusterName string, audit *controlplanev1.APIServerAudit) {
+	var volSrtMode int32 = 420
+	container := &ps.Containers[0]
+	ps.Volumes = append(ps.Volumes, corev1.Volume{
+		Name: clusterName + "-audit-policy",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: audit.PolicyRef,
+				DefaultMode:          &volSrtMode,
+			},
+		},
+	})
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		MountPath: auditPolicyDir,
+		Name:      clusterName + "-audit-policy",
+		ReadOnly:  true,
+	})
+	flags := []string{"--audit-policy-file=" + auditPolicyDir + "/" + auditPolicyKey}
+
+	if log := audit.Log; log != nil {
+		logPath := auditLogPath(log)
+		flags = append(flags, "--audit-log-path="+logPath)
+		if logPath != "-" {
+			ps.Volumes = append(ps.Volumes, corev1.Volume{
+				Name:         clusterName + "-audit-log",
+				VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+			})
+			container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+				MountPath: path.Dir(logPath),
+				Name:      clusterName + "-audit-log",
+			})
+		}
+		if log.MaxAge > 0 {
+			flags = append(flags, "--audit-log-maxage="+strconv.Itoa(int(log.MaxAge)))
+		}
+		if log.MaxBackups > 0 {
+			flags = append(flags, "--audit-log-maxbackup="+strconv.Itoa(int(log.MaxBackups)))
+		}
+		if log.MaxSize > 0 {
+			flags = append(flags, "--audit-log-maxsize="+strconv.Itoa(int(log.MaxSize)))
+		}
+	}
+
+	if audit.Webhook != nil {
+		ps.Volumes = append(ps.Volumes, corev1.Volume{
+			Name: auditWebhookConfigSecretName(clusterName),
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					DefaultMode: &volSrtMode,
+					SecretName:  auditWebhookConfigSecretName(clusterName),
+				},
+			},
+		})
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			MountPath: auditWebhookConfigDir,
+			Name:      auditWebhookConfigSecretName(clusterName),
+			ReadOnly:  true,
+		})
+		flags = append(flags, "--audit-webhook-config-file="+auditWebhookConfigDir+"/"+auditWebhookConfigKey)
+	}
+
+	container.Command = append(removeCommandFlags(container.Command, "audit-policy-file",
+		"audit-log-path", "audit-log-maxage", "audit-log-maxbackup", "audit-log-maxsize",
+		"audit-webhook-config-file"), flags...)
+}
+
+// completeKASAudit configures the audit logging of the apiserver manifest,
+// and records the hash of the audit configuration on it, so that the
+// apiserver is restarted once the audit policy or the webhook change.
+func completeKASAudit(manifests map[string]corev1.Pod, clusterName string,
+	audit *controlplanev1.APIServerAudit, hash string) {
+	kas, ok := manifests[kubeadm.APIServer]
+	if !ok {
+		return
+	}
+	applyAudit(&kas.Spec, clusterName, audit)
+	annotations := kas.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[auditConfigHashAnnotation] = hash
+	kas.SetAnnotations(annotations)
+	manifests[kubeadm.APIServer] = kas
+}
+
+// nestedAPIServerAudit returns the APIServerAudit of the NestedAPIServer
+// among the NestedComponents, if any.
+func nestedAPIServerAudit(components map[string]client.Object) *controlplanev1.APIServerAudit {
+	nkas, ok := components[kubeadm.APIServer].(*controlplanev1.NestedAPIServer)
+	if !ok {
+		return nil
+	}
+	return nkas.Spec.Audit
+}
+
+// reconcileAudit creates or updates the audit webhook config secret of the
+// cluster if the audit events are sent to a webhook, and returns the hash of
+// the audit policy and the webhook config.
+func (r *NestedControlPlaneReconciler) reconcileAudit(ctx context.Context, cluster *clusterv1.Cluster,
+	ncp *controlplanev1.NestedControlPlane, audit *controlplanev1.APIServerAudit) (string, error) {
+	if audit == nil {
+		return "", nil
+	}
+	var policy corev1.ConfigMap
+	if err := r.Get(ctx, types.NamespacedName{
+		Namespace: ncp.GetNamespace(),
+		Name:      audit.PolicyRef.Name,
+	}, &policy); err != nil {
+		return "", errors.Wrapf(err, "failed to get the audit policy %s", audit.PolicyRef.Name)
+	}
+	if _, ok := policy.Data[auditPolicyKey]; !ok {
+		return "", errors.Errorf("audit policy %s has no %s", audit.PolicyRef.Name, auditPolicyKey)
+	}
+	hash := sha256.New()
+	hash.Write([]byte(policy.Data[auditPolicyKey]))
+	if audit.Webhook == nil {
+		return fmt.Sprintf("%x", hash.Sum(nil))[:16], nil
+	}
+
+	config, err := genAuditWebhookConfig(audit.Webhook, cluster.GetName(), cluster.GetNamespace())
+	if err != nil {
+		return "", err
+	}
+	hash.Write(config)
+	var srt corev1.Secret
+	err = r.Get(ctx, types.NamespacedName{
+		Namespace: ncp.GetNamespace(),
+		Name:      auditWebhookConfigSecretName(cluster.GetName()),
+	}, &srt)
+	switch {
+	case apierrors.IsNotFound(err):
+		newSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      auditWebhookConfigSecretName(cluster.GetName()),
+				Namespace: ncp.GetNamespace(),
+			},
+			Data: map[string][]byte{auditWebhookConfigKey: config},
+		}
+		if err := ctrl.SetControllerReference(ncp, newSecret, r.Scheme); err != nil {
+			return "", err
+		}
+		err = r.Create(ctx, newSecret)
+	case err == nil && !bytes.Equal(srt.Data[auditWebhookConfigKey], config):
+		srt.Data = map[string][]byte{auditWebhookConfigKey: config}
+		err = r.Update(ctx, &srt)
+	}
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil))[:16], nil
+}
+
+// auditPolicyToNestedControlPlanes maps the audit policy ConfigMap to the
+// NestedControlPlanes whose NestedAPIServer audits with it, so that the
+// apiserver is restarted once the policy changes.
+func auditPolicyToNestedControlPlanes(cli client.Client) handler.MapFunc {
+	return func(o client.Object) []ctrl.Request {
+		var nkasList controlplanev1.NestedAPIServerList
+		if err := cli.List(context.TODO(), &nkasList, client.InNamespace(o.GetNamespace())); err != nil {
+			return nil
+		}
+		var requests []ctrl.Request
+		for _, nkas := range nkasList.Items {
+			audit := nkas.Spec.Audit
+			if audit == nil || audit.PolicyRef.Name != o.GetName() {
+				continue
+			}
+			owner := getOwner(nkas.ObjectMeta)
+			if owner.Name == "" {
+				continue
+			}
+			requests = append(requests, ctrl.Request{NamespacedName: types.NamespacedName{
+				Namespace: o.GetNamespace(),
+				Name:      owner.Name,
+			}})
+		}
+		return requests
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+
+	controlplanev1 "sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/kubeadm"
+)
+
+func TestCompleteKASAudit(t *testing.T) {
+	templates, err := kubeadm.GenerateTemplates(kubeadm.Options{ClusterName: "c"})
+	if err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	manifests, err := completeTemplates(templates, "c", controlplanev1.NestedControlPlaneSpec{})
+	if err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	completeKASAudit(manifests, "c", &controlplanev1.APIServerAudit{
+		PolicyRef: corev1.LocalObjectReference{Name: "audit-policy"},
+		Log:       &controlplanev1.AuditLogBackend{Path: "/var/log/kubernetes/audit.log", MaxAge: 7},
+		Webhook:   &controlplanev1.AuditWebhookBackend{URL: "https://collector.audit.svc/events"},
+	}, "hash")
+	kas := manifests[kubeadm.APIServer]
+	command := strings.Join(kas.Spec.Containers[0].Command, " ")
+	for _, flag := range []string{
+		"--audit-policy-file=/etc/kubernetes/audit/policy.yaml",
+		"--audit-log-path=/var/log/kubernetes/audit.log",
+		"--audit-log-maxage=7",
+		"--audit-webhook-config-file=/etc/kubernetes/audit-webhook/webhook-config.yaml",
+	} {
+		if !strings.Contains(command, flag) {
+			t.Fatalf("\t%s\texpect flag %s, but get %s", failed, flag, command)
+		}
+	}
+	mounts := map[string]string{}
+	for _, m := range kas.Spec.Containers[0].VolumeMounts {
+		mounts[m.Name] = m.MountPath
+	}
+	if mounts["c-audit-policy"] != auditPolicyDir || mounts["c-audit-log"] != "/var/log/kubernetes" ||
+		mounts["c-audit-webhook"] != auditWebhookConfigDir {
+		t.Fatalf("\t%s\tthe audit configuration is not mounted: %v", failed, mounts)
+	}
+
+	manifest, err := yaml.Marshal(&kas)
+	if err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	sts, err := genStatefulSetManifest(string(manifest), kubeadm.APIServer, "c", "nkas", "default")
+	if err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	if sts.Spec.Template.GetAnnotations()[auditConfigHashAnnotation] != "hash" {
+		t.Fatalf("\t%s\texpect the hash of the audit config on the pod template, but get %v", failed, sts.Spec.Template.GetAnnotations())
+	}
+	t.Logf("\t%s\tthe apiserver audits the requests", succeed)
+}
+
+func TestAuditWebhookConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		webhook  controlplanev1.AuditWebhookBackend
+		expected string
+	}{
+		{
+			name:     "TestUntaggedWebhook",
+			webhook:  controlplanev1.AuditWebhookBackend{URL: "https://collector.audit.svc/events"},
+			expected: "server: https://collector.audit.svc/events\n",
+		},
+		{
+			name: "TestTaggedWebhook",
+			webhook: controlplanev1.AuditWebhookBackend{
+				URL:        "https://collector.audit.svc/events?source=capn",
+				CABundle:   []byte("ca"),
+				TagCluster: true,
+			},
+			expected: "server: https://collector.audit.svc/events?cluster=c&namespace=default&source=capn\n",
+		},
+	}
+	for _, tt := range tests {
+		st := tt
+		t.Run(st.name, func(t *testing.T) {
+			config, err := genAuditWebhookConfig(&st.webhook, "c", "default")
+			if err != nil {
+				t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+			}
+			if !strings.Contains(string(config), st.expected) {
+				t.Fatalf("\t%s\texpect %q in the webhook config, but get\n%s", failed, st.expected, config)
+			}
+			if len(st.webhook.CABundle) != 0 && !strings.Contains(string(config), "certificate-authority-data: Y2E=") {
+				t.Fatalf("\t%s\texpect the CA bundle in the webhook config, but get\n%s", failed, config)
+			}
+			t.Logf("\t%s\tthe webhook config points to %s", succeed, st.webhook.URL)
+		})
+	}
+}
+
+func TestAuditPolicyToNestedControlPlanes(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := controlplanev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	nkas := func(name, owner, policy string) *controlplanev1.NestedAPIServer {
+		nkas := &controlplanev1.NestedAPIServer{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		}
+		if owner != "" {
+			nkas.OwnerReferences = []metav1.OwnerReference{{
+				APIVersion: controlplanev1.GroupVersion.String(),
+				Kind:       "NestedControlPlane",
+				Name:       owner,
+			}}
+		}
+		if policy != "" {
+			nkas.Spec.Audit = &controlplanev1.APIServerAudit{PolicyRef: corev1.LocalObjectReference{Name: policy}}
+		}
+		return nkas
+	}
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		nkas("nkas-1", "ncp-1", "audit-policy"),
+		nkas("nkas-2", "ncp-2", "other-policy"),
+		nkas("nkas-3", "ncp-3", ""),
+		nkas("nkas-4", "", "audit-policy"),
+	).Build()
+	policy := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "audit-policy", Namespace: "default"}}
+	requests := auditPolicyToNestedControlPlanes(cli)(policy)
+	if len(requests) != 1 || requests[0].Name != "ncp-1" || requests[0].Namespace != "default" {
+		t.Fatalf("\t%s\texpect the audit policy to be mapped to ncp-1, but get %v", failed, requests)
+	}
+	t.Logf("\t%s\tthe audit policy is mapped to the NestedControlPlane auditing with it", succeed)
+}
@@ -18,6 +18,8 @@ limitations under the License.
 // api group.
 package controllers
 
+import "time"
+
 const (
 	statefulsetOwnerKeyNEtcd = ".metadata.netcd.controller"
 	statefulsetOwnerKeyNKas  = ".metadata.nkas.controller"
@@ -29,4 +31,41 @@ const (
 	// EtcdManifestConfigmapName is the key name of the etcd manifest in the configmap.
 	EtcdManifestConfigmapName = "netcd-manifest"
 	loopbackAddress           = "127.0.0.1"
+	// etcdScaleRequeueInterval is the interval to check the NestedEtcd
+	// StatefulSet while it is scaled member by member.
+	etcdScaleRequeueInterval = 10 * time.Second
+	// upgradeRequeueInterval is the interval to check the nested components
+	// while the updated manifests are rolled out.
+	upgradeRequeueInterval = 10 * time.Second
+	// deletionRequeueInterval is the interval to check the nested components
+	// while they are torn down.
+	deletionRequeueInterval = 5 * time.Second
+	// externalEtcdRequeueInterval is the interval to check the health of the
+	// external etcd until it is healthy.
+	externalEtcdRequeueInterval = 10 * time.Second
+	// etcdHealthCheckTimeout is the timeout of the health check of an etcd
+	// member.
+	etcdHealthCheckTimeout = 5 * time.Second
+	// templateHashAnnotation records the hash of the manifest and the
+	// NestedComponent.Spec.Patches the NestedComponent StatefulSet is
+	// generated from.
+	templateHashAnnotation = "controlplane.cluster.x-k8s.io/template-hash"
+	// encryptionConfigHashAnnotation records the hash of the
+	// EncryptionConfiguration on the apiserver pod template, so that the
+	// apiserver is restarted once the encryption keys change.
+	encryptionConfigHashAnnotation = "controlplane.cluster.x-k8s.io/encryption-config-hash"
+	// auditConfigHashAnnotation records the hash of the audit policy and the
+	// audit webhook config on the apiserver pod template, so that the
+	// apiserver is restarted once they change.
+	auditConfigHashAnnotation = "controlplane.cluster.x-k8s.io/audit-config-hash"
+	// encryptionKeyRotatedAnnotation records on the encryption config secret
+	// the RotateEncryptionKeyAnnotation of the last finished key rotation.
+	encryptionKeyRotatedAnnotation = "controlplane.cluster.x-k8s.io/encryption-key-rotated"
+	// encryptionKeyRotationPhaseAnnotation records on the encryption config
+	// secret the phase of the ongoing key rotation.
+	encryptionKeyRotationPhaseAnnotation = "controlplane.cluster.x-k8s.io/encryption-key-rotation-phase"
+	// manifestsConfigMapLabel labels the manifests configmap of the
+	// NestedControlPlane, the NestedComponent controllers only watch the
+	// labeled configmaps.
+	manifestsConfigMapLabel = "controlplane.cluster.x-k8s.io/ncp-manifests"
 )
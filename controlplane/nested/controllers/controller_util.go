@@ -19,6 +19,7 @@ package controllers
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"strings"
 	"text/template"
@@ -28,6 +29,7 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -36,10 +38,19 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes/scheme"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/certs"
+	"sigs.k8s.io/cluster-api/util/secret"
 	ctrlcli "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	controlplanev1 "sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/certificate"
 	"sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/kubeadm"
+	ncpatch "sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/patch"
 	addonv1alpha1 "sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/apis/v1alpha1"
 )
 
@@ -63,17 +74,13 @@ func createNestedComponentSts(ctx context.Context,
 
 	if ncKind != kubeadm.ControllerManager {
 		// no need to create the service for the NestedControllerManager
-		ncSvc, err := genServiceObject(ncKind, clusterName, ncMeta.GetName(), ncMeta.GetNamespace())
+		ncSvc, err := genNestedComponentSvc(ncMeta, ncSpec, ncKind, clusterName)
 		if err != nil {
-			return errors.Errorf("fail to generate the Service object: %v", err)
+			return err
 		}
-
-		ncSvc.SetOwnerReferences([]metav1.OwnerReference{*or})
-		if err := cli.Create(ctx, ncSvc); err != nil {
+		if err := reconcileNestedComponentSvc(ctx, cli, ncSvc, log); err != nil {
 			return err
 		}
-		log.Info("successfully create the service for the StatefulSet",
-			"component", ncKind)
 	}
 
 	// set the NestedComponent object as the owner of the StatefulSet
@@ -83,6 +90,252 @@ func createNestedComponentSts(ctx context.Context,
 	return cli.Create(ctx, ncSts)
 }
 
+// syncNestedComponentSts reconciles the NestedComponentSpec onto the existing
+// StatefulSet and the Service of the NestedComponent. It returns true if the
+// StatefulSet is patched.
+func syncNestedComponentSts(ctx context.Context,
+	cli ctrlcli.Client, ncMeta metav1.ObjectMeta,
+	ncSpec controlplanev1.NestedComponentSpec, ncSts *appsv1.StatefulSet,
+	ncKind, clusterName string, log logr.Logger) (bool, error) {
+	if ncKind != kubeadm.ControllerManager {
+		ncSvc, err := genNestedComponentSvc(ncMeta, ncSpec, ncKind, clusterName)
+		if err != nil {
+			return false, err
+		}
+		if err := reconcileNestedComponentSvc(ctx, cli, ncSvc, log); err != nil {
+			return false, err
+		}
+	}
+	podManifest, err := getComponentManifest(ctx, cli, ncMeta.GetNamespace(), ncKind, clusterName)
+	if err != nil {
+		return false, err
+	}
+	hash, ok := ncSts.GetAnnotations()[templateHashAnnotation]
+	if !ok {
+		// adopt the StatefulSet created before the hash is recorded, it is
+		// generated from the current manifest.
+		if err := setTemplateHash(ctx, cli, ncSts, templateHash(podManifest, ncSpec.Patches)); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	if hash != templateHash(podManifest, ncSpec.Patches) {
+		// the manifest is upgraded or the patches are changed.
+		if err := repatchNestedComponentSts(ctx, cli, ncMeta, ncSpec, ncSts, ncKind, clusterName, log); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	return patchNestedComponentSts(ctx, cli, ncSts, ncSpec, log)
+}
+
+// templateHash returns the hash of the manifest and the patches that the
+// NestedComponent StatefulSet is generated from.
+func templateHash(podManifest string, patches []*runtime.RawExtension) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(podManifest+ncpatch.Hash(patches))))[:16]
+}
+
+// setTemplateHash records the template hash on the NestedComponent StatefulSet.
+func setTemplateHash(ctx context.Context, cli ctrlcli.Client, ncSts *appsv1.StatefulSet, hash string) error {
+	mergeFrom := ctrlcli.MergeFrom(ncSts.DeepCopy())
+	annotations := ncSts.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[templateHashAnnotation] = hash
+	ncSts.SetAnnotations(annotations)
+	return cli.Patch(ctx, ncSts, mergeFrom)
+}
+
+// genNestedComponentSvc generates the Service of the NestedComponent, owned by
+// the NestedComponent and patched by the NestedComponent.Spec.Patches.
+func genNestedComponentSvc(ncMeta metav1.ObjectMeta,
+	ncSpec controlplanev1.NestedComponentSpec,
+	ncKind, clusterName string) (*corev1.Service, error) {
+	ncSvc, err := genServiceObject(ncKind, clusterName, ncMeta.GetName(), ncMeta.GetNamespace())
+	if err != nil {
+		return nil, errors.Errorf("fail to generate the Service object: %v", err)
+	}
+	if err := ncpatch.Apply(ncSvc, ncpatch.ServiceKind, ncSvc.GetName(), ncSpec.Patches); err != nil {
+		return nil, errors.Wrap(err, "fail to patch the Service object")
+	}
+	ncSvc.SetOwnerReferences([]metav1.OwnerReference{*metav1.NewControllerRef(&ncMeta,
+		controlplanev1.GroupVersion.WithKind(ncKind))})
+	return ncSvc, nil
+}
+
+// repatchNestedComponentSts regenerates the pod template and the update
+// strategy of the NestedComponent StatefulSet when the manifest or the
+// NestedComponent.Spec.Patches are changed. The other fields of the
+// StatefulSet are only patched on creation.
+func repatchNestedComponentSts(ctx context.Context,
+	cli ctrlcli.Client, ncMeta metav1.ObjectMeta,
+	ncSpec controlplanev1.NestedComponentSpec, ncSts *appsv1.StatefulSet,
+	ncKind, clusterName string, log logr.Logger) error {
+	desired, err := genStatefulSetObject(cli, ncMeta, ncSpec, ncKind, clusterName, log)
+	if err != nil {
+		return errors.Errorf("fail to generate the Statefulset object: %v", err)
+	}
+	mergeFrom := ctrlcli.MergeFrom(ncSts.DeepCopy())
+	ncSts.Spec.Template = desired.Spec.Template
+	ncSts.Spec.UpdateStrategy = desired.Spec.UpdateStrategy
+	if ncKind == kubeadm.Etcd && ncSts.Spec.Replicas != nil {
+		// keep the members of the running etcd cluster
+		setEtcdInitialClusterArgs(&ncSts.Spec.Template.Spec.Containers[0],
+			*ncSts.Spec.Replicas, clusterName, ncMeta.GetNamespace())
+	}
+	annotations := ncSts.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[templateHashAnnotation] = desired.GetAnnotations()[templateHashAnnotation]
+	ncSts.SetAnnotations(annotations)
+	if err := cli.Patch(ctx, ncSts, mergeFrom); err != nil {
+		return err
+	}
+	log.Info("successfully patch the StatefulSet with the updated template",
+		"StatefulSet", ncSts.GetName())
+	return nil
+}
+
+// reconcileNestedComponentSvc creates the Service of the NestedComponent if it
+// is not found, or patches it if its selector or ports drift from ncSvc.
+func reconcileNestedComponentSvc(ctx context.Context,
+	cli ctrlcli.Client, ncSvc *corev1.Service, log logr.Logger) error {
+	var svc corev1.Service
+	if err := cli.Get(ctx, types.NamespacedName{
+		Namespace: ncSvc.GetNamespace(),
+		Name:      ncSvc.GetName(),
+	}, &svc); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		if err := cli.Create(ctx, ncSvc); err != nil {
+			return err
+		}
+		log.Info("successfully create the service for the StatefulSet",
+			"service", ncSvc.GetName())
+		return nil
+	}
+
+	patch := ctrlcli.MergeFrom(svc.DeepCopy())
+	updated := false
+	if !apiequality.Semantic.DeepEqual(svc.Spec.Selector, ncSvc.Spec.Selector) {
+		svc.Spec.Selector = ncSvc.Spec.Selector
+		updated = true
+	}
+	if !servicePortsEqual(svc.Spec.Ports, ncSvc.Spec.Ports) {
+		svc.Spec.Ports = keepNodePorts(ncSvc.Spec.Ports, svc.Spec.Ports)
+		updated = true
+	}
+	if svc.Spec.PublishNotReadyAddresses != ncSvc.Spec.PublishNotReadyAddresses {
+		svc.Spec.PublishNotReadyAddresses = ncSvc.Spec.PublishNotReadyAddresses
+		updated = true
+	}
+	if !updated {
+		return nil
+	}
+	if err := cli.Patch(ctx, &svc, patch); err != nil {
+		return err
+	}
+	log.Info("successfully patch the service of the StatefulSet",
+		"service", svc.GetName())
+	return nil
+}
+
+// servicePortsEqual returns true if the ports have the same names, ports,
+// protocols and target ports. The fields allocated by the apiserver, e.g. the
+// node ports, are ignored, and the protocols and the target ports are
+// compared as the apiserver defaults them.
+func servicePortsEqual(ports, desired []corev1.ServicePort) bool {
+	if len(ports) != len(desired) {
+		return false
+	}
+	for i := range desired {
+		protocol, targetPort := desired[i].Protocol, desired[i].TargetPort
+		if protocol == "" {
+			protocol = corev1.ProtocolTCP
+		}
+		if targetPort == (intstr.IntOrString{}) {
+			targetPort = intstr.FromInt(int(desired[i].Port))
+		}
+		if ports[i].Name != desired[i].Name ||
+			ports[i].Port != desired[i].Port ||
+			ports[i].Protocol != protocol ||
+			ports[i].TargetPort != targetPort {
+			return false
+		}
+	}
+	return true
+}
+
+// keepNodePorts returns the desired ports with the node ports allocated to
+// the current ports of the same names.
+func keepNodePorts(desired, current []corev1.ServicePort) []corev1.ServicePort {
+	ports := make([]corev1.ServicePort, len(desired))
+	for i, p := range desired {
+		ports[i] = p
+		for _, c := range current {
+			if c.Name == p.Name && p.NodePort == 0 {
+				ports[i].NodePort = c.NodePort
+			}
+		}
+	}
+	return ports
+}
+
+// patchNestedComponentSts patches the Replicas and Resources of the
+// NestedComponent StatefulSet if they drift from the ncSpec. It returns true
+// if the StatefulSet is patched.
+func patchNestedComponentSts(ctx context.Context,
+	cli ctrlcli.Client, ncSts *appsv1.StatefulSet,
+	ncSpec controlplanev1.NestedComponentSpec, log logr.Logger) (bool, error) {
+	patch := ctrlcli.MergeFrom(ncSts.DeepCopy())
+	if !applyNestedComponentSpec(ncSts, ncSpec) {
+		return false, nil
+	}
+	if err := cli.Patch(ctx, ncSts, patch); err != nil {
+		return false, err
+	}
+	log.Info("successfully patch the StatefulSet with the NestedComponentSpec",
+		"StatefulSet", ncSts.GetName())
+	return true, nil
+}
+
+// applyNestedComponentSpec sets the NestedComponentSpec.Resources of the
+// component container and NestedComponentSpec.Replicas to the StatefulSet. It returns true if the
+// StatefulSet is changed.
+func applyNestedComponentSpec(ncSts *appsv1.StatefulSet, ncSpec controlplanev1.NestedComponentSpec) bool {
+	changed := false
+	// only the component container is set, the containers added by the
+	// NestedComponent.Spec.Patches keep their own resources.
+	if containers := ncSts.Spec.Template.Spec.Containers; len(containers) != 0 &&
+		!apiequality.Semantic.DeepEqual(containers[0].Resources, ncSpec.Resources) {
+		containers[0].Resources = ncSpec.Resources
+		changed = true
+	}
+	if ncSpec.Replicas != 0 &&
+		(ncSts.Spec.Replicas == nil || *ncSts.Spec.Replicas != ncSpec.Replicas) {
+		replicas := ncSpec.Replicas
+		ncSts.Spec.Replicas = &replicas
+		changed = true
+	}
+	return changed
+}
+
+// isStatefulSetRolledOut returns true if all replicas of the StatefulSet are
+// updated to the latest spec and ready.
+func isStatefulSetRolledOut(sts *appsv1.StatefulSet) bool {
+	replicas := int32(1)
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+	return sts.Status.ObservedGeneration >= sts.Generation &&
+		sts.Status.Replicas == replicas &&
+		sts.Status.ReadyReplicas == replicas &&
+		sts.Status.UpdatedReplicas == replicas
+}
+
 // genServiceObject generates the Service object corresponding to the NestedComponent.
 func genServiceObject(ncKind, clusterName, componentName, componentNamespace string) (*corev1.Service, error) {
 	switch ncKind {
@@ -156,7 +409,7 @@ func genStatefulSetManifest(podManifest, ncKind, clusterName, componentName, com
 	default:
 		return nil, errors.Errorf("invalid component type: %s", ncKind)
 	}
-	return &appsv1.StatefulSet{
+	sts := &appsv1.StatefulSet{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      clusterName + "-" + ncKind,
 			Namespace: componentNamespace,
@@ -177,7 +430,18 @@ func genStatefulSetManifest(podManifest, ncKind, clusterName, componentName, com
 				Spec: pod.Spec,
 			},
 		},
-	}, nil
+	}
+	// restart the apiserver once the encryption keys or the audit
+	// configuration change.
+	for _, annotation := range []string{encryptionConfigHashAnnotation, auditConfigHashAnnotation} {
+		if hash, ok := pod.GetAnnotations()[annotation]; ok {
+			if sts.Spec.Template.Annotations == nil {
+				sts.Spec.Template.Annotations = map[string]string{}
+			}
+			sts.Spec.Template.Annotations[annotation] = hash
+		}
+	}
+	return sts, nil
 }
 
 // genStatefulSetObject generates the StatefulSet object corresponding to the NestedComponent.
@@ -187,52 +451,252 @@ func genStatefulSetObject(
 	ncSpec controlplanev1.NestedComponentSpec,
 	ncKind, clusterName string,
 	log logr.Logger) (*appsv1.StatefulSet, error) {
-	cm := corev1.ConfigMap{}
-	if err := cli.Get(context.TODO(), types.NamespacedName{
-		Namespace: ncMeta.Namespace,
-		Name:      clusterName + "-" + kubeadm.ManifestsConfigmapSuffix,
-	}, &cm); err != nil {
+	// 1. get the pod spec
+	podManifest, err := getComponentManifest(context.TODO(), cli, ncMeta.GetNamespace(), ncKind, clusterName)
+	if err != nil {
 		if apierrors.IsNotFound(err) {
 			log.Error(err, "manifests configmap not found")
 		}
 		return nil, err
 	}
-
-	// 1. get the pod spec
-	podManifest := cm.Data[ncKind]
-	if podManifest == "" {
-		return nil, errors.Errorf("data %s is not found", ncKind)
-	}
 	// 2. generate the statefulset manifest
 	ncSts, err := genStatefulSetManifest(podManifest, ncKind, clusterName, ncMeta.GetName(), ncMeta.GetNamespace())
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to generate the statefulset manifest")
 	}
 
-	// 3. apply NestedComponent.Spec.Resources and NestedComponent.Spec.Replicas
-	// to the NestedComponent StatefulSet
-	for i := range ncSts.Spec.Template.Spec.Containers {
-		ncSts.Spec.Template.Spec.Containers[i].Resources =
-			ncSpec.Resources
+	// 3. apply NestedComponent.Spec.Patches to the NestedComponent StatefulSet,
+	// the component container is kept as the first container, as the
+	// strategic merge patches prepend the containers they add.
+	componentContainer := ncSts.Spec.Template.Spec.Containers[0].Name
+	if err := ncpatch.Apply(ncSts, ncpatch.StatefulSetKind, ncSts.GetName(), ncSpec.Patches); err != nil {
+		return nil, errors.Wrap(err, "failed to patch the statefulset manifest")
 	}
-	if ncSpec.Replicas != 0 {
-		ncSts.Spec.Replicas = &ncSpec.Replicas
+	containers := ncSts.Spec.Template.Spec.Containers
+	for i := range containers {
+		if containers[i].Name == componentContainer {
+			containers[0], containers[i] = containers[i], containers[0]
+			break
+		}
+	}
+	annotations := ncSts.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
 	}
-	log.V(5).Info("The NestedEtcd StatefulSet's Resources and "+
+	annotations[templateHashAnnotation] = templateHash(podManifest, ncSpec.Patches)
+	ncSts.SetAnnotations(annotations)
+
+	// 4. apply NestedComponent.Spec.Resources and NestedComponent.Spec.Replicas
+	// to the NestedComponent StatefulSet
+	applyNestedComponentSpec(ncSts, ncSpec)
+	log.V(5).Info("The NestedComponent StatefulSet's Resources and "+
 		"Replicas fields are set",
 		"StatefulSet", ncSts.GetName())
 
-	// 4. set the "--initial-cluster" command line flag for the Etcd container
+	// 5. set the "--initial-cluster" command line flag for the Etcd container.
+	// The etcd cluster is bootstrapped with a single member, the other members
+	// are added one at a time by the NestedEtcd controller.
 	if ncKind == kubeadm.Etcd {
-		icaVal := genInitialClusterArgs(1, clusterName, clusterName, ncMeta.GetNamespace())
-		ncSts.Spec.Template.Spec.Containers[0].Command = append(
-			ncSts.Spec.Template.Spec.Containers[0].Command,
-			fmt.Sprintf("--initial-cluster=%s", icaVal))
+		var replicas int32 = 1
+		ncSts.Spec.Replicas = &replicas
+		setEtcdInitialClusterArgs(&ncSts.Spec.Template.Spec.Containers[0],
+			replicas, clusterName, ncMeta.GetNamespace())
 		log.V(5).Info("The '--initial-cluster' command line option is set")
+
+		// 6. restore the Etcd from the snapshot of NestedEtcd.Spec.Restore.
+		var netcd controlplanev1.NestedEtcd
+		if err := cli.Get(context.TODO(), types.NamespacedName{
+			Namespace: ncMeta.GetNamespace(),
+			Name:      ncMeta.GetName(),
+		}, &netcd); err != nil {
+			return nil, err
+		}
+		switch restore := netcd.Spec.Restore; {
+		case restore == nil:
+		case netcd.Status.RestoredSnapshot == restore.Snapshot:
+			// the snapshot has been restored, only keep the data directory.
+			if _, err := applyEtcdDataVolume(ncSts, *restore); err != nil {
+				return nil, errors.Wrap(err, "failed to mount the etcd data directory")
+			}
+		default:
+			if err := applyEtcdRestore(ncSts, clusterName, *restore); err != nil {
+				return nil, errors.Wrap(err, "failed to restore the etcd")
+			}
+			log.V(5).Info("The etcd is restored from the snapshot",
+				"snapshot", restore.Snapshot)
+		}
 	}
 	return ncSts, nil
 }
 
+// getComponentManifest gets the pod manifest of the NestedComponent from the
+// manifests configmap.
+func getComponentManifest(ctx context.Context, cli ctrlcli.Client, namespace, ncKind, clusterName string) (string, error) {
+	cm := corev1.ConfigMap{}
+	if err := cli.Get(ctx, types.NamespacedName{
+		Namespace: namespace,
+		Name:      clusterName + "-" + kubeadm.ManifestsConfigmapSuffix,
+	}, &cm); err != nil {
+		return "", err
+	}
+	podManifest := cm.Data[ncKind]
+	if podManifest == "" {
+		return "", errors.Errorf("data %s is not found", ncKind)
+	}
+	return podManifest, nil
+}
+
+// isManifestsConfigMap filters the manifests configmaps out of the
+// configmaps, the NestedComponent controllers only reconcile on the updates of
+// the manifests.
+var isManifestsConfigMap = predicate.NewPredicateFuncs(func(obj ctrlcli.Object) bool {
+	_, ok := obj.GetLabels()[manifestsConfigMapLabel]
+	return ok
+})
+
+// enqueueComponentForManifests enqueues the NestedComponent of the given kind
+// that is referred by the NestedControlPlane owning the updated manifests
+// configmap, so that the updated manifest is rolled out.
+func enqueueComponentForManifests(cli ctrlcli.Client, kind string) handler.EventHandler {
+	return handler.EnqueueRequestsFromMapFunc(manifestsToComponent(cli, kind))
+}
+
+// manifestsToComponent maps the manifests configmap to the NestedComponent of
+// the given kind through the NestedControlPlane owning the configmap.
+func manifestsToComponent(cli ctrlcli.Client, kind string) handler.MapFunc {
+	return func(obj ctrlcli.Object) []reconcile.Request {
+		owner := metav1.GetControllerOf(obj)
+		if owner == nil ||
+			owner.APIVersion != controlplanev1.GroupVersion.String() ||
+			owner.Kind != "NestedControlPlane" {
+			return nil
+		}
+		var ncp controlplanev1.NestedControlPlane
+		if err := cli.Get(context.TODO(), types.NamespacedName{
+			Namespace: obj.GetNamespace(),
+			Name:      owner.Name,
+		}, &ncp); err != nil {
+			return nil
+		}
+		ref := componentRef(ncp.Spec, kind)
+		if ref == nil {
+			return nil
+		}
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{
+			Namespace: obj.GetNamespace(),
+			Name:      ref.Name,
+		}}}
+	}
+}
+
+// componentRef returns the reference to the NestedComponent of the given kind
+// in the NestedControlPlaneSpec.
+func componentRef(spec controlplanev1.NestedControlPlaneSpec, kind string) *corev1.ObjectReference {
+	switch kind {
+	case kubeadm.Etcd:
+		return spec.EtcdRef
+	case kubeadm.APIServer:
+		return spec.APIServerRef
+	case kubeadm.ControllerManager:
+		return spec.ControllerManagerRef
+	default:
+		return nil
+	}
+}
+
+// clusterToComponent maps the Cluster to the NestedComponent of the given kind
+// through the NestedControlPlane it references.
+func clusterToComponent(cli ctrlcli.Client, kind string) handler.MapFunc {
+	return func(obj ctrlcli.Object) []reconcile.Request {
+		var requests []reconcile.Request
+		for _, req := range clusterToNestedControlPlane(obj) {
+			var ncp controlplanev1.NestedControlPlane
+			if err := cli.Get(context.TODO(), req.NamespacedName, &ncp); err != nil {
+				continue
+			}
+			ref := componentRef(ncp.Spec, kind)
+			if ref == nil {
+				continue
+			}
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Namespace: obj.GetNamespace(),
+				Name:      ref.Name,
+			}})
+		}
+		return requests
+	}
+}
+
+// reissueCrt reissues the cert of the given purpose with issue, signed by the
+// CA of caPurpose, if the cert does not cover the host. It does nothing if
+// the cert has not been created yet. It returns true if the cert is reissued.
+func reissueCrt(ctx context.Context, cli ctrlcli.Client, cluster *clusterv1.Cluster,
+	purpose, caPurpose secret.Purpose, host string,
+	issue func(ca *certificate.KeyPair) (*certificate.KeyPair, error)) (bool, error) {
+	var crtSecret corev1.Secret
+	if err := cli.Get(ctx, types.NamespacedName{
+		Namespace: cluster.GetNamespace(),
+		Name:      secret.Name(cluster.GetName(), purpose),
+	}, &crtSecret); err != nil {
+		return false, ctrlcli.IgnoreNotFound(err)
+	}
+	crt, err := certs.DecodeCertPEM(crtSecret.Data[secret.TLSCrtDataName])
+	if err != nil {
+		return false, err
+	}
+	if crt != nil && crt.VerifyHostname(host) == nil {
+		return false, nil
+	}
+
+	certificates := secret.Certificates{&secret.Certificate{Purpose: caPurpose}}
+	if err := certificates.Lookup(ctx, cli, util.ObjectKey(cluster)); err != nil {
+		return false, err
+	}
+	ca := certificates.GetByPurpose(caPurpose)
+	if ca.KeyPair == nil {
+		return false, errors.Errorf("could not fetch %s", caPurpose)
+	}
+	cacrt, err := certs.DecodeCertPEM(ca.KeyPair.Cert)
+	if err != nil {
+		return false, err
+	}
+	cakey, err := certs.DecodePrivateKeyPEM(ca.KeyPair.Key)
+	if err != nil {
+		return false, err
+	}
+
+	keyPair, err := issue(&certificate.KeyPair{Cert: cacrt, Key: cakey})
+	if err != nil {
+		return false, err
+	}
+	crtSecret.Data = keyPair.AsSecret(util.ObjectKey(cluster), metav1.OwnerReference{}).Data
+	if err := cli.Update(ctx, &crtSecret); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// setEtcdInitialClusterArgs sets the "--initial-cluster" command line flag of
+// the etcd container to the given number of members. Members joining an
+// existing cluster also need "--initial-cluster-state=existing".
+func setEtcdInitialClusterArgs(container *corev1.Container,
+	replicas int32, clusterName, namespace string) {
+	command := make([]string, 0, len(container.Command)+2)
+	for _, arg := range container.Command {
+		if strings.HasPrefix(arg, "--initial-cluster=") ||
+			strings.HasPrefix(arg, "--initial-cluster-state=") {
+			continue
+		}
+		command = append(command, arg)
+	}
+	command = append(command, fmt.Sprintf("--initial-cluster=%s",
+		genInitialClusterArgs(replicas, clusterName, clusterName, namespace)))
+	if replicas > 1 {
+		command = append(command, "--initial-cluster-state=existing")
+	}
+	container.Command = command
+}
+
 // yamlToObject deserialize the yaml to the runtime object.
 func yamlToObject(yamlContent []byte, obj runtime.Object) error {
 	decode := serializer.NewCodecFactory(scheme.Scheme).
@@ -311,38 +775,66 @@ func IsComponentReady(status addonv1alpha1.CommonStatus) bool {
 // createManifestsConfigMap create the configmap that holds the manifests of
 // the NestedComponent. NOTE this function will be deprecated once the
 // nestedmachine_controller is implemented.
-func createManifestsConfigMap(cli ctrlcli.Client, manifests map[string]corev1.Pod, clusterName, namespace string) error {
-	data := map[string]string{}
-	for name, pod := range manifests {
-		tmpPod := pod
-		podYaml, err := objectToYaml(&tmpPod)
-		if err != nil {
-			return err
-		}
-		data[name] = string(podYaml)
+func createManifestsConfigMap(cli ctrlcli.Client, manifests map[string]corev1.Pod, clusterName string, ncp *controlplanev1.NestedControlPlane) error {
+	data, err := genManifestsData(manifests)
+	if err != nil {
+		return err
 	}
 	cm := corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Namespace: namespace,
+			Namespace: ncp.GetNamespace(),
 			Name:      clusterName + "-" + kubeadm.ManifestsConfigmapSuffix,
 		},
 		Data: data,
 	}
+	setManifestsConfigMapOwner(&cm, ncp)
 	return cli.Create(context.TODO(), &cm)
 }
 
-// completeTemplates completes the pod templates of nested control plane
-// components.
-func completeTemplates(templates map[string]string, clusterName string) (map[string]corev1.Pod, error) {
-	var ret = make(map[string]corev1.Pod)
-	for name, podTemplate := range templates {
-		pod := corev1.Pod{}
-		if err := yamlToObject([]byte(podTemplate), &pod); err != nil {
+// setManifestsConfigMapOwner labels the manifests configmap and sets the
+// NestedControlPlane as its controller, the NestedComponent controllers watch
+// the labeled configmaps and map them to the components through the owner.
+// It returns false if the configmap is already labeled and owned.
+func setManifestsConfigMapOwner(cm *corev1.ConfigMap, ncp *controlplanev1.NestedControlPlane) bool {
+	_, labeled := cm.GetLabels()[manifestsConfigMapLabel]
+	owner := metav1.GetControllerOf(cm)
+	if labeled && owner != nil && owner.UID == ncp.GetUID() {
+		return false
+	}
+	if cm.Labels == nil {
+		cm.Labels = map[string]string{}
+	}
+	cm.Labels[manifestsConfigMapLabel] = ""
+	if owner == nil {
+		cm.OwnerReferences = append(cm.OwnerReferences,
+			*metav1.NewControllerRef(ncp, controlplanev1.GroupVersion.WithKind("NestedControlPlane")))
+	}
+	return true
+}
+
+// genManifestsData serializes the manifests to the data of the manifests configmap.
+func genManifestsData(manifests map[string]corev1.Pod) (map[string]string, error) {
+	data := map[string]string{}
+	for name, pod := range manifests {
+		tmpPod := pod
+		podYaml, err := objectToYaml(&tmpPod)
+		if err != nil {
 			return nil, err
 		}
+		data[name] = string(podYaml)
+	}
+	return data, nil
+}
+
+// completeTemplates completes the pod templates of nested control plane
+// components, the apiserver uses the datastore of the NestedControlPlane.
+func completeTemplates(templates map[string]corev1.Pod, clusterName string,
+	ncpSpec controlplanev1.NestedControlPlaneSpec) (map[string]corev1.Pod, error) {
+	var ret = make(map[string]corev1.Pod)
+	for name, pod := range templates {
 		switch name {
 		case kubeadm.APIServer:
-			ret[kubeadm.APIServer] = completeKASPodSpec(pod, clusterName)
+			ret[kubeadm.APIServer] = completeKASPodSpec(pod, clusterName, ncpSpec)
 		case kubeadm.ControllerManager:
 			ret[kubeadm.ControllerManager] = completeKCMPodSpec(pod, clusterName)
 		case kubeadm.Etcd:
@@ -354,8 +846,12 @@ func completeTemplates(templates map[string]string, clusterName string) (map[str
 	return ret, nil
 }
 
-// completeKASPodSpec sets volumes, envs and other fields for the kube-apiserver pod spec.
-func completeKASPodSpec(pod corev1.Pod, clusterName string) corev1.Pod {
+// completeKASPodSpec sets volumes, envs and other fields for the kube-apiserver
+// pod spec, points the kube-apiserver to the external etcd or the kine
+// datastore of the NestedControlPlane, if any, and configures the encryption
+// of the secrets if enabled.
+func completeKASPodSpec(pod corev1.Pod, clusterName string,
+	ncpSpec controlplanev1.NestedControlPlaneSpec) corev1.Pod {
 	ps := pod.Spec
 	pod.Spec.DNSConfig = &corev1.PodDNSConfig{
 		Searches: []string{"cluster.local"},
@@ -499,6 +995,15 @@ func completeKASPodSpec(pod corev1.Pod, clusterName string) corev1.Pod {
 			},
 		},
 	}
+	switch {
+	case ncpSpec.ExternalEtcd != nil:
+		applyExternalEtcd(&ps, clusterName, ncpSpec.ExternalEtcd)
+	case ncpSpec.Datastore != nil:
+		applyKineDatastore(&ps, clusterName, ncpSpec.Datastore)
+	}
+	if ncpSpec.SecretEncryption != nil {
+		applySecretEncryption(&ps, clusterName)
+	}
 	pod.Spec = ps
 	return pod
 }
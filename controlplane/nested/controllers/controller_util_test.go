@@ -17,11 +17,22 @@ limitations under the License.
 package controllers
 
 import (
+	"context"
 	"reflect"
 	"testing"
 
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
 	controlplanev1 "sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/kubeadm"
 )
 
 const (
@@ -205,3 +216,225 @@ func TestGenInitialClusterArgs(t *testing.T) {
 		t.Run(st.name, tf)
 	}
 }
+
+func TestApplyNestedComponentSpec(t *testing.T) {
+	var replicas int32 = 1
+	resources := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU: resource.MustParse("100m"),
+		},
+	}
+	tests := []struct {
+		name           string
+		ncSpec         controlplanev1.NestedComponentSpec
+		expectChanged  bool
+		expectReplicas int32
+	}{
+		{
+			"no change",
+			controlplanev1.NestedComponentSpec{
+				Resources: resources,
+			},
+			false,
+			1,
+		},
+		{
+			"replicas changed",
+			controlplanev1.NestedComponentSpec{
+				Replicas:  3,
+				Resources: resources,
+			},
+			true,
+			3,
+		},
+		{
+			"resources changed",
+			controlplanev1.NestedComponentSpec{},
+			true,
+			1,
+		},
+	}
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				sts := &appsv1.StatefulSet{
+					Spec: appsv1.StatefulSetSpec{
+						Replicas: &replicas,
+						Template: corev1.PodTemplateSpec{
+							Spec: corev1.PodSpec{
+								Containers: []corev1.Container{{Resources: *resources.DeepCopy()}},
+							},
+						},
+					},
+				}
+				changed := applyNestedComponentSpec(sts, st.ncSpec)
+				if changed != st.expectChanged {
+					t.Fatalf("\t%s\texpect changed %v, but get %v", failed, st.expectChanged, changed)
+				}
+				if *sts.Spec.Replicas != st.expectReplicas {
+					t.Fatalf("\t%s\texpect replicas %v, but get %v", failed, st.expectReplicas, *sts.Spec.Replicas)
+				}
+				if !reflect.DeepEqual(sts.Spec.Template.Spec.Containers[0].Resources, st.ncSpec.Resources) {
+					t.Fatalf("\t%s\texpect resources %v, but get %v", failed, st.ncSpec.Resources, sts.Spec.Template.Spec.Containers[0].Resources)
+				}
+				t.Logf("\t%s\texpect changed %v, get %v", succeed, st.expectChanged, changed)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestReconcileNestedComponentSvc(t *testing.T) {
+	desired, err := genServiceObject(kubeadm.APIServer, "c", "nkas", "default")
+	if err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	existing := desired.DeepCopy()
+	existing.Spec.Type = corev1.ServiceTypeNodePort
+	existing.Spec.Ports[0].NodePort = 30443
+	cli := fake.NewClientBuilder().WithObjects(existing).Build()
+	var svc corev1.Service
+	if err := cli.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "c-apiserver"}, &svc); err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	resourceVersion := svc.GetResourceVersion()
+
+	if err := reconcileNestedComponentSvc(context.TODO(), cli, desired.DeepCopy(), ctrl.Log); err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	if err := cli.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "c-apiserver"}, &svc); err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	if svc.GetResourceVersion() != resourceVersion {
+		t.Fatalf("\t%s\texpect the service with the allocated node port not to be patched", failed)
+	}
+
+	desired.Spec.Ports[0].Port = 6444
+	if err := reconcileNestedComponentSvc(context.TODO(), cli, desired.DeepCopy(), ctrl.Log); err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	if err := cli.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "c-apiserver"}, &svc); err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	if port := svc.Spec.Ports[0]; port.Port != 6444 || port.NodePort != 30443 || svc.Spec.Type != corev1.ServiceTypeNodePort {
+		t.Fatalf("\t%s\texpect the port patched with the node port and the type kept, but get %v", failed, svc.Spec)
+	}
+	t.Logf("\t%s\tthe service ports are reconciled with the node ports kept", succeed)
+}
+
+func TestSetEtcdInitialClusterArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  []string
+		replicas int32
+		expect   []string
+	}{
+		{
+			"single member",
+			[]string{"etcd", "--name=$(HOSTNAME)"},
+			1,
+			[]string{"etcd", "--name=$(HOSTNAME)",
+				"--initial-cluster=c-etcd-0=https://c-etcd-0.c-etcd.default.svc:2380"},
+		},
+		{
+			"join existing cluster",
+			[]string{"etcd", "--initial-cluster=c-etcd-0=https://c-etcd-0.c-etcd.default.svc:2380", "--name=$(HOSTNAME)"},
+			2,
+			[]string{"etcd", "--name=$(HOSTNAME)",
+				"--initial-cluster=c-etcd-0=https://c-etcd-0.c-etcd.default.svc:2380,c-etcd-1=https://c-etcd-1.c-etcd.default.svc:2380",
+				"--initial-cluster-state=existing"},
+		},
+		{
+			"scale down to single member",
+			[]string{"etcd",
+				"--initial-cluster=c-etcd-0=https://c-etcd-0.c-etcd.default.svc:2380,c-etcd-1=https://c-etcd-1.c-etcd.default.svc:2380",
+				"--initial-cluster-state=existing"},
+			1,
+			[]string{"etcd", "--initial-cluster=c-etcd-0=https://c-etcd-0.c-etcd.default.svc:2380"},
+		},
+	}
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				container := &corev1.Container{Command: st.command}
+				setEtcdInitialClusterArgs(container, st.replicas, "c", "default")
+				if !reflect.DeepEqual(container.Command, st.expect) {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, container.Command)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, container.Command)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestTemplateHash(t *testing.T) {
+	patches := []*runtime.RawExtension{{Raw: []byte(`{"apiVersion":"v1","kind":"Service"}`)}}
+	base := templateHash("manifest", nil)
+	if base != templateHash("manifest", nil) {
+		t.Fatalf("\t%s\texpect the hash to be stable", failed)
+	}
+	if base == templateHash("upgraded manifest", nil) {
+		t.Fatalf("\t%s\texpect the hash to change with the manifest", failed)
+	}
+	if base == templateHash("manifest", patches) {
+		t.Fatalf("\t%s\texpect the hash to change with the patches", failed)
+	}
+	t.Logf("\t%s\tthe hash changes with the manifest and the patches", succeed)
+}
+
+func TestManifestsToComponent(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := controlplanev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	ncp := &controlplanev1.NestedControlPlane{
+		ObjectMeta: metav1.ObjectMeta{Name: "ncp", Namespace: "default", UID: "ncp-uid"},
+		Spec: controlplanev1.NestedControlPlaneSpec{
+			EtcdRef:      &corev1.ObjectReference{Name: "netcd"},
+			APIServerRef: &corev1.ObjectReference{Name: "nkas"},
+		},
+	}
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(ncp).Build()
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "c-ncp-manifests", Namespace: "default"}}
+	if isManifestsConfigMap.Generic(event.GenericEvent{Object: cm}) {
+		t.Fatalf("\t%s\texpect the unlabeled configmap to be filtered", failed)
+	}
+	if requests := manifestsToComponent(cli, kubeadm.Etcd)(cm); len(requests) != 0 {
+		t.Fatalf("\t%s\texpect no requests for the configmap without owner, but get %v", failed, requests)
+	}
+	if !setManifestsConfigMapOwner(cm, ncp) || setManifestsConfigMapOwner(cm, ncp) {
+		t.Fatalf("\t%s\texpect the configmap to be labeled and owned once", failed)
+	}
+	if !isManifestsConfigMap.Generic(event.GenericEvent{Object: cm}) {
+		t.Fatalf("\t%s\texpect the labeled configmap to pass the filter", failed)
+	}
+
+	tests := []struct {
+		kind     string
+		expected []string
+	}{
+		{kubeadm.Etcd, []string{"netcd"}},
+		{kubeadm.APIServer, []string{"nkas"}},
+		{kubeadm.ControllerManager, nil},
+	}
+	for _, tt := range tests {
+		var names []string
+		for _, request := range manifestsToComponent(cli, tt.kind)(cm) {
+			if request.Namespace != "default" {
+				t.Fatalf("\t%s\tunexpected request %v", failed, request)
+			}
+			names = append(names, request.Name)
+		}
+		if !reflect.DeepEqual(names, tt.expected) {
+			t.Fatalf("\t%s\texpect the %s manifests to enqueue %v, but get %v", failed, tt.kind, tt.expected, names)
+		}
+	}
+	t.Logf("\t%s\tthe manifests configmap is mapped through its owner", succeed)
+}
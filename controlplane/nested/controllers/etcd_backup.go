@@ -0,0 +1,598 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlcli "sigs.k8s.io/controller-runtime/pkg/client"
+
+	controlplanev1 "sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/kubeadm"
+)
+
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=batch,resources=cronjobs,verbs=get;list;watch;create;update;patch;delete
+
+const (
+	// etcdBackupMountPath is where the backup storage is mounted in the
+	// snapshot Job.
+	etcdBackupMountPath = "/backup"
+	// etcdBackupBackoffLimit is the number of retries of the snapshot Job.
+	etcdBackupBackoffLimit int32 = 3
+	// etcdSnapshotMountPath is where the snapshot is kept while it is copied
+	// between the etcd and the storage.
+	etcdSnapshotMountPath = "/snapshot"
+	// etcdSnapshotFile is the snapshot copied between the etcd and the storage.
+	etcdSnapshotFile = etcdSnapshotMountPath + "/snapshot.db"
+	// etcdDataDir is the data directory of the etcd, see the etcd manifest.
+	etcdDataDir = "/var/lib/etcd/data"
+
+	// defaultEtcdBackupRetention is the default number of the scheduled
+	// snapshots kept in the storage.
+	defaultEtcdBackupRetention int32 = 7
+	// defaultPVCBackupImage is the default image that copies the snapshots to
+	// and from the PersistentVolumeClaim.
+	defaultPVCBackupImage = "busybox:1.34"
+	// defaultS3BackupImage is the default image that copies the snapshots to
+	// and from the S3 bucket.
+	defaultS3BackupImage = "amazon/aws-cli:2.4.6"
+	// defaultS3Region is the default region of the S3 bucket.
+	defaultS3Region = "us-east-1"
+)
+
+// etcdSnapshotScript names the snapshot, the scheduled snapshots are named
+// after the cluster and the time they are taken, so that the old ones can be
+// found and removed.
+const etcdSnapshotScript = `set -e
+SNAPSHOT_NAME="${SNAPSHOT_NAME:-${CLUSTER_NAME}-$(date -u +%Y%m%d%H%M%S).db}"
+expired() {
+  [ -n "$RETENTION" ] || return 0
+  grep -E "^${CLUSTER_NAME}-[0-9]{14}\.db$" | sort -r | tail -n +$((RETENTION + 1))
+}
+`
+
+// etcdBackupStorage copies the snapshots to and from a storage. The scripts
+// run in the image of the storage with the environment of the storage.
+type etcdBackupStorage interface {
+	image() string
+	volumes() []corev1.Volume
+	volumeMounts() []corev1.VolumeMount
+	env() []corev1.EnvVar
+	envFrom() []corev1.EnvFromSource
+	// uploadScript copies the etcdSnapshotFile to the storage as
+	// $SNAPSHOT_NAME, and removes the expired scheduled snapshots.
+	uploadScript() string
+	// downloadScript copies $SNAPSHOT_NAME from the storage to the
+	// etcdSnapshotFile.
+	downloadScript() string
+}
+
+// newEtcdBackupStorage returns the etcdBackupStorage of the storage.
+func newEtcdBackupStorage(storage controlplanev1.EtcdBackupStorage) (etcdBackupStorage, error) {
+	switch {
+	case storage.PersistentVolumeClaim != nil && storage.S3 != nil:
+		return nil, errors.New("only one storage can be specified for the etcd snapshots")
+	case storage.PersistentVolumeClaim != nil:
+		return &pvcBackupStorage{storage}, nil
+	case storage.S3 != nil:
+		if storage.S3.Bucket == "" {
+			return nil, errors.New("no bucket is specified for the etcd snapshots")
+		}
+		return &s3BackupStorage{storage}, nil
+	default:
+		return nil, errors.New("no storage is specified for the etcd snapshots")
+	}
+}
+
+// pvcBackupStorage stores the snapshots in a PersistentVolumeClaim.
+type pvcBackupStorage struct {
+	controlplanev1.EtcdBackupStorage
+}
+
+func (s *pvcBackupStorage) image() string {
+	if s.Image != "" {
+		return s.Image
+	}
+	return defaultPVCBackupImage
+}
+
+func (s *pvcBackupStorage) volumes() []corev1.Volume {
+	return []corev1.Volume{
+		{
+			Name: "backup",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: s.PersistentVolumeClaim,
+			},
+		},
+	}
+}
+
+func (s *pvcBackupStorage) volumeMounts() []corev1.VolumeMount {
+	return []corev1.VolumeMount{
+		{
+			MountPath: etcdBackupMountPath,
+			Name:      "backup",
+		},
+	}
+}
+
+func (s *pvcBackupStorage) env() []corev1.EnvVar {
+	return []corev1.EnvVar{
+		{
+			Name:  "BACKUP_DIR",
+			Value: etcdBackupMountPath,
+		},
+	}
+}
+
+func (s *pvcBackupStorage) envFrom() []corev1.EnvFromSource {
+	return nil
+}
+
+func (s *pvcBackupStorage) uploadScript() string {
+	return etcdSnapshotScript + `cp "` + etcdSnapshotFile + `" "$BACKUP_DIR/.$SNAPSHOT_NAME"
+mv "$BACKUP_DIR/.$SNAPSHOT_NAME" "$BACKUP_DIR/$SNAPSHOT_NAME"
+ls "$BACKUP_DIR" | expired | while read -r f; do rm -f "$BACKUP_DIR/$f"; done
+`
+}
+
+func (s *pvcBackupStorage) downloadScript() string {
+	return etcdSnapshotScript + `cp "$BACKUP_DIR/$SNAPSHOT_NAME" "` + etcdSnapshotFile + `"
+`
+}
+
+// s3BackupStorage stores the snapshots in an S3 bucket.
+type s3BackupStorage struct {
+	controlplanev1.EtcdBackupStorage
+}
+
+func (s *s3BackupStorage) image() string {
+	if s.Image != "" {
+		return s.Image
+	}
+	return defaultS3BackupImage
+}
+
+func (s *s3BackupStorage) volumes() []corev1.Volume {
+	return nil
+}
+
+func (s *s3BackupStorage) volumeMounts() []corev1.VolumeMount {
+	return nil
+}
+
+func (s *s3BackupStorage) env() []corev1.EnvVar {
+	region := s.S3.Region
+	if region == "" {
+		region = defaultS3Region
+	}
+	return []corev1.EnvVar{
+		{
+			Name:  "AWS_DEFAULT_REGION",
+			Value: region,
+		},
+		{
+			Name:  "S3_ENDPOINT",
+			Value: s.S3.Endpoint,
+		},
+		{
+			Name:  "S3_BUCKET",
+			Value: s.S3.Bucket,
+		},
+		{
+			Name:  "S3_PREFIX",
+			Value: s.S3.Prefix,
+		},
+	}
+}
+
+func (s *s3BackupStorage) envFrom() []corev1.EnvFromSource {
+	return []corev1.EnvFromSource{
+		{
+			SecretRef: &corev1.SecretEnvSource{
+				LocalObjectReference: s.S3.CredentialsSecretRef,
+			},
+		},
+	}
+}
+
+// s3Script runs the aws cli against the endpoint of the storage, if any.
+const s3Script = `s3() {
+  if [ -n "$S3_ENDPOINT" ]; then aws --endpoint-url "$S3_ENDPOINT" s3 "$@"; else aws s3 "$@"; fi
+}
+`
+
+func (s *s3BackupStorage) uploadScript() string {
+	return etcdSnapshotScript + s3Script + `s3 cp "` + etcdSnapshotFile + `" "s3://$S3_BUCKET/$S3_PREFIX$SNAPSHOT_NAME"
+s3 ls "s3://$S3_BUCKET/$S3_PREFIX" | while read -r _ _ _ f; do echo "$f"; done | expired | while read -r f; do
+  s3 rm "s3://$S3_BUCKET/$S3_PREFIX$f"
+done
+`
+}
+
+func (s *s3BackupStorage) downloadScript() string {
+	return etcdSnapshotScript + s3Script + `s3 cp "s3://$S3_BUCKET/$S3_PREFIX$SNAPSHOT_NAME" "` + etcdSnapshotFile + `"
+`
+}
+
+// genEtcdSnapshotPodSpec generates the spec of the pod that takes a snapshot
+// of the etcd of the cluster, and copies it to the storage. The snapshot is
+// named snapshotName, or after the cluster and the time it is taken if
+// snapshotName is empty, in which case only the latest retention snapshots
+// are kept. The snapshot is taken by etcdctl of the etcd image with the
+// health check client certificate.
+func genEtcdSnapshotPodSpec(namespace, clusterName, etcdImage, snapshotName string,
+	retention int32, storage controlplanev1.EtcdBackupStorage) (*corev1.PodSpec, error) {
+	s, err := newEtcdBackupStorage(storage)
+	if err != nil {
+		return nil, err
+	}
+	env := []corev1.EnvVar{
+		{
+			Name:  "CLUSTER_NAME",
+			Value: clusterName,
+		},
+	}
+	if snapshotName != "" {
+		env = append(env, corev1.EnvVar{Name: "SNAPSHOT_NAME", Value: snapshotName})
+	}
+	if retention > 0 {
+		env = append(env, corev1.EnvVar{Name: "RETENTION", Value: fmt.Sprint(retention)})
+	}
+
+	var volSrtMode int32 = 420
+	return &corev1.PodSpec{
+		RestartPolicy: corev1.RestartPolicyNever,
+		InitContainers: []corev1.Container{
+			{
+				Name:  "etcd-snapshot",
+				Image: etcdImage,
+				Command: []string{
+					"etcdctl",
+					fmt.Sprintf("--endpoints=https://%s-etcd-0.%s-etcd.%s:2379",
+						clusterName, clusterName, namespace),
+					"--cacert=/etc/kubernetes/pki/ca/tls.crt",
+					"--cert=/etc/kubernetes/pki/health/tls.crt",
+					"--key=/etc/kubernetes/pki/health/tls.key",
+					"snapshot",
+					"save",
+					etcdSnapshotFile,
+				},
+				Env: []corev1.EnvVar{
+					{
+						Name:  "ETCDCTL_API",
+						Value: "3",
+					},
+				},
+				VolumeMounts: []corev1.VolumeMount{
+					{
+						MountPath: "/etc/kubernetes/pki/ca",
+						Name:      clusterName + "-etcd-ca",
+						ReadOnly:  true,
+					},
+					{
+						MountPath: "/etc/kubernetes/pki/health",
+						Name:      clusterName + "-etcd-health-client",
+						ReadOnly:  true,
+					},
+					{
+						MountPath: etcdSnapshotMountPath,
+						Name:      "snapshot",
+					},
+				},
+			},
+		},
+		Containers: []corev1.Container{
+			{
+				Name:    "etcd-backup",
+				Image:   s.image(),
+				Command: []string{"/bin/sh", "-c", s.uploadScript()},
+				Env:     append(env, s.env()...),
+				EnvFrom: s.envFrom(),
+				VolumeMounts: append([]corev1.VolumeMount{
+					{
+						MountPath: etcdSnapshotMountPath,
+						Name:      "snapshot",
+						ReadOnly:  true,
+					},
+				}, s.volumeMounts()...),
+			},
+		},
+		Volumes: append([]corev1.Volume{
+			{
+				Name: clusterName + "-etcd-ca",
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{
+						DefaultMode: &volSrtMode,
+						SecretName:  clusterName + "-etcd",
+					},
+				},
+			},
+			{
+				Name: clusterName + "-etcd-health-client",
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{
+						DefaultMode: &volSrtMode,
+						SecretName:  clusterName + "-etcd-health-client",
+					},
+				},
+			},
+			{
+				Name: "snapshot",
+				VolumeSource: corev1.VolumeSource{
+					EmptyDir: &corev1.EmptyDirVolumeSource{},
+				},
+			},
+		}, s.volumes()...),
+	}, nil
+}
+
+// genEtcdSnapshotJob generates the Job that takes a snapshot of the etcd of
+// the cluster, and saves it as snapshotName to the storage.
+func genEtcdSnapshotJob(name, namespace, clusterName, image, snapshotName string,
+	storage controlplanev1.EtcdBackupStorage) (*batchv1.Job, error) {
+	podSpec, err := genEtcdSnapshotPodSpec(namespace, clusterName, image, snapshotName, 0, storage)
+	if err != nil {
+		return nil, err
+	}
+	backoffLimit := etcdBackupBackoffLimit
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				Spec: *podSpec,
+			},
+		},
+	}, nil
+}
+
+// genEtcdBackupCronJob generates the CronJob that takes the scheduled
+// snapshots of the etcd of the cluster, and keeps the latest ones in the
+// storage as specified by the backup.
+func genEtcdBackupCronJob(namespace, clusterName, image string,
+	backup controlplanev1.NestedEtcdBackup) (*batchv1.CronJob, error) {
+	if backup.Schedule == "" {
+		return nil, errors.New("no schedule is specified for the etcd snapshots")
+	}
+	retention := backup.Retention
+	if retention <= 0 {
+		retention = defaultEtcdBackupRetention
+	}
+	podSpec, err := genEtcdSnapshotPodSpec(namespace, clusterName, image, "", retention, backup.Storage)
+	if err != nil {
+		return nil, err
+	}
+	backoffLimit := etcdBackupBackoffLimit
+	var successfulJobsHistoryLimit, failedJobsHistoryLimit int32 = 1, 3
+	return &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clusterName + "-etcd-backup",
+			Namespace: namespace,
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:                   backup.Schedule,
+			ConcurrencyPolicy:          batchv1.ForbidConcurrent,
+			SuccessfulJobsHistoryLimit: &successfulJobsHistoryLimit,
+			FailedJobsHistoryLimit:     &failedJobsHistoryLimit,
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					BackoffLimit: &backoffLimit,
+					Template: corev1.PodTemplateSpec{
+						Spec: *podSpec,
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// applyEtcdDataVolume keeps the data directory of the etcd members in
+// PersistentVolumeClaims, so that the restored data survives the restarts of
+// the members. The data directory is kept in the claims of
+// NestedEtcdRestore.DataVolumeClaim, unless it is mounted by a patch, which
+// must mount it from a PersistentVolumeClaim.
+func applyEtcdDataVolume(sts *appsv1.StatefulSet,
+	restore controlplanev1.NestedEtcdRestore) (*corev1.VolumeMount, error) {
+	podSpec := &sts.Spec.Template.Spec
+	etcdContainer := &podSpec.Containers[0]
+	for i, m := range etcdContainer.VolumeMounts {
+		if etcdDataDir != m.MountPath && !strings.HasPrefix(etcdDataDir, strings.TrimSuffix(m.MountPath, "/")+"/") {
+			continue
+		}
+		for _, vct := range sts.Spec.VolumeClaimTemplates {
+			if vct.GetName() == m.Name {
+				return &etcdContainer.VolumeMounts[i], nil
+			}
+		}
+		for _, v := range podSpec.Volumes {
+			if v.Name == m.Name && v.PersistentVolumeClaim != nil {
+				return &etcdContainer.VolumeMounts[i], nil
+			}
+		}
+		return nil, errors.Errorf("the data directory of the etcd is mounted from "+
+			"the volume %s, which is not a PersistentVolumeClaim", m.Name)
+	}
+
+	size := resource.MustParse("1Gi")
+	var storageClassName *string
+	if claim := restore.DataVolumeClaim; claim != nil {
+		if claim.Size != nil {
+			size = *claim.Size
+		}
+		storageClassName = claim.StorageClassName
+	}
+	sts.Spec.VolumeClaimTemplates = append(sts.Spec.VolumeClaimTemplates, corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "etcd-data",
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			StorageClassName: storageClassName,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: size,
+				},
+			},
+		},
+	})
+	etcdContainer.VolumeMounts = append(etcdContainer.VolumeMounts, corev1.VolumeMount{
+		MountPath: path.Dir(etcdDataDir),
+		Name:      "etcd-data",
+	})
+	return &etcdContainer.VolumeMounts[len(etcdContainer.VolumeMounts)-1], nil
+}
+
+// applyEtcdRestore adds the init containers that restore the first member of
+// the etcd StatefulSet from the snapshot, if the member has no data yet. The
+// other members join the restored cluster as they are added. The data
+// directory is kept in PersistentVolumeClaims, see applyEtcdDataVolume.
+func applyEtcdRestore(sts *appsv1.StatefulSet, clusterName string,
+	restore controlplanev1.NestedEtcdRestore) error {
+	s, err := newEtcdBackupStorage(restore.Storage)
+	if err != nil {
+		return err
+	}
+	dataMount, err := applyEtcdDataVolume(sts, restore)
+	if err != nil {
+		return err
+	}
+	podSpec := &sts.Spec.Template.Spec
+	etcdContainer := &podSpec.Containers[0]
+
+	firstMember := fmt.Sprintf("%s-etcd-0", clusterName)
+	snapshotMount := corev1.VolumeMount{
+		MountPath: etcdSnapshotMountPath,
+		Name:      "snapshot",
+	}
+	podSpec.Volumes = append(podSpec.Volumes, append([]corev1.Volume{
+		{
+			Name: "snapshot",
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		},
+	}, s.volumes()...)...)
+	podSpec.InitContainers = append(podSpec.InitContainers,
+		corev1.Container{
+			Name:  "etcd-snapshot-fetch",
+			Image: s.image(),
+			Command: []string{"/bin/sh", "-c",
+				"rm -rf " + path.Join(etcdSnapshotMountPath, "data") + "\n" + s.downloadScript()},
+			Env: append([]corev1.EnvVar{
+				{
+					Name:  "SNAPSHOT_NAME",
+					Value: restore.Snapshot,
+				},
+			}, s.env()...),
+			EnvFrom:      s.envFrom(),
+			VolumeMounts: append([]corev1.VolumeMount{snapshotMount}, s.volumeMounts()...),
+		},
+		corev1.Container{
+			Name:  "etcd-snapshot-restore",
+			Image: etcdContainer.Image,
+			Command: []string{
+				"etcdctl",
+				"snapshot",
+				"restore",
+				etcdSnapshotFile,
+				"--data-dir=" + path.Join(etcdSnapshotMountPath, "data"),
+				"--name=" + firstMember,
+				"--initial-cluster=" + genInitialClusterArgs(1, clusterName, clusterName, sts.GetNamespace()),
+				fmt.Sprintf("--initial-advertise-peer-urls=https://%s.%s-etcd.%s.svc:2380",
+					firstMember, clusterName, sts.GetNamespace()),
+			},
+			Env: []corev1.EnvVar{
+				{
+					Name:  "ETCDCTL_API",
+					Value: "3",
+				},
+			},
+			VolumeMounts: []corev1.VolumeMount{snapshotMount},
+		},
+		corev1.Container{
+			Name:  "etcd-snapshot-install",
+			Image: s.image(),
+			Command: []string{"/bin/sh", "-c", fmt.Sprintf(
+				`[ "$(hostname)" = %q ] || exit 0
+[ -d "$DATA_DIR/member" ] && exit 0
+mkdir -p "$DATA_DIR"
+cp -a %s "$DATA_DIR/"
+`, firstMember, path.Join(etcdSnapshotMountPath, "data", "member"))},
+			Env: []corev1.EnvVar{
+				{
+					Name:  "DATA_DIR",
+					Value: etcdDataDir,
+				},
+			},
+			VolumeMounts: []corev1.VolumeMount{snapshotMount, *dataMount},
+		},
+	)
+	return nil
+}
+
+// isEtcdRestorePending returns true if the StatefulSet still runs the init
+// containers that restore the etcd from the snapshot.
+func isEtcdRestorePending(sts *appsv1.StatefulSet) bool {
+	for _, c := range sts.Spec.Template.Spec.InitContainers {
+		if c.Name == "etcd-snapshot-fetch" {
+			return true
+		}
+	}
+	return false
+}
+
+// getEtcdImage gets the image of the etcd from the manifests configmap.
+func getEtcdImage(ctx context.Context, cli ctrlcli.Client, namespace, clusterName string) (string, error) {
+	podManifest, err := getComponentManifest(ctx, cli, namespace, kubeadm.Etcd, clusterName)
+	if err != nil {
+		return "", err
+	}
+	pod := corev1.Pod{}
+	if err := yamlToObject([]byte(podManifest), &pod); err != nil {
+		return "", errors.Errorf("fail to convert yaml file to pod: %v", err)
+	}
+	if len(pod.Spec.Containers) == 0 {
+		return "", errors.New("the etcd manifest has no container")
+	}
+	return pod.Spec.Containers[0].Image, nil
+}
+
+// isJobFinished returns the type of the condition the Job finished with, or
+// an empty string if the Job is still running.
+func isJobFinished(job *batchv1.Job) batchv1.JobConditionType {
+	for _, c := range job.Status.Conditions {
+		if (c.Type == batchv1.JobComplete || c.Type == batchv1.JobFailed) &&
+			c.Status == corev1.ConditionTrue {
+			return c.Type
+		}
+	}
+	return ""
+}
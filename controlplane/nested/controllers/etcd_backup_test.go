@@ -0,0 +1,247 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	controlplanev1 "sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/api/v1alpha4"
+)
+
+func TestGenEtcdSnapshotJob(t *testing.T) {
+	if _, err := genEtcdSnapshotJob("c-etcd-backup", "default", "c", "etcd:3.4.13-0",
+		"c.db", controlplanev1.EtcdBackupStorage{}); err == nil {
+		t.Fatalf("\t%s\texpect error without storage, but get nil", failed)
+	}
+	job, err := genEtcdSnapshotJob("c-etcd-backup", "default", "c", "etcd:3.4.13-0",
+		"c.db", controlplanev1.EtcdBackupStorage{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "backup"},
+		})
+	if err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	podSpec := job.Spec.Template.Spec
+	command := podSpec.InitContainers[0].Command
+	if command[1] != "--endpoints=https://c-etcd-0.c-etcd.default:2379" ||
+		command[len(command)-1] != "/snapshot/snapshot.db" {
+		t.Fatalf("\t%s\tunexpected command %v", failed, command)
+	}
+	if env := podSpec.Containers[0].Env; !hasEnvVar(env, "SNAPSHOT_NAME", "c.db") || hasEnvVar(env, "RETENTION", "") {
+		t.Fatalf("\t%s\tunexpected env %v", failed, env)
+	}
+	if claim := podSpec.Volumes[3].PersistentVolumeClaim; claim == nil || claim.ClaimName != "backup" {
+		t.Fatalf("\t%s\texpect the snapshot to be saved to the claim, but get %v", failed, claim)
+	}
+	t.Logf("\t%s\tthe snapshot Job is generated", succeed)
+}
+
+func TestGenEtcdBackupCronJob(t *testing.T) {
+	s3 := controlplanev1.EtcdBackupStorage{
+		S3: &controlplanev1.S3BackupStorage{
+			Endpoint:             "https://minio:9000",
+			Bucket:               "snapshots",
+			Prefix:               "c/",
+			CredentialsSecretRef: corev1.LocalObjectReference{Name: "s3-credentials"},
+		},
+	}
+	tests := []struct {
+		name        string
+		backup      controlplanev1.NestedEtcdBackup
+		expectErr   bool
+		expectImage string
+		expectEnv   map[string]string
+	}{
+		{
+			name:      "TestNoSchedule",
+			backup:    controlplanev1.NestedEtcdBackup{Storage: s3},
+			expectErr: true,
+		},
+		{
+			name: "TestBothStorages",
+			backup: controlplanev1.NestedEtcdBackup{
+				Schedule: "0 * * * *",
+				Storage: controlplanev1.EtcdBackupStorage{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "backup"},
+					S3:                    s3.S3,
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "TestPVCWithDefaultRetention",
+			backup: controlplanev1.NestedEtcdBackup{
+				Schedule: "0 * * * *",
+				Storage: controlplanev1.EtcdBackupStorage{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "backup"},
+				},
+			},
+			expectImage: defaultPVCBackupImage,
+			expectEnv:   map[string]string{"CLUSTER_NAME": "c", "RETENTION": "7", "BACKUP_DIR": "/backup"},
+		},
+		{
+			name:        "TestS3",
+			backup:      controlplanev1.NestedEtcdBackup{Schedule: "0 * * * *", Retention: 3, Storage: s3},
+			expectImage: defaultS3BackupImage,
+			expectEnv: map[string]string{"CLUSTER_NAME": "c", "RETENTION": "3", "AWS_DEFAULT_REGION": "us-east-1",
+				"S3_ENDPOINT": "https://minio:9000", "S3_BUCKET": "snapshots", "S3_PREFIX": "c/"},
+		},
+	}
+	for _, tt := range tests {
+		st := tt
+		t.Run(st.name, func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			cronJob, err := genEtcdBackupCronJob("default", "c", "etcd:3.4.13-0", st.backup)
+			if st.expectErr {
+				if err == nil {
+					t.Fatalf("\t%s\texpect error, but get nil", failed)
+				}
+				t.Logf("\t%s\tget the expected error: %v", succeed, err)
+				return
+			}
+			if err != nil {
+				t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+			}
+			if cronJob.GetName() != "c-etcd-backup" || cronJob.Spec.Schedule != st.backup.Schedule ||
+				cronJob.Spec.ConcurrencyPolicy != batchv1.ForbidConcurrent {
+				t.Fatalf("\t%s\tunexpected CronJob %s %s", failed, cronJob.GetName(), cronJob.Spec.Schedule)
+			}
+			container := cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0]
+			if container.Image != st.expectImage {
+				t.Fatalf("\t%s\texpect image %s, but get %s", failed, st.expectImage, container.Image)
+			}
+			for name, value := range st.expectEnv {
+				if !hasEnvVar(container.Env, name, value) {
+					t.Fatalf("\t%s\texpect env %s=%s, but get %v", failed, name, value, container.Env)
+				}
+			}
+			if hasEnvVar(container.Env, "SNAPSHOT_NAME", "") {
+				t.Fatalf("\t%s\tthe scheduled snapshots should be named by the script", failed)
+			}
+			t.Logf("\t%s\tthe backup CronJob is generated", succeed)
+		})
+	}
+}
+
+func TestApplyEtcdRestore(t *testing.T) {
+	restore := controlplanev1.NestedEtcdRestore{
+		Snapshot: "c-20211201000000.db",
+		Storage: controlplanev1.EtcdBackupStorage{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "backup"},
+		},
+	}
+	tests := []struct {
+		name            string
+		mounts          []corev1.VolumeMount
+		volumes         []corev1.Volume
+		expectDataMount string
+		expectClaim     bool
+		expectErr       bool
+	}{
+		{
+			name:            "TestClaimedDataDir",
+			expectDataMount: "etcd-data",
+			expectClaim:     true,
+		},
+		{
+			name:   "TestPatchedDataDir",
+			mounts: []corev1.VolumeMount{{Name: "data", MountPath: "/var/lib/etcd/"}},
+			volumes: []corev1.Volume{{
+				Name: "data",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "etcd-data"},
+				},
+			}},
+			expectDataMount: "data",
+		},
+		{
+			name:   "TestEmptyDirDataDir",
+			mounts: []corev1.VolumeMount{{Name: "data", MountPath: "/var/lib/etcd/"}},
+			volumes: []corev1.Volume{{
+				Name:         "data",
+				VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+			}},
+			expectErr: true,
+		},
+	}
+	for _, tt := range tests {
+		st := tt
+		t.Run(st.name, func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			sts := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "c-etcd", Namespace: "default"},
+			}
+			sts.Spec.Template.Spec.Containers = []corev1.Container{
+				{Name: "etcd", Image: "etcd:3.4.13-0", VolumeMounts: st.mounts},
+			}
+			sts.Spec.Template.Spec.Volumes = st.volumes
+			err := applyEtcdRestore(sts, "c", restore)
+			if st.expectErr {
+				if err == nil {
+					t.Fatalf("\t%s\texpect the data dir out of a PersistentVolumeClaim to be rejected", failed)
+				}
+				t.Logf("\t%s\tthe data dir out of a PersistentVolumeClaim is rejected", succeed)
+				return
+			}
+			if err != nil {
+				t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+			}
+			if claimed := len(sts.Spec.VolumeClaimTemplates) == 1 &&
+				sts.Spec.VolumeClaimTemplates[0].Name == "etcd-data"; claimed != st.expectClaim {
+				t.Fatalf("\t%s\texpect the data dir claimed %v, but get %v", failed, st.expectClaim, sts.Spec.VolumeClaimTemplates)
+			}
+			podSpec := sts.Spec.Template.Spec
+			if len(podSpec.InitContainers) != 3 {
+				t.Fatalf("\t%s\texpect 3 init containers, but get %d", failed, len(podSpec.InitContainers))
+			}
+			if !hasEnvVar(podSpec.InitContainers[0].Env, "SNAPSHOT_NAME", restore.Snapshot) {
+				t.Fatalf("\t%s\tthe snapshot to fetch is not set: %v", failed, podSpec.InitContainers[0].Env)
+			}
+			restoreCmd := strings.Join(podSpec.InitContainers[1].Command, " ")
+			if podSpec.InitContainers[1].Image != "etcd:3.4.13-0" ||
+				!strings.Contains(restoreCmd, "--name=c-etcd-0 --initial-cluster=c-etcd-0=https://c-etcd-0.c-etcd.default.svc:2380") {
+				t.Fatalf("\t%s\tunexpected restore command %s", failed, restoreCmd)
+			}
+			install := podSpec.InitContainers[2]
+			if mounts := install.VolumeMounts; mounts[len(mounts)-1].Name != st.expectDataMount {
+				t.Fatalf("\t%s\texpect the data dir in %s, but get %v", failed, st.expectDataMount, mounts)
+			}
+			if mounts := podSpec.Containers[0].VolumeMounts; mounts[len(mounts)-1].Name != st.expectDataMount {
+				t.Fatalf("\t%s\texpect the etcd data dir in %s, but get %v", failed, st.expectDataMount, mounts)
+			}
+			t.Logf("\t%s\tthe restore init containers are added", succeed)
+		})
+	}
+}
+
+// hasEnvVar returns true if the env has the variable, with the value unless
+// the value is empty.
+func hasEnvVar(env []corev1.EnvVar, name, value string) bool {
+	for _, e := range env {
+		if e.Name == name && (value == "" || e.Value == value) {
+			return true
+		}
+	}
+	return false
+}
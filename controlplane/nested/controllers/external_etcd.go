@@ -0,0 +1,188 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	ctrlcli "sigs.k8s.io/controller-runtime/pkg/client"
+
+	controlplanev1 "sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/api/v1alpha4"
+)
+
+const (
+	// externalEtcdCAKey is the key of the CA certificate of the external etcd
+	// in the certificates secret.
+	externalEtcdCAKey = "ca.crt"
+	// defaultEtcdClientPort is the client port of the etcd endpoints that
+	// have no port.
+	defaultEtcdClientPort = 2379
+)
+
+// applyExternalEtcd points the kube-apiserver pod spec to the external etcd.
+// The certificates of the external etcd are mounted in place of the ones of
+// the NestedEtcd, so that the etcd flags of the apiserver are kept.
+func applyExternalEtcd(ps *corev1.PodSpec, clusterName string, etcd *controlplanev1.ExternalEtcd) {
+	for i := range ps.Volumes {
+		switch ps.Volumes[i].Name {
+		case clusterName + "-etcd-ca":
+			ps.Volumes[i].Secret.SecretName = etcd.CertificatesSecretRef.Name
+			ps.Volumes[i].Secret.Items = []corev1.KeyToPath{
+				{Key: externalEtcdCAKey, Path: corev1.TLSCertKey},
+			}
+		case clusterName + "-etcd-client":
+			ps.Volumes[i].Secret.SecretName = etcd.CertificatesSecretRef.Name
+			ps.Volumes[i].Secret.Items = []corev1.KeyToPath{
+				{Key: corev1.TLSCertKey, Path: corev1.TLSCertKey},
+				{Key: corev1.TLSPrivateKeyKey, Path: corev1.TLSPrivateKeyKey},
+			}
+		}
+	}
+
+	container := &ps.Containers[0]
+	container.Command = append(removeCommandFlags(container.Command, "etcd-servers", "etcd-prefix"),
+		"--etcd-servers="+strings.Join(etcd.Endpoints, ","))
+	if etcd.KeyPrefix != "" {
+		container.Command = append(container.Command, "--etcd-prefix="+etcd.KeyPrefix)
+	}
+}
+
+// removeCommandFlags removes the command line flags from the command.
+func removeCommandFlags(command []string, flags ...string) []string {
+	ret := make([]string, 0, len(command))
+	for _, arg := range command {
+		removed := false
+		for _, flag := range flags {
+			if arg == "--"+flag || strings.HasPrefix(arg, "--"+flag+"=") {
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			ret = append(ret, arg)
+		}
+	}
+	return ret
+}
+
+// externalEtcdAddresses returns the addresses of the endpoints of the
+// external etcd, the invalid endpoints are skipped.
+func externalEtcdAddresses(etcd *controlplanev1.ExternalEtcd) []controlplanev1.NestedEtcdAddress {
+	addresses := make([]controlplanev1.NestedEtcdAddress, 0, len(etcd.Endpoints))
+	for _, endpoint := range etcd.Endpoints {
+		u, err := url.Parse(endpoint)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		port := int32(defaultEtcdClientPort)
+		if p, err := strconv.ParseInt(u.Port(), 10, 32); err == nil {
+			port = int32(p)
+		}
+		addresses = append(addresses, controlplanev1.NestedEtcdAddress{
+			Hostname: u.Hostname(),
+			Port:     port,
+		})
+	}
+	return addresses
+}
+
+// checkExternalEtcdHealth checks the health of the external etcd through the
+// /health endpoint of its members with the client certificate of the
+// apiserver. The etcd is healthy if any member reports it healthy, as the
+// member reports the health of the whole cluster.
+func checkExternalEtcdHealth(ctx context.Context, cli ctrlcli.Client,
+	namespace string, etcd *controlplanev1.ExternalEtcd) error {
+	var certs corev1.Secret
+	if err := cli.Get(ctx, types.NamespacedName{
+		Namespace: namespace,
+		Name:      etcd.CertificatesSecretRef.Name,
+	}, &certs); err != nil {
+		return errors.Wrap(err, "fail to get the certificates of the external etcd")
+	}
+	tlsConfig, err := genEtcdClientTLSConfig(&certs)
+	if err != nil {
+		return err
+	}
+	httpClient := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		Timeout:   etcdHealthCheckTimeout,
+	}
+
+	var errs []error
+	for _, endpoint := range etcd.Endpoints {
+		if err := checkEtcdEndpointHealth(ctx, httpClient, endpoint); err != nil {
+			errs = append(errs, errors.Wrapf(err, "etcd endpoint %s", endpoint))
+			continue
+		}
+		return nil
+	}
+	return kerrors.NewAggregate(errs)
+}
+
+// genEtcdClientTLSConfig generates the TLS config of the etcd client from the
+// certificates secret.
+func genEtcdClientTLSConfig(certs *corev1.Secret) (*tls.Config, error) {
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM(certs.Data[externalEtcdCAKey]) {
+		return nil, errors.Errorf("no valid %s is found in the secret %s", externalEtcdCAKey, certs.GetName())
+	}
+	clientCert, err := tls.X509KeyPair(certs.Data[corev1.TLSCertKey], certs.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid client certificate in the secret %s", certs.GetName())
+	}
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		RootCAs:      rootCAs,
+		Certificates: []tls.Certificate{clientCert},
+	}, nil
+}
+
+// checkEtcdEndpointHealth queries the /health endpoint of the etcd member.
+func checkEtcdEndpointHealth(ctx context.Context, httpClient *http.Client, endpoint string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(endpoint, "/")+"/health", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// the unhealthy member responds 503 with the reason.
+	health := struct {
+		Health string `json:"health"`
+		Reason string `json:"reason,omitempty"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return errors.Wrapf(err, "invalid health response with status %s", resp.Status)
+	}
+	if health.Health != "true" {
+		return errors.Errorf("unhealthy: %s", health.Reason)
+	}
+	return nil
+}
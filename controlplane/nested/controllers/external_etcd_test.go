@@ -0,0 +1,133 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/cluster-api/util/secret"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	controlplanev1 "sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/api/v1alpha4"
+	certutil "sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/certificate/util"
+	"sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/kubeadm"
+)
+
+func TestCompleteTemplatesWithExternalEtcd(t *testing.T) {
+	templates, err := kubeadm.GenerateTemplates(kubeadm.Options{ClusterName: "c"})
+	if err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	externalEtcd := &controlplanev1.ExternalEtcd{
+		Endpoints:             []string{"https://etcd-0.example.com:2379", "https://etcd-1.example.com:2379"},
+		CertificatesSecretRef: corev1.LocalObjectReference{Name: "external-etcd"},
+		KeyPrefix:             "/tenants/c/registry",
+	}
+	manifests, err := completeTemplates(templates, "c", controlplanev1.NestedControlPlaneSpec{ExternalEtcd: externalEtcd})
+	if err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	ps := manifests[kubeadm.APIServer].Spec
+	command := strings.Join(ps.Containers[0].Command, " ")
+	if strings.Count(command, "--etcd-servers=") != 1 ||
+		!strings.Contains(command, "--etcd-servers=https://etcd-0.example.com:2379,https://etcd-1.example.com:2379") ||
+		!strings.Contains(command, "--etcd-prefix=/tenants/c/registry") {
+		t.Fatalf("\t%s\tthe apiserver is not pointed to the external etcd: %s", failed, command)
+	}
+	for _, v := range ps.Volumes {
+		if v.Name != "c-etcd-ca" && v.Name != "c-etcd-client" {
+			continue
+		}
+		if v.Secret.SecretName != "external-etcd" {
+			t.Fatalf("\t%s\texpect volume %s from the external etcd secret, but get %s", failed, v.Name, v.Secret.SecretName)
+		}
+	}
+	t.Logf("\t%s\tthe apiserver uses the external etcd", succeed)
+}
+
+func TestExternalEtcdAddresses(t *testing.T) {
+	addresses := externalEtcdAddresses(&controlplanev1.ExternalEtcd{
+		Endpoints: []string{"https://etcd-0.example.com:2380", "https://10.0.0.1", "://invalid"},
+	})
+	expect := []controlplanev1.NestedEtcdAddress{
+		{Hostname: "etcd-0.example.com", Port: 2380},
+		{Hostname: "10.0.0.1", Port: 2379},
+	}
+	if !reflect.DeepEqual(addresses, expect) {
+		t.Fatalf("\t%s\texpect %v, but get %v", failed, expect, addresses)
+	}
+	t.Logf("\t%s\tthe addresses of the external etcd are parsed", succeed)
+}
+
+func TestCheckExternalEtcdHealth(t *testing.T) {
+	healthy := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"health":"true"}`)
+	}))
+	defer healthy.Close()
+	unhealthy := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, `{"health":"false","reason":"RAFT NO LEADER"}`)
+	}))
+	defer unhealthy.Close()
+
+	clientCert := &secret.Certificate{Purpose: secret.EtcdCA}
+	if err := clientCert.Generate(); err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	// the test servers share their certificate.
+	certs := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "external-etcd", Namespace: "default"},
+		Data: map[string][]byte{
+			"ca.crt":                certutil.EncodeCertPEM(healthy.Certificate()),
+			corev1.TLSCertKey:       clientCert.KeyPair.Cert,
+			corev1.TLSPrivateKeyKey: clientCert.KeyPair.Key,
+		},
+	}
+	cli := fake.NewClientBuilder().WithObjects(certs).Build()
+
+	tests := []struct {
+		name      string
+		endpoints []string
+		expectErr bool
+	}{
+		{"TestHealthy", []string{unhealthy.URL, healthy.URL}, false},
+		{"TestUnhealthy", []string{unhealthy.URL}, true},
+		{"TestUnreachable", []string{"https://127.0.0.1:1"}, true},
+	}
+	for _, tt := range tests {
+		st := tt
+		t.Run(st.name, func(t *testing.T) {
+			t.Logf("\tTestCase: %s", st.name)
+			err := checkExternalEtcdHealth(context.TODO(), cli, "default", &controlplanev1.ExternalEtcd{
+				Endpoints:             st.endpoints,
+				CertificatesSecretRef: corev1.LocalObjectReference{Name: "external-etcd"},
+			})
+			if (err != nil) != st.expectErr {
+				t.Fatalf("\t%s\texpect error %v, but get %v", failed, st.expectErr, err)
+			}
+			t.Logf("\t%s\tget the expected health: %v", succeed, err)
+		})
+	}
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	controlplanev1 "sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/kubeadm"
+)
+
+// +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create
+
+const (
+	// defaultKineImage is the default image of kine.
+	defaultKineImage = "rancher/kine:v0.8.1"
+	// kineListenAddress is the address kine serves the etcd API on, it is
+	// only reachable from the apiserver in the same pod.
+	kineListenAddress = "127.0.0.1:2379"
+	// kineDataDir is where the SQLite database is stored.
+	kineDataDir = "/var/lib/kine"
+	// defaultKineStorageSize is the default size of the PersistentVolumeClaim
+	// of the SQLite database.
+	defaultKineStorageSize = "1Gi"
+)
+
+// kinePVCName returns the name of the PersistentVolumeClaim of the SQLite
+// database of the cluster.
+func kinePVCName(clusterName string) string {
+	return clusterName + "-kine"
+}
+
+// applyKineDatastore replaces the etcd of the kube-apiserver pod spec with a
+// kine sidecar, which serves the etcd API on the loopback address without TLS.
+func applyKineDatastore(ps *corev1.PodSpec, clusterName string, datastore *controlplanev1.KineDatastore) {
+	volumes := make([]corev1.Volume, 0, len(ps.Volumes))
+	for _, v := range ps.Volumes {
+		if v.Name != clusterName+"-etcd-ca" && v.Name != clusterName+"-etcd-client" {
+			volumes = append(volumes, v)
+		}
+	}
+	ps.Volumes = volumes
+	container := &ps.Containers[0]
+	mounts := make([]corev1.VolumeMount, 0, len(container.VolumeMounts))
+	for _, m := range container.VolumeMounts {
+		if m.Name != clusterName+"-etcd-ca" && m.Name != clusterName+"-etcd-client" {
+			mounts = append(mounts, m)
+		}
+	}
+	container.VolumeMounts = mounts
+	container.Command = append(removeCommandFlags(container.Command,
+		"etcd-servers", "etcd-cafile", "etcd-certfile", "etcd-keyfile"),
+		"--etcd-servers=http://"+kineListenAddress)
+
+	image := datastore.Image
+	if image == "" {
+		image = defaultKineImage
+	}
+	kine := corev1.Container{
+		Name:            "kine",
+		Image:           image,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"kine", "--listen-address=" + kineListenAddress},
+	}
+	switch {
+	case datastore.SQLite != nil:
+		kine.Command = append(kine.Command,
+			"--endpoint=sqlite://"+kineDataDir+"/state.db?_journal=WAL&cache=shared")
+		kine.VolumeMounts = []corev1.VolumeMount{
+			{
+				MountPath: kineDataDir,
+				Name:      "kine-data",
+			},
+		}
+		ps.Volumes = append(ps.Volumes, corev1.Volume{
+			Name: "kine-data",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: kinePVCName(clusterName),
+				},
+			},
+		})
+	case datastore.SQL != nil:
+		kine.Command = append(kine.Command, "--endpoint=$(KINE_ENDPOINT)")
+		kine.Env = []corev1.EnvVar{
+			{
+				Name: "KINE_ENDPOINT",
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: datastore.SQL.EndpointSecretRef.DeepCopy(),
+				},
+			},
+		}
+	}
+	ps.Containers = append(ps.Containers, kine)
+}
+
+// kineReplicasError returns an error if the SQLite database would be opened
+// by more than one replica of the NestedAPIServer among the NestedComponents.
+func kineReplicasError(datastore *controlplanev1.KineDatastore, components map[string]client.Object) error {
+	if datastore == nil || datastore.SQLite == nil {
+		return nil
+	}
+	nkas, ok := components[kubeadm.APIServer].(*controlplanev1.NestedAPIServer)
+	if !ok || nkas.Spec.Replicas <= 1 {
+		return nil
+	}
+	return errors.Errorf("the SQLite datastore needs a single replica of the NestedAPIServer %s, but get %d",
+		nkas.GetName(), nkas.Spec.Replicas)
+}
+
+// genKinePVC generates the PersistentVolumeClaim of the SQLite database.
+func genKinePVC(namespace, clusterName string, sqlite *controlplanev1.SQLiteDatastore) (*corev1.PersistentVolumeClaim, error) {
+	size := resource.MustParse(defaultKineStorageSize)
+	if sqlite.Size != nil {
+		size = *sqlite.Size
+	}
+	if size.Sign() <= 0 {
+		return nil, errors.Errorf("invalid size %s of the SQLite datastore", size.String())
+	}
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      kinePVCName(clusterName),
+			Namespace: namespace,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			StorageClassName: sqlite.StorageClassName,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: size,
+				},
+			},
+		},
+	}, nil
+}
+
+// reconcileKineStorage creates the PersistentVolumeClaim of the SQLite
+// database if it is not found, the claim is deleted along with the
+// NestedControlPlane.
+func (r *NestedControlPlaneReconciler) reconcileKineStorage(ctx context.Context,
+	ncp *controlplanev1.NestedControlPlane, clusterName string) error {
+	if ncp.Spec.Datastore == nil || ncp.Spec.Datastore.SQLite == nil {
+		return nil
+	}
+	var pvc corev1.PersistentVolumeClaim
+	if err := r.Get(ctx, types.NamespacedName{
+		Namespace: ncp.GetNamespace(),
+		Name:      kinePVCName(clusterName),
+	}, &pvc); err == nil || !apierrors.IsNotFound(err) {
+		return err
+	}
+	newPVC, err := genKinePVC(ncp.GetNamespace(), clusterName, ncp.Spec.Datastore.SQLite)
+	if err != nil {
+		return err
+	}
+	if err := ctrl.SetControllerReference(ncp, newPVC, r.Scheme); err != nil {
+		return err
+	}
+	return r.Create(ctx, newPVC)
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	controlplanev1 "sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/kubeadm"
+)
+
+func TestCompleteTemplatesWithKine(t *testing.T) {
+	tests := []struct {
+		name          string
+		datastore     *controlplanev1.KineDatastore
+		expectCommand string
+		expectVolume  string
+	}{
+		{
+			name:          "TestSQLite",
+			datastore:     &controlplanev1.KineDatastore{SQLite: &controlplanev1.SQLiteDatastore{}},
+			expectCommand: "--endpoint=sqlite:///var/lib/kine/state.db",
+			expectVolume:  "c-kine",
+		},
+		{
+			name: "TestSQL",
+			datastore: &controlplanev1.KineDatastore{
+				Image: "kine:test",
+				SQL: &controlplanev1.SQLDatastore{
+					EndpointSecretRef: corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "postgres"},
+						Key:                  "endpoint",
+					},
+				},
+			},
+			expectCommand: "--endpoint=$(KINE_ENDPOINT)",
+		},
+	}
+	for _, tt := range tests {
+		st := tt
+		t.Run(st.name, func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			templates, err := kubeadm.GenerateTemplates(kubeadm.Options{ClusterName: "c"})
+			if err != nil {
+				t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+			}
+			manifests, err := completeTemplates(templates, "c",
+				controlplanev1.NestedControlPlaneSpec{Datastore: st.datastore})
+			if err != nil {
+				t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+			}
+			ps := manifests[kubeadm.APIServer].Spec
+			if len(ps.Containers) != 2 || ps.Containers[1].Name != "kine" {
+				t.Fatalf("\t%s\texpect the kine sidecar, but get %d containers", failed, len(ps.Containers))
+			}
+			command := strings.Join(ps.Containers[0].Command, " ")
+			if !strings.Contains(command, "--etcd-servers=http://127.0.0.1:2379") ||
+				strings.Contains(command, "--etcd-cafile") || strings.Count(command, "--etcd-servers") != 1 {
+				t.Fatalf("\t%s\tthe apiserver is not pointed to kine: %s", failed, command)
+			}
+			if kineCommand := strings.Join(ps.Containers[1].Command, " "); !strings.Contains(kineCommand, st.expectCommand) {
+				t.Fatalf("\t%s\texpect kine command with %s, but get %s", failed, st.expectCommand, kineCommand)
+			}
+			claimName := ""
+			for _, v := range ps.Volumes {
+				if strings.HasPrefix(v.Name, "c-etcd") {
+					t.Fatalf("\t%s\tthe etcd volume %s is not removed", failed, v.Name)
+				}
+				if v.PersistentVolumeClaim != nil {
+					claimName = v.PersistentVolumeClaim.ClaimName
+				}
+			}
+			if claimName != st.expectVolume {
+				t.Fatalf("\t%s\texpect claim %q, but get %q", failed, st.expectVolume, claimName)
+			}
+			t.Logf("\t%s\tthe apiserver uses the kine datastore", succeed)
+		})
+	}
+}
+
+func TestGenKinePVC(t *testing.T) {
+	storageClass := "fast"
+	size := resource.MustParse("5Gi")
+	pvc, err := genKinePVC("default", "c", &controlplanev1.SQLiteDatastore{StorageClassName: &storageClass, Size: &size})
+	if err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	if pvc.GetName() != "c-kine" || *pvc.Spec.StorageClassName != "fast" ||
+		!pvc.Spec.Resources.Requests.Storage().Equal(size) {
+		t.Fatalf("\t%s\tunexpected PersistentVolumeClaim %v", failed, pvc)
+	}
+	pvc, err = genKinePVC("default", "c", &controlplanev1.SQLiteDatastore{})
+	if err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	if !pvc.Spec.Resources.Requests.Storage().Equal(resource.MustParse("1Gi")) || pvc.Spec.StorageClassName != nil {
+		t.Fatalf("\t%s\texpect the default size and storage class, but get %v", failed, pvc.Spec)
+	}
+	t.Logf("\t%s\tthe PersistentVolumeClaim of the SQLite datastore is generated", succeed)
+}
+
+func TestKineReplicasError(t *testing.T) {
+	sqlite := &controlplanev1.KineDatastore{SQLite: &controlplanev1.SQLiteDatastore{}}
+	sql := &controlplanev1.KineDatastore{SQL: &controlplanev1.SQLDatastore{}}
+	nkas := func(replicas int32) map[string]client.Object {
+		return map[string]client.Object{kubeadm.APIServer: &controlplanev1.NestedAPIServer{
+			Spec: controlplanev1.NestedAPIServerSpec{
+				NestedComponentSpec: controlplanev1.NestedComponentSpec{Replicas: replicas},
+			},
+		}}
+	}
+	if err := kineReplicasError(sqlite, nkas(1)); err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	if err := kineReplicasError(sql, nkas(3)); err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	if err := kineReplicasError(sqlite, nkas(3)); err == nil {
+		t.Fatalf("\t%s\texpect the replicas of the NestedAPIServer sharing the SQLite datastore to be rejected", failed)
+	}
+	t.Logf("\t%s\tthe SQLite datastore needs a single NestedAPIServer replica", succeed)
+}
@@ -19,9 +19,11 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"net"
 
 	"github.com/go-logr/logr"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -30,7 +32,10 @@ import (
 	"sigs.k8s.io/cluster-api/util/certs"
 	"sigs.k8s.io/cluster-api/util/secret"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	controlplanev1 "sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/api/v1alpha4"
 	"sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/certificate"
@@ -56,6 +61,10 @@ func (r *NestedAPIServerReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 	if err := r.Get(ctx, req.NamespacedName, &nkas); err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
+	if !nkas.GetDeletionTimestamp().IsZero() {
+		// the NestedAPIServer is being deleted, don't recreate its StatefulSet.
+		return ctrl.Result{}, nil
+	}
 	log.Info("creating NestedAPIServer",
 		"namespace", nkas.GetNamespace(),
 		"name", nkas.GetName())
@@ -124,7 +133,25 @@ func (r *NestedAPIServerReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		return ctrl.Result{}, err
 	}
 
-	// 3. reconcile the NestedAPIServer based on the status of the StatefulSet.
+	// 3. reissue the serving cert if the ControlPlaneEndpoint is populated
+	// after the cert has been issued.
+	if err := r.reissueAPIServerCrt(ctx, cluster, &nkas); err != nil {
+		log.Error(err, "fail to reissue NestedAPIServer serving cert")
+		return ctrl.Result{}, err
+	}
+
+	// 4. reconcile the NestedComponentSpec onto the existing StatefulSet and Service.
+	if patched, err := syncNestedComponentSts(ctx, r.Client, nkas.ObjectMeta,
+		nkas.Spec.NestedComponentSpec, &nkasSts,
+		kubeadm.APIServer, cluster.GetName(), log); err != nil {
+		log.Error(err, "fail to update NestedAPIServer StatefulSet")
+		return ctrl.Result{}, err
+	} else if patched {
+		// wait for the StatefulSet to roll out the new spec.
+		return ctrl.Result{}, nil
+	}
+
+	// 5. reconcile the NestedAPIServer based on the status of the StatefulSet.
 	// Mark the NestedAPIServer as Ready if the StatefulSet is ready.
 	if nkasSts.Status.ReadyReplicas == nkasSts.Status.Replicas {
 		log.Info("The NestedAPIServer StatefulSet is ready")
@@ -190,6 +217,11 @@ func (r *NestedAPIServerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&controlplanev1.NestedAPIServer{}).
 		Owns(&appsv1.StatefulSet{}).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}},
+			enqueueComponentForManifests(mgr.GetClient(), kubeadm.APIServer),
+			builder.WithPredicates(isManifestsConfigMap)).
+		Watches(&source.Kind{Type: &clusterv1.Cluster{}},
+			handler.EnqueueRequestsFromMapFunc(clusterToComponent(mgr.GetClient(), kubeadm.APIServer))).
 		Complete(r)
 }
 
@@ -215,7 +247,7 @@ func (r *NestedAPIServerReconciler) createAPIServerClientCrts(ctx context.Contex
 	}
 
 	// TODO(christopherhein) figure out how to get service clusterIPs.
-	apiKeyPair, err := certificate.NewAPIServerCrtAndKey(&certificate.KeyPair{Cert: cacrt, Key: cakey}, nkas.GetName(), "", cluster.Spec.ControlPlaneEndpoint.Host)
+	apiKeyPair, err := newAPIServerCrtAndKey(&certificate.KeyPair{Cert: cacrt, Key: cakey}, nkas.GetName(), cluster.Spec.ControlPlaneEndpoint.Host)
 	if err != nil {
 		return err
 	}
@@ -254,3 +286,30 @@ func (r *NestedAPIServerReconciler) createAPIServerClientCrts(ctx context.Contex
 	controllerRef := metav1.NewControllerRef(ncp, controlplanev1.GroupVersion.WithKind("NestedControlPlane"))
 	return certs.LookupOrSave(ctx, r.Client, util.ObjectKey(cluster), *controllerRef)
 }
+
+// newAPIServerCrtAndKey creates the apiserver serving cert for the
+// ControlPlaneEndpoint host, which is usually an IP, e.g., the ClusterIP or
+// the LoadBalancer IP of the apiserver service.
+func newAPIServerCrtAndKey(ca *certificate.KeyPair, name, host string) (*certificate.KeyPair, error) {
+	var ips []string
+	if net.ParseIP(host) != nil {
+		ips = append(ips, host)
+	}
+	return certificate.NewAPIServerCrtAndKey(ca, name, "", host, ips...)
+}
+
+// reissueAPIServerCrt reissues the apiserver serving cert if it does not
+// cover the ControlPlaneEndpoint host, e.g., the endpoint is populated after
+// the cert has been created. The apiserver reloads the updated secret
+// without restarting.
+func (r *NestedAPIServerReconciler) reissueAPIServerCrt(ctx context.Context, cluster *clusterv1.Cluster, nkas *controlplanev1.NestedAPIServer) error {
+	host := cluster.Spec.ControlPlaneEndpoint.Host
+	if host == "" {
+		return nil
+	}
+	_, err := reissueCrt(ctx, r.Client, cluster, certificate.APIServerClient, secret.ClusterCA, host,
+		func(ca *certificate.KeyPair) (*certificate.KeyPair, error) {
+			return newAPIServerCrtAndKey(ca, nkas.GetName(), host)
+		})
+	return err
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/certs"
+	"sigs.k8s.io/cluster-api/util/secret"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	controlplanev1 "sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/certificate"
+)
+
+func TestReissueAPIServerCrt(t *testing.T) {
+	cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "default"}}
+	ca := &secret.Certificate{Purpose: secret.ClusterCA}
+	if err := ca.Generate(); err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	cacrt, err := certs.DecodeCertPEM(ca.KeyPair.Cert)
+	if err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	cakey, err := certs.DecodePrivateKeyPEM(ca.KeyPair.Key)
+	if err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	nkas := &controlplanev1.NestedAPIServer{ObjectMeta: metav1.ObjectMeta{Name: "c-apiserver", Namespace: "default"}}
+
+	tests := []struct {
+		name       string
+		issuedHost string
+		host       string
+		reissued   bool
+	}{
+		{"TestEndpointNotSet", "", "", false},
+		{"TestEndpointPopulatedLater", "", "10.0.0.10", true},
+		{"TestEndpointIPCovered", "10.0.0.10", "10.0.0.10", false},
+		{"TestEndpointChanged", "10.0.0.10", "10.0.0.20", true},
+		{"TestEndpointDNSCovered", "apiserver.example.com", "apiserver.example.com", false},
+	}
+	for _, tt := range tests {
+		st := tt
+		t.Run(st.name, func(t *testing.T) {
+			t.Logf("\tTestCase: %s", st.name)
+			issued, err := newAPIServerCrtAndKey(&certificate.KeyPair{Cert: cacrt, Key: cakey}, nkas.GetName(), st.issuedHost)
+			if err != nil {
+				t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+			}
+			c := cluster.DeepCopy()
+			c.Spec.ControlPlaneEndpoint.Host = st.host
+			r := &NestedAPIServerReconciler{
+				Client: fake.NewClientBuilder().WithObjects(
+					ca.AsSecret(util.ObjectKey(c), metav1.OwnerReference{}),
+					issued.AsSecret(util.ObjectKey(c), metav1.OwnerReference{}),
+				).Build(),
+				Log: ctrl.Log,
+			}
+			if err := r.reissueAPIServerCrt(context.TODO(), c, nkas); err != nil {
+				t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+			}
+
+			var got corev1.Secret
+			if err := r.Get(context.TODO(), types.NamespacedName{
+				Namespace: "default",
+				Name:      secret.Name("c", certificate.APIServerClient),
+			}, &got); err != nil {
+				t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+			}
+			crt, err := certs.DecodeCertPEM(got.Data[secret.TLSCrtDataName])
+			if err != nil {
+				t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+			}
+			if reissued := !crt.Equal(issued.Cert); reissued != st.reissued {
+				t.Fatalf("\t%s\texpect the cert to be reissued %v, but get %v", failed, st.reissued, reissued)
+			}
+			if st.host != "" {
+				if err := crt.VerifyHostname(st.host); err != nil {
+					t.Fatalf("\t%s\texpect the cert to cover %s, but get %v", failed, st.host, err)
+				}
+			}
+			t.Logf("\t%s\tget the expected serving cert", succeed)
+		})
+	}
+}
@@ -22,12 +22,15 @@ import (
 
 	"github.com/go-logr/logr"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	controlplanev1 "sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/api/v1alpha4"
 	"sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/kubeadm"
@@ -51,6 +54,10 @@ func (r *NestedControllerManagerReconciler) Reconcile(ctx context.Context, req c
 	if err := r.Get(ctx, req.NamespacedName, &nkcm); err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
+	if !nkcm.GetDeletionTimestamp().IsZero() {
+		// the NestedControllerManager is being deleted, don't recreate its StatefulSet.
+		return ctrl.Result{}, nil
+	}
 	log.Info("creating NestedControllerManager",
 		"namespace", nkcm.GetNamespace(),
 		"name", nkcm.GetName())
@@ -115,7 +122,18 @@ func (r *NestedControllerManagerReconciler) Reconcile(ctx context.Context, req c
 		return ctrl.Result{}, err
 	}
 
-	// 3. reconcile the NestedControllerManager based on the status of the StatefulSet.
+	// 3. reconcile the NestedComponentSpec onto the existing StatefulSet.
+	if patched, err := syncNestedComponentSts(ctx, r.Client, nkcm.ObjectMeta,
+		nkcm.Spec.NestedComponentSpec, &nkcmSts,
+		kubeadm.ControllerManager, cluster.GetName(), log); err != nil {
+		log.Error(err, "fail to update NestedControllerManager StatefulSet")
+		return ctrl.Result{}, err
+	} else if patched {
+		// wait for the StatefulSet to roll out the new spec.
+		return ctrl.Result{}, nil
+	}
+
+	// 4. reconcile the NestedControllerManager based on the status of the StatefulSet.
 	// Mark the NestedControllerManager as Ready if the StatefulSet is ready
 	if nkcmSts.Status.ReadyReplicas == nkcmSts.Status.Replicas {
 		log.Info("The NestedControllerManager StatefulSet is ready")
@@ -174,5 +192,8 @@ func (r *NestedControllerManagerReconciler) SetupWithManager(mgr ctrl.Manager) e
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&controlplanev1.NestedControllerManager{}).
 		Owns(&appsv1.StatefulSet{}).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}},
+			enqueueComponentForManifests(mgr.GetClient(), kubeadm.ControllerManager),
+			builder.WithPredicates(isManifestsConfigMap)).
 		Complete(r)
 }
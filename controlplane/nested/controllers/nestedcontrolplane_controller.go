@@ -18,10 +18,12 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -40,6 +42,8 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 	addonv1alpha1 "sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/apis/v1alpha1"
 
 	controlplanev1 "sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/api/v1alpha4"
@@ -67,9 +71,29 @@ func (r *NestedControlPlaneReconciler) SetupWithManager(mgr ctrl.Manager) error
 		Owns(&controlplanev1.NestedEtcd{}).
 		Owns(&controlplanev1.NestedAPIServer{}).
 		Owns(&controlplanev1.NestedControllerManager{}).
+		Watches(&source.Kind{Type: &clusterv1.Cluster{}},
+			handler.EnqueueRequestsFromMapFunc(clusterToNestedControlPlane)).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}},
+			handler.EnqueueRequestsFromMapFunc(auditPolicyToNestedControlPlanes(mgr.GetClient()))).
 		Complete(r)
 }
 
+// clusterToNestedControlPlane maps the Cluster to its NestedControlPlane, so
+// that the NestedControlPlane is reconciled once the ControlPlaneEndpoint is
+// populated.
+func clusterToNestedControlPlane(o client.Object) []ctrl.Request {
+	cluster, ok := o.(*clusterv1.Cluster)
+	if !ok {
+		return nil
+	}
+	ref := cluster.Spec.ControlPlaneRef
+	if ref == nil || ref.Kind != "NestedControlPlane" ||
+		ref.GroupVersionKind().Group != controlplanev1.GroupVersion.Group {
+		return nil
+	}
+	return []ctrl.Request{{NamespacedName: types.NamespacedName{Namespace: cluster.Namespace, Name: ref.Name}}}
+}
+
 // Reconcile is ths main process which will handle updating the NCP.
 func (r *NestedControlPlaneReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := r.Log.WithValues("nestedcontrolplane", req.NamespacedName)
@@ -123,7 +147,7 @@ func (r *NestedControlPlaneReconciler) Reconcile(ctx context.Context, req ctrl.R
 	// TODO(christopherhein) handle deletion
 	if !ncp.ObjectMeta.DeletionTimestamp.IsZero() {
 		// Handle deletion reconciliation loop.
-		return r.reconcileDelete(ctx, log, ncp)
+		return r.reconcileDelete(ctx, log, cluster, ncp)
 	}
 
 	defer func() {
@@ -136,21 +160,64 @@ func (r *NestedControlPlaneReconciler) Reconcile(ctx context.Context, req ctrl.R
 	return r.reconcile(ctx, log, cluster, ncp)
 }
 
-// reconcileDelete will delete the control plane and all it's nestedcomponents.
-func (r *NestedControlPlaneReconciler) reconcileDelete(ctx context.Context, log logr.Logger, ncp *controlplanev1.NestedControlPlane) (ctrl.Result, error) {
+// reconcileDelete tears down the nested components in order. The
+// NestedControllerManager is stopped first, the NestedEtcd is backed up if the
+// EtcdBackupOnDelete is set, then the NestedAPIServer and the NestedEtcd are
+// deleted. The finalizer is removed once all the nested components are gone.
+func (r *NestedControlPlaneReconciler) reconcileDelete(ctx context.Context, log logr.Logger, cluster *clusterv1.Cluster, ncp *controlplanev1.NestedControlPlane) (res ctrl.Result, reterr error) {
 	patchHelper, err := patch.NewHelper(ncp, r.Client)
 	if err != nil {
 		log.Error(err, "Failed to configure the patch helper")
 		return ctrl.Result{Requeue: true}, nil
 	}
 
+	ncp.Status.Ready = false
+	conditions.MarkFalse(ncp, clusterv1.ReadyCondition, clusterv1.DeletingReason, clusterv1.ConditionSeverityInfo, "")
+
+	teardown := []struct {
+		component client.Object
+		ref       *corev1.ObjectReference
+		reason    string
+	}{
+		{&controlplanev1.NestedControllerManager{}, ncp.Spec.ControllerManagerRef, controlplanev1.DeletingControllerManagerReason},
+		{nil, ncp.Spec.EtcdRef, controlplanev1.BackingUpEtcdReason},
+		{&controlplanev1.NestedAPIServer{}, ncp.Spec.APIServerRef, controlplanev1.DeletingAPIServerReason},
+		{&controlplanev1.NestedEtcd{}, ncp.Spec.EtcdRef, controlplanev1.DeletingEtcdReason},
+	}
+	patchOpts := []patch.Option{
+		patch.WithOwnedConditions{Conditions: []clusterv1.ConditionType{
+			clusterv1.ReadyCondition,
+			controlplanev1.NestedComponentsDeletedCondition,
+		}},
+	}
+	for _, step := range teardown {
+		conditions.MarkFalse(ncp, controlplanev1.NestedComponentsDeletedCondition, step.reason, clusterv1.ConditionSeverityInfo, "")
+		var done bool
+		if step.component == nil {
+			done, err = r.backupEtcdOnDelete(ctx, log, cluster.GetName(), ncp)
+		} else {
+			done, err = r.deleteComponent(ctx, ncp, step.component, step.ref)
+		}
+		if err != nil || !done {
+			if patchErr := patchHelper.Patch(ctx, ncp, patchOpts...); patchErr != nil {
+				log.Error(patchErr, "Failed to patch NestedControlPlane")
+			}
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{RequeueAfter: deletionRequeueInterval}, nil
+		}
+	}
+	conditions.MarkTrue(ncp, controlplanev1.NestedComponentsDeletedCondition)
+	log.Info("All the nested components are deleted")
+
 	if controllerutil.ContainsFinalizer(ncp, controlplanev1.NestedControlPlaneFinalizer) {
 		controllerutil.RemoveFinalizer(ncp, controlplanev1.NestedControlPlaneFinalizer)
 
 		// patch and return right away instead of reusing the main defer,
 		// because the main defer may take too much time to get cluster status
 		// Patch ObservedGeneration only if the reconciliation completed successfully
-		patchOpts := []patch.Option{patch.WithStatusObservedGeneration{}}
+		patchOpts = append(patchOpts, patch.WithStatusObservedGeneration{})
 		if err := patchHelper.Patch(ctx, ncp, patchOpts...); err != nil {
 			log.Error(err, "Failed to patch NestedControlPlane to remove finalizer")
 			return ctrl.Result{}, err
@@ -161,12 +228,97 @@ func (r *NestedControlPlaneReconciler) reconcileDelete(ctx context.Context, log
 	return ctrl.Result{}, nil
 }
 
+// deleteComponent deletes the referenced nested component in the foreground,
+// so that it is gone after its StatefulSet is deleted. It returns true once
+// the component is not found.
+func (r *NestedControlPlaneReconciler) deleteComponent(ctx context.Context,
+	ncp *controlplanev1.NestedControlPlane, component client.Object,
+	ref *corev1.ObjectReference) (bool, error) {
+	if ref == nil {
+		return true, nil
+	}
+	objectKey := types.NamespacedName{Namespace: ncp.GetNamespace(), Name: ref.Name}
+	if err := r.Get(ctx, objectKey, component); err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	if component.GetDeletionTimestamp().IsZero() {
+		if err := r.Delete(ctx, component,
+			client.PropagationPolicy(metav1.DeletePropagationForeground)); err != nil &&
+			!apierrors.IsNotFound(err) {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// backupEtcdOnDelete takes a snapshot of the NestedEtcd with a Job if the
+// EtcdBackupOnDelete is set. It returns true once the snapshot is taken, or
+// there is nothing to back up.
+func (r *NestedControlPlaneReconciler) backupEtcdOnDelete(ctx context.Context,
+	log logr.Logger, clusterName string, ncp *controlplanev1.NestedControlPlane) (bool, error) {
+	if ncp.Spec.EtcdBackupOnDelete == nil || ncp.Spec.EtcdRef == nil {
+		return true, nil
+	}
+	var netcd controlplanev1.NestedEtcd
+	if err := r.Get(ctx, types.NamespacedName{
+		Namespace: ncp.GetNamespace(),
+		Name:      ncp.Spec.EtcdRef.Name,
+	}, &netcd); err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+
+	name := clusterName + "-etcd-backup-on-delete"
+	var job batchv1.Job
+	if err := r.Get(ctx, types.NamespacedName{Namespace: ncp.GetNamespace(), Name: name}, &job); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return false, err
+		}
+		image, err := getEtcdImage(ctx, r.Client, ncp.GetNamespace(), clusterName)
+		if err != nil {
+			return false, err
+		}
+		snapshotName := fmt.Sprintf("%s-%s.db", clusterName, time.Now().UTC().Format("20060102150405"))
+		newJob, err := genEtcdSnapshotJob(name, ncp.GetNamespace(), clusterName, image,
+			snapshotName, *ncp.Spec.EtcdBackupOnDelete)
+		if err != nil {
+			return false, err
+		}
+		if err := ctrl.SetControllerReference(ncp, newJob, r.Scheme); err != nil {
+			return false, err
+		}
+		if err := r.Create(ctx, newJob); err != nil {
+			return false, err
+		}
+		log.Info("Backing up the NestedEtcd before deletion", "job", name, "snapshot", snapshotName)
+		return false, nil
+	}
+
+	switch isJobFinished(&job) {
+	case batchv1.JobComplete:
+		return true, nil
+	case batchv1.JobFailed:
+		conditions.MarkFalse(ncp, controlplanev1.NestedComponentsDeletedCondition,
+			controlplanev1.EtcdBackupFailedReason, clusterv1.ConditionSeverityError,
+			"Job %s failed to back up the etcd, delete the Job to retry or unset the etcdBackupOnDelete to skip the backup", name)
+		return false, nil
+	default:
+		return false, nil
+	}
+}
+
 func patchControlPlane(ctx context.Context, patchHelper *patch.Helper, ncp *controlplanev1.NestedControlPlane) error {
 	// Always update the readyCondition by summarizing the state of other conditions.
 	conditions.SetSummary(ncp,
 		conditions.WithConditions(
 			kcpv1.AvailableCondition,
 			kcpv1.CertificatesAvailableCondition,
+			kcpv1.EtcdClusterHealthyCondition,
 		),
 	)
 
@@ -178,6 +330,8 @@ func patchControlPlane(ctx context.Context, patchHelper *patch.Helper, ncp *cont
 			clusterv1.ReadyCondition,
 			kcpv1.AvailableCondition,
 			kcpv1.CertificatesAvailableCondition,
+			kcpv1.MachinesSpecUpToDateCondition,
+			kcpv1.EtcdClusterHealthyCondition,
 		}},
 		patch.WithStatusObservedGeneration{},
 	)
@@ -210,6 +364,10 @@ func (r *NestedControlPlaneReconciler) reconcile(ctx context.Context, log logr.L
 		return result, err
 	}
 
+	if countEtcdBackends(ncp.Spec) > 1 {
+		return ctrl.Result{}, errors.New("only one of etcd, externalEtcd and datastore can be set")
+	}
+
 	addOwners := []client.Object{}
 	isReady := []int{}
 	nestedComponents := map[client.Object]*corev1.ObjectReference{
@@ -218,25 +376,6 @@ func (r *NestedControlPlaneReconciler) reconcile(ctx context.Context, log logr.L
 		&controlplanev1.NestedControllerManager{}: ncp.Spec.ControllerManagerRef,
 	}
 
-	// generate manifests by calling the kubeadm
-	templates, err := kubeadm.GenerateTemplates(log, cluster.GetName())
-	if err != nil {
-		return ctrl.Result{}, err
-	}
-
-	// complete the manifests with CAPN specific configurations
-	manifests, err := completeTemplates(templates, cluster.GetName())
-	if err != nil {
-		return ctrl.Result{}, err
-	}
-
-	// create the configmap that holds the manifest of each component
-	if err := createManifestsConfigMap(r.Client,
-		manifests, cluster.GetName(),
-		ncp.GetNamespace()); err != nil && !apierrors.IsAlreadyExists(err) {
-		return ctrl.Result{}, err
-	}
-
 	// Adopt NestedComponents in the same Namespace
 	for component, nestedComponent := range nestedComponents {
 		if nestedComponent != nil {
@@ -262,6 +401,82 @@ func (r *NestedControlPlaneReconciler) reconcile(ctx context.Context, log logr.L
 		}
 	}
 
+	// the external etcd is ready once it is healthy
+	if ncp.Spec.ExternalEtcd != nil {
+		ncp.Status.Etcd = &controlplanev1.NestedControlPlaneStatusEtcd{
+			Addresses: externalEtcdAddresses(ncp.Spec.ExternalEtcd),
+		}
+		if err := checkExternalEtcdHealth(ctx, r.Client, ncp.GetNamespace(), ncp.Spec.ExternalEtcd); err != nil {
+			log.Info("External etcd is not healthy", "error", err.Error())
+			conditions.MarkFalse(ncp, kcpv1.EtcdClusterHealthyCondition, kcpv1.EtcdClusterUnhealthyReason,
+				clusterv1.ConditionSeverityError, err.Error())
+		} else {
+			conditions.MarkTrue(ncp, kcpv1.EtcdClusterHealthyCondition)
+			isReady = append(isReady, 1)
+		}
+	}
+
+	// kine runs in the apiserver pods, it is ready along with the NestedAPIServer
+	if ncp.Spec.Datastore != nil {
+		if err := kineReplicasError(ncp.Spec.Datastore, componentsByKind(nestedComponents)); err != nil {
+			log.Error(err, "invalid replicas of the NestedAPIServer")
+			return ctrl.Result{}, err
+		}
+		if err := r.reconcileKineStorage(ctx, ncp, cluster.GetName()); err != nil {
+			log.Error(err, "failed to reconcile the storage of the kine datastore")
+			return ctrl.Result{}, err
+		}
+		isReady = append(isReady, 1)
+	}
+
+	// generate the keys the apiserver encrypts the secrets with, or rotate
+	// them
+	encryptionHash, rotatingEncryptionKey, err := r.reconcileSecretEncryption(ctx, log, cluster, ncp)
+	if err != nil {
+		log.Error(err, "failed to reconcile the secret encryption")
+		return ctrl.Result{}, err
+	}
+
+	// create the audit webhook config of the NestedAPIServer, if any
+	audit := nestedAPIServerAudit(componentsByKind(nestedComponents))
+	auditHash, err := r.reconcileAudit(ctx, cluster, ncp, audit)
+	if err != nil {
+		log.Error(err, "failed to reconcile the audit logging")
+		return ctrl.Result{}, err
+	}
+
+	// generate manifests with the versions of the NestedControlPlane and the
+	// NestedComponents
+	opts, err := genKubeadmOptions(ncp, cluster.GetName(), nestedComponents)
+	if err != nil {
+		log.Error(err, "invalid version of the nested components")
+		return ctrl.Result{}, err
+	}
+	templates, err := kubeadm.GenerateTemplates(opts)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// complete the manifests with CAPN specific configurations
+	manifests, err := completeTemplates(templates, cluster.GetName(), ncp.Spec)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if encryptionHash != "" {
+		setEncryptionConfigHash(manifests, encryptionHash)
+	}
+	if audit != nil {
+		completeKASAudit(manifests, cluster.GetName(), audit, auditHash)
+	}
+
+	// create the configmap that holds the manifest of each component, or roll
+	// out the updated manifests one component at a time
+	upgradeResult, err := r.reconcileManifests(ctx, log, ncp, cluster.GetName(),
+		manifests, componentsByKind(nestedComponents), desiredVersion(opts))
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// Add Controller Reference
 	if err := r.reconcileControllerOwners(ctx, ncp, addOwners); err != nil {
 		return ctrl.Result{Requeue: true}, err
@@ -284,10 +499,76 @@ func (r *NestedControlPlaneReconciler) reconcile(ctx context.Context, log logr.L
 			return ctrl.Result{}, err
 		}
 	} else if !ncp.Status.Ready && len(isReady) < 3 {
+		if ncp.Spec.ExternalEtcd != nil {
+			return ctrl.Result{RequeueAfter: externalEtcdRequeueInterval}, nil
+		}
 		return ctrl.Result{Requeue: true}, nil
 	}
 
-	return ctrl.Result{}, nil
+	// check the rolled out apiserver to move the key rotation forward
+	if rotatingEncryptionKey && upgradeResult.IsZero() {
+		return ctrl.Result{RequeueAfter: upgradeRequeueInterval}, nil
+	}
+	return upgradeResult, nil
+}
+
+// countEtcdBackends counts the etcd backends set in the NestedControlPlaneSpec.
+func countEtcdBackends(spec controlplanev1.NestedControlPlaneSpec) int {
+	count := 0
+	if spec.EtcdRef != nil {
+		count++
+	}
+	if spec.ExternalEtcd != nil {
+		count++
+	}
+	if spec.Datastore != nil {
+		count++
+	}
+	return count
+}
+
+// genKubeadmOptions generates the options of the manifests from the
+// NestedControlPlane and the versions of the fetched NestedComponents.
+func genKubeadmOptions(ncp *controlplanev1.NestedControlPlane, clusterName string,
+	nestedComponents map[client.Object]*corev1.ObjectReference) (kubeadm.Options, error) {
+	opts := kubeadm.Options{
+		ClusterName:       clusterName,
+		ImageRepository:   ncp.Spec.ImageRepository,
+		KubernetesVersion: ncp.Spec.Version,
+	}
+	for component := range nestedComponents {
+		commonObject, ok := component.(addonv1alpha1.CommonObject)
+		if !ok {
+			continue
+		}
+		version, err := componentVersion(commonObject.CommonSpec())
+		if err != nil {
+			return opts, errors.Wrapf(err, "invalid version of %s", commonObject.ComponentName())
+		}
+		switch component.(type) {
+		case *controlplanev1.NestedEtcd:
+			opts.EtcdVersion = version
+		case *controlplanev1.NestedAPIServer:
+			opts.APIServerVersion = version
+		case *controlplanev1.NestedControllerManager:
+			opts.ControllerManagerVersion = version
+		}
+	}
+	return opts, nil
+}
+
+// componentVersion resolves the version of the NestedComponent, an empty
+// version means the default one.
+func componentVersion(spec addonv1alpha1.CommonSpec) (string, error) {
+	if spec.Version != "" {
+		return spec.Version, nil
+	}
+	switch spec.Channel {
+	case "", kubeadm.StableChannel:
+		return "", nil
+	default:
+		return "", errors.Errorf("unknown channel %q", spec.Channel)
+	}
 }
 
 // reconcileKubeconfig will check if the control plane endpoint has been set
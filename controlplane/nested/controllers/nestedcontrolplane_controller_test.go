@@ -0,0 +1,285 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	controlplanev1 "sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/kubeadm"
+)
+
+func TestClusterToNestedControlPlane(t *testing.T) {
+	tests := []struct {
+		name     string
+		ref      *corev1.ObjectReference
+		expected int
+	}{
+		{
+			name: "TestNestedControlPlane",
+			ref: &corev1.ObjectReference{
+				APIVersion: controlplanev1.GroupVersion.String(),
+				Kind:       "NestedControlPlane",
+				Name:       "ncp",
+			},
+			expected: 1,
+		},
+		{
+			name: "TestOtherControlPlane",
+			ref: &corev1.ObjectReference{
+				APIVersion: "controlplane.cluster.x-k8s.io/v1alpha4",
+				Kind:       "KubeadmControlPlane",
+				Name:       "kcp",
+			},
+		},
+		{
+			name: "TestNoControlPlane",
+		},
+	}
+	for _, tt := range tests {
+		st := tt
+		t.Run(st.name, func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			cluster := &clusterv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "default"},
+				Spec:       clusterv1.ClusterSpec{ControlPlaneRef: st.ref},
+			}
+			requests := clusterToNestedControlPlane(cluster)
+			if len(requests) != st.expected {
+				t.Fatalf("\t%s\texpect %d requests, but get %v", failed, st.expected, requests)
+			}
+			if st.expected == 1 && (requests[0].Name != "ncp" || requests[0].Namespace != "default") {
+				t.Fatalf("\t%s\tunexpected request %v", failed, requests[0])
+			}
+			t.Logf("\t%s\tthe Cluster is mapped to %d requests", succeed, len(requests))
+		})
+	}
+}
+
+func TestReconcileDelete(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	if err := controlplanev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "default"}}
+	ncp := &controlplanev1.NestedControlPlane{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "ncp",
+			Namespace:  "default",
+			Finalizers: []string{controlplanev1.NestedControlPlaneFinalizer},
+		},
+		Spec: controlplanev1.NestedControlPlaneSpec{
+			EtcdRef:              &corev1.ObjectReference{Name: "c-etcd"},
+			APIServerRef:         &corev1.ObjectReference{Name: "c-apiserver"},
+			ControllerManagerRef: &corev1.ObjectReference{Name: "c-controller-manager"},
+			EtcdBackupOnDelete: &controlplanev1.EtcdBackupStorage{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "backup"},
+			},
+		},
+	}
+	// the components are deleted in the foreground, they are terminating
+	// until the test removes the finalizer.
+	foreground := []string{metav1.FinalizerDeleteDependents}
+	components := map[string]client.Object{
+		kubeadm.ControllerManager: &controlplanev1.NestedControllerManager{
+			ObjectMeta: metav1.ObjectMeta{Name: "c-controller-manager", Namespace: "default", Finalizers: foreground},
+		},
+		kubeadm.APIServer: &controlplanev1.NestedAPIServer{
+			ObjectMeta: metav1.ObjectMeta{Name: "c-apiserver", Namespace: "default", Finalizers: foreground},
+		},
+		kubeadm.Etcd: &controlplanev1.NestedEtcd{
+			ObjectMeta: metav1.ObjectMeta{Name: "c-etcd", Namespace: "default", Finalizers: foreground},
+		},
+	}
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(ncp,
+		components[kubeadm.ControllerManager], components[kubeadm.APIServer], components[kubeadm.Etcd],
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "c-" + kubeadm.ManifestsConfigmapSuffix, Namespace: "default"},
+			Data: map[string]string{
+				kubeadm.Etcd: "spec:\n  containers:\n  - name: etcd\n    image: k8s.gcr.io/etcd:3.4.13-0\n",
+			},
+		}).Build()
+	r := &NestedControlPlaneReconciler{Client: cli, Log: ctrl.Log, Scheme: scheme}
+
+	// state returns whether the components are terminating or gone, and
+	// whether the backup Job exists.
+	state := func() (map[string]string, bool) {
+		states := map[string]string{}
+		for kind, component := range components {
+			obj := component.DeepCopyObject().(client.Object)
+			err := cli.Get(context.TODO(), client.ObjectKeyFromObject(component), obj)
+			switch {
+			case apierrors.IsNotFound(err):
+				states[kind] = "gone"
+			case err != nil:
+				t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+			case !obj.GetDeletionTimestamp().IsZero():
+				states[kind] = "terminating"
+			default:
+				states[kind] = "running"
+			}
+		}
+		var job batchv1.Job
+		err := cli.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "c-etcd-backup-on-delete"}, &job)
+		if err != nil && !apierrors.IsNotFound(err) {
+			t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+		}
+		return states, err == nil
+	}
+	// remove finishes the deletion of the terminating component.
+	remove := func(kind string) func() {
+		return func() {
+			obj := components[kind].DeepCopyObject().(client.Object)
+			if err := cli.Get(context.TODO(), client.ObjectKeyFromObject(obj), obj); err != nil {
+				t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+			}
+			obj.SetFinalizers(nil)
+			if err := cli.Update(context.TODO(), obj); err != nil {
+				t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+			}
+		}
+	}
+	completeBackup := func() {
+		var job batchv1.Job
+		if err := cli.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "c-etcd-backup-on-delete"}, &job); err != nil {
+			t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+		}
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+		if err := cli.Update(context.TODO(), &job); err != nil {
+			t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+		}
+	}
+
+	steps := []struct {
+		name string
+		// prepare changes the components before the reconciliation.
+		prepare func()
+		// states are the expected states of the controller-manager, the
+		// apiserver and the etcd.
+		states [3]string
+		backup bool
+		reason string
+	}{
+		{
+			name:    "TestControllerManagerDeletedFirst",
+			prepare: func() {},
+			states:  [3]string{"terminating", "running", "running"},
+			reason:  controlplanev1.DeletingControllerManagerReason,
+		},
+		{
+			name:    "TestBackupWaitsForControllerManager",
+			prepare: func() {},
+			states:  [3]string{"terminating", "running", "running"},
+			reason:  controlplanev1.DeletingControllerManagerReason,
+		},
+		{
+			name:    "TestEtcdBackedUpAfterControllerManager",
+			prepare: remove(kubeadm.ControllerManager),
+			states:  [3]string{"gone", "running", "running"},
+			backup:  true,
+			reason:  controlplanev1.BackingUpEtcdReason,
+		},
+		{
+			name:    "TestAPIServerWaitsForBackup",
+			prepare: func() {},
+			states:  [3]string{"gone", "running", "running"},
+			backup:  true,
+			reason:  controlplanev1.BackingUpEtcdReason,
+		},
+		{
+			name:    "TestAPIServerDeletedAfterBackup",
+			prepare: completeBackup,
+			states:  [3]string{"gone", "terminating", "running"},
+			backup:  true,
+			reason:  controlplanev1.DeletingAPIServerReason,
+		},
+		{
+			name:    "TestEtcdWaitsForAPIServer",
+			prepare: func() {},
+			states:  [3]string{"gone", "terminating", "running"},
+			backup:  true,
+			reason:  controlplanev1.DeletingAPIServerReason,
+		},
+		{
+			name:    "TestEtcdDeletedAfterAPIServer",
+			prepare: remove(kubeadm.APIServer),
+			states:  [3]string{"gone", "gone", "terminating"},
+			backup:  true,
+			reason:  controlplanev1.DeletingEtcdReason,
+		},
+		{
+			name:    "TestNestedComponentsDeleted",
+			prepare: remove(kubeadm.Etcd),
+			states:  [3]string{"gone", "gone", "gone"},
+			backup:  true,
+		},
+	}
+	// the steps depend on each other, hence run in order.
+	for _, st := range steps {
+		t.Logf("\tTestCase: %s", st.name)
+		st.prepare()
+		result, err := r.reconcileDelete(context.TODO(), ctrl.Log, cluster, ncp)
+		if err != nil {
+			t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+		}
+
+		states, backup := state()
+		for i, kind := range []string{kubeadm.ControllerManager, kubeadm.APIServer, kubeadm.Etcd} {
+			if states[kind] != st.states[i] {
+				t.Fatalf("\t%s\texpect the %s to be %s, but get %s", failed, kind, st.states[i], states[kind])
+			}
+		}
+		if backup != st.backup {
+			t.Fatalf("\t%s\texpect the backup Job to exist %v, but get %v", failed, st.backup, backup)
+		}
+		if st.reason == "" {
+			if !conditions.IsTrue(ncp, controlplanev1.NestedComponentsDeletedCondition) {
+				t.Fatalf("\t%s\texpect the nested components to be deleted", failed)
+			}
+			if controllerutil.ContainsFinalizer(ncp, controlplanev1.NestedControlPlaneFinalizer) {
+				t.Fatalf("\t%s\texpect the finalizer to be removed", failed)
+			}
+		} else {
+			if reason := conditions.GetReason(ncp, controlplanev1.NestedComponentsDeletedCondition); reason != st.reason {
+				t.Fatalf("\t%s\texpect the reason %s, but get %s", failed, st.reason, reason)
+			}
+			if result.RequeueAfter != deletionRequeueInterval {
+				t.Fatalf("\t%s\texpect requeue after %v, but get %v", failed, deletionRequeueInterval, result.RequeueAfter)
+			}
+		}
+		t.Logf("\t%s\tthe nested components are torn down in order", succeed)
+	}
+}
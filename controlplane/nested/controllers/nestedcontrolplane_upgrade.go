@@ -0,0 +1,190 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	kcpv1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	addonv1alpha1 "sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/apis/v1alpha1"
+
+	controlplanev1 "sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/kubeadm"
+)
+
+// upgradeOrder is the order in which the updated manifests are rolled out to
+// the nested components, each component waits for the previous one to be
+// rolled out and ready.
+var upgradeOrder = []string{kubeadm.Etcd, kubeadm.APIServer, kubeadm.ControllerManager}
+
+// reconcileManifests creates the manifests configmap if it is not found.
+// Otherwise, it rolls out the updated manifests one nested component at a
+// time in the upgradeOrder, and updates the version, the replicas and the
+// MachinesSpecUpToDate condition of the NestedControlPlane.
+func (r *NestedControlPlaneReconciler) reconcileManifests(ctx context.Context,
+	log logr.Logger, ncp *controlplanev1.NestedControlPlane, clusterName string,
+	manifests map[string]corev1.Pod, components map[string]client.Object,
+	version string) (ctrl.Result, error) {
+	var cm corev1.ConfigMap
+	if err := r.Get(ctx, types.NamespacedName{
+		Namespace: ncp.GetNamespace(),
+		Name:      clusterName + "-" + kubeadm.ManifestsConfigmapSuffix,
+	}, &cm); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, createManifestsConfigMap(r.Client, manifests, clusterName, ncp)
+	}
+	// the manifests configmaps created by the earlier versions are neither
+	// labeled nor owned by the NestedControlPlane.
+	mergeFrom := client.MergeFrom(cm.DeepCopy())
+	if setManifestsConfigMapOwner(&cm, ncp) {
+		if err := r.Patch(ctx, &cm, mergeFrom); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	desired, err := genManifestsData(manifests)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var replicas, updatedReplicas, readyReplicas int32
+	// upToDate denotes that the components so far run the desired manifests
+	// and are ready.
+	upToDate := true
+	for _, kind := range upgradeOrder {
+		// the updated manifest is rolled out after the previous components
+		// are rolled out.
+		if cm.Data[kind] != desired[kind] && upToDate {
+			mergeFrom := client.MergeFrom(cm.DeepCopy())
+			cm.Data[kind] = desired[kind]
+			if err := r.Patch(ctx, &cm, mergeFrom); err != nil {
+				return ctrl.Result{}, err
+			}
+			log.Info("rolling out the updated manifest", "component", kind)
+		}
+
+		component, ok := components[kind]
+		if !ok {
+			// the component is not managed by the NestedControlPlane.
+			continue
+		}
+		var sts appsv1.StatefulSet
+		if err := r.Get(ctx, types.NamespacedName{
+			Namespace: ncp.GetNamespace(),
+			Name:      clusterName + "-" + kind,
+		}, &sts); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return ctrl.Result{}, err
+			}
+			upToDate = false
+			continue
+		}
+		if sts.Spec.Replicas != nil {
+			replicas += *sts.Spec.Replicas
+		}
+		readyReplicas += sts.Status.ReadyReplicas
+
+		updated := sts.GetAnnotations()[templateHashAnnotation] ==
+			templateHash(desired[kind], componentPatches(component))
+		if updated {
+			updatedReplicas += sts.Status.UpdatedReplicas
+		}
+		ready := false
+		if commonObject, ok := component.(addonv1alpha1.CommonObject); ok {
+			ready = IsComponentReady(commonObject.GetCommonStatus())
+		}
+		if !updated || !isStatefulSetRolledOut(&sts) || !ready {
+			upToDate = false
+		}
+	}
+
+	ncp.Status.Replicas = replicas
+	ncp.Status.UpdatedReplicas = updatedReplicas
+	ncp.Status.ReadyReplicas = readyReplicas
+	ncp.Status.UnavailableReplicas = replicas - readyReplicas
+
+	if upToDate {
+		conditions.MarkTrue(ncp, kcpv1.MachinesSpecUpToDateCondition)
+		ncp.Status.Version = &version
+		return ctrl.Result{}, nil
+	}
+	if !ncp.Status.Ready {
+		// the control plane is being provisioned.
+		return ctrl.Result{}, nil
+	}
+	conditions.MarkFalse(ncp, kcpv1.MachinesSpecUpToDateCondition,
+		kcpv1.RollingUpdateInProgressReason, clusterv1.ConditionSeverityWarning,
+		"Rolling %d replicas with outdated spec (%d replicas up to date)",
+		replicas-updatedReplicas, updatedReplicas)
+	return ctrl.Result{RequeueAfter: upgradeRequeueInterval}, nil
+}
+
+// componentsByKind maps the kinds to the nested components that are found.
+func componentsByKind(nestedComponents map[client.Object]*corev1.ObjectReference) map[string]client.Object {
+	components := map[string]client.Object{}
+	for component, ref := range nestedComponents {
+		if ref == nil || component.GetName() == "" {
+			continue
+		}
+		switch component.(type) {
+		case *controlplanev1.NestedEtcd:
+			components[kubeadm.Etcd] = component
+		case *controlplanev1.NestedAPIServer:
+			components[kubeadm.APIServer] = component
+		case *controlplanev1.NestedControllerManager:
+			components[kubeadm.ControllerManager] = component
+		}
+	}
+	return components
+}
+
+// componentPatches returns the NestedComponent.Spec.Patches of the component.
+func componentPatches(component client.Object) []*runtime.RawExtension {
+	switch c := component.(type) {
+	case *controlplanev1.NestedEtcd:
+		return c.Spec.Patches
+	case *controlplanev1.NestedAPIServer:
+		return c.Spec.Patches
+	case *controlplanev1.NestedControllerManager:
+		return c.Spec.Patches
+	}
+	return nil
+}
+
+// desiredVersion returns the Kubernetes version of the apiserver generated
+// with the options.
+func desiredVersion(opts kubeadm.Options) string {
+	switch {
+	case opts.APIServerVersion != "":
+		return opts.APIServerVersion
+	case opts.KubernetesVersion != "":
+		return opts.KubernetesVersion
+	default:
+		return kubeadm.DefaultKubernetesVersion
+	}
+}
@@ -0,0 +1,225 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	kcpv1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	addonv1alpha1 "sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/apis/v1alpha1"
+
+	controlplanev1 "sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/kubeadm"
+)
+
+func TestDesiredVersion(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   kubeadm.Options
+		expect string
+	}{
+		{"default version", kubeadm.Options{}, kubeadm.DefaultKubernetesVersion},
+		{"control plane version", kubeadm.Options{KubernetesVersion: "v1.22.2"}, "v1.22.2"},
+		{"apiserver version", kubeadm.Options{KubernetesVersion: "v1.22.2", APIServerVersion: "v1.21.5"}, "v1.21.5"},
+	}
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			if got := desiredVersion(st.opts); got != st.expect {
+				t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, got)
+			}
+			t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, st.expect)
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestReconcileManifests(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	if err := controlplanev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	// the current manifests are placeholders, the rollout only compares them
+	// to the desired ones generated from the (empty) manifests passed in.
+	oldData, newData := map[string]string{}, map[string]string{}
+	for _, kind := range upgradeOrder {
+		oldData[kind] = kind + " manifest of v1.21.1"
+		newData[kind] = ""
+	}
+
+	ncp := &controlplanev1.NestedControlPlane{
+		ObjectMeta: metav1.ObjectMeta{Name: "ncp", Namespace: "default"},
+		Status:     controlplanev1.NestedControlPlaneStatus{Ready: true},
+	}
+	objs := []client.Object{&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "c-" + kubeadm.ManifestsConfigmapSuffix, Namespace: "default"},
+		Data:       oldData,
+	}}
+	replicas := int32(1)
+	for _, kind := range upgradeOrder {
+		objs = append(objs, &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "c-" + kind,
+				Namespace:   "default",
+				Annotations: map[string]string{templateHashAnnotation: templateHash(oldData[kind], nil)},
+			},
+			Spec:   appsv1.StatefulSetSpec{Replicas: &replicas},
+			Status: appsv1.StatefulSetStatus{Replicas: 1, ReadyReplicas: 1, UpdatedReplicas: 1},
+		})
+	}
+	ready := addonv1alpha1.CommonStatus{Phase: string(controlplanev1.Ready)}
+	components := map[string]client.Object{
+		kubeadm.Etcd:              &controlplanev1.NestedEtcd{Status: controlplanev1.NestedEtcdStatus{CommonStatus: ready}},
+		kubeadm.APIServer:         &controlplanev1.NestedAPIServer{Status: controlplanev1.NestedAPIServerStatus{CommonStatus: ready}},
+		kubeadm.ControllerManager: &controlplanev1.NestedControllerManager{Status: controlplanev1.NestedControllerManagerStatus{CommonStatus: ready}},
+	}
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+	r := &NestedControlPlaneReconciler{Client: cli, Log: ctrl.Log, Scheme: scheme}
+
+	// rollOut mimics the NestedComponent controller rolling out the manifest
+	// of the component, the StatefulSet is rolled out once updated is set.
+	rollOut := func(kind string, updated bool) {
+		var sts appsv1.StatefulSet
+		if err := cli.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "c-" + kind}, &sts); err != nil {
+			t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+		}
+		sts.Annotations[templateHashAnnotation] = templateHash(newData[kind], nil)
+		sts.Status.UpdatedReplicas = 0
+		if updated {
+			sts.Status.UpdatedReplicas = 1
+		}
+		if err := cli.Update(context.TODO(), &sts); err != nil {
+			t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+		}
+	}
+	setPhase := func(kind string, phase controlplanev1.ComponentPhase) {
+		component := components[kind].(addonv1alpha1.CommonObject)
+		component.SetCommonStatus(addonv1alpha1.CommonStatus{Phase: string(phase)})
+	}
+
+	steps := []struct {
+		name string
+		// prepare changes the components before the reconciliation.
+		prepare func()
+		// rolling is the components whose updated manifests are rolled out.
+		rolling  []string
+		upToDate bool
+	}{
+		{
+			name:    "TestEtcdRolledOutFirst",
+			prepare: func() {},
+			rolling: []string{kubeadm.Etcd},
+		},
+		{
+			name:    "TestAPIServerWaitsForEtcdRollout",
+			prepare: func() { rollOut(kubeadm.Etcd, false) },
+			rolling: []string{kubeadm.Etcd},
+		},
+		{
+			name: "TestAPIServerWaitsForEtcdReady",
+			prepare: func() {
+				rollOut(kubeadm.Etcd, true)
+				setPhase(kubeadm.Etcd, controlplanev1.Unready)
+			},
+			rolling: []string{kubeadm.Etcd},
+		},
+		{
+			name:    "TestAPIServerRolledOutAfterEtcd",
+			prepare: func() { setPhase(kubeadm.Etcd, controlplanev1.Ready) },
+			rolling: []string{kubeadm.Etcd, kubeadm.APIServer},
+		},
+		{
+			name: "TestControllerManagerWaitsForAPIServerReady",
+			prepare: func() {
+				rollOut(kubeadm.APIServer, true)
+				setPhase(kubeadm.APIServer, controlplanev1.Unready)
+			},
+			rolling: []string{kubeadm.Etcd, kubeadm.APIServer},
+		},
+		{
+			name:    "TestControllerManagerRolledOutAfterAPIServer",
+			prepare: func() { setPhase(kubeadm.APIServer, controlplanev1.Ready) },
+			rolling: upgradeOrder,
+		},
+		{
+			name:     "TestControlPlaneUpToDate",
+			prepare:  func() { rollOut(kubeadm.ControllerManager, true) },
+			rolling:  upgradeOrder,
+			upToDate: true,
+		},
+	}
+	// the steps depend on each other, hence run in order.
+	for _, st := range steps {
+		t.Logf("\tTestCase: %s", st.name)
+		st.prepare()
+		result, err := r.reconcileManifests(context.TODO(), ctrl.Log, ncp, "c", map[string]corev1.Pod{}, components, "v1.22.2")
+		if err != nil {
+			t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+		}
+
+		var cm corev1.ConfigMap
+		if err := cli.Get(context.TODO(), types.NamespacedName{
+			Namespace: "default",
+			Name:      "c-" + kubeadm.ManifestsConfigmapSuffix,
+		}, &cm); err != nil {
+			t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+		}
+		rolling := map[string]bool{}
+		for _, kind := range st.rolling {
+			rolling[kind] = true
+		}
+		for _, kind := range upgradeOrder {
+			expect := oldData[kind]
+			if rolling[kind] {
+				expect = newData[kind]
+			}
+			if cm.Data[kind] != expect {
+				t.Fatalf("\t%s\texpect the %s manifest to be rolled out %v, but not", failed, kind, rolling[kind])
+			}
+		}
+		if upToDate := conditions.IsTrue(ncp, kcpv1.MachinesSpecUpToDateCondition); upToDate != st.upToDate {
+			t.Fatalf("\t%s\texpect the control plane to be up to date %v, but get %v", failed, st.upToDate, upToDate)
+		}
+		if st.upToDate {
+			if ncp.Status.Version == nil || *ncp.Status.Version != "v1.22.2" {
+				t.Fatalf("\t%s\texpect the version v1.22.2, but get %v", failed, ncp.Status.Version)
+			}
+			if result.RequeueAfter != 0 {
+				t.Fatalf("\t%s\texpect no requeue, but get %v", failed, result.RequeueAfter)
+			}
+		} else if result.RequeueAfter != upgradeRequeueInterval {
+			t.Fatalf("\t%s\texpect requeue after %v, but get %v", failed, upgradeRequeueInterval, result.RequeueAfter)
+		}
+		t.Logf("\t%s\tthe manifests are rolled out in order", succeed)
+	}
+}
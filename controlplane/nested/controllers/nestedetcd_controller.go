@@ -23,7 +23,9 @@ import (
 	"github.com/go-logr/logr"
 
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -32,7 +34,9 @@ import (
 	"sigs.k8s.io/cluster-api/util/certs"
 	"sigs.k8s.io/cluster-api/util/secret"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	controlplanev1 "sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/api/v1alpha4"
 	"sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/certificate"
@@ -45,11 +49,14 @@ type NestedEtcdReconciler struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
+
+	members etcdMemberClient
 }
 
 // +kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=nestedetcds,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=nestedetcds/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=controlplane.cluster.x-k8s.io,resources=nestedetcds/finalizers,verbs=update
+// +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;delete
 
 func (r *NestedEtcdReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := r.Log.WithValues("nestedetcd", req.NamespacedName)
@@ -58,6 +65,10 @@ func (r *NestedEtcdReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	if err := r.Get(ctx, req.NamespacedName, &netcd); err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
+	if !netcd.GetDeletionTimestamp().IsZero() {
+		// the NestedEtcd is being deleted, don't recreate its StatefulSet.
+		return ctrl.Result{}, nil
+	}
 	log.Info("creating NestedEtcd",
 		"namespace", netcd.GetNamespace(),
 		"name", netcd.GetName())
@@ -126,6 +137,36 @@ func (r *NestedEtcdReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
+	// reconcile the NestedComponentSpec onto the existing StatefulSet and Service,
+	// the replicas are reconciled member by member by reconcileEtcdMembers.
+	ncSpec := netcd.Spec.NestedComponentSpec
+	ncSpec.Replicas = 0
+	if patched, err := syncNestedComponentSts(ctx, r.Client, netcd.ObjectMeta,
+		ncSpec, &netcdSts, kubeadm.Etcd, cluster.GetName(), log); err != nil {
+		log.Error(err, "fail to update NestedEtcd StatefulSet")
+		return ctrl.Result{}, err
+	} else if patched {
+		// wait for the StatefulSet to roll out the new spec.
+		return ctrl.Result{}, nil
+	}
+	if restoring, err := r.reconcileEtcdRestore(ctx, cluster.GetName(), &netcd,
+		ncSpec, &netcdSts, log); err != nil {
+		log.Error(err, "fail to restore the NestedEtcd from the snapshot")
+		return ctrl.Result{}, err
+	} else if restoring {
+		return ctrl.Result{RequeueAfter: etcdScaleRequeueInterval}, nil
+	}
+	if scaling, err := r.reconcileEtcdMembers(ctx, cluster, &netcd, &netcdSts, log); err != nil {
+		log.Error(err, "fail to scale NestedEtcd StatefulSet")
+		return ctrl.Result{}, err
+	} else if scaling {
+		return ctrl.Result{RequeueAfter: etcdScaleRequeueInterval}, nil
+	}
+	if err := r.reconcileEtcdBackup(ctx, cluster.GetName(), &netcd, log); err != nil {
+		log.Error(err, "fail to reconcile the NestedEtcd backup")
+		return ctrl.Result{}, err
+	}
+
 	if netcdSts.Status.ReadyReplicas == netcdSts.Status.Replicas {
 		log.Info("The NestedEtcd StatefulSet is ready")
 		if !IsComponentReady(netcd.Status.CommonStatus) {
@@ -192,12 +233,228 @@ func (r *NestedEtcdReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		return err
 	}
 
+	if r.members == nil {
+		members, err := newPodExecEtcdMemberClient(mgr.GetConfig())
+		if err != nil {
+			return err
+		}
+		r.members = members
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&controlplanev1.NestedEtcd{}).
 		Owns(&appsv1.StatefulSet{}).
+		Owns(&batchv1.CronJob{}).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}},
+			enqueueComponentForManifests(mgr.GetClient(), kubeadm.Etcd),
+			builder.WithPredicates(isManifestsConfigMap)).
 		Complete(r)
 }
 
+// reconcileEtcdMembers scales the NestedEtcd StatefulSet to the replicas of the
+// NestedEtcd one member at a time. The member is added to the etcd cluster
+// before its pod is created and removed from the etcd cluster before its pod
+// is deleted, so that the quorum is kept. The data of a removed member is
+// deleted before it is added again, and the serving cert is reissued if it
+// does not cover the new member. It returns true if the scaling is in
+// progress.
+func (r *NestedEtcdReconciler) reconcileEtcdMembers(ctx context.Context,
+	cluster *controlplanev1alpha4.Cluster, netcd *controlplanev1.NestedEtcd,
+	netcdSts *appsv1.StatefulSet, log logr.Logger) (bool, error) {
+	clusterName := cluster.GetName()
+	desired := netcd.Spec.Replicas
+	if desired == 0 {
+		desired = 1
+	}
+	var current int32 = 1
+	if netcdSts.Spec.Replicas != nil {
+		current = *netcdSts.Spec.Replicas
+	}
+	if desired == current {
+		return false, nil
+	}
+	if !isStatefulSetRolledOut(netcdSts) {
+		log.Info("waiting for the NestedEtcd StatefulSet to be ready before scaling",
+			"replicas", current, "desired", desired)
+		return true, nil
+	}
+
+	seed := types.NamespacedName{
+		Namespace: netcd.GetNamespace(),
+		Name:      fmt.Sprintf("%s-etcd-0", clusterName),
+	}
+	members, err := r.members.MemberList(ctx, seed)
+	if err != nil {
+		return false, err
+	}
+
+	next := current + 1
+	if desired < current {
+		next = current - 1
+	}
+	if next > current {
+		name := fmt.Sprintf("%s-etcd-%d", clusterName, current)
+		peerURL := fmt.Sprintf("https://%s.%s-etcd.%s.svc:2380", name, clusterName, netcd.GetNamespace())
+		if findEtcdMember(members, name, peerURL) == nil {
+			// the member may have been removed before, don't let it restart
+			// with the stale member ID in its data directory.
+			if deleted, err := r.deleteEtcdMemberClaims(ctx, netcdSts, current); err != nil {
+				return false, err
+			} else if !deleted {
+				log.Info("waiting for the data of the removed etcd member to be deleted", "member", name)
+				return true, nil
+			}
+			// the members use the same cert, which has to cover the peer URL
+			// of the new member.
+			if reissued, err := reissueCrt(ctx, r.Client, cluster, certificate.EtcdClient, secret.EtcdCA,
+				fmt.Sprintf("%s.%s-etcd.%s.svc", name, clusterName, netcd.GetNamespace()),
+				func(ca *certificate.KeyPair) (*certificate.KeyPair, error) {
+					return certificate.NewEtcdServerCertAndKey(ca, getEtcdServers(clusterName, netcd.GetNamespace()))
+				}); err != nil {
+				return false, err
+			} else if reissued {
+				log.Info("reissued the etcd serving cert, waiting for the members to reload it", "member", name)
+				return true, nil
+			}
+			if err := r.members.MemberAdd(ctx, seed, name, peerURL); err != nil {
+				return false, err
+			}
+			log.Info("added the etcd member", "member", name)
+		}
+	} else {
+		name := fmt.Sprintf("%s-etcd-%d", clusterName, next)
+		peerURL := fmt.Sprintf("https://%s.%s-etcd.%s.svc:2380", name, clusterName, netcd.GetNamespace())
+		if member := findEtcdMember(members, name, peerURL); member != nil {
+			if err := r.members.MemberRemove(ctx, seed, member.ID); err != nil {
+				return false, err
+			}
+			log.Info("removed the etcd member", "member", name)
+		}
+	}
+
+	patch := client.MergeFrom(netcdSts.DeepCopy())
+	netcdSts.Spec.Replicas = &next
+	setEtcdInitialClusterArgs(&netcdSts.Spec.Template.Spec.Containers[0],
+		next, clusterName, netcd.GetNamespace())
+	if err := r.Patch(ctx, netcdSts, patch); err != nil {
+		return false, err
+	}
+	log.Info("scaled the NestedEtcd StatefulSet", "replicas", next, "desired", desired)
+	return true, nil
+}
+
+// reconcileEtcdRestore records the snapshot of NestedEtcd.Spec.Restore in the
+// status once the first member is restored from it, and then removes the init
+// containers that restore the snapshot from the StatefulSet, so that the
+// snapshot is not restored again. It returns true if the restore is in
+// progress.
+func (r *NestedEtcdReconciler) reconcileEtcdRestore(ctx context.Context,
+	clusterName string, netcd *controlplanev1.NestedEtcd,
+	ncSpec controlplanev1.NestedComponentSpec, netcdSts *appsv1.StatefulSet,
+	log logr.Logger) (bool, error) {
+	restore := netcd.Spec.Restore
+	if restore == nil {
+		return false, nil
+	}
+	if netcd.Status.RestoredSnapshot != restore.Snapshot {
+		if netcdSts.Status.ReadyReplicas == 0 {
+			// wait for the first member to be restored.
+			return true, nil
+		}
+		netcd.Status.RestoredSnapshot = restore.Snapshot
+		if err := r.Status().Update(ctx, netcd); err != nil {
+			return false, err
+		}
+		log.Info("restored the etcd from the snapshot", "snapshot", restore.Snapshot)
+		return true, nil
+	}
+	if !isEtcdRestorePending(netcdSts) {
+		return false, nil
+	}
+	if err := repatchNestedComponentSts(ctx, r.Client, netcd.ObjectMeta,
+		ncSpec, netcdSts, kubeadm.Etcd, clusterName, log); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// reconcileEtcdBackup creates, updates or deletes the CronJob that takes the
+// scheduled snapshots of the NestedEtcd as specified by NestedEtcd.Spec.Backup,
+// and records the time of the last successful snapshot.
+func (r *NestedEtcdReconciler) reconcileEtcdBackup(ctx context.Context,
+	clusterName string, netcd *controlplanev1.NestedEtcd, log logr.Logger) error {
+	var cronJob batchv1.CronJob
+	err := r.Get(ctx, types.NamespacedName{
+		Namespace: netcd.GetNamespace(),
+		Name:      clusterName + "-etcd-backup",
+	}, &cronJob)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	found := err == nil
+
+	if netcd.Spec.Backup == nil {
+		if found && metav1.IsControlledBy(&cronJob, netcd) {
+			if err := r.Delete(ctx, &cronJob); err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+			log.Info("deleted the etcd backup CronJob")
+		}
+		return nil
+	}
+
+	image, err := getEtcdImage(ctx, r.Client, netcd.GetNamespace(), clusterName)
+	if err != nil {
+		return err
+	}
+	desired, err := genEtcdBackupCronJob(netcd.GetNamespace(), clusterName, image, *netcd.Spec.Backup)
+	if err != nil {
+		return err
+	}
+	if err := ctrl.SetControllerReference(netcd, desired, r.Scheme); err != nil {
+		return err
+	}
+	if !found {
+		if err := r.Create(ctx, desired); err != nil {
+			return err
+		}
+		log.Info("created the etcd backup CronJob", "schedule", desired.Spec.Schedule)
+		return nil
+	}
+	if !apiequality.Semantic.DeepDerivative(desired.Spec, cronJob.Spec) {
+		patch := client.MergeFrom(cronJob.DeepCopy())
+		cronJob.Spec = desired.Spec
+		if err := r.Patch(ctx, &cronJob, patch); err != nil {
+			return err
+		}
+		log.Info("updated the etcd backup CronJob", "schedule", desired.Spec.Schedule)
+	}
+
+	if last := cronJob.Status.LastSuccessfulTime; last != nil && !last.Equal(netcd.Status.LastBackupTime) {
+		netcd.Status.LastBackupTime = last.DeepCopy()
+		if err := r.Status().Update(ctx, netcd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findEtcdMember finds the etcd member by its name or, as a member that is
+// added but not started has no name, by its peer url.
+func findEtcdMember(members []etcdMember, name, peerURL string) *etcdMember {
+	for i := range members {
+		if members[i].Name == name {
+			return &members[i]
+		}
+		for _, url := range members[i].PeerURLs {
+			if url == peerURL {
+				return &members[i]
+			}
+		}
+	}
+	return nil
+}
+
 func getNestedEtcdSvcClusterIP(ctx context.Context, cli client.Client,
 	clusterName string, netcd *controlplanev1.NestedEtcd) (string, error) {
 	var svc corev1.Service
@@ -228,13 +485,42 @@ func genInitialClusterArgs(replicas int32,
 	return argsVal
 }
 
-func getEtcdServers(name, namespace string, replicas int32) (etcdServers []string) {
-	var i int32
-	for ; i < replicas; i++ {
-		etcdServers = append(etcdServers, fmt.Sprintf("%s-etcd-%d.%s-etcd.%s", name, i, name, namespace))
+// getEtcdServers returns the names covered by the etcd serving cert, which
+// is shared by all the members, so that the members added later are covered
+// as well.
+func getEtcdServers(name, namespace string) []string {
+	return []string{
+		fmt.Sprintf("*.%s-etcd.%s", name, namespace),
+		fmt.Sprintf("*.%s-etcd.%s.svc", name, namespace),
+		name,
+	}
+}
+
+// deleteEtcdMemberClaims deletes the PersistentVolumeClaims of the member
+// with the given ordinal, which are left behind when the member is removed.
+// It returns true once the claims are gone.
+func (r *NestedEtcdReconciler) deleteEtcdMemberClaims(ctx context.Context,
+	netcdSts *appsv1.StatefulSet, ordinal int32) (bool, error) {
+	deleted := true
+	for _, vct := range netcdSts.Spec.VolumeClaimTemplates {
+		var pvc corev1.PersistentVolumeClaim
+		if err := r.Get(ctx, types.NamespacedName{
+			Namespace: netcdSts.GetNamespace(),
+			Name:      fmt.Sprintf("%s-%s-%d", vct.GetName(), netcdSts.GetName(), ordinal),
+		}, &pvc); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return false, err
+		}
+		deleted = false
+		if pvc.GetDeletionTimestamp().IsZero() {
+			if err := r.Delete(ctx, &pvc); client.IgnoreNotFound(err) != nil {
+				return false, err
+			}
+		}
 	}
-	etcdServers = append(etcdServers, name)
-	return etcdServers
+	return deleted, nil
 }
 
 // createEtcdClientCrts will find of create client certs for the etcd cluster.
@@ -258,7 +544,7 @@ func (r *NestedEtcdReconciler) createEtcdClientCrts(ctx context.Context, cluster
 		return err
 	}
 
-	etcdKeyPair, err := certificate.NewEtcdServerCertAndKey(&certificate.KeyPair{Cert: crt, Key: key}, getEtcdServers(cluster.GetName(), cluster.GetNamespace(), netcd.Spec.Replicas))
+	etcdKeyPair, err := certificate.NewEtcdServerCertAndKey(&certificate.KeyPair{Cert: crt, Key: key}, getEtcdServers(cluster.GetName(), cluster.GetNamespace()))
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,247 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/certs"
+	"sigs.k8s.io/cluster-api/util/secret"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	controlplanev1 "sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/certificate"
+)
+
+func TestReconcileEtcdRestore(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := controlplanev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	netcd := &controlplanev1.NestedEtcd{
+		ObjectMeta: metav1.ObjectMeta{Name: "netcd", Namespace: "default"},
+		Spec: controlplanev1.NestedEtcdSpec{
+			Restore: &controlplanev1.NestedEtcdRestore{Snapshot: "c-20211201000000.db"},
+		},
+	}
+	r := &NestedEtcdReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(netcd).Build(),
+		Log:    ctrl.Log,
+		Scheme: scheme,
+	}
+	sts := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "c-etcd", Namespace: "default"}}
+	sts.Spec.Template.Spec.InitContainers = []corev1.Container{{Name: "etcd-snapshot-fetch"}}
+
+	restoring, err := r.reconcileEtcdRestore(context.TODO(), "c", netcd, netcd.Spec.NestedComponentSpec, sts, r.Log)
+	if err != nil || !restoring || netcd.Status.RestoredSnapshot != "" {
+		t.Fatalf("\t%s\texpect the restore to wait for the first member, but get %v, %v", failed, restoring, err)
+	}
+	sts.Status.ReadyReplicas = 1
+	restoring, err = r.reconcileEtcdRestore(context.TODO(), "c", netcd, netcd.Spec.NestedComponentSpec, sts, r.Log)
+	if err != nil || !restoring {
+		t.Fatalf("\t%s\texpect the restore to be recorded, but get %v, %v", failed, restoring, err)
+	}
+	var got controlplanev1.NestedEtcd
+	if err := r.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "netcd"}, &got); err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	if got.Status.RestoredSnapshot != "c-20211201000000.db" {
+		t.Fatalf("\t%s\texpect the restored snapshot in the status, but get %q", failed, got.Status.RestoredSnapshot)
+	}
+	sts.Spec.Template.Spec.InitContainers = nil
+	restoring, err = r.reconcileEtcdRestore(context.TODO(), "c", &got, got.Spec.NestedComponentSpec, sts, r.Log)
+	if err != nil || restoring {
+		t.Fatalf("\t%s\texpect the restore to be done, but get %v, %v", failed, restoring, err)
+	}
+	t.Logf("\t%s\tthe snapshot is restored once", succeed)
+}
+
+// fakeEtcdMemberClient records the etcdctl member commands along with the
+// replicas of the etcd StatefulSet at the time they are run.
+type fakeEtcdMemberClient struct {
+	client.Client
+	members []etcdMember
+	nextID  int
+	calls   []string
+}
+
+func (f *fakeEtcdMemberClient) replicas(ctx context.Context, pod types.NamespacedName) int32 {
+	var sts appsv1.StatefulSet
+	if err := f.Get(ctx, types.NamespacedName{Namespace: pod.Namespace, Name: "c-etcd"}, &sts); err != nil {
+		return -1
+	}
+	return *sts.Spec.Replicas
+}
+
+func (f *fakeEtcdMemberClient) MemberList(ctx context.Context, pod types.NamespacedName) ([]etcdMember, error) {
+	return append([]etcdMember(nil), f.members...), nil
+}
+
+func (f *fakeEtcdMemberClient) MemberAdd(ctx context.Context, pod types.NamespacedName, name, peerURL string) error {
+	f.nextID++
+	f.members = append(f.members, etcdMember{ID: strconv.Itoa(f.nextID), Name: name, PeerURLs: []string{peerURL}})
+	f.calls = append(f.calls, fmt.Sprintf("add %s with %d replicas", name, f.replicas(ctx, pod)))
+	return nil
+}
+
+func (f *fakeEtcdMemberClient) MemberRemove(ctx context.Context, pod types.NamespacedName, id string) error {
+	for i, m := range f.members {
+		if m.ID == id {
+			f.members = append(f.members[:i], f.members[i+1:]...)
+			f.calls = append(f.calls, fmt.Sprintf("remove %s with %d replicas", m.Name, f.replicas(ctx, pod)))
+			return nil
+		}
+	}
+	return fmt.Errorf("member %s not found", id)
+}
+
+func TestReconcileEtcdMembers(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	cluster := &clusterv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "default"}}
+	ca := &secret.Certificate{Purpose: secret.EtcdCA}
+	if err := ca.Generate(); err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	cacrt, err := certs.DecodeCertPEM(ca.KeyPair.Cert)
+	if err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	cakey, err := certs.DecodePrivateKeyPEM(ca.KeyPair.Key)
+	if err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	// the cert issued for the initial member only.
+	etcdCrt, err := certificate.NewEtcdServerCertAndKey(&certificate.KeyPair{Cert: cacrt, Key: cakey},
+		[]string{"c-etcd-0.c-etcd.default", "c"})
+	if err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+
+	replicas := int32(1)
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "c-etcd", Namespace: "default"},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "etcd", Command: []string{"etcd"}}},
+			}},
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{ObjectMeta: metav1.ObjectMeta{Name: "etcd-data"}}},
+		},
+		Status: appsv1.StatefulSetStatus{Replicas: 1, ReadyReplicas: 1, UpdatedReplicas: 1},
+	}
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sts,
+		ca.AsSecret(util.ObjectKey(cluster), metav1.OwnerReference{}),
+		etcdCrt.AsSecret(util.ObjectKey(cluster), metav1.OwnerReference{})).Build()
+	members := &fakeEtcdMemberClient{Client: cli, members: []etcdMember{{ID: "0", Name: "c-etcd-0"}}}
+	r := &NestedEtcdReconciler{Client: cli, Log: ctrl.Log, Scheme: scheme, members: members}
+
+	// reconcile runs reconcileEtcdMembers once and rolls out the StatefulSet.
+	reconcile := func(desired int32) (bool, int32) {
+		var got appsv1.StatefulSet
+		if err := cli.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "c-etcd"}, &got); err != nil {
+			t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+		}
+		netcd := &controlplanev1.NestedEtcd{ObjectMeta: metav1.ObjectMeta{Name: "netcd", Namespace: "default"}}
+		netcd.Spec.Replicas = desired
+		scaling, err := r.reconcileEtcdMembers(context.TODO(), cluster, netcd, &got, r.Log)
+		if err != nil {
+			t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+		}
+		if err := cli.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "c-etcd"}, &got); err != nil {
+			t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+		}
+		got.Status = appsv1.StatefulSetStatus{
+			ObservedGeneration: got.Generation,
+			Replicas:           *got.Spec.Replicas,
+			ReadyReplicas:      *got.Spec.Replicas,
+			UpdatedReplicas:    *got.Spec.Replicas,
+		}
+		if err := cli.Update(context.TODO(), &got); err != nil {
+			t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+		}
+		return scaling, *got.Spec.Replicas
+	}
+
+	// the cert is reissued before the first member is added.
+	if scaling, got := reconcile(3); !scaling || got != 1 || len(members.calls) != 0 {
+		t.Fatalf("\t%s\texpect the cert to be reissued first, but get %v, %d replicas, calls %v", failed, scaling, got, members.calls)
+	}
+	var crtSecret corev1.Secret
+	if err := cli.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: secret.Name("c", certificate.EtcdClient)}, &crtSecret); err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	crt, err := certs.DecodeCertPEM(crtSecret.Data[secret.TLSCrtDataName])
+	if err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	for _, host := range []string{"c-etcd-1.c-etcd.default.svc", "c-etcd-2.c-etcd.default.svc", "c-etcd-2.c-etcd.default"} {
+		if err := crt.VerifyHostname(host); err != nil {
+			t.Fatalf("\t%s\texpect the reissued cert to cover %s, but get %v", failed, host, err)
+		}
+	}
+
+	for _, desired := range []int32{3, 3, 3, 1, 1, 1} {
+		reconcile(desired)
+	}
+	// the removed member left its data behind, which is deleted before it is added again.
+	pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "etcd-data-c-etcd-1", Namespace: "default"}}
+	if err := cli.Create(context.TODO(), pvc); err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	if scaling, got := reconcile(2); !scaling || got != 1 {
+		t.Fatalf("\t%s\texpect to wait for the data to be deleted, but get %v, %d replicas", failed, scaling, got)
+	}
+	if err := cli.Get(context.TODO(), client.ObjectKeyFromObject(pvc), pvc); !apierrors.IsNotFound(err) {
+		t.Fatalf("\t%s\texpect the data of the removed member to be deleted, but get %v", failed, err)
+	}
+	if scaling, got := reconcile(2); !scaling || got != 2 {
+		t.Fatalf("\t%s\texpect to scale to 2 replicas, but get %v, %d replicas", failed, scaling, got)
+	}
+	if scaling, _ := reconcile(2); scaling {
+		t.Fatalf("\t%s\texpect the scaling to be done", failed)
+	}
+
+	expected := []string{
+		"add c-etcd-1 with 1 replicas",
+		"add c-etcd-2 with 2 replicas",
+		"remove c-etcd-2 with 3 replicas",
+		"remove c-etcd-1 with 2 replicas",
+		"add c-etcd-1 with 1 replicas",
+	}
+	if !reflect.DeepEqual(members.calls, expected) {
+		t.Fatalf("\t%s\texpect the members to be changed in order %v, but get %v", failed, expected, members.calls)
+	}
+	t.Logf("\t%s\tthe members are changed before the StatefulSet is scaled", succeed)
+}
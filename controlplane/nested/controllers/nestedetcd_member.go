@@ -0,0 +1,145 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=pods/exec,verbs=create
+
+// etcdMember is a member of the NestedEtcd cluster.
+type etcdMember struct {
+	ID       string
+	Name     string
+	PeerURLs []string
+}
+
+// etcdMemberClient manages the members of the NestedEtcd cluster through one
+// of its running members.
+type etcdMemberClient interface {
+	// MemberList lists the members of the cluster.
+	MemberList(ctx context.Context, pod types.NamespacedName) ([]etcdMember, error)
+	// MemberAdd adds a member to the cluster.
+	MemberAdd(ctx context.Context, pod types.NamespacedName, name, peerURL string) error
+	// MemberRemove removes a member from the cluster.
+	MemberRemove(ctx context.Context, pod types.NamespacedName, id string) error
+}
+
+// podExecEtcdMemberClient runs etcdctl in the etcd pods, which saves the
+// controller from reaching the etcd pods over the network.
+type podExecEtcdMemberClient struct {
+	config *rest.Config
+	client kubernetes.Interface
+}
+
+var _ etcdMemberClient = &podExecEtcdMemberClient{}
+
+func newPodExecEtcdMemberClient(config *rest.Config) (*podExecEtcdMemberClient, error) {
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &podExecEtcdMemberClient{config: config, client: client}, nil
+}
+
+func (c *podExecEtcdMemberClient) MemberList(ctx context.Context, pod types.NamespacedName) ([]etcdMember, error) {
+	out, err := c.etcdctl(ctx, pod, "member", "list", "--write-out=simple")
+	if err != nil {
+		return nil, err
+	}
+	return parseEtcdMemberList(out)
+}
+
+func (c *podExecEtcdMemberClient) MemberAdd(ctx context.Context, pod types.NamespacedName, name, peerURL string) error {
+	_, err := c.etcdctl(ctx, pod, "member", "add", name, "--peer-urls="+peerURL)
+	return err
+}
+
+func (c *podExecEtcdMemberClient) MemberRemove(ctx context.Context, pod types.NamespacedName, id string) error {
+	_, err := c.etcdctl(ctx, pod, "member", "remove", id)
+	return err
+}
+
+// etcdctl runs etcdctl with the health check client certificate in the pod.
+func (c *podExecEtcdMemberClient) etcdctl(ctx context.Context, pod types.NamespacedName, args ...string) (string, error) {
+	command := append([]string{
+		"etcdctl",
+		"--endpoints=https://" + loopbackAddress + ":2379",
+		"--cacert=/etc/kubernetes/pki/ca/tls.crt",
+		"--cert=/etc/kubernetes/pki/health/tls.crt",
+		"--key=/etc/kubernetes/pki/health/tls.key",
+	}, args...)
+
+	req := c.client.CoreV1().RESTClient().Post().
+		Namespace(pod.Namespace).
+		Resource("pods").
+		Name(pod.Name).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Command: command,
+			Stdout:  true,
+			Stderr:  true,
+		}, scheme.ParameterCodec)
+	executor, err := remotecommand.NewSPDYExecutor(c.config, "POST", req.URL())
+	if err != nil {
+		return "", err
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := executor.Stream(remotecommand.StreamOptions{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}); err != nil {
+		return "", errors.Errorf("fail to run %s in pod %s: %v: %s",
+			strings.Join(args, " "), pod, err, stderr.String())
+	}
+	return stdout.String(), nil
+}
+
+// parseEtcdMemberList parses the output of `etcdctl member list --write-out=simple`,
+// e.g. "8e9e05c52164694d, started, infra0, https://infra0:2380, https://infra0:2379, false".
+func parseEtcdMemberList(out string) ([]etcdMember, error) {
+	var members []etcdMember
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(line, ", ")
+		if len(fields) < 4 {
+			return nil, errors.Errorf("invalid etcd member: %q", line)
+		}
+		members = append(members, etcdMember{
+			ID:       fields[0],
+			Name:     fields[2],
+			PeerURLs: strings.Split(fields[3], ","),
+		})
+	}
+	return members, nil
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEtcdMemberList(t *testing.T) {
+	out := `8e9e05c52164694d, started, c-etcd-0, https://c-etcd-0.c-etcd.default.svc:2380, https://c-etcd-0.c-etcd.default.svc:2379, false
+91bc3c398fb3c146, unstarted, , https://c-etcd-1.c-etcd.default.svc:2380, , false
+`
+	members, err := parseEtcdMemberList(out)
+	if err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	expect := []etcdMember{
+		{ID: "8e9e05c52164694d", Name: "c-etcd-0", PeerURLs: []string{"https://c-etcd-0.c-etcd.default.svc:2380"}},
+		{ID: "91bc3c398fb3c146", Name: "", PeerURLs: []string{"https://c-etcd-1.c-etcd.default.svc:2380"}},
+	}
+	if !reflect.DeepEqual(members, expect) {
+		t.Fatalf("\t%s\texpect %v, but get %v", failed, expect, members)
+	}
+	if m := findEtcdMember(members, "c-etcd-1", "https://c-etcd-1.c-etcd.default.svc:2380"); m == nil || m.ID != "91bc3c398fb3c146" {
+		t.Fatalf("\t%s\texpect to find the unstarted member by peer url, but get %v", failed, m)
+	}
+}
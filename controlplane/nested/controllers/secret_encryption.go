@@ -0,0 +1,377 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	apiserverconfigv1 "k8s.io/apiserver/pkg/apis/config/v1"
+	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/controllers/remote"
+	kcpv1 "sigs.k8s.io/cluster-api/controlplane/kubeadm/api/v1alpha4"
+	"sigs.k8s.io/cluster-api/util"
+	"sigs.k8s.io/cluster-api/util/conditions"
+	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlcli "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	controlplanev1 "sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/kubeadm"
+)
+
+const (
+	// encryptionConfigKey is the key of the EncryptionConfiguration in the
+	// encryption config secret.
+	encryptionConfigKey = "encryption-config.yaml"
+	// encryptionConfigDir is where the encryption config secret is mounted
+	// in the apiserver.
+	encryptionConfigDir = "/etc/kubernetes/encryption"
+	// encryptionKeySize is the size of the keys in bytes, aesgcm uses
+	// AES-256.
+	encryptionKeySize = 32
+	// reencryptPageSize is the number of secrets listed at a time while they
+	// are re-encrypted.
+	reencryptPageSize = 500
+
+	secretboxProvider = "secretbox"
+	aesgcmProvider    = "aesgcm"
+
+	// encryptionKeyAdded is the phase of a key rotation in which the new key
+	// is added as a read-only key, so that all the apiservers can decrypt
+	// with it before any of them encrypts with it.
+	encryptionKeyAdded = "KeyAdded"
+	// encryptionKeyPromoted is the phase of a key rotation in which the new
+	// key encrypts the secrets, the previous keys are removed once the
+	// secrets are re-encrypted.
+	encryptionKeyPromoted = "KeyPromoted"
+)
+
+// encryptionKey is a key of the EncryptionConfiguration along with its
+// provider.
+type encryptionKey struct {
+	Provider string
+	apiserverconfigv1.Key
+}
+
+// encryptionConfigSecretName returns the name of the secret that holds the
+// EncryptionConfiguration of the cluster.
+func encryptionConfigSecretName(clusterName string) string {
+	return clusterName + "-encryption-config"
+}
+
+// encryptionConfigHash returns the hash of the EncryptionConfiguration.
+func encryptionConfigHash(config []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(config))[:16]
+}
+
+// encryptionProvider returns the provider the new keys are generated for.
+func encryptionProvider(se *controlplanev1.SecretEncryption) string {
+	if se.Provider == "" {
+		return secretboxProvider
+	}
+	return se.Provider
+}
+
+// newEncryptionKey generates a random key of the provider, which is named
+// after the latest of the existing keys, e.g. key2 follows key1.
+func newEncryptionKey(provider string, keys []encryptionKey) (encryptionKey, error) {
+	latest := 0
+	for _, key := range keys {
+		if i, err := strconv.Atoi(strings.TrimPrefix(key.Name, "key")); err == nil && i > latest {
+			latest = i
+		}
+	}
+	secret := make([]byte, encryptionKeySize)
+	if _, err := rand.Read(secret); err != nil {
+		return encryptionKey{}, err
+	}
+	return encryptionKey{
+		Provider: provider,
+		Key: apiserverconfigv1.Key{
+			Name:   fmt.Sprintf("key%d", latest+1),
+			Secret: base64.StdEncoding.EncodeToString(secret),
+		},
+	}, nil
+}
+
+// genEncryptionConfig generates the EncryptionConfiguration that encrypts the
+// secrets with the first key and decrypts them with any of the keys. The
+// identity provider comes last so that the secrets stored before the
+// encryption is enabled can be read.
+func genEncryptionConfig(keys []encryptionKey) ([]byte, error) {
+	var providers []apiserverconfigv1.ProviderConfiguration
+	for i, key := range keys {
+		if i == 0 || key.Provider != keys[i-1].Provider {
+			providers = append(providers, apiserverconfigv1.ProviderConfiguration{})
+		}
+		provider := &providers[len(providers)-1]
+		switch key.Provider {
+		case secretboxProvider:
+			if provider.Secretbox == nil {
+				provider.Secretbox = &apiserverconfigv1.SecretboxConfiguration{}
+			}
+			provider.Secretbox.Keys = append(provider.Secretbox.Keys, key.Key)
+		case aesgcmProvider:
+			if provider.AESGCM == nil {
+				provider.AESGCM = &apiserverconfigv1.AESConfiguration{}
+			}
+			provider.AESGCM.Keys = append(provider.AESGCM.Keys, key.Key)
+		default:
+			return nil, errors.Errorf("unknown encryption provider %s", key.Provider)
+		}
+	}
+	providers = append(providers, apiserverconfigv1.ProviderConfiguration{
+		Identity: &apiserverconfigv1.IdentityConfiguration{},
+	})
+	return yaml.Marshal(&apiserverconfigv1.EncryptionConfiguration{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: apiserverconfigv1.SchemeGroupVersion.String(),
+			Kind:       "EncryptionConfiguration",
+		},
+		Resources: []apiserverconfigv1.ResourceConfiguration{
+			{
+				Resources: []string{"secrets"},
+				Providers: providers,
+			},
+		},
+	})
+}
+
+// parseEncryptionKeys reads the keys of the EncryptionConfiguration in order.
+func parseEncryptionKeys(data []byte) ([]encryptionKey, error) {
+	var config apiserverconfigv1.EncryptionConfiguration
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, errors.Wrap(err, "invalid encryption config")
+	}
+	var keys []encryptionKey
+	for _, resource := range config.Resources {
+		for _, provider := range resource.Providers {
+			switch {
+			case provider.Secretbox != nil:
+				for _, key := range provider.Secretbox.Keys {
+					keys = append(keys, encryptionKey{Provider: secretboxProvider, Key: key})
+				}
+			case provider.AESGCM != nil:
+				for _, key := range provider.AESGCM.Keys {
+					keys = append(keys, encryptionKey{Provider: aesgcmProvider, Key: key})
+				}
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("encryption config has no key")
+	}
+	return keys, nil
+}
+
+// rotateEncryptionKeys moves a key rotation to the next phase, and returns
+// the keys and the phase after it. A new key of the provider is added as a
+// read-only key first, then it is promoted to encrypt the secrets, and the
+// previous keys are removed once the secrets are re-encrypted with it.
+func rotateEncryptionKeys(keys []encryptionKey, phase, provider string) ([]encryptionKey, string, error) {
+	switch phase {
+	case "":
+		key, err := newEncryptionKey(provider, keys)
+		if err != nil {
+			return nil, "", err
+		}
+		return append(append([]encryptionKey{}, keys...), key), encryptionKeyAdded, nil
+	case encryptionKeyAdded:
+		latest := keys[len(keys)-1]
+		return append([]encryptionKey{latest}, keys[:len(keys)-1]...), encryptionKeyPromoted, nil
+	case encryptionKeyPromoted:
+		return keys[:1], "", nil
+	default:
+		return nil, "", errors.Errorf("unknown phase %s of the encryption key rotation", phase)
+	}
+}
+
+// applySecretEncryption mounts the encryption config secret into the
+// kube-apiserver pod spec and points the kube-apiserver to it.
+func applySecretEncryption(ps *corev1.PodSpec, clusterName string) {
+	var volSrtMode int32 = 420
+	ps.Volumes = append(ps.Volumes, corev1.Volume{
+		Name: encryptionConfigSecretName(clusterName),
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				DefaultMode: &volSrtMode,
+				SecretName:  encryptionConfigSecretName(clusterName),
+			},
+		},
+	})
+	container := &ps.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		MountPath: encryptionConfigDir,
+		Name:      encryptionConfigSecretName(clusterName),
+		ReadOnly:  true,
+	})
+	container.Command = append(removeCommandFlags(container.Command, "encryption-provider-config"),
+		"--encryption-provider-config="+encryptionConfigDir+"/"+encryptionConfigKey)
+}
+
+// setEncryptionConfigHash records the hash of the EncryptionConfiguration on
+// the apiserver manifest, so that the apiserver is restarted once the keys
+// change.
+func setEncryptionConfigHash(manifests map[string]corev1.Pod, hash string) {
+	kas, ok := manifests[kubeadm.APIServer]
+	if !ok {
+		return
+	}
+	annotations := kas.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[encryptionConfigHashAnnotation] = hash
+	kas.SetAnnotations(annotations)
+	manifests[kubeadm.APIServer] = kas
+}
+
+// reconcileSecretEncryption creates the encryption config secret with a new
+// key if it is not found, and moves the key rotation requested by the
+// RotateEncryptionKeyAnnotation to the next phase once the apiserver runs
+// with the current keys. It returns the hash of the EncryptionConfiguration
+// and whether a key rotation is in progress.
+func (r *NestedControlPlaneReconciler) reconcileSecretEncryption(ctx context.Context, log logr.Logger,
+	cluster *clusterv1.Cluster, ncp *controlplanev1.NestedControlPlane) (string, bool, error) {
+	if ncp.Spec.SecretEncryption == nil {
+		return "", false, nil
+	}
+	var srt corev1.Secret
+	if err := r.Get(ctx, types.NamespacedName{
+		Namespace: ncp.GetNamespace(),
+		Name:      encryptionConfigSecretName(cluster.GetName()),
+	}, &srt); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return "", false, err
+		}
+		key, err := newEncryptionKey(encryptionProvider(ncp.Spec.SecretEncryption), nil)
+		if err != nil {
+			return "", false, err
+		}
+		config, err := genEncryptionConfig([]encryptionKey{key})
+		if err != nil {
+			return "", false, err
+		}
+		newSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      encryptionConfigSecretName(cluster.GetName()),
+				Namespace: ncp.GetNamespace(),
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: map[string][]byte{
+				encryptionConfigKey: config,
+			},
+		}
+		if err := ctrl.SetControllerReference(ncp, newSecret, r.Scheme); err != nil {
+			return "", false, err
+		}
+		return encryptionConfigHash(config), false, r.Create(ctx, newSecret)
+	}
+
+	hash := encryptionConfigHash(srt.Data[encryptionConfigKey])
+	requested := ncp.GetAnnotations()[controlplanev1.RotateEncryptionKeyAnnotation]
+	phase := srt.GetAnnotations()[encryptionKeyRotationPhaseAnnotation]
+	if phase == "" && (requested == "" || requested == srt.GetAnnotations()[encryptionKeyRotatedAnnotation]) {
+		return hash, false, nil
+	}
+	// each phase waits for the apiserver to be rolled out with the keys of
+	// the previous one.
+	if !ncp.Status.Ready || !conditions.IsTrue(ncp, kcpv1.MachinesSpecUpToDateCondition) {
+		log.Info("Waiting for the apiserver to run with the encryption keys", "phase", phase)
+		return hash, true, nil
+	}
+
+	keys, err := parseEncryptionKeys(srt.Data[encryptionConfigKey])
+	if err != nil {
+		return "", false, err
+	}
+	if phase == encryptionKeyPromoted {
+		log.Info("Re-encrypting the secrets with the new key")
+		tenantClient, err := remote.NewClusterClient(ctx, "nestedcontrolplane", r.Client, util.ObjectKey(cluster))
+		if err != nil {
+			return "", false, err
+		}
+		if err := reencryptSecrets(ctx, tenantClient); err != nil {
+			return "", false, err
+		}
+	}
+	keys, phase, err = rotateEncryptionKeys(keys, phase, encryptionProvider(ncp.Spec.SecretEncryption))
+	if err != nil {
+		return "", false, err
+	}
+	config, err := genEncryptionConfig(keys)
+	if err != nil {
+		return "", false, err
+	}
+
+	mergeFrom := ctrlcli.MergeFrom(srt.DeepCopy())
+	srt.Data[encryptionConfigKey] = config
+	annotations := srt.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	if phase != "" {
+		annotations[encryptionKeyRotationPhaseAnnotation] = phase
+	} else {
+		delete(annotations, encryptionKeyRotationPhaseAnnotation)
+		annotations[encryptionKeyRotatedAnnotation] = requested
+	}
+	srt.SetAnnotations(annotations)
+	log.Info("Rotating the encryption key", "phase", phase)
+	if err := r.Patch(ctx, &srt, mergeFrom); err != nil {
+		return "", false, err
+	}
+	return encryptionConfigHash(config), phase != "", nil
+}
+
+// reencryptSecrets rewrites all the secrets of the cluster, so that the
+// apiserver stores them encrypted with the current key. The apiserver writes
+// an unchanged secret to the storage if it was decrypted with another key or
+// was not encrypted.
+func reencryptSecrets(ctx context.Context, cli ctrlcli.Client) error {
+	continueToken := ""
+	for {
+		var secrets corev1.SecretList
+		if err := cli.List(ctx, &secrets, ctrlcli.Limit(reencryptPageSize), ctrlcli.Continue(continueToken)); err != nil {
+			return err
+		}
+		for i := range secrets.Items {
+			srt := &secrets.Items[i]
+			// a secret that is deleted or updated since it is listed is
+			// already stored with the current key.
+			if err := cli.Update(ctx, srt); err != nil && !apierrors.IsNotFound(err) && !apierrors.IsConflict(err) {
+				return errors.Wrapf(err, "failed to re-encrypt secret %s/%s", srt.GetNamespace(), srt.GetName())
+			}
+		}
+		if secrets.Continue == "" {
+			return nil
+		}
+		continueToken = secrets.Continue
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+
+	controlplanev1 "sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/api/v1alpha4"
+	"sigs.k8s.io/cluster-api-provider-nested/controlplane/nested/kubeadm"
+)
+
+func TestCompleteTemplatesWithSecretEncryption(t *testing.T) {
+	templates, err := kubeadm.GenerateTemplates(kubeadm.Options{ClusterName: "c"})
+	if err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	manifests, err := completeTemplates(templates, "c", controlplanev1.NestedControlPlaneSpec{
+		SecretEncryption: &controlplanev1.SecretEncryption{},
+	})
+	if err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	setEncryptionConfigHash(manifests, "hash")
+	kas := manifests[kubeadm.APIServer]
+	command := strings.Join(kas.Spec.Containers[0].Command, " ")
+	if !strings.Contains(command, "--encryption-provider-config=/etc/kubernetes/encryption/encryption-config.yaml") {
+		t.Fatalf("\t%s\tthe apiserver is not pointed to the encryption config: %s", failed, command)
+	}
+	mounted := false
+	for _, m := range kas.Spec.Containers[0].VolumeMounts {
+		mounted = mounted || (m.Name == "c-encryption-config" && m.MountPath == encryptionConfigDir)
+	}
+	if !mounted {
+		t.Fatalf("\t%s\tthe encryption config is not mounted: %v", failed, kas.Spec.Containers[0].VolumeMounts)
+	}
+
+	manifest, err := yaml.Marshal(&kas)
+	if err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	sts, err := genStatefulSetManifest(string(manifest), kubeadm.APIServer, "c", "nkas", "default")
+	if err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	if sts.Spec.Template.GetAnnotations()[encryptionConfigHashAnnotation] != "hash" {
+		t.Fatalf("\t%s\texpect the hash of the encryption config on the pod template, but get %v", failed, sts.Spec.Template.GetAnnotations())
+	}
+	t.Logf("\t%s\tthe apiserver encrypts the secrets", succeed)
+}
+
+func TestEncryptionConfig(t *testing.T) {
+	key1, err := newEncryptionKey(aesgcmProvider, nil)
+	if err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	keys, phase, err := rotateEncryptionKeys([]encryptionKey{key1}, "", secretboxProvider)
+	if err != nil || phase != encryptionKeyAdded || len(keys) != 2 || keys[0] != key1 || keys[1].Name != "key2" {
+		t.Fatalf("\t%s\texpect key2 to be added after key1, but get phase %s, keys %v: %v", failed, phase, keys, err)
+	}
+	key2 := keys[1]
+
+	keys, phase, err = rotateEncryptionKeys(keys, phase, secretboxProvider)
+	if err != nil || phase != encryptionKeyPromoted || !reflect.DeepEqual(keys, []encryptionKey{key2, key1}) {
+		t.Fatalf("\t%s\texpect key2 to be promoted, but get phase %s, keys %v: %v", failed, phase, keys, err)
+	}
+	config, err := genEncryptionConfig(keys)
+	if err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	// the secretbox key encrypts, the aesgcm key and the identity provider
+	// only decrypt.
+	secretbox, aesgcm, identity := strings.Index(string(config), "secretbox:"),
+		strings.Index(string(config), "aesgcm:"), strings.Index(string(config), "identity:")
+	if secretbox < 0 || aesgcm < secretbox || identity < aesgcm {
+		t.Fatalf("\t%s\texpect the providers secretbox, aesgcm and identity in order, but get\n%s", failed, config)
+	}
+	parsed, err := parseEncryptionKeys(config)
+	if err != nil || !reflect.DeepEqual(parsed, keys) {
+		t.Fatalf("\t%s\texpect keys %v, but get %v: %v", failed, keys, parsed, err)
+	}
+
+	keys, phase, err = rotateEncryptionKeys(keys, phase, secretboxProvider)
+	if err != nil || phase != "" || !reflect.DeepEqual(keys, []encryptionKey{key2}) {
+		t.Fatalf("\t%s\texpect key1 to be removed, but get phase %s, keys %v: %v", failed, phase, keys, err)
+	}
+	t.Logf("\t%s\tthe encryption key is rotated", succeed)
+}
+
+func TestReencryptSecrets(t *testing.T) {
+	cli := fake.NewClientBuilder().WithObjects(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "default", ResourceVersion: "1"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "s2", Namespace: "kube-system", ResourceVersion: "1"}},
+	).Build()
+	if err := reencryptSecrets(context.TODO(), cli); err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	var secrets corev1.SecretList
+	if err := cli.List(context.TODO(), &secrets); err != nil {
+		t.Fatalf("\t%s\tunexpected error: %v", failed, err)
+	}
+	for _, s := range secrets.Items {
+		if s.GetResourceVersion() == "1" {
+			t.Fatalf("\t%s\texpect secret %s/%s to be rewritten", failed, s.GetNamespace(), s.GetName())
+		}
+	}
+	t.Logf("\t%s\tthe secrets are rewritten", succeed)
+}
@@ -17,16 +17,17 @@ limitations under the License.
 package kubeadm
 
 const (
-	// KubeadmExecPath denotes the path to the kubeadm executable.
-	KubeadmExecPath = "/kubeadm"
-	// KASManifestsPath denotes the Path to the apiserver static pod manifest.
-	KASManifestsPath = "/etc/kubernetes/manifests/kube-apiserver.yaml"
-	// KCMManifestsPath denotes the Path to the controller-manager static pod manifest.
-	KCMManifestsPath = "/etc/kubernetes/manifests/kube-controller-manager.yaml"
-	// EtcdManifestsPath denotes the Path to the etcd static pod manifest.
-	EtcdManifestsPath = "/etc/kubernetes/manifests/etcd.yaml"
-	// DefaultKubeadmConfigPath denotes the Path to the default kubeadm config.
-	DefaultKubeadmConfigPath = "/kubeadm.config"
+	// DefaultImageRepository denotes the default repository of the control
+	// plane images.
+	DefaultImageRepository = "k8s.gcr.io"
+	// DefaultKubernetesVersion denotes the default version of the apiserver
+	// and the controller-manager.
+	DefaultKubernetesVersion = "v1.21.1"
+	// MinimumKubernetesVersion denotes the minimum supported version of the
+	// apiserver and the controller-manager.
+	MinimumKubernetesVersion = "v1.19.0"
+	// StableChannel denotes the channel that resolves to the default versions.
+	StableChannel = "stable"
 	// ManifestsConfigmapSuffix is the name of the configmap that will store the
 	// manifests of the nested components' manifests.
 	ManifestsConfigmapSuffix = "ncp-manifests"
@@ -38,11 +39,14 @@ const (
 	Etcd = "etcd"
 )
 
-var (
-	// KASSubcommand is the command that generates the apiserver manifest.
-	KASSubcommand = []string{"init", "phase", "control-plane", "apiserver", "--config", DefaultKubeadmConfigPath}
-	// KCMSubcommand is the command that generates the controller-manager manifest.
-	KCMSubcommand = []string{"init", "phase", "control-plane", "controller-manager", "--config", DefaultKubeadmConfigPath}
-	// EtcdSubcommand is the command that generates the etcd manifest.
-	EtcdSubcommand = []string{"init", "phase", "etcd", "local", "--config", DefaultKubeadmConfigPath}
-)
+// supportedEtcdVersion maps the minor version of kubernetes to the version of
+// etcd that kubeadm deploys with it.
+var supportedEtcdVersion = map[uint]string{
+	19: "3.4.13-0",
+	20: "3.4.13-0",
+	21: "3.4.13-0",
+	22: "3.5.0-0",
+	23: "3.5.1-0",
+	24: "3.5.3-0",
+	25: "3.5.4-0",
+}
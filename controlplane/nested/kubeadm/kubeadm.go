@@ -15,186 +15,323 @@ limitations under the License.
 */
 
 // Package kubeadm contains functions that used to generate pod manifests
-// of the nested control-plane using the kubeadm.
+// of the nested control-plane the same way as the
+// `kubeadm init phase control-plane/etcd` does.
 package kubeadm
 
 import (
-	"bytes"
-	"io/ioutil"
-	"os"
-	"os/exec"
-	"path/filepath"
+	"fmt"
+	"sort"
+	"strings"
 
-	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
-	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/version"
 )
 
-// DefaultKubeadmConfig denotes the content of the default kubeadm config.
-const DefaultKubeadmConfig = `
-apiVersion: kubeadm.k8s.io/v1beta3
-certificatesDir: /etc/kubernetes/pki
-clusterName: kubernetes
-imageRepository: k8s.gcr.io
-kind: ClusterConfiguration
-kubernetesVersion: 1.21.1
-apiServer:
-  timeoutForControlPlane: 4m0s
-  extraArgs:
-    advertise-address:                0.0.0.0
-    client-ca-file:                   /etc/kubernetes/pki/apiserver/ca/tls.crt
-    tls-cert-file:                    /etc/kubernetes/pki/apiserver/tls.crt
-    tls-private-key-file:             /etc/kubernetes/pki/apiserver/tls.key
-    kubelet-certificate-authority:    /etc/kubernetes/pki/apiserver/ca/tls.crt
-    kubelet-client-certificate:       /etc/kubernetes/pki/kubelet/tls.crt
-    kubelet-client-key:               /etc/kubernetes/pki/kubelet/tls.key
-    etcd-cafile:                      /etc/kubernetes/pki/etcd/ca/tls.crt
-    etcd-certfile:                    /etc/kubernetes/pki/etcd/tls.crt
-    etcd-keyfile:                     /etc/kubernetes/pki/etcd/tls.key
-    service-account-key-file:         /etc/kubernetes/pki/service-account/tls.key
-    service-account-signing-key-file: /etc/kubernetes/pki/service-account/tls.key
-    proxy-client-cert-file:           /etc/kubernetes/pki/proxy/tls.crt
-    proxy-client-key-file:            /etc/kubernetes/pki/proxy/tls.key
-    requestheader-client-ca-file:     /etc/kubernetes/pki/proxy/ca/tls.crt
-
-controllerManager:
-  extraArgs:
-    bind-address:                     0.0.0.0
-    cluster-signing-cert-file:        /etc/kubernetes/pki/root/tls.crt
-    cluster-signing-key-file:         /etc/kubernetes/pki/root/tls.key
-    kubeconfig:                       /etc/kubernetes/kubeconfig/controller-manager-kubeconfig
-    authorization-kubeconfig:         /etc/kubernetes/kubeconfig/controller-manager-kubeconfig
-    authentication-kubeconfig:        /etc/kubernetes/kubeconfig/controller-manager-kubeconfig
-    leader-elect:                     "false"
-    requestheader-client-ca-file:     /etc/kubernetes/pki/proxy/ca/tls.crt
-    client-ca-file:                   ""
-    root-ca-file:                     /etc/kubernetes/pki/root/ca/tls.crt
-    service-account-private-key-file: /etc/kubernetes/pki/service-account/tls.key
-    controllers:                      "*,-nodelifecycle,bootstrapsigner,tokencleaner"
-       
-etcd:
-  local:
-    dataDir: /var/lib/etcd
-    extraArgs: 
-      trusted-ca-file:             /etc/kubernetes/pki/ca/tls.crt
-      client-cert-auth:            "true"
-      cert-file:                   /etc/kubernetes/pki/etcd/tls.crt
-      key-file:                    /etc/kubernetes/pki/etcd/tls.key
-      peer-client-cert-auth:       "true"
-      peer-trusted-ca-file:        /etc/kubernetes/pki/ca/tls.crt
-      peer-cert-file:              /etc/kubernetes/pki/etcd/tls.crt
-      peer-key-file:               /etc/kubernetes/pki/etcd/tls.key
-      listen-peer-urls:            https://0.0.0.0:2380
-      listen-client-urls:          https://0.0.0.0:2379
-      name:                        $(HOSTNAME)
-      data-dir:                    /var/lib/etcd/data`
-
-func execCommand(log logr.Logger, command string, subcommand ...string) error {
-	cmd := exec.Command(command, subcommand...)
-	var (
-		out    bytes.Buffer
-		stderr bytes.Buffer
-	)
-	cmd.Stdout = &out
-	cmd.Stderr = &stderr
-	if err := cmd.Run(); err != nil {
-		log.Error(err, "fail to run kubeadm", "stderr", stderr.String())
-		return err
-	}
-	log.Info("successfully execute the kubeadm command", "stdout", out.String())
-	return nil
+// Options defines the versions and the image repository of the generated
+// manifests.
+type Options struct {
+	// ClusterName is the name of the Cluster the manifests are generated for.
+	ClusterName string
+	// ImageRepository is the repository the images are pulled from,
+	// DefaultImageRepository is used if not set.
+	ImageRepository string
+	// KubernetesVersion is the version of the apiserver and the
+	// controller-manager, DefaultKubernetesVersion is used if not set.
+	KubernetesVersion string
+	// APIServerVersion overrides the KubernetesVersion for the apiserver.
+	APIServerVersion string
+	// ControllerManagerVersion overrides the KubernetesVersion for the
+	// controller-manager.
+	ControllerManagerVersion string
+	// EtcdVersion is the version of the etcd, the version kubeadm deploys
+	// with the KubernetesVersion is used if not set.
+	EtcdVersion string
+}
+
+// apiServerArgs are the command line flags of the apiserver, which override
+// the kubeadm defaults.
+var apiServerArgs = map[string]string{
+	"advertise-address":                "0.0.0.0",
+	"client-ca-file":                   "/etc/kubernetes/pki/apiserver/ca/tls.crt",
+	"tls-cert-file":                    "/etc/kubernetes/pki/apiserver/tls.crt",
+	"tls-private-key-file":             "/etc/kubernetes/pki/apiserver/tls.key",
+	"kubelet-certificate-authority":    "/etc/kubernetes/pki/apiserver/ca/tls.crt",
+	"kubelet-client-certificate":       "/etc/kubernetes/pki/kubelet/tls.crt",
+	"kubelet-client-key":               "/etc/kubernetes/pki/kubelet/tls.key",
+	"etcd-cafile":                      "/etc/kubernetes/pki/etcd/ca/tls.crt",
+	"etcd-certfile":                    "/etc/kubernetes/pki/etcd/tls.crt",
+	"etcd-keyfile":                     "/etc/kubernetes/pki/etcd/tls.key",
+	"service-account-key-file":         "/etc/kubernetes/pki/service-account/tls.key",
+	"service-account-signing-key-file": "/etc/kubernetes/pki/service-account/tls.key",
+	"proxy-client-cert-file":           "/etc/kubernetes/pki/proxy/tls.crt",
+	"proxy-client-key-file":            "/etc/kubernetes/pki/proxy/tls.key",
+	"requestheader-client-ca-file":     "/etc/kubernetes/pki/proxy/ca/tls.crt",
+}
+
+// controllerManagerArgs are the command line flags of the controller-manager,
+// which override the kubeadm defaults.
+var controllerManagerArgs = map[string]string{
+	"bind-address":                     "0.0.0.0",
+	"cluster-signing-cert-file":        "/etc/kubernetes/pki/root/tls.crt",
+	"cluster-signing-key-file":         "/etc/kubernetes/pki/root/tls.key",
+	"kubeconfig":                       "/etc/kubernetes/kubeconfig/controller-manager-kubeconfig",
+	"authorization-kubeconfig":         "/etc/kubernetes/kubeconfig/controller-manager-kubeconfig",
+	"authentication-kubeconfig":        "/etc/kubernetes/kubeconfig/controller-manager-kubeconfig",
+	"leader-elect":                     "false",
+	"requestheader-client-ca-file":     "/etc/kubernetes/pki/proxy/ca/tls.crt",
+	"client-ca-file":                   "",
+	"root-ca-file":                     "/etc/kubernetes/pki/root/ca/tls.crt",
+	"service-account-private-key-file": "/etc/kubernetes/pki/service-account/tls.key",
+	"controllers":                      "*,-nodelifecycle,bootstrapsigner,tokencleaner",
+}
+
+// etcdArgs are the command line flags of the etcd, which override the
+// kubeadm defaults.
+var etcdArgs = map[string]string{
+	"trusted-ca-file":       "/etc/kubernetes/pki/ca/tls.crt",
+	"client-cert-auth":      "true",
+	"cert-file":             "/etc/kubernetes/pki/etcd/tls.crt",
+	"key-file":              "/etc/kubernetes/pki/etcd/tls.key",
+	"peer-client-cert-auth": "true",
+	"peer-trusted-ca-file":  "/etc/kubernetes/pki/ca/tls.crt",
+	"peer-cert-file":        "/etc/kubernetes/pki/etcd/tls.crt",
+	"peer-key-file":         "/etc/kubernetes/pki/etcd/tls.key",
+	"listen-peer-urls":      "https://0.0.0.0:2380",
+	"listen-client-urls":    "https://0.0.0.0:2379",
+	"name":                  "$(HOSTNAME)",
+	"data-dir":              "/var/lib/etcd/data",
 }
 
 // GenerateTemplates generates the manifests for the nested apiserver,
-// controller-manager and etcd by calling the `kubeadm init phase control-plane/etcd`.
-func GenerateTemplates(log logr.Logger, clusterName string) (map[string]string, error) {
-	// create the cluster manifests directory if not exist
-	if err := os.MkdirAll("/"+clusterName, 0755); err != nil {
-		return nil, errors.Wrap(err, "fail to create the cluster manifests directory")
-	}
-	// defer os.RemoveAll("/" + clusterName)
-	log.Info("cluster manifests directory is created")
-	if err := generateKubeadmConfig(clusterName); err != nil {
-		return nil, err
+// controller-manager and etcd.
+func GenerateTemplates(opts Options) (map[string]corev1.Pod, error) {
+	imageRepository := opts.ImageRepository
+	if imageRepository == "" {
+		imageRepository = DefaultImageRepository
+	}
+	kubernetesVersion := opts.KubernetesVersion
+	if kubernetesVersion == "" {
+		kubernetesVersion = DefaultKubernetesVersion
+	}
+	kasVersion, err := parseKubernetesVersion(kubernetesVersion, opts.APIServerVersion)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid apiserver version")
+	}
+	kcmVersion, err := parseKubernetesVersion(kubernetesVersion, opts.ControllerManagerVersion)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid controller-manager version")
+	}
+	etcdVersion := opts.EtcdVersion
+	if etcdVersion == "" {
+		etcdVersion = GetEtcdVersion(kasVersion)
 	}
-	log.Info("kubeadmconfig is generated")
 
-	// store manifests of different nested cluster in different directory
-	KASSubcommand = append(KASSubcommand, "--rootfs", "/"+clusterName)
-	KCMSubcommand = append(KCMSubcommand, "--rootfs", "/"+clusterName)
-	EtcdSubcommand = append(EtcdSubcommand, "--rootfs", "/"+clusterName)
-	// generate the manifests
-	if err := execCommand(log, KubeadmExecPath, KASSubcommand...); err != nil {
-		return nil, errors.Wrap(err, "fail to generate the apiserver manifests")
+	return map[string]corev1.Pod{
+		APIServer:         genAPIServerPod(opts.ClusterName, imageRepository, kasVersion),
+		ControllerManager: genControllerManagerPod(imageRepository, kcmVersion),
+		Etcd:              genEtcdPod(opts.ClusterName, imageRepository, etcdVersion),
+	}, nil
+}
+
+// ParseKubernetesVersion parses the version of the kubernetes components, it
+// must be a semantic version no lower than the MinimumKubernetesVersion.
+func ParseKubernetesVersion(kubernetesVersion string) (*version.Version, error) {
+	return parseKubernetesVersion(kubernetesVersion, "")
+}
+
+// parseKubernetesVersion parses the component version, or the
+// kubernetesVersion if the component version is not set.
+func parseKubernetesVersion(kubernetesVersion, componentVersion string) (*version.Version, error) {
+	if componentVersion != "" {
+		kubernetesVersion = componentVersion
 	}
-	if err := execCommand(log, KubeadmExecPath, KCMSubcommand...); err != nil {
-		return nil, errors.Wrap(err, "fail to generate the controller-manager manifests")
+	v, err := version.ParseSemantic(strings.TrimPrefix(kubernetesVersion, "v"))
+	if err != nil {
+		return nil, err
 	}
-	if err := execCommand(log, KubeadmExecPath, EtcdSubcommand...); err != nil {
-		return nil, errors.Wrap(err, "fail to generate the etcd manifests")
+	if v.LessThan(version.MustParseSemantic(MinimumKubernetesVersion)) {
+		return nil, errors.Errorf("version %s is lower than the minimum supported version %s",
+			kubernetesVersion, MinimumKubernetesVersion)
 	}
-	log.Info("static pod manifests generated")
+	return v, nil
+}
 
-	var loadErr error
-	kasPath := filepath.Join("/", clusterName, KASManifestsPath)
-	KASManifests, loadErr := ioutil.ReadFile(filepath.Clean(kasPath))
-	if loadErr != nil {
-		return nil, errors.Wrap(loadErr, "fail to load the apiserver manifests")
+// GetEtcdVersion returns the version of the etcd that kubeadm deploys with the
+// given version of kubernetes.
+func GetEtcdVersion(kubernetesVersion *version.Version) string {
+	if etcdVersion, ok := supportedEtcdVersion[kubernetesVersion.Minor()]; ok {
+		return etcdVersion
 	}
-	kcmPath := filepath.Join("/", clusterName, KCMManifestsPath)
-	KCMManifests, loadErr := ioutil.ReadFile(filepath.Clean(kcmPath))
-	if loadErr != nil {
-		return nil, errors.Wrap(loadErr, "fail to load the controller-manager manifests")
+	var latest uint
+	for minor := range supportedEtcdVersion {
+		if minor > latest {
+			latest = minor
+		}
 	}
-	etcdPath := filepath.Join("/", clusterName, EtcdManifestsPath)
-	EtcdManifests, loadErr := ioutil.ReadFile(filepath.Clean(etcdPath))
-	if loadErr != nil {
-		return nil, errors.Wrap(loadErr, "fail to load the etcd manifests")
+	return supportedEtcdVersion[latest]
+}
+
+// kubernetesImage returns the image of the kubernetes component.
+func kubernetesImage(imageRepository, component string, v *version.Version) string {
+	return fmt.Sprintf("%s/%s:v%s", imageRepository, component, v.String())
+}
+
+// buildCommand builds the command line from the kubeadm defaults overridden by
+// args, the flags are sorted by name as kubeadm does.
+func buildCommand(command string, defaults, args map[string]string) []string {
+	merged := make(map[string]string, len(defaults)+len(args))
+	for k, v := range defaults {
+		merged[k] = v
 	}
+	for k, v := range args {
+		merged[k] = v
+	}
+	keys := make([]string, 0, len(merged))
+	for k := range merged {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
 
-	return map[string]string{
-		APIServer:         string(KASManifests),
-		ControllerManager: string(KCMManifests),
-		Etcd:              string(EtcdManifests),
-	}, nil
+	ret := []string{command}
+	for _, k := range keys {
+		ret = append(ret, fmt.Sprintf("--%s=%s", k, merged[k]))
+	}
+	return ret
 }
 
-// generateKubeadmConfig writes the DefaultKubeadmConfig to the DefaultKubeadmConfigPath,
-// which will be read by the `kubeadm init` command.
-func generateKubeadmConfig(clusterName string) error {
-	completedKubeadmConfig, err := completeDefaultKubeadmConfig(clusterName)
-	if err != nil {
-		return err
+func httpProbe(host, path string, port int, scheme corev1.URIScheme,
+	initialDelaySeconds, timeoutSeconds, failureThreshold, periodSeconds int32) *corev1.Probe {
+	return &corev1.Probe{
+		Handler: corev1.Handler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Host:   host,
+				Path:   path,
+				Port:   intstr.FromInt(port),
+				Scheme: scheme,
+			},
+		},
+		InitialDelaySeconds: initialDelaySeconds,
+		TimeoutSeconds:      timeoutSeconds,
+		FailureThreshold:    failureThreshold,
+		PeriodSeconds:       periodSeconds,
+	}
+}
+
+// staticPod generates the pod the same way as the kubeadm generates the
+// static pods of the control plane.
+func staticPod(component string, container corev1.Container) corev1.Pod {
+	container.Name = component
+	container.ImagePullPolicy = corev1.PullIfNotPresent
+	return corev1.Pod{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Pod",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      component,
+			Namespace: metav1.NamespaceSystem,
+			Labels: map[string]string{
+				"component": component,
+				"tier":      "control-plane",
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers:        []corev1.Container{container},
+			PriorityClassName: "system-node-critical",
+			HostNetwork:       true,
+		},
 	}
-	return os.WriteFile("/"+clusterName+DefaultKubeadmConfigPath, []byte(completedKubeadmConfig), 0600)
 }
 
-func completeDefaultKubeadmConfig(clusterName string) (string, error) {
-	config := make(map[string]interface{})
-	if err := yaml.Unmarshal([]byte(DefaultKubeadmConfig), &config); err != nil {
-		return "", err
+func genAPIServerPod(clusterName, imageRepository string, v *version.Version) corev1.Pod {
+	defaults := map[string]string{
+		"allow-privileged":                   "true",
+		"authorization-mode":                 "Node,RBAC",
+		"enable-admission-plugins":           "NodeRestriction",
+		"enable-bootstrap-token-auth":        "true",
+		"kubelet-preferred-address-types":    "InternalIP,ExternalIP,Hostname",
+		"requestheader-allowed-names":        "front-proxy-client",
+		"requestheader-extra-headers-prefix": "X-Remote-Extra-",
+		"requestheader-group-headers":        "X-Remote-Group",
+		"requestheader-username-headers":     "X-Remote-User",
+		"secure-port":                        "6443",
+		"service-account-issuer":             "https://kubernetes.default.svc.cluster.local",
+		"service-cluster-ip-range":           "10.96.0.0/12",
 	}
-	kasConfig := config["apiServer"].(map[interface{}]interface{})
-	kasExtraConfig, ok := kasConfig["extraArgs"].(map[interface{}]interface{})
-	if !ok {
-		return "", errors.Errorf("fail to assert apiServer.extraArgs to map")
+	args := map[string]string{
+		"etcd-servers": "https://" + clusterName + "-etcd-0." + clusterName + "-etcd.$(NAMESPACE):2379",
 	}
-	kasExtraConfig["etcd-servers"] = "https://" + clusterName + "-etcd-0." + clusterName + "-etcd.$(NAMESPACE):2379"
+	for k, val := range apiServerArgs {
+		args[k] = val
+	}
+
+	return staticPod("kube-apiserver", corev1.Container{
+		Image:          kubernetesImage(imageRepository, "kube-apiserver", v),
+		Command:        buildCommand("kube-apiserver", defaults, args),
+		LivenessProbe:  httpProbe("0.0.0.0", "/livez", 6443, corev1.URISchemeHTTPS, 10, 15, 8, 10),
+		ReadinessProbe: httpProbe("0.0.0.0", "/readyz", 6443, corev1.URISchemeHTTPS, 0, 15, 3, 1),
+		StartupProbe:   httpProbe("0.0.0.0", "/livez", 6443, corev1.URISchemeHTTPS, 10, 15, 24, 10),
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("250m"),
+			},
+		},
+	})
+}
 
-	etcdConfig := config["etcd"].(map[interface{}]interface{})
-	etcdLocalConfig, ok := etcdConfig["local"].(map[interface{}]interface{})
-	if !ok {
-		return "", errors.Errorf("fail to assert etcd.local to map")
+func genControllerManagerPod(imageRepository string, v *version.Version) corev1.Pod {
+	defaults := map[string]string{
+		"bind-address":                    "127.0.0.1",
+		"cluster-name":                    "kubernetes",
+		"leader-elect":                    "true",
+		"use-service-account-credentials": "true",
 	}
-	etcdExtraConfig, ok := etcdLocalConfig["extraArgs"].(map[interface{}]interface{})
-	if !ok {
-		return "", errors.Errorf("fail to assert etcd.local.extraArgs to map")
+	if v.LessThan(version.MustParseSemantic("1.22.0")) {
+		// the insecure port is disabled by default since v1.22.
+		defaults["port"] = "0"
 	}
-	etcdExtraConfig["initial-advertise-peer-urls"] = "https://$(HOSTNAME)." + clusterName + "-etcd.$(NAMESPACE).svc:2380"
-	etcdExtraConfig["advertise-client-urls"] = "https://$(HOSTNAME)." + clusterName + "-etcd.$(NAMESPACE).svc:2379"
-	completedConfigYaml, err := yaml.Marshal(config)
-	if err != nil {
-		return "", err
+
+	return staticPod("kube-controller-manager", corev1.Container{
+		Image:         kubernetesImage(imageRepository, "kube-controller-manager", v),
+		Command:       buildCommand("kube-controller-manager", defaults, controllerManagerArgs),
+		LivenessProbe: httpProbe("127.0.0.1", "/healthz", 10257, corev1.URISchemeHTTPS, 10, 15, 8, 10),
+		StartupProbe:  httpProbe("127.0.0.1", "/healthz", 10257, corev1.URISchemeHTTPS, 10, 15, 24, 10),
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("200m"),
+			},
+		},
+	})
+}
+
+func genEtcdPod(clusterName, imageRepository, etcdVersion string) corev1.Pod {
+	defaults := map[string]string{
+		"listen-metrics-urls": "http://127.0.0.1:2381",
+		"snapshot-count":      "10000",
+	}
+	args := map[string]string{
+		"initial-advertise-peer-urls": "https://$(HOSTNAME)." + clusterName + "-etcd.$(NAMESPACE).svc:2380",
+		"advertise-client-urls":       "https://$(HOSTNAME)." + clusterName + "-etcd.$(NAMESPACE).svc:2379",
 	}
-	return string(completedConfigYaml), nil
+	for k, val := range etcdArgs {
+		args[k] = val
+	}
+
+	return staticPod("etcd", corev1.Container{
+		Image:         fmt.Sprintf("%s/etcd:%s", imageRepository, etcdVersion),
+		Command:       buildCommand("etcd", defaults, args),
+		LivenessProbe: httpProbe("127.0.0.1", "/health", 2381, corev1.URISchemeHTTP, 10, 15, 8, 10),
+		StartupProbe:  httpProbe("127.0.0.1", "/health", 2381, corev1.URISchemeHTTP, 10, 15, 24, 10),
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("100m"),
+				corev1.ResourceMemory: resource.MustParse("100Mi"),
+			},
+		},
+	})
 }
@@ -0,0 +1,112 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeadm
+
+import (
+	"testing"
+)
+
+func TestGenerateTemplates(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        Options
+		expectErr   bool
+		expectImage map[string]string
+	}{
+		{
+			name: "default versions",
+			opts: Options{ClusterName: "test"},
+			expectImage: map[string]string{
+				APIServer:         "k8s.gcr.io/kube-apiserver:v1.21.1",
+				ControllerManager: "k8s.gcr.io/kube-controller-manager:v1.21.1",
+				Etcd:              "k8s.gcr.io/etcd:3.4.13-0",
+			},
+		},
+		{
+			name: "kubernetes version and image repository",
+			opts: Options{ClusterName: "test", ImageRepository: "registry.example.com", KubernetesVersion: "1.22.2"},
+			expectImage: map[string]string{
+				APIServer:         "registry.example.com/kube-apiserver:v1.22.2",
+				ControllerManager: "registry.example.com/kube-controller-manager:v1.22.2",
+				Etcd:              "registry.example.com/etcd:3.5.0-0",
+			},
+		},
+		{
+			name: "component versions",
+			opts: Options{
+				ClusterName:              "test",
+				KubernetesVersion:        "v1.21.1",
+				ControllerManagerVersion: "v1.20.4",
+				EtcdVersion:              "3.4.9-1",
+			},
+			expectImage: map[string]string{
+				APIServer:         "k8s.gcr.io/kube-apiserver:v1.21.1",
+				ControllerManager: "k8s.gcr.io/kube-controller-manager:v1.20.4",
+				Etcd:              "k8s.gcr.io/etcd:3.4.9-1",
+			},
+		},
+		{
+			name:      "invalid version",
+			opts:      Options{ClusterName: "test", KubernetesVersion: "latest"},
+			expectErr: true,
+		},
+		{
+			name:      "unsupported version",
+			opts:      Options{ClusterName: "test", APIServerVersion: "v1.18.0"},
+			expectErr: true,
+		},
+	}
+	for _, tt := range tests {
+		st := tt
+		t.Run(st.name, func(t *testing.T) {
+			templates, err := GenerateTemplates(st.opts)
+			if st.expectErr {
+				if err == nil {
+					t.Fatalf("expect error, but get nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for component, image := range st.expectImage {
+				pod, ok := templates[component]
+				if !ok {
+					t.Fatalf("template of %s is not generated", component)
+				}
+				if got := pod.Spec.Containers[0].Image; got != image {
+					t.Errorf("expect image of %s to be %s, but get %s", component, image, got)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildCommand(t *testing.T) {
+	got := buildCommand("etcd",
+		map[string]string{"snapshot-count": "10000", "data-dir": "/var/lib/etcd"},
+		map[string]string{"data-dir": "/var/lib/etcd/data", "name": "$(HOSTNAME)"})
+	expect := []string{"etcd", "--data-dir=/var/lib/etcd/data", "--name=$(HOSTNAME)", "--snapshot-count=10000"}
+	if len(got) != len(expect) {
+		t.Fatalf("expect %v, but get %v", expect, got)
+	}
+	for i := range expect {
+		if got[i] != expect[i] {
+			t.Fatalf("expect %v, but get %v", expect, got)
+		}
+	}
+}
@@ -88,7 +88,7 @@ func InitFlags(fs *pflag.FlagSet) {
 		"The minimum interval at which watched resources are reconciled (e.g. 15m)")
 
 	fs.IntVar(&webhookPort, "webhook-port", 0,
-		"Webhook Server port, disabled by default. When enabled, the manager will only work as webhook server, no reconcilers are installed.")
+		"Webhook Server port, disabled by default. When enabled, the manager serves the validating webhooks along with the reconcilers.")
 
 	fs.StringVar(&healthAddr, "health-addr", ":9440",
 		"The address the health endpoint binds to.")
@@ -177,6 +177,25 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "NestedControllerManager")
 		os.Exit(1)
 	}
+
+	if webhookPort != 0 {
+		if err := (&controlplanev1alpha4.NestedEtcd{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "NestedEtcd")
+			os.Exit(1)
+		}
+		if err := (&controlplanev1alpha4.NestedAPIServer{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "NestedAPIServer")
+			os.Exit(1)
+		}
+		if err := (&controlplanev1alpha4.NestedControllerManager{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "NestedControllerManager")
+			os.Exit(1)
+		}
+		if err := (&controlplanev1alpha4.NestedControlPlane{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "NestedControlPlane")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("Starting manager", "version", version.Get().String())
@@ -0,0 +1,192 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package patch applies the patches specified in the PatchSpec of the
+// NestedComponents to the generated StatefulSets and Services.
+//
+// Each patch is an object with the apiVersion and kind, and optionally the
+// metadata.name, of the generated object it applies to. The object is applied
+// as a strategic merge patch, unless it has the jsonPatches field, which holds
+// a list of RFC 6902 JSON patch operations, e.g.
+//
+//	patches:
+//	- apiVersion: apps/v1
+//	  kind: StatefulSet
+//	  spec:
+//	    template:
+//	      spec:
+//	        tolerations:
+//	        - operator: Exists
+//	- apiVersion: apps/v1
+//	  kind: StatefulSet
+//	  jsonPatches:
+//	  - op: add
+//	    path: /spec/template/spec/containers/0/command/-
+//	    value: --v=4
+package patch
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// JSONPatchesField is the field of the patch that holds the JSON patch operations.
+const JSONPatchesField = "jsonPatches"
+
+var (
+	// StatefulSetKind is the kind of the patches applied to the StatefulSet.
+	StatefulSetKind = appsv1.SchemeGroupVersion.WithKind("StatefulSet")
+	// ServiceKind is the kind of the patches applied to the Service.
+	ServiceKind = corev1.SchemeGroupVersion.WithKind("Service")
+
+	// supportedKinds maps the kinds that can be patched to their types.
+	supportedKinds = map[schema.GroupVersionKind]runtime.Object{
+		StatefulSetKind: &appsv1.StatefulSet{},
+		ServiceKind:     &corev1.Service{},
+	}
+)
+
+// Patch is a parsed patch.
+type Patch struct {
+	// GroupVersionKind is the kind of the object the patch applies to.
+	GroupVersionKind schema.GroupVersionKind
+	// Name is the name of the object the patch applies to, the patch applies
+	// to any object of the kind if it is empty.
+	Name string
+	// JSONPatch is the JSON patch, if the patch is a JSON patch.
+	JSONPatch jsonpatch.Patch
+	// StrategicMergePatch is the strategic merge patch, if the patch is not a
+	// JSON patch.
+	StrategicMergePatch []byte
+}
+
+// Parse parses the patch and checks it applies to a supported kind.
+func Parse(raw *runtime.RawExtension) (*Patch, error) {
+	if raw == nil || len(raw.Raw) == 0 {
+		return nil, errors.New("patch is empty")
+	}
+	obj := map[string]interface{}{}
+	if err := json.Unmarshal(raw.Raw, &obj); err != nil {
+		return nil, errors.Wrap(err, "patch is not an object")
+	}
+
+	apiVersion, _ := obj["apiVersion"].(string)
+	kind, _ := obj["kind"].(string)
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid apiVersion %q", apiVersion)
+	}
+	p := &Patch{GroupVersionKind: gv.WithKind(kind)}
+	if _, ok := supportedKinds[p.GroupVersionKind]; !ok {
+		return nil, errors.Errorf("unsupported kind %s, only %s and %s can be patched",
+			p.GroupVersionKind, StatefulSetKind, ServiceKind)
+	}
+	if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+		p.Name, _ = metadata["name"].(string)
+	}
+
+	ops, ok := obj[JSONPatchesField]
+	if !ok {
+		p.StrategicMergePatch = raw.Raw
+		// make sure the patch matches the schema of the kind.
+		dataStruct := supportedKinds[p.GroupVersionKind]
+		data, err := strategicpatch.StrategicMergePatch([]byte("{}"), p.StrategicMergePatch, dataStruct)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid strategic merge patch")
+		}
+		if err := json.Unmarshal(data, dataStruct.DeepCopyObject()); err != nil {
+			return nil, errors.Wrap(err, "invalid strategic merge patch")
+		}
+		return p, nil
+	}
+	for field := range obj {
+		if field != "apiVersion" && field != "kind" && field != "metadata" && field != JSONPatchesField {
+			return nil, errors.Errorf("field %s can not be used along with %s", field, JSONPatchesField)
+		}
+	}
+	data, err := json.Marshal(ops)
+	if err != nil {
+		return nil, err
+	}
+	if p.JSONPatch, err = jsonpatch.DecodePatch(data); err != nil {
+		return nil, errors.Wrap(err, "invalid json patch")
+	}
+	for _, op := range p.JSONPatch {
+		if _, err := op.Path(); err != nil {
+			return nil, errors.Wrap(err, "invalid json patch")
+		}
+	}
+	return p, nil
+}
+
+// Apply applies the patches of the kind gvk and the given name to obj, which
+// must be a pointer to the type of the kind.
+func Apply(obj runtime.Object, gvk schema.GroupVersionKind, name string, patches []*runtime.RawExtension) error {
+	if len(patches) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	for i, raw := range patches {
+		p, err := Parse(raw)
+		if err != nil {
+			return errors.Wrapf(err, "invalid patch %d", i)
+		}
+		if p.GroupVersionKind != gvk || (p.Name != "" && p.Name != name) {
+			continue
+		}
+		if p.JSONPatch != nil {
+			data, err = p.JSONPatch.Apply(data)
+		} else {
+			data, err = strategicpatch.StrategicMergePatch(data, p.StrategicMergePatch, obj)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "fail to apply patch %d to %s %s", i, gvk.Kind, name)
+		}
+	}
+
+	// reset obj, so that the fields removed by the patches are removed.
+	v := reflect.ValueOf(obj).Elem()
+	v.Set(reflect.Zero(v.Type()))
+	return json.Unmarshal(data, obj)
+}
+
+// Hash returns the hash of the patches, or an empty string if there is no patch.
+func Hash(patches []*runtime.RawExtension) string {
+	if len(patches) == 0 {
+		return ""
+	}
+	h := sha256.New()
+	for _, raw := range patches {
+		if raw != nil {
+			h.Write(raw.Raw)
+		}
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))[:16]
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package patch
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func raw(s string) *runtime.RawExtension {
+	return &runtime.RawExtension{Raw: []byte(s)}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		patch     *runtime.RawExtension
+		expectErr bool
+	}{
+		{"strategic merge patch", raw(`{"apiVersion":"apps/v1","kind":"StatefulSet","spec":{"replicas":3}}`), false},
+		{"json patch", raw(`{"apiVersion":"v1","kind":"Service","jsonPatches":[{"op":"add","path":"/spec/type","value":"NodePort"}]}`), false},
+		{"not an object", raw(`[]`), true},
+		{"unsupported kind", raw(`{"apiVersion":"v1","kind":"Pod"}`), true},
+		{"invalid strategic merge patch", raw(`{"apiVersion":"apps/v1","kind":"StatefulSet","spec":{"replicas":"three"}}`), true},
+		{"invalid json patch", raw(`{"apiVersion":"apps/v1","kind":"StatefulSet","jsonPatches":[{"op":"add"}]}`), true},
+		{"json patch mixed with fields", raw(`{"apiVersion":"apps/v1","kind":"StatefulSet","spec":{},"jsonPatches":[]}`), true},
+	}
+	for _, tt := range tests {
+		st := tt
+		t.Run(st.name, func(t *testing.T) {
+			_, err := Parse(st.patch)
+			if (err != nil) != st.expectErr {
+				t.Fatalf("expect error %v, but get %v", st.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	sts := &appsv1.StatefulSet{
+		Spec: appsv1.StatefulSetSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "etcd", Image: "etcd", Command: []string{"etcd"}}},
+				},
+			},
+		},
+	}
+	patches := []*runtime.RawExtension{
+		raw(`{"apiVersion":"apps/v1","kind":"StatefulSet","jsonPatches":[{"op":"add","path":"/spec/template/spec/containers/0/command/-","value":"--debug"}]}`),
+		raw(`{"apiVersion":"apps/v1","kind":"StatefulSet","spec":{"template":{"spec":{"containers":[{"name":"sidecar","image":"busybox"}]}}}}`),
+		raw(`{"apiVersion":"apps/v1","kind":"StatefulSet","metadata":{"name":"other"},"spec":{"replicas":3}}`),
+		raw(`{"apiVersion":"v1","kind":"Service","spec":{"type":"NodePort"}}`),
+	}
+	if err := Apply(sts, StatefulSetKind, "test-etcd", patches); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	containers := sts.Spec.Template.Spec.Containers
+	if len(containers) != 2 || containers[0].Name != "sidecar" || containers[1].Name != "etcd" {
+		t.Fatalf("expect the sidecar to be merged into the containers, but get %v", containers)
+	}
+	if len(containers[1].Command) != 2 || containers[1].Command[1] != "--debug" {
+		t.Fatalf("expect --debug to be appended to the command, but get %v", containers[1].Command)
+	}
+	if sts.Spec.Replicas != nil {
+		t.Fatalf("expect the patch of other StatefulSet to be skipped, but get replicas %d", *sts.Spec.Replicas)
+	}
+
+	if Hash(nil) != "" || Hash(patches) == Hash(patches[:1]) {
+		t.Fatalf("expect the hash to be empty without patches and to change with the patches")
+	}
+}
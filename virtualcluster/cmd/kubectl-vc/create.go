@@ -274,6 +274,9 @@ func replaceServerAddr(kubecfg clientcmd.ClientConfig, cli client.Client, cluste
 			return nil, err
 		}
 		newStr = fmt.Sprintf("https://%s:%d", externalIP, apiSvcPort)
+	default:
+		// the in-cluster address of a ClusterIP service is kept as is
+		return kubecfg, nil
 	}
 
 	rawConfig, err := kubecfg.RawConfig()
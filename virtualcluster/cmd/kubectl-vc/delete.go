@@ -0,0 +1,124 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+)
+
+const (
+	deleteExample = `
+	# Delete a virtualcluster
+	kubectl vc delete -n foo bar
+
+	# Delete a virtualcluster and wait until its control plane is removed
+	kubectl vc delete foo/bar --wait --timeout 10m`
+
+	pollPeriod = 2 * time.Second
+)
+
+type DeleteOption struct {
+	client    client.Client
+	vcclient  vcclient.Interface
+	namespace string
+	name      string
+	wait      bool
+	timeout   time.Duration
+}
+
+func NewCmdDelete(f Factory) *cobra.Command {
+	o := &DeleteOption{}
+
+	cmd := &cobra.Command{
+		Use:     "delete VC_NAME",
+		Short:   "Delete a virtualcluster",
+		Example: deleteExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(f, cmd, args))
+			CheckErr(o.Run())
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.namespace, "namespace", "n", metav1.NamespaceDefault, "If present, the namespace scope for this CLI request")
+	cmd.Flags().BoolVar(&o.wait, "wait", false, "If true, wait until the virtualcluster and its cluster namespace are removed")
+	cmd.Flags().DurationVar(&o.timeout, "timeout", 5*time.Minute, "The length of time to wait for the deletion, only used with --wait")
+
+	return cmd
+}
+
+func (o *DeleteOption) Complete(f Factory, cmd *cobra.Command, args []string) error {
+	var err error
+	o.vcclient, err = f.VirtualClusterClientSet()
+	if err != nil {
+		return err
+	}
+
+	o.client, err = f.GenericClient()
+	if err != nil {
+		return err
+	}
+
+	o.namespace, o.name, err = parseVCName(cmd, args, o.namespace)
+	return err
+}
+
+func (o *DeleteOption) Run() error {
+	vcs := o.vcclient.TenancyV1alpha1().VirtualClusters(o.namespace)
+	vc, err := vcs.Get(o.name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	clusterNS := clusterNamespace(vc)
+
+	propagation := metav1.DeletePropagationForeground
+	if err := vcs.Delete(o.name, &metav1.DeleteOptions{PropagationPolicy: &propagation}); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	log.Printf("VirtualCluster %s/%s deleted\n", o.namespace, o.name)
+	if !o.wait {
+		return nil
+	}
+
+	err = wait.PollImmediate(pollPeriod, o.timeout, func() (bool, error) {
+		if _, err := vcs.Get(o.name, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+			return false, client.IgnoreNotFound(err)
+		}
+		err := o.client.Get(context.TODO(), types.NamespacedName{Name: clusterNS}, &corev1.Namespace{})
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	})
+	if err != nil {
+		return fmt.Errorf("fail to wait for the deletion of virtualcluster %s/%s: %v", o.namespace, o.name, err)
+	}
+	log.Printf("the control plane of VirtualCluster %s/%s in namespace %s is removed\n", o.namespace, o.name, clusterNS)
+	return nil
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+const (
+	describeExample = `
+	# Describe a virtualcluster
+	kubectl vc describe -n foo bar
+
+	# Describe a virtualcluster served by a syncer deployed elsewhere
+	kubectl vc describe foo/bar --syncer-namespace syncer --syncer-name syncer`
+
+	defaultSyncerNamespace = "vc-manager"
+	defaultSyncerName      = "vc-syncer"
+)
+
+type DescribeOption struct {
+	client          client.Client
+	vcclient        vcclient.Interface
+	namespace       string
+	name            string
+	syncerNamespace string
+	syncerName      string
+}
+
+// virtualClusterDescription gathers what is shown by the describe command.
+type virtualClusterDescription struct {
+	vc *tenancyv1alpha1.VirtualCluster
+	// cv is nil if the ClusterVersion is not found.
+	cv *tenancyv1alpha1.ClusterVersion
+	// components are the control plane StatefulSets in the cluster namespace.
+	components []appsv1.StatefulSet
+	// syncer is nil if the syncer Deployment is not found.
+	syncer          *appsv1.Deployment
+	syncerNamespace string
+	syncerName      string
+}
+
+func NewCmdDescribe(f Factory) *cobra.Command {
+	o := &DescribeOption{}
+
+	cmd := &cobra.Command{
+		Use:     "describe VC_NAME",
+		Short:   "Show the details of a virtualcluster",
+		Example: describeExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(f, cmd, args))
+			CheckErr(o.Run())
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.namespace, "namespace", "n", metav1.NamespaceDefault, "If present, the namespace scope for this CLI request")
+	cmd.Flags().StringVar(&o.syncerNamespace, "syncer-namespace", defaultSyncerNamespace, "The namespace of the syncer Deployment")
+	cmd.Flags().StringVar(&o.syncerName, "syncer-name", defaultSyncerName, "The name of the syncer Deployment")
+
+	return cmd
+}
+
+func (o *DescribeOption) Complete(f Factory, cmd *cobra.Command, args []string) error {
+	var err error
+	o.vcclient, err = f.VirtualClusterClientSet()
+	if err != nil {
+		return err
+	}
+
+	o.client, err = f.GenericClient()
+	if err != nil {
+		return err
+	}
+
+	o.namespace, o.name, err = parseVCName(cmd, args, o.namespace)
+	return err
+}
+
+func (o *DescribeOption) Run() error {
+	vc, err := o.vcclient.TenancyV1alpha1().VirtualClusters(o.namespace).Get(o.name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	d := &virtualClusterDescription{
+		vc:              vc,
+		syncerNamespace: o.syncerNamespace,
+		syncerName:      o.syncerName,
+	}
+
+	d.cv, err = o.vcclient.TenancyV1alpha1().ClusterVersions().Get(vc.Spec.ClusterVersionName, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		d.cv = nil
+	}
+
+	stsList := &appsv1.StatefulSetList{}
+	if err := o.client.List(context.TODO(), stsList, client.InNamespace(clusterNamespace(vc))); err != nil {
+		return err
+	}
+	d.components = stsList.Items
+
+	syncer := &appsv1.Deployment{}
+	err = o.client.Get(context.TODO(), types.NamespacedName{Namespace: o.syncerNamespace, Name: o.syncerName}, syncer)
+	switch {
+	case err == nil:
+		d.syncer = syncer
+	case !apierrors.IsNotFound(err):
+		return err
+	}
+
+	d.print(os.Stdout)
+	return nil
+}
+
+// clusterNamespace returns the namespace where the control plane of vc is deployed.
+func clusterNamespace(vc *tenancyv1alpha1.VirtualCluster) string {
+	if vc.Status.ClusterNamespace != "" {
+		return vc.Status.ClusterNamespace
+	}
+	return conversion.ToClusterKey(vc)
+}
+
+func (d *virtualClusterDescription) print(out io.Writer) {
+	vc := d.vc
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "Name:\t%s\n", vc.Name)
+	fmt.Fprintf(w, "Namespace:\t%s\n", vc.Namespace)
+	fmt.Fprintf(w, "ClusterNamespace:\t%s\n", clusterNamespace(vc))
+	fmt.Fprintf(w, "ClusterVersion:\t%s\n", d.clusterVersionSummary())
+	fmt.Fprintf(w, "Phase:\t%s\n", vc.Status.Phase)
+	fmt.Fprintf(w, "Reason:\t%s\n", vc.Status.Reason)
+	fmt.Fprintf(w, "Message:\t%s\n", vc.Status.Message)
+	fmt.Fprintf(w, "Created:\t%s\n", vc.CreationTimestamp.UTC().Format(time.RFC3339))
+
+	fmt.Fprintln(w, "Conditions:")
+	if len(vc.Status.Conditions) == 0 {
+		fmt.Fprintln(w, "  <none>")
+	} else {
+		fmt.Fprintln(w, "  STATUS\tREASON\tMESSAGE\tLAST TRANSITION")
+		for _, c := range vc.Status.Conditions {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", c.Status, c.Reason, c.Message, c.LastTransitionTime.UTC().Format(time.RFC3339))
+		}
+	}
+
+	fmt.Fprintln(w, "Components:")
+	if len(d.components) == 0 {
+		fmt.Fprintln(w, "  <none>")
+	} else {
+		fmt.Fprintln(w, "  NAME\tREADY\tUP-TO-DATE")
+		for _, sts := range d.components {
+			replicas := int32(1)
+			if sts.Spec.Replicas != nil {
+				replicas = *sts.Spec.Replicas
+			}
+			fmt.Fprintf(w, "  %s\t%d/%d\t%d\n", sts.Name, sts.Status.ReadyReplicas, replicas, sts.Status.UpdatedReplicas)
+		}
+	}
+
+	fmt.Fprintln(w, "Syncer:")
+	if d.syncer == nil {
+		fmt.Fprintf(w, "  %s/%s not found\n", d.syncerNamespace, d.syncerName)
+	} else {
+		fmt.Fprintf(w, "  %s/%s\t%s\n", d.syncer.Namespace, d.syncer.Name, syncerHealth(d.syncer))
+	}
+}
+
+// clusterVersionSummary shows the ClusterVersion and whether the latest revision of it is applied.
+func (d *virtualClusterDescription) clusterVersionSummary() string {
+	name := d.vc.Spec.ClusterVersionName
+	if d.cv == nil {
+		return name + " (not found)"
+	}
+	applied, ok := d.vc.Labels[constants.LabelClusterVersionApplied]
+	switch {
+	case !ok:
+		return name
+	case applied == d.cv.ResourceVersion:
+		return name + " (up to date)"
+	case d.vc.Labels[constants.LabelVCReadyForUpgrade] == "true":
+		return name + " (upgrading)"
+	default:
+		return name + " (upgrade available)"
+	}
+}
+
+// syncerHealth summarizes the availability of the syncer Deployment.
+func syncerHealth(syncer *appsv1.Deployment) string {
+	replicas := int32(1)
+	if syncer.Spec.Replicas != nil {
+		replicas = *syncer.Spec.Replicas
+	}
+	health := "Healthy"
+	if syncer.Status.AvailableReplicas == 0 {
+		health = "Unavailable"
+	} else if syncer.Status.AvailableReplicas < replicas {
+		health = "Degraded"
+	}
+	return fmt.Sprintf("%s (%d/%d available)", health, syncer.Status.AvailableReplicas, replicas)
+}
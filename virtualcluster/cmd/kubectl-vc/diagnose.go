@@ -0,0 +1,629 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/diff"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+const (
+	diagnoseExample = `
+	# Report the tenant objects in the default namespace of a virtualcluster that drift from the super cluster
+	kubectl vc diagnose -n foo bar
+
+	# Report the drift in all tenant namespaces
+	kubectl vc diagnose foo/bar --all-tenant-namespaces
+
+	# Compare a tenant pod with its super cluster counterpart and list the related events
+	kubectl vc diagnose foo/bar pod/nginx --tenant-namespace web`
+)
+
+// DiagnoseOption compares tenant objects with their super cluster counterparts using the
+// equality checks of the syncer.
+type DiagnoseOption struct {
+	client              client.Client
+	tenantClient        client.Client
+	vcclient            vcclient.Interface
+	namespace           string
+	name                string
+	kind                *diagnoseKind
+	objectName          string
+	tenantNamespace     string
+	allTenantNamespaces bool
+	opaqueMetaDomains   []string
+
+	vc              *tenancyv1alpha1.VirtualCluster
+	syncerConf      *config.SyncerConfiguration
+	superNamespaces map[string]string
+}
+
+// diagnoseKind is a kind of tenant object synced downward by the syncer.
+type diagnoseKind struct {
+	kind      string
+	aliases   []string
+	newObject func() client.Object
+	newList   func() client.ObjectList
+	// compare returns the drift the syncer or its patrol checker would act on.
+	compare func(conf *config.SyncerConfiguration, vc *tenancyv1alpha1.VirtualCluster, pObj, vObj client.Object) []fieldDrift
+}
+
+// fieldDrift is a difference between a tenant object and its super cluster counterpart.
+type fieldDrift struct {
+	field  string
+	remedy string
+	diff   string
+}
+
+// objectDiagnosis is the result of diagnosing a tenant object, or an orphan super cluster object.
+type objectDiagnosis struct {
+	kind           string
+	namespace      string
+	name           string
+	superNamespace string
+	superName      string
+	superObj       client.Object
+	findings       []string
+	drifts         []fieldDrift
+}
+
+var diagnoseKinds = []*diagnoseKind{
+	{
+		kind:      "Pod",
+		aliases:   []string{"pod", "pods", "po"},
+		newObject: func() client.Object { return &corev1.Pod{} },
+		newList:   func() client.ObjectList { return &corev1.PodList{} },
+		compare:   comparePods,
+	},
+	{
+		kind:      "Service",
+		aliases:   []string{"service", "services", "svc"},
+		newObject: func() client.Object { return &corev1.Service{} },
+		newList:   func() client.ObjectList { return &corev1.ServiceList{} },
+		compare:   compareServices,
+	},
+	{
+		kind:      "ConfigMap",
+		aliases:   []string{"configmap", "configmaps", "cm"},
+		newObject: func() client.Object { return &corev1.ConfigMap{} },
+		newList:   func() client.ObjectList { return &corev1.ConfigMapList{} },
+		compare:   compareConfigMaps,
+	},
+	{
+		kind:      "Secret",
+		aliases:   []string{"secret", "secrets"},
+		newObject: func() client.Object { return &corev1.Secret{} },
+		newList:   func() client.ObjectList { return &corev1.SecretList{} },
+		compare:   compareSecrets,
+	},
+	{
+		kind:      "PersistentVolumeClaim",
+		aliases:   []string{"persistentvolumeclaim", "persistentvolumeclaims", "pvc"},
+		newObject: func() client.Object { return &corev1.PersistentVolumeClaim{} },
+		newList:   func() client.ObjectList { return &corev1.PersistentVolumeClaimList{} },
+		compare:   comparePVCs,
+	},
+}
+
+func NewCmdDiagnose(f Factory) *cobra.Command {
+	o := &DiagnoseOption{}
+
+	cmd := &cobra.Command{
+		Use:   "diagnose VC_NAME [RESOURCE/NAME]",
+		Short: "Report the drift between tenant objects and their super cluster counterparts",
+		Long: `Report the drift between tenant objects and their super cluster counterparts.
+
+The super cluster counterpart of a tenant object is resolved through the namespace annotations
+and the tenancy.x-k8s.io/uid annotation set by the syncer. The objects are compared with the
+equality checks of the syncer, so the reported drift is what the syncer and its patrol checkers
+would try to fix. For a single object, the related super cluster events are listed as well.
+
+Supported resources are: ` + strings.Join(diagnoseKindNames(), ", "),
+		Example: diagnoseExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(f, cmd, args))
+			CheckErr(o.Run())
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.namespace, "namespace", "n", metav1.NamespaceDefault, "If present, the namespace scope for this CLI request")
+	cmd.Flags().StringVar(&o.tenantNamespace, "tenant-namespace", metav1.NamespaceDefault, "The namespace of the objects in the virtualcluster")
+	cmd.Flags().BoolVar(&o.allTenantNamespaces, "all-tenant-namespaces", false, "If present, diagnose the objects in all namespaces of the virtualcluster")
+	cmd.Flags().StringSliceVar(&o.opaqueMetaDomains, "default-opaque-meta-domains", []string{"kubernetes.io", "k8s.io"}, "The default opaque meta domains of the syncer")
+
+	return cmd
+}
+
+func (o *DiagnoseOption) Complete(f Factory, cmd *cobra.Command, args []string) error {
+	var err error
+	if len(args) > 2 {
+		return UsageErrorf(cmd, "unexpected arguments %v", args[2:])
+	}
+	if len(args) == 2 {
+		parts := strings.SplitN(args[1], "/", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return UsageErrorf(cmd, "expect RESOURCE/NAME, got %s", args[1])
+		}
+		o.kind = getDiagnoseKind(parts[0])
+		if o.kind == nil {
+			return UsageErrorf(cmd, "unsupported resource %s, supported resources are: %s", parts[0], strings.Join(diagnoseKindNames(), ", "))
+		}
+		o.objectName = parts[1]
+		if o.allTenantNamespaces {
+			return UsageErrorf(cmd, "--all-tenant-namespaces can not be used with RESOURCE/NAME")
+		}
+	}
+
+	o.vcclient, err = f.VirtualClusterClientSet()
+	if err != nil {
+		return err
+	}
+
+	o.client, err = f.GenericClient()
+	if err != nil {
+		return err
+	}
+
+	o.namespace, o.name, err = parseVCName(cmd, args, o.namespace)
+	return err
+}
+
+func (o *DiagnoseOption) Run() error {
+	var err error
+	o.vc, err = o.vcclient.TenancyV1alpha1().VirtualClusters(o.namespace).Get(o.name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	cv, err := o.vcclient.TenancyV1alpha1().ClusterVersions().Get(o.vc.Spec.ClusterVersionName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	kubecfgBytes, err := genKubeConfig(o.client, o.vc, cv)
+	if err != nil {
+		return err
+	}
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(kubecfgBytes)
+	if err != nil {
+		return err
+	}
+	o.tenantClient, err = client.New(restConfig, client.Options{Scheme: scheme.Scheme})
+	if err != nil {
+		return err
+	}
+	o.syncerConf = &config.SyncerConfiguration{DefaultOpaqueMetaDomains: o.opaqueMetaDomains}
+
+	if err := o.loadSuperNamespaces(); err != nil {
+		return err
+	}
+
+	if o.kind != nil {
+		return o.diagnoseObject(os.Stdout)
+	}
+	return o.diagnoseNamespaces(os.Stdout)
+}
+
+// loadSuperNamespaces maps the tenant namespaces of the virtualcluster to the super cluster
+// namespaces based on the annotations set by the syncer, so that any naming strategy is supported.
+func (o *DiagnoseOption) loadSuperNamespaces() error {
+	nsList := &corev1.NamespaceList{}
+	if err := o.client.List(context.TODO(), nsList); err != nil {
+		return err
+	}
+	cluster := conversion.ToClusterKey(o.vc)
+	o.superNamespaces = make(map[string]string)
+	for _, ns := range nsList.Items {
+		if ns.GetAnnotations()[constants.LabelCluster] != cluster {
+			continue
+		}
+		if tenantNS := ns.GetAnnotations()[constants.LabelNamespace]; tenantNS != "" {
+			o.superNamespaces[tenantNS] = ns.Name
+		}
+	}
+	return nil
+}
+
+func (o *DiagnoseOption) diagnoseObject(out io.Writer) error {
+	vObj := o.kind.newObject()
+	if err := o.tenantClient.Get(context.TODO(), client.ObjectKey{Namespace: o.tenantNamespace, Name: o.objectName}, vObj); err != nil {
+		return err
+	}
+	d, err := o.diagnose(o.kind, vObj)
+	if err != nil {
+		return err
+	}
+
+	var events []corev1.Event
+	if d.superObj != nil {
+		eventList := &corev1.EventList{}
+		err := o.client.List(context.TODO(), eventList, client.InNamespace(d.superNamespace), client.MatchingFields{
+			"involvedObject.kind": o.kind.kind,
+			"involvedObject.name": d.superName,
+		})
+		if err != nil {
+			return err
+		}
+		events = eventList.Items
+	}
+	printObjectDiagnosis(out, d, events, time.Now())
+	return nil
+}
+
+func (o *DiagnoseOption) diagnoseNamespaces(out io.Writer) error {
+	namespaces := []string{o.tenantNamespace}
+	if o.allTenantNamespaces {
+		nsList := &corev1.NamespaceList{}
+		if err := o.tenantClient.List(context.TODO(), nsList); err != nil {
+			return err
+		}
+		namespaces = namespaces[:0]
+		for _, ns := range nsList.Items {
+			namespaces = append(namespaces, ns.Name)
+		}
+	}
+
+	var results []*objectDiagnosis
+	checked := 0
+	for _, ns := range namespaces {
+		for _, kind := range diagnoseKinds {
+			vList := kind.newList()
+			if err := o.tenantClient.List(context.TODO(), vList, client.InNamespace(ns)); err != nil {
+				return err
+			}
+			vObjs, err := meta.ExtractList(vList)
+			if err != nil {
+				return err
+			}
+			tenantNames := make(map[string]bool, len(vObjs))
+			for _, each := range vObjs {
+				vObj := each.(client.Object)
+				tenantNames[vObj.GetName()] = true
+				if vObj.GetLabels()[constants.LabelTenantIgnoreSync] == "true" {
+					continue
+				}
+				d, err := o.diagnose(kind, vObj)
+				if err != nil {
+					return err
+				}
+				checked++
+				if len(d.findings) != 0 || len(d.drifts) != 0 {
+					results = append(results, d)
+				}
+			}
+
+			orphans, err := o.findOrphans(kind, ns, tenantNames)
+			if err != nil {
+				return err
+			}
+			results = append(results, orphans...)
+		}
+	}
+
+	fmt.Fprintf(out, "%d objects checked, %d with drift\n", checked, len(results))
+	if len(results) == 0 {
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAMESPACE\tNAME\tSUPER OBJECT\tDRIFT")
+	for _, d := range results {
+		super := "<none>"
+		if d.superObj != nil {
+			super = d.superNamespace + "/" + d.superName
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", d.kind, d.namespace, d.name, super, strings.Join(d.summary(), "; "))
+	}
+	return w.Flush()
+}
+
+// diagnose finds the super cluster counterpart of vObj and compares them.
+func (o *DiagnoseOption) diagnose(kind *diagnoseKind, vObj client.Object) (*objectDiagnosis, error) {
+	d := &objectDiagnosis{
+		kind:      kind.kind,
+		namespace: vObj.GetNamespace(),
+		name:      vObj.GetName(),
+		superName: vObj.GetName(),
+	}
+	superNS, ok := o.superNamespaces[vObj.GetNamespace()]
+	if !ok {
+		d.findings = append(d.findings, fmt.Sprintf("namespace %s is not synced to the super cluster", vObj.GetNamespace()))
+		return d, nil
+	}
+	d.superNamespace = superNS
+
+	pObj, err := o.getSuperObject(kind, superNS, vObj)
+	if err != nil {
+		return nil, err
+	}
+	if pObj == nil {
+		if vObj.GetDeletionTimestamp() != nil {
+			d.findings = append(d.findings, "missing in the super cluster while the tenant object is being deleted, the checker would force delete the tenant object")
+		} else {
+			d.findings = append(d.findings, "missing in the super cluster, the checker would requeue the tenant object")
+		}
+		return d, nil
+	}
+	d.superObj = pObj
+	d.superName = pObj.GetName()
+
+	if uid := pObj.GetAnnotations()[constants.LabelUID]; uid != string(vObj.GetUID()) {
+		d.findings = append(d.findings, fmt.Sprintf("delegated UID %q differs from the tenant UID %q, the checker would delete the super cluster object", uid, vObj.GetUID()))
+		return d, nil
+	}
+	if vObj.GetDeletionTimestamp() != nil && pObj.GetDeletionTimestamp() == nil {
+		d.findings = append(d.findings, "the tenant object is being deleted but the super cluster object is not")
+	}
+	d.drifts = kind.compare(o.syncerConf, o.vc, pObj, vObj)
+	return d, nil
+}
+
+// getSuperObject returns the super cluster counterpart of vObj, or nil if it is not found.
+func (o *DiagnoseOption) getSuperObject(kind *diagnoseKind, superNS string, vObj client.Object) (client.Object, error) {
+	if secret, ok := vObj.(*corev1.Secret); ok && secret.Type == corev1.SecretTypeServiceAccountToken {
+		// the service account token secrets are synced under generated names
+		secretList := &corev1.SecretList{}
+		err := o.client.List(context.TODO(), secretList, client.InNamespace(superNS), client.MatchingLabels{
+			constants.LabelSecretUID: string(secret.UID),
+		})
+		if err != nil || len(secretList.Items) == 0 {
+			return nil, err
+		}
+		return &secretList.Items[0], nil
+	}
+
+	pObj := kind.newObject()
+	err := o.client.Get(context.TODO(), client.ObjectKey{Namespace: superNS, Name: vObj.GetName()}, pObj)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	return pObj, err
+}
+
+// findOrphans returns the objects synced to the super cluster namespace of ns whose tenant
+// objects are gone.
+func (o *DiagnoseOption) findOrphans(kind *diagnoseKind, ns string, tenantNames map[string]bool) ([]*objectDiagnosis, error) {
+	superNS, ok := o.superNamespaces[ns]
+	if !ok {
+		return nil, nil
+	}
+	pList := kind.newList()
+	if err := o.client.List(context.TODO(), pList, client.InNamespace(superNS)); err != nil {
+		return nil, err
+	}
+	pObjs, err := meta.ExtractList(pList)
+	if err != nil {
+		return nil, err
+	}
+	var orphans []*objectDiagnosis
+	for _, each := range pObjs {
+		pObj := each.(client.Object)
+		if _, synced := pObj.GetAnnotations()[constants.LabelUID]; !synced {
+			continue
+		}
+		name := pObj.GetName()
+		if secretName := pObj.GetAnnotations()[constants.LabelSecretName]; secretName != "" {
+			name = secretName
+		}
+		if tenantNames[name] {
+			continue
+		}
+		orphans = append(orphans, &objectDiagnosis{
+			kind:           kind.kind,
+			namespace:      ns,
+			name:           name,
+			superNamespace: superNS,
+			superName:      pObj.GetName(),
+			superObj:       pObj,
+			findings:       []string{"orphan in the super cluster, the checker would delete it"},
+		})
+	}
+	return orphans, nil
+}
+
+// summary is a short description of the drift of d.
+func (d *objectDiagnosis) summary() []string {
+	summary := append([]string{}, d.findings...)
+	for _, each := range d.drifts {
+		summary = append(summary, each.field+" differs")
+	}
+	return summary
+}
+
+func printObjectDiagnosis(out io.Writer, d *objectDiagnosis, events []corev1.Event, now time.Time) {
+	fmt.Fprintf(out, "%s %s/%s\n", d.kind, d.namespace, d.name)
+	if d.superObj != nil {
+		fmt.Fprintf(out, "Super cluster object: %s/%s\n", d.superNamespace, d.superName)
+	} else {
+		fmt.Fprintln(out, "Super cluster object: <none>")
+	}
+	if len(d.findings) == 0 && len(d.drifts) == 0 {
+		fmt.Fprintln(out, "Drift: <none>")
+	} else {
+		fmt.Fprintln(out, "Drift:")
+		for _, each := range d.findings {
+			fmt.Fprintf(out, "  - %s\n", each)
+		}
+		for _, each := range d.drifts {
+			fmt.Fprintf(out, "  - %s differs, %s\n", each.field, each.remedy)
+		}
+		for _, each := range d.drifts {
+			fmt.Fprintf(out, "\n%s:\n%s\n", each.field, each.diff)
+		}
+	}
+
+	fmt.Fprintln(out, "Super cluster events:")
+	if len(events) == 0 {
+		fmt.Fprintln(out, "  <none>")
+		return
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return eventTime(&events[i]).Before(eventTime(&events[j]))
+	})
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "  LAST SEEN\tTYPE\tREASON\tCOUNT\tMESSAGE")
+	for i := range events {
+		e := &events[i]
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%d\t%s\n", duration.HumanDuration(now.Sub(eventTime(e))), e.Type, e.Reason, e.Count, strings.TrimSpace(e.Message))
+	}
+	w.Flush()
+}
+
+func eventTime(e *corev1.Event) time.Time {
+	if !e.LastTimestamp.IsZero() {
+		return e.LastTimestamp.Time
+	}
+	if !e.EventTime.IsZero() {
+		return e.EventTime.Time
+	}
+	return e.CreationTimestamp.Time
+}
+
+func getDiagnoseKind(name string) *diagnoseKind {
+	name = strings.ToLower(name)
+	for _, kind := range diagnoseKinds {
+		for _, alias := range kind.aliases {
+			if alias == name {
+				return kind
+			}
+		}
+	}
+	return nil
+}
+
+func diagnoseKindNames() []string {
+	names := make([]string, 0, len(diagnoseKinds))
+	for _, kind := range diagnoseKinds {
+		names = append(names, kind.aliases[0])
+	}
+	return names
+}
+
+const (
+	remedyDownward = "the syncer would update the super cluster object (-super +tenant)"
+	remedyUpward   = "the syncer would update the tenant object (-tenant +super)"
+)
+
+func comparePods(conf *config.SyncerConfiguration, vc *tenancyv1alpha1.VirtualCluster, pObj, vObj client.Object) []fieldDrift {
+	pPod, vPod := pObj.(*corev1.Pod), vObj.(*corev1.Pod)
+	var drifts []fieldDrift
+	if pPod.Spec.NodeName != "" && vPod.Spec.NodeName != "" && pPod.Spec.NodeName != vPod.Spec.NodeName {
+		drifts = append(drifts, fieldDrift{
+			field:  "nodeName",
+			remedy: "the checker would delete the tenant pod",
+			diff:   diff.ObjectReflectDiff(pPod.Spec.NodeName, vPod.Spec.NodeName),
+		})
+	}
+	if updated := conversion.Equality(conf, vc).CheckPodEquality(pPod, vPod); updated != nil {
+		drifts = append(drifts, fieldDrift{field: "spec", remedy: remedyDownward, diff: diff.ObjectReflectDiff(pPod, updated)})
+	}
+	if updated := conversion.CheckDWPodConditionEquality(pPod, vPod); updated != nil {
+		drifts = append(drifts, fieldDrift{field: "readiness gate conditions", remedy: remedyDownward, diff: diff.ObjectReflectDiff(&pPod.Status, updated)})
+	}
+	if updated := conversion.Equality(conf, nil).CheckUWPodStatusEquality(pPod, vPod); updated != nil {
+		drifts = append(drifts, fieldDrift{field: "status", remedy: remedyUpward, diff: diff.ObjectReflectDiff(&vPod.Status, updated)})
+	}
+	if updated := conversion.Equality(conf, vc).CheckUWObjectMetaEquality(&pPod.ObjectMeta, &vPod.ObjectMeta); updated != nil {
+		drifts = append(drifts, fieldDrift{field: "metadata", remedy: remedyUpward, diff: diff.ObjectReflectDiff(&vPod.ObjectMeta, updated)})
+	}
+	return drifts
+}
+
+func compareServices(conf *config.SyncerConfiguration, vc *tenancyv1alpha1.VirtualCluster, pObj, vObj client.Object) []fieldDrift {
+	pSvc, vSvc := pObj.(*corev1.Service), vObj.(*corev1.Service)
+	var drifts []fieldDrift
+	if updated := conversion.Equality(conf, vc).CheckServiceEquality(pSvc, vSvc); updated != nil {
+		drifts = append(drifts, fieldDrift{field: "spec", remedy: remedyDownward, diff: diff.ObjectReflectDiff(pSvc, updated)})
+	}
+	if pSvc.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		if updated := conversion.Equality(conf, vc).CheckUWObjectMetaEquality(&pSvc.ObjectMeta, &vSvc.ObjectMeta); updated != nil {
+			drifts = append(drifts, fieldDrift{field: "metadata", remedy: remedyUpward, diff: diff.ObjectReflectDiff(&vSvc.ObjectMeta, updated)})
+		}
+		if d := diff.ObjectReflectDiff(&vSvc.Status, &pSvc.Status); d != "" {
+			drifts = append(drifts, fieldDrift{field: "status", remedy: remedyUpward, diff: d})
+		}
+	}
+	return drifts
+}
+
+func compareConfigMaps(conf *config.SyncerConfiguration, vc *tenancyv1alpha1.VirtualCluster, pObj, vObj client.Object) []fieldDrift {
+	pCM, vCM := pObj.(*corev1.ConfigMap), vObj.(*corev1.ConfigMap)
+	if updated := conversion.Equality(conf, vc).CheckConfigMapEquality(pCM, vCM); updated != nil {
+		return []fieldDrift{{field: "data", remedy: remedyDownward, diff: diff.ObjectReflectDiff(pCM, updated)}}
+	}
+	return nil
+}
+
+func compareSecrets(conf *config.SyncerConfiguration, vc *tenancyv1alpha1.VirtualCluster, pObj, vObj client.Object) []fieldDrift {
+	pSecret, vSecret := pObj.(*corev1.Secret), vObj.(*corev1.Secret)
+	if updated := conversion.Equality(conf, vc).CheckSecretEquality(pSecret, vSecret); updated != nil {
+		return []fieldDrift{{field: "data", remedy: remedyDownward, diff: diff.ObjectReflectDiff(redactSecret(pSecret), redactSecret(updated))}}
+	}
+	return nil
+}
+
+func comparePVCs(conf *config.SyncerConfiguration, vc *tenancyv1alpha1.VirtualCluster, pObj, vObj client.Object) []fieldDrift {
+	pPVC, vPVC := pObj.(*corev1.PersistentVolumeClaim), vObj.(*corev1.PersistentVolumeClaim)
+	var drifts []fieldDrift
+	if updated := conversion.Equality(conf, vc).CheckPVCEquality(pPVC, vPVC); updated != nil {
+		drifts = append(drifts, fieldDrift{field: "spec", remedy: remedyDownward, diff: diff.ObjectReflectDiff(pPVC, updated)})
+	}
+	if updated := conversion.Equality(conf, vc).CheckUWPVCStatusEquality(pPVC, vPVC); updated != nil {
+		drifts = append(drifts, fieldDrift{field: "status", remedy: remedyUpward, diff: diff.ObjectReflectDiff(vPVC, updated)})
+	}
+	return drifts
+}
+
+// redactSecret replaces the values of the secret with their digests, so that the diff
+// shows which keys differ without printing the secret.
+func redactSecret(secret *corev1.Secret) *corev1.Secret {
+	redacted := secret.DeepCopy()
+	for k, v := range redacted.Data {
+		redacted.Data[k] = []byte(digest(v))
+	}
+	for k, v := range redacted.StringData {
+		redacted.StringData[k] = digest([]byte(v))
+	}
+	return redacted
+}
+
+func digest(value []byte) string {
+	sum := sha256.Sum256(value)
+	return "sha256:" + hex.EncodeToString(sum[:])[:12]
+}
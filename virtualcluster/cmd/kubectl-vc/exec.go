@@ -23,7 +23,6 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
-	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -85,18 +84,8 @@ func (o *ExecOption) Complete(f Factory, cmd *cobra.Command, args []string) erro
 		return err
 	}
 
-	if len(args) == 0 {
-		return UsageErrorf(cmd, "VC_NAME should not be empty")
-	}
-
-	o.name = args[0]
-	if strings.Contains(o.name, "/") {
-		namespacedName := strings.SplitN(o.name, "/", 2)
-		o.namespace = namespacedName[0]
-		o.name = namespacedName[1]
-	}
-
-	return nil
+	o.namespace, o.name, err = parseVCName(cmd, args, o.namespace)
+	return err
 }
 
 func (o *ExecOption) Run() error {
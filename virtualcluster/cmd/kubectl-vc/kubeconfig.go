@@ -0,0 +1,177 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+)
+
+const (
+	kubeconfigExample = `
+	# Print the kubeconfig of a virtualcluster
+	kubectl vc kubeconfig -n foo bar
+
+	# Write the kubeconfig of a virtualcluster to a file
+	kubectl vc kubeconfig foo/bar -o vc.kubeconfig
+
+	# Merge the kubeconfig of a virtualcluster into ~/.kube/config and switch to it
+	kubectl vc kubeconfig foo/bar --merge --set-current`
+)
+
+type KubeconfigOption struct {
+	client     client.Client
+	vcclient   vcclient.Interface
+	namespace  string
+	name       string
+	outputPath string
+	merge      bool
+	setCurrent bool
+}
+
+func NewCmdKubeconfig(f Factory) *cobra.Command {
+	o := &KubeconfigOption{}
+
+	cmd := &cobra.Command{
+		Use:     "kubeconfig VC_NAME",
+		Short:   "Print or merge the kubeconfig of a virtualcluster",
+		Example: kubeconfigExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(f, cmd, args))
+			CheckErr(o.Validate(cmd))
+			CheckErr(o.Run())
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.namespace, "namespace", "n", metav1.NamespaceDefault, "If present, the namespace scope for this CLI request")
+	cmd.Flags().StringVarP(&o.outputPath, "output", "o", "", "The path of the kubeconfig file to write, the kubeconfig is printed to stdout if not set")
+	cmd.Flags().BoolVar(&o.merge, "merge", false, "If true, merge the kubeconfig into the default kubeconfig file")
+	cmd.Flags().BoolVar(&o.setCurrent, "set-current", false, "If true, set the current-context of the default kubeconfig file to the virtualcluster, only used with --merge")
+
+	return cmd
+}
+
+func (o *KubeconfigOption) Complete(f Factory, cmd *cobra.Command, args []string) error {
+	var err error
+	o.vcclient, err = f.VirtualClusterClientSet()
+	if err != nil {
+		return err
+	}
+
+	o.client, err = f.GenericClient()
+	if err != nil {
+		return err
+	}
+
+	o.namespace, o.name, err = parseVCName(cmd, args, o.namespace)
+	return err
+}
+
+func (o *KubeconfigOption) Validate(cmd *cobra.Command) error {
+	if o.merge && o.outputPath != "" {
+		return UsageErrorf(cmd, "--output and --merge can not be used together")
+	}
+	if o.setCurrent && !o.merge {
+		return UsageErrorf(cmd, "--set-current can only be used with --merge")
+	}
+	return nil
+}
+
+func (o *KubeconfigOption) Run() error {
+	vc, err := o.vcclient.TenancyV1alpha1().VirtualClusters(o.namespace).Get(o.name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	cv, err := o.vcclient.TenancyV1alpha1().ClusterVersions().Get(vc.Spec.ClusterVersionName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	kubecfgBytes, err := genKubeConfig(o.client, vc, cv)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case o.merge:
+		kubecfg, err := clientcmd.Load(kubecfgBytes)
+		if err != nil {
+			return err
+		}
+		path := clientcmd.NewDefaultPathOptions().GetDefaultFilename()
+		contextName := fmt.Sprintf("%s-%s", o.namespace, o.name)
+		if err := mergeKubeConfig(path, contextName, kubecfg, o.setCurrent); err != nil {
+			return err
+		}
+		log.Printf("context %s of VirtualCluster %s/%s is merged into %s\n", contextName, o.namespace, o.name, path)
+	case o.outputPath != "":
+		if err := ioutil.WriteFile(o.outputPath, kubecfgBytes, 0600); err != nil {
+			return err
+		}
+	default:
+		_, err = os.Stdout.Write(kubecfgBytes)
+		return err
+	}
+	return nil
+}
+
+// mergeKubeConfig merges the current context of kubecfg into the kubeconfig file at
+// path as contextName. The cluster and the user are named after the context as well,
+// so that the entries of different virtualclusters do not collide.
+func mergeKubeConfig(path, contextName string, kubecfg *clientcmdapi.Config, setCurrent bool) error {
+	current, ok := kubecfg.Contexts[kubecfg.CurrentContext]
+	if !ok {
+		return fmt.Errorf("current context %q not found in the kubeconfig of the virtualcluster", kubecfg.CurrentContext)
+	}
+	cluster, ok := kubecfg.Clusters[current.Cluster]
+	if !ok {
+		return fmt.Errorf("cluster %q not found in the kubeconfig of the virtualcluster", current.Cluster)
+	}
+	authInfo, ok := kubecfg.AuthInfos[current.AuthInfo]
+	if !ok {
+		return fmt.Errorf("user %q not found in the kubeconfig of the virtualcluster", current.AuthInfo)
+	}
+
+	config, err := clientcmd.LoadFromFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		config = clientcmdapi.NewConfig()
+	}
+
+	config.Clusters[contextName] = cluster
+	config.AuthInfos[contextName] = authInfo
+	ctx := clientcmdapi.NewContext()
+	ctx.Cluster = contextName
+	ctx.AuthInfo = contextName
+	ctx.Namespace = current.Namespace
+	config.Contexts[contextName] = ctx
+	if setCurrent || config.CurrentContext == "" {
+		config.CurrentContext = contextName
+	}
+	return clientcmd.WriteToFile(*config, path)
+}
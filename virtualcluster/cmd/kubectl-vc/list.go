@@ -0,0 +1,115 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+)
+
+const (
+	listExample = `
+	# List the virtualclusters in the default namespace
+	kubectl vc list
+
+	# List the virtualclusters in all namespaces
+	kubectl vc list -A`
+)
+
+type ListOption struct {
+	vcclient      vcclient.Interface
+	namespace     string
+	allNamespaces bool
+}
+
+func NewCmdList(f Factory) *cobra.Command {
+	o := &ListOption{}
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List virtualclusters",
+		Example: listExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(f))
+			CheckErr(o.Run())
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.namespace, "namespace", "n", metav1.NamespaceDefault, "If present, the namespace scope for this CLI request")
+	cmd.Flags().BoolVarP(&o.allNamespaces, "all-namespaces", "A", false, "If present, list the virtualclusters across all namespaces")
+
+	return cmd
+}
+
+func (o *ListOption) Complete(f Factory) error {
+	var err error
+	o.vcclient, err = f.VirtualClusterClientSet()
+	if err != nil {
+		return err
+	}
+	if o.allNamespaces {
+		o.namespace = metav1.NamespaceAll
+	}
+	return nil
+}
+
+func (o *ListOption) Run() error {
+	vcList, err := o.vcclient.TenancyV1alpha1().VirtualClusters(o.namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	if len(vcList.Items) == 0 {
+		if o.allNamespaces {
+			fmt.Println("No virtualclusters found")
+		} else {
+			fmt.Printf("No virtualclusters found in %s namespace\n", o.namespace)
+		}
+		return nil
+	}
+	printVirtualClusters(os.Stdout, vcList.Items, time.Now())
+	return nil
+}
+
+// printVirtualClusters prints the virtualclusters as a table sorted by namespace and name.
+func printVirtualClusters(out io.Writer, vcs []tenancyv1alpha1.VirtualCluster, now time.Time) {
+	sort.Slice(vcs, func(i, j int) bool {
+		if vcs[i].Namespace != vcs[j].Namespace {
+			return vcs[i].Namespace < vcs[j].Namespace
+		}
+		return vcs[i].Name < vcs[j].Name
+	})
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tPHASE\tCLUSTERVERSION\tCLUSTERNAMESPACE\tAGE")
+	for _, vc := range vcs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", vc.Namespace, vc.Name, vc.Status.Phase,
+			vc.Spec.ClusterVersionName, vc.Status.ClusterNamespace,
+			duration.HumanDuration(now.Sub(vc.CreationTimestamp.Time)))
+	}
+	w.Flush()
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+const (
+	migrateNamespacesExample = `
+	# Show how the tenant namespaces in the super cluster are renamed by the hash strategy
+	kubectl vc migrate-namespaces --strategy hash
+
+	# Only show the namespaces of one virtualcluster
+	kubectl vc migrate-namespaces --strategy hash --cluster default-c64b4b-vc-sample-1`
+)
+
+// MigrateNamespacesOption plans the renaming of super cluster namespaces when the syncer
+// switches to another namespace naming strategy.
+type MigrateNamespacesOption struct {
+	client   kubernetes.Interface
+	strategy string
+	cluster  string
+}
+
+type namespaceMigration struct {
+	cluster   string
+	namespace string
+	from      string
+	to        string
+}
+
+func NewCmdMigrateNamespaces(f Factory) *cobra.Command {
+	o := &MigrateNamespacesOption{}
+
+	cmd := &cobra.Command{
+		Use:   "migrate-namespaces",
+		Short: "Plan the migration of super cluster namespaces to a namespace naming strategy",
+		Long: `Plan the migration of super cluster namespaces to a namespace naming strategy.
+
+The command lists the tenant namespaces synced to the super cluster, computes their names
+under the given strategy and fails if two tenant namespaces would share a name. It only prints
+the plan and does not rename, create or delete any namespace.
+
+Changing the strategy does not move existing objects. After the syncer and vn-agent are
+restarted with the same --namespace-naming-strategy, the objects in the namespaces listed in
+the plan have to be recreated under the new names and the old super cluster namespaces
+cleaned up by the operator.`,
+		Example: migrateNamespacesExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(f, cmd, args))
+			CheckErr(o.Run())
+		},
+	}
+
+	cmd.Flags().StringVar(&o.strategy, "strategy", conversion.NamespaceNamingHash, "The target namespace naming strategy. Options are: "+strings.Join(conversion.NamespaceNamingStrategyNames(), ", "))
+	cmd.Flags().StringVar(&o.cluster, "cluster", "", "If present, only plan the namespaces of the virtualcluster with this cluster key")
+
+	return cmd
+}
+
+func (o *MigrateNamespacesOption) Complete(f Factory, cmd *cobra.Command, args []string) error {
+	var err error
+	if len(args) != 0 {
+		return UsageErrorf(cmd, "unexpected arguments %v", args)
+	}
+	if _, err = conversion.GetNamespaceNamingStrategy(o.strategy); err != nil {
+		return UsageErrorf(cmd, "%v", err)
+	}
+
+	o.client, err = f.KubernetesClientSet()
+	return err
+}
+
+func (o *MigrateNamespacesOption) Run() error {
+	strategy, err := conversion.GetNamespaceNamingStrategy(o.strategy)
+	if err != nil {
+		return err
+	}
+	nsList, err := o.client.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	var plan []namespaceMigration
+	existing := make(map[string]bool, len(nsList.Items))
+	for _, ns := range nsList.Items {
+		existing[ns.Name] = true
+		cluster, namespace := ns.GetAnnotations()[constants.LabelCluster], ns.GetAnnotations()[constants.LabelNamespace]
+		if cluster == "" || namespace == "" {
+			continue
+		}
+		if o.cluster != "" && o.cluster != cluster {
+			continue
+		}
+		plan = append(plan, namespaceMigration{
+			cluster:   cluster,
+			namespace: namespace,
+			from:      ns.Name,
+			to:        strategy.SuperClusterNamespace(cluster, namespace),
+		})
+	}
+	sort.Slice(plan, func(i, j int) bool { return plan[i].from < plan[j].from })
+
+	targets := make(map[string][]namespaceMigration, len(plan))
+	for _, each := range plan {
+		targets[each.to] = append(targets[each.to], each)
+	}
+	sources := make(map[string]bool, len(plan))
+	for _, each := range plan {
+		sources[each.from] = true
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "CLUSTER\tNAMESPACE\tCURRENT\tTARGET\tSTATUS")
+	var conflicts []string
+	for _, each := range plan {
+		status := "rename"
+		switch {
+		case each.from == each.to:
+			status = "unchanged"
+		case len(targets[each.to]) > 1:
+			status = "conflict"
+		case existing[each.to] && !sources[each.to]:
+			// the target name is taken by a namespace that is not a tenant namespace.
+			status = "conflict"
+		}
+		if status == "conflict" {
+			conflicts = append(conflicts, fmt.Sprintf("%s/%s", each.cluster, each.namespace))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", each.cluster, each.namespace, each.from, each.to, status)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(conflicts) != 0 {
+		return fmt.Errorf("strategy %s cannot be used, the super cluster namespaces of %s conflict", o.strategy, strings.Join(conflicts, ", "))
+	}
+	return nil
+}
@@ -41,7 +41,14 @@ func main() {
 	}
 
 	rootCmd.AddCommand(NewCmdCreate(f))
+	rootCmd.AddCommand(NewCmdList(f))
+	rootCmd.AddCommand(NewCmdDescribe(f))
+	rootCmd.AddCommand(NewCmdDelete(f))
+	rootCmd.AddCommand(NewCmdDiagnose(f))
+	rootCmd.AddCommand(NewCmdKubeconfig(f))
+	rootCmd.AddCommand(NewCmdUpgrade(f))
 	rootCmd.AddCommand(NewCmdExec(f))
+	rootCmd.AddCommand(NewCmdMigrateNamespaces(f))
 
 	CheckErr(rootCmd.Execute())
 }
@@ -0,0 +1,142 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+const (
+	upgradeExample = `
+	# Upgrade a virtualcluster to the latest revision of its clusterversion
+	kubectl vc upgrade -n foo bar
+
+	# Request the upgrade without waiting for it
+	kubectl vc upgrade foo/bar --wait=false`
+
+	upgradeFailedReason = "TenantControlPlaneUpgradeFailed"
+)
+
+type UpgradeOption struct {
+	vcclient  vcclient.Interface
+	namespace string
+	name      string
+	wait      bool
+	timeout   time.Duration
+}
+
+func NewCmdUpgrade(f Factory) *cobra.Command {
+	o := &UpgradeOption{}
+
+	cmd := &cobra.Command{
+		Use:   "upgrade VC_NAME",
+		Short: "Upgrade a virtualcluster to the latest revision of its clusterversion",
+		Long: `Upgrade a virtualcluster to the latest revision of its clusterversion.
+
+The virtualcluster is labeled as ready for upgrade, the upgrade is performed by
+the vc-manager, which needs the ClusterVersionPartialUpgrade feature gate enabled.`,
+		Example: upgradeExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			CheckErr(o.Complete(f, cmd, args))
+			CheckErr(o.Run())
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.namespace, "namespace", "n", metav1.NamespaceDefault, "If present, the namespace scope for this CLI request")
+	cmd.Flags().BoolVar(&o.wait, "wait", true, "If true, wait until the upgrade is completed")
+	cmd.Flags().DurationVar(&o.timeout, "timeout", 10*time.Minute, "The length of time to wait for the upgrade, only used with --wait")
+
+	return cmd
+}
+
+func (o *UpgradeOption) Complete(f Factory, cmd *cobra.Command, args []string) error {
+	var err error
+	o.vcclient, err = f.VirtualClusterClientSet()
+	if err != nil {
+		return err
+	}
+
+	o.namespace, o.name, err = parseVCName(cmd, args, o.namespace)
+	return err
+}
+
+func (o *UpgradeOption) Run() error {
+	vcs := o.vcclient.TenancyV1alpha1().VirtualClusters(o.namespace)
+	vc, err := vcs.Get(o.name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if vc.Status.Phase != tenancyv1alpha1.ClusterRunning {
+		return fmt.Errorf("virtualcluster %s/%s is %s, only a running virtualcluster can be upgraded", o.namespace, o.name, vc.Status.Phase)
+	}
+	cv, err := o.vcclient.TenancyV1alpha1().ClusterVersions().Get(vc.Spec.ClusterVersionName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if vc.Labels[constants.LabelClusterVersionApplied] == cv.ResourceVersion {
+		log.Printf("VirtualCluster %s/%s is already running the latest revision of clusterversion %s\n", o.namespace, o.name, cv.Name)
+		return nil
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]string{
+				constants.LabelVCReadyForUpgrade: "true",
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := vcs.Patch(o.name, types.MergePatchType, patch); err != nil {
+		return err
+	}
+	log.Printf("VirtualCluster %s/%s is marked as ready for upgrade\n", o.namespace, o.name)
+	if !o.wait {
+		return nil
+	}
+
+	// the vc-manager removes the label once the upgrade is finished
+	err = wait.PollImmediate(pollPeriod, o.timeout, func() (bool, error) {
+		vc, err = vcs.Get(o.name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		_, upgrading := vc.Labels[constants.LabelVCReadyForUpgrade]
+		return !upgrading, nil
+	})
+	if err != nil {
+		return fmt.Errorf("fail to wait for the upgrade of virtualcluster %s/%s: %v", o.namespace, o.name, err)
+	}
+	if vc.Status.Reason == upgradeFailedReason {
+		return fmt.Errorf("virtualcluster %s/%s: %s", o.namespace, o.name, vc.Status.Message)
+	}
+	log.Printf("VirtualCluster %s/%s upgraded successfully\n", o.namespace, o.name)
+	return nil
+}
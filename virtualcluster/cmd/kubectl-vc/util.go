@@ -109,3 +109,17 @@ func readFromFileOrURL(path string) ([]byte, error) {
 func isURL(path string) bool {
 	return strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "http://")
 }
+
+// parseVCName returns the namespace and name of the virtualcluster given as the first argument,
+// either VC_NAME in the namespace or NAMESPACE/VC_NAME.
+func parseVCName(cmd *cobra.Command, args []string, namespace string) (string, string, error) {
+	if len(args) == 0 {
+		return "", "", UsageErrorf(cmd, "VC_NAME should not be empty")
+	}
+	name := args[0]
+	if strings.Contains(name, "/") {
+		namespacedName := strings.SplitN(name, "/", 2)
+		namespace, name = namespacedName[0], namespacedName[1]
+	}
+	return namespace, name, nil
+}
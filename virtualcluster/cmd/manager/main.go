@@ -22,6 +22,7 @@ import (
 	"os"
 	"time"
 
+	utilnet "k8s.io/apimachinery/pkg/util/net"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	clusterv1 "sigs.k8s.io/cluster-api/api/v1alpha4"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -31,8 +32,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis"
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/webhook"
+	vcwebhook "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/webhook/virtualcluster"
 
 	cliflag "k8s.io/component-base/cli/flag"
 
@@ -58,7 +61,16 @@ func main() {
 		versionOpt                        bool
 		disableStacktrace                 bool
 		enableWebhook                     bool
+		webhookCertMode                   string
+		webhookCertSecret                 string
+		webhookCertRenewBefore            time.Duration
+		superNodePortRange                string
 		provisionerTimeout                time.Duration
+		certRotation                      bool
+		certRenewBefore                   time.Duration
+		rotateCA                          bool
+		caTrustWindow                     time.Duration
+		encryptionKeyRotation             bool
 
 		featureGates map[string]bool
 	)
@@ -81,7 +93,21 @@ func main() {
 	flag.BoolVar(&versionOpt, "version", false, "Print the version information")
 	flag.BoolVar(&disableStacktrace, "disable-stacktrace", false, "If set, the automatic stacktrace is disabled")
 	flag.BoolVar(&enableWebhook, "enable-webhook", false, "If set, the virtualcluster webhook is enabled")
+	flag.StringVar(&webhookCertMode, "webhook-cert-mode", vcwebhook.CertModeSelfSigned,
+		"How the webhook serving certificate is provisioned, one of self-signed, secret and cert-manager")
+	flag.StringVar(&webhookCertSecret, "webhook-cert-secret", vcwebhook.VCWebhookCertSecretName,
+		"The secret holding the webhook serving certificate, used by the secret and cert-manager cert modes")
+	flag.DurationVar(&webhookCertRenewBefore, "webhook-cert-renew-before", 720*time.Hour,
+		"How long before expiry the webhook serving certificate is renewed, used by the secret cert mode")
+	flag.StringVar(&superNodePortRange, "super-node-port-range", tenancyv1alpha1.SuperClusterNodePortRange,
+		"The --service-node-port-range of the super cluster, the webhook checks that the node port range of a virtualcluster is within it")
 	flag.DurationVar(&provisionerTimeout, "provisioner-timeout", 10*time.Minute, "The timeout for provision control-plane statefulsets")
+	flag.BoolVar(&certRotation, "cert-rotation", true, "If set, the certificates of the native virtual clusters are rotated before they expire")
+	flag.DurationVar(&certRenewBefore, "cert-renew-before", 720*time.Hour, "How long before expiry the certificates of the native virtual clusters are rotated")
+	flag.BoolVar(&rotateCA, "rotate-ca", false, "If set, the root ca of the native virtual clusters is rotated before it expires")
+	flag.DurationVar(&caTrustWindow, "ca-trust-window", 24*time.Hour, "How long the previous root ca is trusted after a root ca rotation")
+	flag.BoolVar(&encryptionKeyRotation, "encryption-key-rotation", true,
+		"If set, the encryption key of the secrets of the native virtual clusters is rotated on the tenancy.x-k8s.io/rotate-encryption-key annotation")
 
 	flag.Var(cliflag.NewMapStringBool(&featureGates), "feature-gates", "A set of key=value pairs that describe featuregate gates for various features.")
 
@@ -172,6 +198,11 @@ func main() {
 		ProvisionerName:         controlPlaneProvisioner,
 		ProvisionerTimeout:      provisionerTimeout,
 		MaxConcurrentReconciles: maxConcurrentReconciles,
+		CertRotation:            certRotation,
+		CertRenewBefore:         certRenewBefore,
+		RotateCA:                rotateCA,
+		CATrustWindow:           caTrustWindow,
+		EncryptionKeyRotation:   encryptionKeyRotation,
 	}).SetupWithManager(mgr); err != nil {
 		log.Error(err, "unable to register controllers to the manager")
 		os.Exit(1)
@@ -179,6 +210,22 @@ func main() {
 
 	if enableWebhook {
 		log.Info("setting up webhooks")
+		switch webhookCertMode {
+		case vcwebhook.CertModeSelfSigned, vcwebhook.CertModeSecret, vcwebhook.CertModeCertManager:
+		default:
+			log.Error(fmt.Errorf("unknown webhook cert mode %s", webhookCertMode), "unable to set up webhooks")
+			os.Exit(1)
+		}
+		if _, err := utilnet.ParsePortRange(superNodePortRange); err != nil {
+			log.Error(err, "invalid node port range of the super cluster")
+			os.Exit(1)
+		}
+		tenancyv1alpha1.SuperClusterNodePortRange = superNodePortRange
+		vcwebhook.WebhookCertOptions = vcwebhook.CertOptions{
+			Mode:        webhookCertMode,
+			SecretName:  webhookCertSecret,
+			RenewBefore: webhookCertRenewBefore,
+		}
 		if err := webhook.AddToManager(mgr, mgrOpt.CertDir); err != nil {
 			log.Error(err, "unable to register webhooks to the manager")
 			os.Exit(1)
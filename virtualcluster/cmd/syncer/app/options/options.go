@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -29,6 +30,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/client-go/informers"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -47,6 +49,8 @@ import (
 	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
 	vcinformers "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions"
 	syncerconfig "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/resources/event"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
 )
@@ -69,6 +73,8 @@ type ResourceSyncerOptions struct {
 	CertFile            string
 	KeyFile             string
 	DNSOptions          map[string]string
+	// GenericSyncingConfig is the file listing the resources synced by the generic syncer.
+	GenericSyncingConfig string
 }
 
 // NewResourceSyncerOptions creates a new resource syncer with a default config.
@@ -92,9 +98,12 @@ func NewResourceSyncerOptions() (*ResourceSyncerOptions, error) {
 			ExtraSyncingResources:      []string{},
 			ExtraNodeLabels:            []string{},
 			OpaqueTaintKeys:            []string{},
+			EventInvolvedObjectKinds:   event.DefaultInvolvedObjectKinds(),
+			EventReasons:               []string{},
 			VNAgentPort:                int32(10550),
 			VNAgentNamespacedName:      "vc-manager/vn-agent",
 			VNAgentLabelSelector:       "app=vn-agent",
+			NamespaceNamingStrategy:    conversion.NamespaceNamingPrefix,
 			FeatureGates: map[string]bool{
 				featuregate.SuperClusterPooling:        false,
 				featuregate.SuperClusterServiceNetwork: false,
@@ -126,15 +135,21 @@ func (o *ResourceSyncerOptions) Flags() cliflag.NamedFlagSets {
 	fs.BoolVar(&o.ComponentConfig.DisableServiceAccountToken, "disable-service-account-token", o.ComponentConfig.DisableServiceAccountToken, "DisableServiceAccountToken indicates whether to disable super cluster service account tokens being auto generated and mounted in vc pods.")
 	fs.BoolVar(&o.ComponentConfig.DisablePodServiceLinks, "disable-service-links", o.ComponentConfig.DisablePodServiceLinks, "DisablePodServiceLinks indicates whether to disable the `EnableServiceLinks` field in pPod spec.")
 	fs.StringSliceVar(&o.ComponentConfig.DefaultOpaqueMetaDomains, "default-opaque-meta-domains", o.ComponentConfig.DefaultOpaqueMetaDomains, "DefaultOpaqueMetaDomains is the default opaque meta configuration for each Virtual Cluster.")
-	fs.StringSliceVar(&o.ComponentConfig.ExtraSyncingResources, "extra-syncing-resources", o.ComponentConfig.ExtraSyncingResources, "ExtraSyncingResources defines additional resources that need to be synced for each Virtual Cluster. (priorityclass, ingress, crd)")
+	fs.StringSliceVar(&o.ComponentConfig.ExtraSyncingResources, "extra-syncing-resources", o.ComponentConfig.ExtraSyncingResources, "ExtraSyncingResources defines additional resources that need to be synced for each Virtual Cluster. (priorityclass, ingress, crd, resourcequota, limitrange)")
 	fs.Var(cliflag.NewMapStringBool(&o.ComponentConfig.FeatureGates), "feature-gates", "A set of key=value pairs that describe feature gates for various features."+
 		"Options are:\n"+strings.Join(featuregate.DefaultFeatureGate.KnownFeatures(), "\n"))
 	fs.StringSliceVar(&o.ComponentConfig.ExtraNodeLabels, "extra-node-labels", o.ComponentConfig.ExtraNodeLabels, "ExtraNodeLabels defines additional node labels that need to be synced for each Virtual Cluster")
 	fs.StringSliceVar(&o.ComponentConfig.OpaqueTaintKeys, "opaque-taint-keys", o.ComponentConfig.OpaqueTaintKeys, "OpaqueTaintKeys defines taint keys that need to be synced for each Virtual Cluster")
+	fs.StringSliceVar(&o.ComponentConfig.EventInvolvedObjectKinds, "event-involved-object-kinds", o.ComponentConfig.EventInvolvedObjectKinds, "EventInvolvedObjectKinds defines the kinds of the involved objects whose events are back populated to each Virtual Cluster. "+
+		"Options are: "+strings.Join(event.SupportedInvolvedObjectKinds(), ", "))
+	fs.StringSliceVar(&o.ComponentConfig.EventReasons, "event-reasons", o.ComponentConfig.EventReasons, "EventReasons defines the reasons of the events back populated to each Virtual Cluster, all reasons are back populated if it is empty")
 	fs.Int32Var(&o.ComponentConfig.VNAgentPort, "vn-agent-port", 10550, "Port the vn-agent listens on")
 	fs.StringVar(&o.ComponentConfig.VNAgentNamespacedName, "vn-agent-namespace-name", "vc-manager/vn-agent", "Namespace/Name of the vn-agent running in cluster, used for VNodeProviderService")
 	fs.Var(cliflag.NewMapStringString(&o.DNSOptions), "dns-options", "DNSOptions is the default DNS options attached to each pod")
 	fs.StringVar(&o.ComponentConfig.VNAgentLabelSelector, "vn-agent-label-selector", "app=vn-agent", "Label key=value of the vn-agent running in cluster, used for VNodeProviderPodIP")
+	fs.StringVar(&o.GenericSyncingConfig, "generic-syncing-config", o.GenericSyncingConfig, "Path to a YAML file with the list of resources synced by the generic syncer, e.g., custom resources without a dedicated syncer.")
+	fs.StringVar(&o.ComponentConfig.NamespaceNamingStrategy, "namespace-naming-strategy", o.ComponentConfig.NamespaceNamingStrategy, "The strategy that maps tenant namespaces to super cluster namespaces, must match the vn-agent setting. "+
+		"Options are: "+strings.Join(conversion.NamespaceNamingStrategyNames(), ", "))
 
 	serverFlags := fss.FlagSet("metricsServer")
 	serverFlags.StringVar(&o.Address, "address", o.Address, "The server address.")
@@ -244,10 +259,21 @@ func (o *ResourceSyncerOptions) Config() (*syncerappconfig.Config, error) {
 		return nil, err
 	}
 
+	if err := conversion.SetNamespaceNamingStrategy(c.ComponentConfig.NamespaceNamingStrategy); err != nil {
+		return nil, err
+	}
+
 	// Setup Scheme for all resources
 	if err := apis.AddToScheme(scheme.Scheme); err != nil {
 		return nil, err
 	}
+	if o.GenericSyncingConfig != "" {
+		c.ComponentConfig.GenericSyncingResources, err = loadGenericSyncingResources(o.GenericSyncingConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	c.ComponentConfig.RestConfig = superRestConfig
 	c.ComponentConfig.DNSOptions = dnsOptionsConvert(o.DNSOptions)
 	c.VirtualClusterClient = virtualClusterClient
@@ -268,6 +294,21 @@ func (o *ResourceSyncerOptions) Config() (*syncerappconfig.Config, error) {
 	return c, nil
 }
 
+// loadGenericSyncingResources reads the list of resources synced by the generic syncer from a YAML or JSON file.
+func loadGenericSyncingResources(path string) ([]syncerconfig.GenericSyncingResource, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open generic syncing config: %v", err)
+	}
+	defer f.Close()
+
+	var resources []syncerconfig.GenericSyncingResource
+	if err := yaml.NewYAMLOrJSONDecoder(f, 4096).Decode(&resources); err != nil {
+		return nil, fmt.Errorf("failed to decode generic syncing config %s: %v", path, err)
+	}
+	return resources, nil
+}
+
 // makeLeaderElectionConfig builds a leader election configuration. It will
 // create a new resource lock associated with the configuration.
 func makeLeaderElectionConfig(config syncerconfig.SyncerLeaderElectionConfiguration, client clientset.Interface, recorder record.EventRecorder, syncername string) (*leaderelection.LeaderElectionConfig, error) {
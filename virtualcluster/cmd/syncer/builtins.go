@@ -20,11 +20,13 @@ import (
 	_ "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/resources/configmap"
 	_ "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/resources/endpoints"
 	_ "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/resources/event"
+	_ "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/resources/generic"
 	_ "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/resources/namespace"
 	_ "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/resources/node"
 	_ "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/resources/persistentvolume"
 	_ "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/resources/persistentvolumeclaim"
 	_ "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/resources/pod"
+	_ "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/resources/pod/validationplugin/resourceceiling"
 	_ "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/resources/secret"
 	_ "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/resources/service"
 	_ "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/resources/serviceaccount"
@@ -20,10 +20,12 @@ import (
 	"crypto/tls"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/pkg/errors"
 	cliflag "k8s.io/component-base/cli/flag"
 
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/vn-agent/config"
 )
@@ -62,6 +64,10 @@ type ServerOption struct {
 
 	// FeatureGates enabled by the user.
 	FeatureGates map[string]bool
+
+	// NamespaceNamingStrategy is the strategy that maps tenant namespaces to super cluster
+	// namespaces, it must be the same as the one used by the syncer.
+	NamespaceNamingStrategy string
 }
 
 // KubeletClientConfig is a subset of the full options exposed in k8s.io/kubernetes/pkg/kubelet/client.KubeletClientConfig
@@ -79,7 +85,8 @@ func NewVnAgentOptions() (*Options, error) {
 	return &Options{
 		KubeletOption: KubeletClientConfig{},
 		ServerOption: ServerOption{
-			FeatureGates: map[string]bool{},
+			FeatureGates:            map[string]bool{},
+			NamespaceNamingStrategy: conversion.NamespaceNamingPrefix,
 		},
 	}, nil
 }
@@ -98,6 +105,8 @@ func (o *Options) Flags() cliflag.NamedFlagSets {
 	serverFS.StringVar(&o.MetricsAddr, "metrics-addr", ":9100", "Bind address for the metrics server.")
 	serverFS.BoolVar(&o.EnableMetrics, "enable-metrics", true, "Enable metrics server.")
 	serverFS.Var(cliflag.NewMapStringBool(&o.ServerOption.FeatureGates), "feature-gates", "A set of key=value pairs that describe featuregate gates for various features.")
+	serverFS.StringVar(&o.NamespaceNamingStrategy, "namespace-naming-strategy", o.NamespaceNamingStrategy, "The strategy that maps tenant namespaces to super cluster namespaces, must match the syncer setting. "+
+		"Options are: "+strings.Join(conversion.NamespaceNamingStrategyNames(), ", "))
 
 	kubeletFS := fss.FlagSet("kubelet")
 	kubeletFS.StringVar(&o.KubeletOption.CertFile, "kubelet-client-certificate", o.KubeletOption.CertFile, "Path to a client cert file for TLS")
@@ -117,6 +126,10 @@ func fileNotExistOrEmpty(fn string) bool {
 
 // Config is the config to create a vn-agent server handler.
 func (o *Options) Config() (*config.Config, *ServerOption, error) {
+	if err := conversion.SetNamespaceNamingStrategy(o.NamespaceNamingStrategy); err != nil {
+		return nil, nil, err
+	}
+
 	// vc-kubelet-client may be a place holder that contains empty certificate and key data
 	if fileNotExistOrEmpty(o.KubeletOption.CertFile) || fileNotExistOrEmpty(o.KubeletOption.KeyFile) {
 		return &config.Config{KubeletClientCert: nil}, &o.ServerOption, nil
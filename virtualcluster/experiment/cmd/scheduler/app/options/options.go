@@ -82,6 +82,7 @@ func (o *SchedulerOptions) Flags() cliflag.NamedFlagSets {
 	fs := fss.FlagSet("server")
 	fs.StringVar(&o.MetaCluster, "meta-cluster", o.MetaCluster, "The address of the meta cluster Kubernetes APIServer (overrides any value in meta-cluster-kubeconfig).")
 	fs.StringVar(&o.ComponentConfig.ClientConnection.Kubeconfig, "meta-master-kubeconfig", o.ComponentConfig.ClientConnection.Kubeconfig, "Path to kubeconfig file with authorization and meta cluster location information.")
+	fs.BoolVar(&o.ComponentConfig.EnablePodLevelScheduling, "enable-pod-level-scheduling", o.ComponentConfig.EnablePodLevelScheduling, "Schedule the pods in namespaces without ResourceQuota to super clusters individually using their container requests.")
 
 	BindFlags(&o.ComponentConfig.LeaderElection, fss.FlagSet("leader election"))
 
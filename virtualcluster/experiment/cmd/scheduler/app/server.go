@@ -149,6 +149,7 @@ func startScheduler(s *scheduler.Scheduler, stopCh <-chan struct{}) func(context
 			metrics.Register()
 			mux := http.NewServeMux()
 			mux.Handle("/metrics", promhttp.Handler())
+			s.InstallIntrospectionHandlers(mux)
 			address := net.JoinHostPort("", "80")
 			klog.Fatal(http.ListenAndServe(address, mux))
 		}()
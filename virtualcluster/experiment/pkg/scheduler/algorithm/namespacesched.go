@@ -87,10 +87,10 @@ func fitSlice(request corev1.ResourceList, cluster *internalcache.ClusterUsage)
 
 // SchedulePod checks snapshot and returns cluster name that fits the pod
 func SchedulePod(pod *internalcache.Pod, snapshot *internalcache.PodSchedSnapshot) (string, error) {
-	var err error
+	err := fmt.Errorf("no cluster is available for pod %s", pod.GetKey())
 	// First fit
 	for name, cluster := range snapshot.GetClusterUsageMap() {
-		if err := fitSlice(pod.GetRequest(), cluster); err == nil {
+		if err = fitSlice(pod.GetRequest(), cluster); err == nil {
 			return name, nil
 		}
 	}
@@ -35,6 +35,10 @@ type SchedulerConfiguration struct {
 
 	// Super control plane rest config
 	RestConfig *rest.Config
+
+	// EnablePodLevelScheduling allows the pods in namespaces without ResourceQuota to be
+	// placed to the super clusters one by one based on their container requests.
+	EnablePodLevelScheduling bool
 }
 
 // SchedulerLeaderElectionConfiguration expands LeaderElectionConfiguration
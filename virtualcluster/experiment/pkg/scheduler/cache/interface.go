@@ -37,5 +37,7 @@ type Cache interface {
 	UpdateClusterCapacity(string, corev1.ResourceList) error
 	SnapshotForNamespaceSched(...*Namespace) (*NamespaceSchedSnapshot, error)
 	SnapshotForPodSched(pod *Pod) (*PodSchedSnapshot, error)
+	SnapshotForUnquotedPodSched(pod *Pod) (*PodSchedSnapshot, error)
 	Dump() string
+	View() *View
 }
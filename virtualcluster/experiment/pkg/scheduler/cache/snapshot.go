@@ -99,13 +99,14 @@ func (c *schedulerCache) SnapshotForNamespaceSched(nsToRemove ...*Namespace) (*N
 	defer c.mu.Unlock()
 
 	s := NewNamespaceSchedSnapshot()
+	unquotedUsage := c.unquotedPodUsage("")
 	for n, cluster := range c.clusters {
 		if cluster.shadow {
 			continue
 		}
 		s.clusterUsageMap[n] = &ClusterUsage{
 			capacity:  cluster.capacity.DeepCopy(),
-			alloc:     cluster.alloc.DeepCopy(),
+			alloc:     addResourceList(cluster.alloc, unquotedUsage[n]),
 			provision: cluster.provision.DeepCopy(),
 		}
 	}
@@ -225,3 +226,66 @@ func (c *schedulerCache) SnapshotForPodSched(pod *Pod) (*PodSchedSnapshot, error
 
 	return s, nil
 }
+
+func addResourceList(a, b corev1.ResourceList) corev1.ResourceList {
+	ret := a.DeepCopy()
+	for k, v := range b {
+		val, ok := ret[k]
+		if !ok {
+			continue
+		}
+		val.Add(v)
+		ret[k] = val
+	}
+	return ret
+}
+
+// unquotedPodUsage accumulates the requests of the pods that are placed individually, i.e., the pods
+// whose namespaces are not sliced, per cluster. The pod with the skip key is excluded.
+func (c *schedulerCache) unquotedPodUsage(skip string) map[string]corev1.ResourceList {
+	usage := make(map[string]corev1.ResourceList)
+	for key, pod := range c.pods {
+		if key == skip {
+			continue
+		}
+		if _, ok := c.namespaces[pod.GetNamespaceKey()]; ok {
+			continue
+		}
+		cur, ok := usage[pod.cluster]
+		if !ok {
+			cur = corev1.ResourceList{}
+			usage[pod.cluster] = cur
+		}
+		for k, v := range pod.request {
+			val := cur[k]
+			val.Add(v)
+			cur[k] = val
+		}
+	}
+	return usage
+}
+
+// SnapshotForUnquotedPodSched returns the usage of all non-shadow clusters for placing a pod whose
+// namespace is not sliced. The allocation of a cluster includes the slices of the scheduled namespaces
+// as well as the requests of other individually placed pods.
+func (c *schedulerCache) SnapshotForUnquotedPodSched(pod *Pod) (*PodSchedSnapshot, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.namespaces[pod.GetNamespaceKey()]; ok {
+		return nil, fmt.Errorf("ns %s has been scheduled, pod %s cannot be placed individually", pod.GetNamespaceKey(), pod.GetKey())
+	}
+
+	s := NewPodSchedSnapshot()
+	unquotedUsage := c.unquotedPodUsage(pod.GetKey())
+	for n, cluster := range c.clusters {
+		if cluster.shadow {
+			continue
+		}
+		s.clusterUsageMap[n] = &ClusterUsage{
+			capacity: cluster.capacity.DeepCopy(),
+			alloc:    addResourceList(MaxAlloc(cluster.alloc, cluster.provision), unquotedUsage[n]),
+		}
+	}
+	return s, nil
+}
@@ -192,3 +192,87 @@ func TestSnapshotForNamespaceSched(t *testing.T) {
 		})
 	}
 }
+
+func TestSnapshotForUnquotedPodSched(t *testing.T) {
+	defaultCapacity := corev1.ResourceList{
+		"cpu":    resource.MustParse("4"),
+		"memory": resource.MustParse("8Gi"),
+	}
+
+	defaultQuota := corev1.ResourceList{
+		"cpu":    resource.MustParse("1"),
+		"memory": resource.MustParse("2Gi"),
+	}
+
+	defaultQuotaSlice := corev1.ResourceList{
+		"cpu":    resource.MustParse("0.5"),
+		"memory": resource.MustParse("1Gi"),
+	}
+
+	podRequest := corev1.ResourceList{
+		"cpu":    resource.MustParse("1"),
+		"memory": resource.MustParse("1Gi"),
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	cache := NewSchedulerCache(stop).(*schedulerCache)
+	cache.AddTenant(defaultTenant)
+
+	if err := cache.AddCluster(NewCluster(defaultCluster1, nil, defaultCapacity)); err != nil {
+		t.Fatalf("failed to add cluster %s", defaultCluster1)
+	}
+	if err := cache.AddCluster(NewCluster(defaultCluster2, nil, defaultCapacity)); err != nil {
+		t.Fatalf("failed to add cluster %s", defaultCluster2)
+	}
+	if err := cache.AddNamespace(NewNamespace(defaultTenant, defaultNamespace, nil, defaultQuota, defaultQuotaSlice,
+		[]*Placement{NewPlacement(defaultCluster1, 2)})); err != nil {
+		t.Fatalf("failed to add namespace: %v", err)
+	}
+
+	unquoted := "unquoted"
+	if err := cache.AddPod(NewPod(defaultTenant, unquoted, "pod-1", defaultCluster1, podRequest)); err != nil {
+		t.Fatalf("failed to add pod: %v", err)
+	}
+	if err := cache.AddPod(NewPod(defaultTenant, unquoted, "pod-2", defaultCluster2, podRequest)); err != nil {
+		t.Fatalf("failed to add pod: %v", err)
+	}
+
+	if _, err := cache.SnapshotForUnquotedPodSched(NewPod(defaultTenant, defaultNamespace, "pod", "", podRequest)); err == nil {
+		t.Errorf("pods in a sliced namespace should not be placed individually")
+	}
+
+	// pod-2 is rescheduled, hence excluded from the snapshot.
+	s, err := cache.SnapshotForUnquotedPodSched(NewPod(defaultTenant, unquoted, "pod-2", "", podRequest))
+	if err != nil {
+		t.Fatalf("failed to get snapshot: %v", err)
+	}
+	expected := map[string]corev1.ResourceList{
+		defaultCluster1: {
+			"cpu":    resource.MustParse("2"),
+			"memory": resource.MustParse("3Gi"),
+		},
+		defaultCluster2: {
+			"cpu":    resource.MustParse("0"),
+			"memory": resource.MustParse("0"),
+		},
+	}
+	for cluster, alloc := range expected {
+		usage, ok := s.GetClusterUsageMap()[cluster]
+		if !ok {
+			t.Fatalf("cluster %s is missing from the snapshot", cluster)
+		}
+		if !Equals(alloc, usage.GetMaxAlloc()) {
+			t.Errorf("unexpected alloc of cluster %s. Exp: %v, Got %v", cluster, alloc, usage.GetMaxAlloc())
+		}
+	}
+
+	// individually placed pods are counted when scheduling namespaces.
+	ns, err := cache.SnapshotForNamespaceSched()
+	if err != nil {
+		t.Fatalf("failed to get namespace snapshot: %v", err)
+	}
+	if !Equals(ns.GetClusterUsageMap()[defaultCluster2].GetMaxAlloc(), podRequest) {
+		t.Errorf("unexpected alloc of cluster %s: %v", defaultCluster2, ns.GetClusterUsageMap()[defaultCluster2].GetMaxAlloc())
+	}
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterView is the read-only representation of a super cluster in the scheduler cache.
+type ClusterView struct {
+	Name           string              `json:"name"`
+	Labels         map[string]string   `json:"labels,omitempty"`
+	Shadow         bool                `json:"shadow"`
+	Capacity       corev1.ResourceList `json:"capacity"`
+	Alloc          corev1.ResourceList `json:"alloc"`
+	Provision      corev1.ResourceList `json:"provision"`
+	AllocItems     map[string]int      `json:"allocItems,omitempty"`     // ns key -> number of slices
+	ProvisionItems map[string]int      `json:"provisionItems,omitempty"` // ns key -> number of slices
+	Pods           map[string][]string `json:"pods,omitempty"`           // ns key -> pod names
+	LastUpdateTime metav1.Time         `json:"lastUpdateTime"`
+}
+
+// PlacementView is the read-only representation of a namespace placement.
+type PlacementView struct {
+	Cluster string `json:"cluster"`
+	Num     int    `json:"num"`
+}
+
+// NamespaceView is the read-only representation of a tenant namespace in the scheduler cache.
+type NamespaceView struct {
+	Key        string              `json:"key"`
+	Owner      string              `json:"owner"`
+	Name       string              `json:"name"`
+	Labels     map[string]string   `json:"labels,omitempty"`
+	Quota      corev1.ResourceList `json:"quota"`
+	QuotaSlice corev1.ResourceList `json:"quotaSlice"`
+	Slices     int                 `json:"slices"`
+	Placements []PlacementView     `json:"placements,omitempty"`
+}
+
+// PodView is the read-only representation of a tenant pod in the scheduler cache.
+type PodView struct {
+	Key       string              `json:"key"`
+	Owner     string              `json:"owner"`
+	Namespace string              `json:"namespace"`
+	Name      string              `json:"name"`
+	Cluster   string              `json:"cluster"`
+	Request   corev1.ResourceList `json:"request"`
+}
+
+// View is a point-in-time copy of the scheduler cache. The items are sorted by key.
+type View struct {
+	Clusters   []ClusterView   `json:"clusters"`
+	Namespaces []NamespaceView `json:"namespaces"`
+	Pods       []PodView       `json:"pods"`
+}
+
+func countSlices(items map[string][]*Slice) map[string]int {
+	if len(items) == 0 {
+		return nil
+	}
+	ret := make(map[string]int, len(items))
+	for k, v := range items {
+		ret[k] = len(v)
+	}
+	return ret
+}
+
+func (c *Cluster) View() ClusterView {
+	var labels map[string]string
+	if c.labels != nil {
+		labels = make(map[string]string, len(c.labels))
+		for k, v := range c.labels {
+			labels[k] = v
+		}
+	}
+	var pods map[string][]string
+	if len(c.pods) != 0 {
+		pods = make(map[string][]string, len(c.pods))
+		for k, v := range c.pods {
+			names := make([]string, 0, len(v))
+			for name := range v {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			pods[k] = names
+		}
+	}
+	return ClusterView{
+		Name:           c.name,
+		Labels:         labels,
+		Shadow:         c.shadow,
+		Capacity:       c.capacity.DeepCopy(),
+		Alloc:          c.alloc.DeepCopy(),
+		Provision:      c.provision.DeepCopy(),
+		AllocItems:     countSlices(c.allocItems),
+		ProvisionItems: countSlices(c.provisionItems),
+		Pods:           pods,
+		LastUpdateTime: c.lastUpdateTime,
+	}
+}
+
+func (n *Namespace) View() NamespaceView {
+	var labels map[string]string
+	if n.labels != nil {
+		labels = make(map[string]string, len(n.labels))
+		for k, v := range n.labels {
+			labels[k] = v
+		}
+	}
+	placements := make([]PlacementView, 0, len(n.schedule))
+	for _, each := range n.schedule {
+		placements = append(placements, PlacementView{Cluster: each.cluster, Num: each.num})
+	}
+	sort.Slice(placements, func(i, j int) bool { return placements[i].Cluster < placements[j].Cluster })
+	return NamespaceView{
+		Key:        n.GetKey(),
+		Owner:      n.owner,
+		Name:       n.name,
+		Labels:     labels,
+		Quota:      n.quota.DeepCopy(),
+		QuotaSlice: n.quotaSlice.DeepCopy(),
+		Slices:     n.GetTotalSlices(),
+		Placements: placements,
+	}
+}
+
+func (p *Pod) View() PodView {
+	return PodView{
+		Key:       p.GetKey(),
+		Owner:     p.owner,
+		Namespace: p.namespace,
+		Name:      p.name,
+		Cluster:   p.cluster,
+		Request:   p.request.DeepCopy(),
+	}
+}
+
+func (c *schedulerCache) View() *View {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	v := &View{
+		Clusters:   make([]ClusterView, 0, len(c.clusters)),
+		Namespaces: make([]NamespaceView, 0, len(c.namespaces)),
+		Pods:       make([]PodView, 0, len(c.pods)),
+	}
+	for _, each := range c.clusters {
+		v.Clusters = append(v.Clusters, each.View())
+	}
+	for _, each := range c.namespaces {
+		v.Namespaces = append(v.Namespaces, each.View())
+	}
+	for _, each := range c.pods {
+		v.Pods = append(v.Pods, each.View())
+	}
+	sort.Slice(v.Clusters, func(i, j int) bool { return v.Clusters[i].Name < v.Clusters[j].Name })
+	sort.Slice(v.Namespaces, func(i, j int) bool { return v.Namespaces[i].Key < v.Namespaces[j].Key })
+	sort.Slice(v.Pods, func(i, j int) bool { return v.Pods[i].Key < v.Pods[j].Key })
+	return v
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestView(t *testing.T) {
+	defaultCapacity := corev1.ResourceList{
+		"cpu":    resource.MustParse("4"),
+		"memory": resource.MustParse("8Gi"),
+	}
+
+	defaultQuota := corev1.ResourceList{
+		"cpu":    resource.MustParse("2"),
+		"memory": resource.MustParse("4Gi"),
+	}
+
+	defaultQuotaSlice := corev1.ResourceList{
+		"cpu":    resource.MustParse("0.5"),
+		"memory": resource.MustParse("1Gi"),
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	cache := NewSchedulerCache(stop).(*schedulerCache)
+	cache.AddTenant(defaultTenant)
+
+	if err := cache.AddCluster(NewCluster(defaultCluster2, nil, defaultCapacity)); err != nil {
+		t.Fatalf("failed to add cluster %s: %v", defaultCluster2, err)
+	}
+	if err := cache.AddCluster(NewCluster(defaultCluster1, map[string]string{"zone": "a"}, defaultCapacity)); err != nil {
+		t.Fatalf("failed to add cluster %s: %v", defaultCluster1, err)
+	}
+	namespace := NewNamespace(defaultTenant, defaultNamespace, nil, defaultQuota, defaultQuotaSlice,
+		[]*Placement{
+			NewPlacement(defaultCluster2, 1),
+			NewPlacement(defaultCluster1, 3),
+		})
+	if err := cache.AddNamespace(namespace); err != nil {
+		t.Fatalf("failed to add namespace: %v", err)
+	}
+	pod := NewPod(defaultTenant, defaultNamespace, "pod-1", defaultCluster1, corev1.ResourceList{
+		"cpu":    resource.MustParse("0.5"),
+		"memory": resource.MustParse("1Gi"),
+	})
+	if err := cache.AddPod(pod); err != nil {
+		t.Fatalf("failed to add pod: %v", err)
+	}
+
+	view := cache.View()
+
+	if len(view.Clusters) != 2 || view.Clusters[0].Name != defaultCluster1 || view.Clusters[1].Name != defaultCluster2 {
+		t.Fatalf("unexpected clusters in view: %+v", view.Clusters)
+	}
+	c1 := view.Clusters[0]
+	if c1.Labels["zone"] != "a" {
+		t.Errorf("expect cluster labels to be copied, got %v", c1.Labels)
+	}
+	if c1.AllocItems[namespace.GetKey()] != 3 {
+		t.Errorf("expect 3 slices in %s, got %v", defaultCluster1, c1.AllocItems)
+	}
+	if !Equals(c1.Alloc, corev1.ResourceList{"cpu": resource.MustParse("1.5"), "memory": resource.MustParse("3Gi")}) {
+		t.Errorf("unexpected alloc of %s: %v", defaultCluster1, c1.Alloc)
+	}
+	if !reflect.DeepEqual(c1.Pods, map[string][]string{namespace.GetKey(): {"pod-1"}}) {
+		t.Errorf("unexpected pods of %s: %v", defaultCluster1, c1.Pods)
+	}
+
+	if len(view.Namespaces) != 1 {
+		t.Fatalf("expect 1 namespace in view, got %d", len(view.Namespaces))
+	}
+	ns := view.Namespaces[0]
+	if ns.Key != namespace.GetKey() || ns.Slices != 4 {
+		t.Errorf("unexpected namespace view: %+v", ns)
+	}
+	expectedPlacements := []PlacementView{{Cluster: defaultCluster1, Num: 3}, {Cluster: defaultCluster2, Num: 1}}
+	if !reflect.DeepEqual(ns.Placements, expectedPlacements) {
+		t.Errorf("expect placements %v, got %v", expectedPlacements, ns.Placements)
+	}
+
+	if len(view.Pods) != 1 || view.Pods[0].Key != pod.GetKey() || view.Pods[0].Cluster != defaultCluster1 {
+		t.Errorf("unexpected pods in view: %+v", view.Pods)
+	}
+
+	// mutating the view must not change the cache.
+	view.Clusters[0].Alloc["cpu"] = resource.MustParse("100")
+	if !Equals(cache.clusters[defaultCluster1].alloc, corev1.ResourceList{"cpu": resource.MustParse("1.5"), "memory": resource.MustParse("3Gi")}) {
+		t.Errorf("cache is mutated through the view")
+	}
+
+	if _, err := json.Marshal(view); err != nil {
+		t.Errorf("failed to marshal view: %v", err)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"sync"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2"
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/scheduler/algorithm"
@@ -33,7 +34,17 @@ type Engine interface {
 	EnsureNamespacePlacements(*internalcache.Namespace) error
 	DeScheduleNamespace(key string) error
 	SchedulePod(pod *internalcache.Pod) (*internalcache.Pod, error)
+	SchedulePodInUnquotedNamespace(pod *internalcache.Pod) (*internalcache.Pod, error)
 	DeSchedulePod(key string) error
+	GetNamespaceDecision(key string) *Decision
+}
+
+// Decision records the outcome of the last scheduling attempt of a namespace.
+type Decision struct {
+	Namespace  string         `json:"namespace"`
+	Placements map[string]int `json:"placements,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	Time       metav1.Time    `json:"time"`
 }
 
 var _ Engine = &schedulerEngine{}
@@ -42,11 +53,54 @@ type schedulerEngine struct {
 	mu sync.RWMutex
 
 	cache internalcache.Cache
+
+	decisionLock sync.RWMutex
+	decisions    map[string]*Decision // ns key -> last scheduling decision
 }
 
 // NewSchedulerEngine creates new instance of Engine with cache
 func NewSchedulerEngine(schedulerCache internalcache.Cache) Engine {
-	return &schedulerEngine{cache: schedulerCache}
+	return &schedulerEngine{
+		cache:     schedulerCache,
+		decisions: make(map[string]*Decision),
+	}
+}
+
+func (e *schedulerEngine) recordNamespaceDecision(key string, placements map[string]int, err error) {
+	d := &Decision{
+		Namespace: key,
+		Time:      metav1.Now(),
+	}
+	if err != nil {
+		d.Error = err.Error()
+	} else {
+		d.Placements = make(map[string]int, len(placements))
+		for k, v := range placements {
+			d.Placements[k] = v
+		}
+	}
+	e.decisionLock.Lock()
+	defer e.decisionLock.Unlock()
+	e.decisions[key] = d
+}
+
+// GetNamespaceDecision returns a copy of the last scheduling decision of the namespace, or nil if
+// the namespace has not been scheduled by this engine.
+func (e *schedulerEngine) GetNamespaceDecision(key string) *Decision {
+	e.decisionLock.RLock()
+	defer e.decisionLock.RUnlock()
+	d, ok := e.decisions[key]
+	if !ok {
+		return nil
+	}
+	clone := *d
+	if d.Placements != nil {
+		clone.Placements = make(map[string]int, len(d.Placements))
+		for k, v := range d.Placements {
+			clone.Placements[k] = v
+		}
+	}
+	return &clone
 }
 
 // GetSlicesToSchedule retrieve all slices and return unscheduled
@@ -102,6 +156,16 @@ func (e *schedulerEngine) ScheduleNamespace(namespace *internalcache.Namespace)
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
+	ret, err := e.scheduleNamespace(namespace)
+	if err != nil {
+		e.recordNamespaceDecision(namespace.GetKey(), nil, err)
+	} else {
+		e.recordNamespaceDecision(namespace.GetKey(), ret.GetPlacementMap(), nil)
+	}
+	return ret, err
+}
+
+func (e *schedulerEngine) scheduleNamespace(namespace *internalcache.Namespace) (*internalcache.Namespace, error) {
 	// The namespace may already exist in cache. The reasons could be:
 	// 1. it was scheduled successfully but the result was failed to be updated in tenant namespace;
 	// 2. it is rescheduled due to the namespace quota change or previous placement results were manually modified;
@@ -146,6 +210,11 @@ func (e *schedulerEngine) ScheduleNamespace(namespace *internalcache.Namespace)
 func (e *schedulerEngine) DeScheduleNamespace(key string) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
+
+	e.decisionLock.Lock()
+	delete(e.decisions, key)
+	e.decisionLock.Unlock()
+
 	if ns := e.cache.GetNamespace(key); ns != nil {
 		return e.cache.RemoveNamespace(ns)
 	}
@@ -194,6 +263,30 @@ func (e *schedulerEngine) SchedulePod(pod *internalcache.Pod) (*internalcache.Po
 	return ret, err
 }
 
+// SchedulePodInUnquotedNamespace places a pod whose namespace has no quota, hence is not sliced,
+// to any super cluster that fits the pod requests.
+func (e *schedulerEngine) SchedulePodInUnquotedNamespace(pod *internalcache.Pod) (*internalcache.Pod, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	snapshot, err := e.cache.SnapshotForUnquotedPodSched(pod)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := algorithm.SchedulePod(pod, snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := pod.DeepCopy()
+	ret.SetCluster(result)
+
+	err = e.cache.AddPod(ret)
+
+	return ret, err
+}
+
 func (e *schedulerEngine) DeSchedulePod(key string) error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
@@ -188,3 +188,92 @@ func TestGetSlicesToSchedule(t *testing.T) {
 		})
 	}
 }
+
+func TestNamespaceDecision(t *testing.T) {
+	capacity := corev1.ResourceList{
+		"cpu":    resource.MustParse("2"),
+		"memory": resource.MustParse("2Gi"),
+	}
+	quotaSlice := corev1.ResourceList{
+		"cpu":    resource.MustParse("1"),
+		"memory": resource.MustParse("1Gi"),
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	cache := internalcache.NewSchedulerCache(stop)
+	cache.AddTenant("tenant")
+	if err := cache.AddCluster(internalcache.NewCluster("cluster1", nil, capacity)); err != nil {
+		t.Fatalf("failed to add cluster: %v", err)
+	}
+	e := NewSchedulerEngine(cache)
+
+	fit := internalcache.NewNamespace("tenant", "fit", nil, capacity, quotaSlice, nil)
+	if e.GetNamespaceDecision(fit.GetKey()) != nil {
+		t.Errorf("expect no decision before scheduling")
+	}
+	if _, err := e.ScheduleNamespace(fit); err != nil {
+		t.Fatalf("failed to schedule namespace: %v", err)
+	}
+	d := e.GetNamespaceDecision(fit.GetKey())
+	if d == nil || d.Error != "" || !reflect.DeepEqual(d.Placements, map[string]int{"cluster1": 2}) {
+		t.Errorf("unexpected decision %+v", d)
+	}
+
+	tooLarge := internalcache.NewNamespace("tenant", "toolarge", nil, capacity, quotaSlice, nil)
+	if _, err := e.ScheduleNamespace(tooLarge); err == nil {
+		t.Fatalf("expect scheduling to fail since the cluster is full")
+	}
+	d = e.GetNamespaceDecision(tooLarge.GetKey())
+	if d == nil || d.Error == "" || d.Placements != nil {
+		t.Errorf("expect a failed decision, got %+v", d)
+	}
+
+	if err := e.DeScheduleNamespace(fit.GetKey()); err != nil {
+		t.Fatalf("failed to deschedule namespace: %v", err)
+	}
+	if e.GetNamespaceDecision(fit.GetKey()) != nil {
+		t.Errorf("expect decision to be removed after deschedule")
+	}
+}
+
+func TestSchedulePodInUnquotedNamespace(t *testing.T) {
+	capacity := corev1.ResourceList{
+		"cpu":    resource.MustParse("2"),
+		"memory": resource.MustParse("2Gi"),
+	}
+	request := corev1.ResourceList{
+		"cpu":    resource.MustParse("1"),
+		"memory": resource.MustParse("1Gi"),
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	cache := internalcache.NewSchedulerCache(stop)
+	cache.AddTenant("tenant")
+	if err := cache.AddCluster(internalcache.NewCluster("cluster1", nil, capacity)); err != nil {
+		t.Fatalf("failed to add cluster: %v", err)
+	}
+	e := NewSchedulerEngine(cache)
+
+	for _, name := range []string{"pod-1", "pod-2"} {
+		ret, err := e.SchedulePodInUnquotedNamespace(internalcache.NewPod("tenant", "default", name, "", request))
+		if err != nil {
+			t.Fatalf("failed to schedule pod %s: %v", name, err)
+		}
+		if ret.GetCluster() != "cluster1" {
+			t.Errorf("expect pod %s to be scheduled to cluster1, got %s", name, ret.GetCluster())
+		}
+	}
+
+	if _, err := e.SchedulePodInUnquotedNamespace(internalcache.NewPod("tenant", "default", "pod-3", "", request)); err == nil {
+		t.Errorf("expect scheduling to fail since the cluster is full")
+	}
+
+	if err := e.DeSchedulePod("tenant/default/pod-1"); err != nil {
+		t.Fatalf("failed to deschedule pod: %v", err)
+	}
+	if _, err := e.SchedulePodInUnquotedNamespace(internalcache.NewPod("tenant", "default", "pod-3", "", request)); err != nil {
+		t.Errorf("failed to schedule pod after releasing capacity: %v", err)
+	}
+}
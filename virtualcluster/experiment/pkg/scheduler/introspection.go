@@ -0,0 +1,107 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	internalcache "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/scheduler/cache"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/scheduler/engine"
+)
+
+const (
+	// IntrospectionCachePath serves the scheduler cache snapshot.
+	IntrospectionCachePath = "/introspection/cache"
+	// IntrospectionNamespacePath serves the scheduling state of one tenant namespace,
+	// addressed as <IntrospectionNamespacePath><cluster key>/<namespace>.
+	IntrospectionNamespacePath = "/introspection/namespaces/"
+)
+
+// NamespaceIntrospection is the scheduling state of a tenant namespace.
+type NamespaceIntrospection struct {
+	// Namespace is the cached namespace, nil if the namespace has no placements.
+	Namespace *internalcache.NamespaceView `json:"namespace,omitempty"`
+	// Pods are the cached pods of the namespace.
+	Pods []internalcache.PodView `json:"pods,omitempty"`
+	// LastDecision is the outcome of the last scheduling attempt of the namespace.
+	LastDecision *engine.Decision `json:"lastDecision,omitempty"`
+}
+
+// InstallIntrospectionHandlers registers the read-only introspection endpoints on the mux.
+func (s *Scheduler) InstallIntrospectionHandlers(mux *http.ServeMux) {
+	mux.HandleFunc(IntrospectionCachePath, s.serveCache)
+	mux.HandleFunc(IntrospectionNamespacePath, s.serveNamespace)
+}
+
+func (s *Scheduler) serveCache(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, s.schedulerCache.View())
+}
+
+func (s *Scheduler) serveNamespace(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, IntrospectionNamespacePath), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		http.Error(w, fmt.Sprintf("expect path %s<cluster>/<namespace>", IntrospectionNamespacePath), http.StatusBadRequest)
+		return
+	}
+	key := fmt.Sprintf("%s/%s", parts[0], parts[1])
+
+	ret := &NamespaceIntrospection{
+		LastDecision: s.schedulerEngine.GetNamespaceDecision(key),
+	}
+	view := s.schedulerCache.View()
+	for i := range view.Namespaces {
+		if view.Namespaces[i].Key == key {
+			ret.Namespace = &view.Namespaces[i]
+			break
+		}
+	}
+	for _, each := range view.Pods {
+		if each.Owner == parts[0] && each.Namespace == parts[1] {
+			ret.Pods = append(ret.Pods, each)
+		}
+	}
+	if ret.Namespace == nil && ret.LastDecision == nil && len(ret.Pods) == 0 {
+		http.Error(w, fmt.Sprintf("namespace %s is unknown to the scheduler", key), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, ret)
+}
+
+func writeJSON(w http.ResponseWriter, obj interface{}) {
+	b, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(b); err != nil {
+		klog.Warningf("failed to write introspection response: %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	internalcache "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/scheduler/cache"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/scheduler/engine"
+)
+
+func TestIntrospectionHandlers(t *testing.T) {
+	capacity := corev1.ResourceList{
+		"cpu":    resource.MustParse("2"),
+		"memory": resource.MustParse("2Gi"),
+	}
+	quotaSlice := corev1.ResourceList{
+		"cpu":    resource.MustParse("1"),
+		"memory": resource.MustParse("1Gi"),
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	s := &Scheduler{schedulerCache: internalcache.NewSchedulerCache(stop)}
+	s.schedulerEngine = engine.NewSchedulerEngine(s.schedulerCache)
+	s.schedulerCache.AddTenant("tenant")
+	if err := s.schedulerCache.AddCluster(internalcache.NewCluster("cluster1", nil, capacity)); err != nil {
+		t.Fatalf("failed to add cluster: %v", err)
+	}
+	if _, err := s.schedulerEngine.ScheduleNamespace(internalcache.NewNamespace("tenant", "ns", nil, capacity, quotaSlice, nil)); err != nil {
+		t.Fatalf("failed to schedule namespace: %v", err)
+	}
+	if _, err := s.schedulerEngine.ScheduleNamespace(internalcache.NewNamespace("tenant", "failed", nil, capacity, quotaSlice, nil)); err == nil {
+		t.Fatalf("expect scheduling to fail")
+	}
+
+	mux := http.NewServeMux()
+	s.InstallIntrospectionHandlers(mux)
+
+	testcases := map[string]struct {
+		method string
+		path   string
+		code   int
+		check  func(t *testing.T, body []byte)
+	}{
+		"cache": {
+			method: http.MethodGet,
+			path:   IntrospectionCachePath,
+			code:   http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				view := &internalcache.View{}
+				if err := json.Unmarshal(body, view); err != nil {
+					t.Fatalf("failed to decode cache view: %v", err)
+				}
+				if len(view.Clusters) != 1 || len(view.Namespaces) != 1 {
+					t.Errorf("unexpected cache view %s", string(body))
+				}
+			},
+		},
+		"scheduled namespace": {
+			method: http.MethodGet,
+			path:   IntrospectionNamespacePath + "tenant/ns",
+			code:   http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				ret := &NamespaceIntrospection{}
+				if err := json.Unmarshal(body, ret); err != nil {
+					t.Fatalf("failed to decode namespace introspection: %v", err)
+				}
+				if ret.Namespace == nil || ret.LastDecision == nil || ret.LastDecision.Placements["cluster1"] != 2 {
+					t.Errorf("unexpected namespace introspection %s", string(body))
+				}
+			},
+		},
+		"failed namespace": {
+			method: http.MethodGet,
+			path:   IntrospectionNamespacePath + "tenant/failed",
+			code:   http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				ret := &NamespaceIntrospection{}
+				if err := json.Unmarshal(body, ret); err != nil {
+					t.Fatalf("failed to decode namespace introspection: %v", err)
+				}
+				if ret.Namespace != nil || ret.LastDecision == nil || ret.LastDecision.Error == "" {
+					t.Errorf("unexpected namespace introspection %s", string(body))
+				}
+			},
+		},
+		"unknown namespace": {
+			method: http.MethodGet,
+			path:   IntrospectionNamespacePath + "tenant/unknown",
+			code:   http.StatusNotFound,
+		},
+		"malformed path": {
+			method: http.MethodGet,
+			path:   IntrospectionNamespacePath + "tenant",
+			code:   http.StatusBadRequest,
+		},
+		"write is rejected": {
+			method: http.MethodPost,
+			path:   IntrospectionCachePath,
+			code:   http.StatusMethodNotAllowed,
+		},
+	}
+
+	for k, tc := range testcases {
+		tc := tc
+		t.Run(k, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, nil))
+			if rec.Code != tc.code {
+				t.Fatalf("expect code %d, got %d: %s", tc.code, rec.Code, rec.Body.String())
+			}
+			if tc.check != nil {
+				tc.check(t, rec.Body.Bytes())
+			}
+		})
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"reflect"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -137,9 +138,16 @@ func (c *controller) Reconcile(request reconciler.Request) (reconciler.Result, e
 
 	expect, _ := internalcache.GetLeastFitSliceNum(quota, quotaSlice)
 	if expect == 0 {
-		// the quota is gone. we should delete the ns scheduling placements and update the scheduler cache
-		if err := c.updateSchedulingResult(request.ClusterName, namespace, nil); err != nil {
-			return reconciler.Result{}, fmt.Errorf("failed to remove scheduing placements from namespace %s in %s: %v", request.Name, request.ClusterName, err)
+		// the quota is gone. we should delete the ns scheduling placements and update the scheduler cache.
+		// If the pods are placed individually, the clusters hosting them are kept in the placements.
+		var newPlacements map[string]int
+		if c.Config.EnablePodLevelScheduling {
+			newPlacements = util.ZeroPlacements(placements)
+		}
+		if !reflect.DeepEqual(placements, newPlacements) {
+			if err := c.updateSchedulingResult(request.ClusterName, namespace, newPlacements); err != nil {
+				return reconciler.Result{}, fmt.Errorf("failed to remove scheduing placements from namespace %s in %s: %v", request.Name, request.ClusterName, err)
+			}
 		}
 		if err := c.SchedulerEngine.DeScheduleNamespace(fmt.Sprintf("%s/%s", request.ClusterName, request.Name)); err != nil {
 			return reconciler.Result{}, fmt.Errorf("failed to unreserve namespace %s in %s: %v", request.Name, request.ClusterName, err)
@@ -149,6 +157,10 @@ func (c *controller) Reconcile(request reconciler.Request) (reconciler.Result, e
 	numSched := 0
 	schedule := make([]*internalcache.Placement, 0, len(placements))
 	for k, v := range placements {
+		if v == 0 {
+			// the cluster only hosts individually placed pods.
+			continue
+		}
 		numSched += v
 		schedule = append(schedule, internalcache.NewPlacement(k, v))
 	}
@@ -175,6 +187,14 @@ func (c *controller) Reconcile(request reconciler.Request) (reconciler.Result, e
 	}
 	// update virtualcluster namespace with the scheduling result.
 	placementMap := ret.GetPlacementMap()
+	if c.Config.EnablePodLevelScheduling {
+		// keep the clusters that may still host individually placed pods.
+		for k := range placements {
+			if _, ok := placementMap[k]; !ok {
+				placementMap[k] = 0
+			}
+		}
+	}
 	err = c.updateSchedulingResult(request.ClusterName, namespace, placementMap)
 	if err == nil {
 		updatedPlacement, _ := json.Marshal(placementMap)
@@ -18,14 +18,17 @@ package pod
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/scheduler"
 	schedulerconfig "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/experiment/pkg/scheduler/apis/config"
@@ -119,8 +122,21 @@ func (c *controller) Reconcile(request reconciler.Request) (reconciler.Result, e
 		return reconciler.Result{}, nil
 	}
 
+	unquoted := false
+	if c.Config.EnablePodLevelScheduling {
+		unquoted, err = c.isUnquotedNamespace(request.ClusterName, pod.Namespace)
+		if err != nil {
+			return reconciler.Result{}, err
+		}
+	}
+
 	candidate := internalcache.NewPod(request.ClusterName, pod.Namespace, pod.Name, "", util.GetPodRequirements(pod))
-	ret, err := c.SchedulerEngine.SchedulePod(candidate)
+	var ret *internalcache.Pod
+	if unquoted {
+		ret, err = c.SchedulerEngine.SchedulePodInUnquotedNamespace(candidate)
+	} else {
+		ret, err = c.SchedulerEngine.SchedulePod(candidate)
+	}
 	if err != nil {
 		c.MultiClusterController.Eventf(request.ClusterName, &corev1.ObjectReference{
 			Kind:      "Pod",
@@ -131,12 +147,19 @@ func (c *controller) Reconcile(request reconciler.Request) (reconciler.Result, e
 		return reconciler.Result{}, fmt.Errorf("failed to schedule pod %s in %s: %v", request.Name, request.ClusterName, err)
 	}
 
-	// update virtualcluster pod with the scheduling result.
 	vcClient, err := c.MultiClusterController.GetClusterClient(request.ClusterName)
 	if err != nil {
 		return reconciler.Result{}, fmt.Errorf("failed to get vc %s's client: %v", request.ClusterName, err)
 	}
 
+	if unquoted {
+		// the namespace has to be synced to the cluster before the pod.
+		if err := ensureNamespacePlacement(vcClient, pod.Namespace, ret.GetCluster()); err != nil {
+			return reconciler.Result{}, fmt.Errorf("failed to add cluster %s to the placements of namespace %s in %s: %v", ret.GetCluster(), pod.Namespace, request.ClusterName, err)
+		}
+	}
+
+	// update virtualcluster pod with the scheduling result.
 	clone := pod.DeepCopy()
 	err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
 		if clone.Annotations == nil {
@@ -164,6 +187,47 @@ func (c *controller) Reconcile(request reconciler.Request) (reconciler.Result, e
 	return reconciler.Result{}, err
 }
 
+// isUnquotedNamespace returns true if the namespace has no cpu or memory quota, hence its pods are placed individually.
+func (c *controller) isUnquotedNamespace(clusterName, namespace string) (bool, error) {
+	quotaList := &corev1.ResourceQuotaList{}
+	if err := c.MultiClusterController.List(clusterName, quotaList, client.InNamespace(namespace)); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return false, fmt.Errorf("failed to get resource quota in %s/%s: %v", clusterName, namespace, err)
+		}
+		return true, nil
+	}
+	return util.IsQuotaEmpty(util.GetMaxQuota(quotaList)), nil
+}
+
+// ensureNamespacePlacement adds the cluster to the namespace placements without any slice, so that the
+// syncer of the cluster syncs the namespace and the objects in it.
+func ensureNamespacePlacement(vcClient clientset.Interface, namespace, cluster string) error {
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		ns, err := vcClient.CoreV1().Namespaces().Get(context.TODO(), namespace, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		placements, _, err := util.GetSchedulingInfo(ns)
+		if err != nil {
+			return err
+		}
+		if _, ok := placements[cluster]; ok {
+			return nil
+		}
+		if placements == nil {
+			placements = make(map[string]int)
+		}
+		placements[cluster] = 0
+		updatedPlacement, _ := json.Marshal(placements)
+		if ns.Annotations == nil {
+			ns.Annotations = make(map[string]string)
+		}
+		ns.Annotations[utilconst.LabelScheduledPlacements] = string(updatedPlacement)
+		_, err = vcClient.CoreV1().Namespaces().Update(context.TODO(), ns, metav1.UpdateOptions{})
+		return err
+	})
+}
+
 func (c *controller) skipPodSchedule(pod *corev1.Pod) bool {
 	if pod.DeletionTimestamp != nil {
 		klog.Infof("skip schedule deleting pod %s/%s", pod.GetNamespace(), pod.GetName())
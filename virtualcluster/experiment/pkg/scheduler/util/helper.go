@@ -295,6 +295,46 @@ func GetPodSchedulingInfo(pod *corev1.Pod) string {
 	return pod.GetAnnotations()[utilconst.LabelScheduledCluster]
 }
 
+// IsQuotaEmpty returns true if the namespace quota has neither cpu nor memory.
+func IsQuotaEmpty(quota corev1.ResourceList) bool {
+	cpu := quota[corev1.ResourceCPU]
+	mem := quota[corev1.ResourceMemory]
+	return cpu.IsZero() && mem.IsZero()
+}
+
+// ZeroPlacements keeps the clusters in the placements but releases all the slices. It is used for the
+// namespaces without quota whose pods are placed individually. The syncer of a super cluster only syncs
+// a namespace, and the objects in it, if the super cluster appears in the namespace placements.
+func ZeroPlacements(placements map[string]int) map[string]int {
+	if placements == nil {
+		return nil
+	}
+	ret := make(map[string]int, len(placements))
+	for k := range placements {
+		ret[k] = 0
+	}
+	return ret
+}
+
+func syncUnquotedPods(client clientset.Interface, clustername, namespace string, cache internalcache.Cache) error {
+	podlist, err := client.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list pods in namespace %s in cluster %s with error %v", namespace, clustername, err)
+	}
+	for podIndex, pod := range podlist.Items {
+		supercluster, ok := pod.GetAnnotations()[utilconst.LabelScheduledCluster]
+		if !ok {
+			continue
+		}
+		cPod := internalcache.NewPod(clustername, namespace, pod.Name, supercluster, GetPodRequirements(&podlist.Items[podIndex]))
+		if err := cache.AddPod(cPod); err != nil {
+			// the super cluster may have been removed, the pod will not be counted.
+			klog.Warningf("failed to add unquoted pod to cache: %s/%s/%s with error %v", clustername, namespace, pod.Name, err)
+		}
+	}
+	return nil
+}
+
 func SyncVirtualClusterState(metaClient clientset.Interface, vc *v1alpha1.VirtualCluster, cache internalcache.Cache) error {
 	clustername := conversion.ToClusterKey(vc)
 	cache.AddTenant(clustername)
@@ -327,6 +367,10 @@ func SyncVirtualClusterState(metaClient clientset.Interface, vc *v1alpha1.Virtua
 			if placements != nil {
 				// TODO: we may need to clear the schedule.
 			}
+			// the pods in a namespace without quota may have been placed individually.
+			if err := syncUnquotedPods(client, clustername, each.Name, cache); err != nil {
+				return err
+			}
 			continue
 		}
 
@@ -339,6 +383,10 @@ func SyncVirtualClusterState(metaClient clientset.Interface, vc *v1alpha1.Virtua
 		numSched := 0
 		var schedule []*internalcache.Placement
 		for k, v := range placements {
+			if v == 0 {
+				// the cluster only hosts individually placed pods.
+				continue
+			}
 			numSched += v
 			schedule = append(schedule, internalcache.NewPlacement(k, v))
 		}
@@ -369,7 +417,7 @@ func SyncVirtualClusterState(metaClient clientset.Interface, vc *v1alpha1.Virtua
 			if !ok {
 				continue
 			}
-			if _, ok := placements[supercluster]; !ok {
+			if num, ok := placements[supercluster]; !ok || num == 0 {
 				// TODO: Pod scheduling result is inconsistent, we need to delete the Pod or send warnings.
 				continue
 			}
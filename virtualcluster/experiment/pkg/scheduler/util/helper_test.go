@@ -17,6 +17,7 @@ limitations under the License.
 package util
 
 import (
+	"reflect"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
@@ -331,3 +332,34 @@ func TestGetPodRequirements(t *testing.T) {
 		})
 	}
 }
+
+func TestZeroPlacements(t *testing.T) {
+	testcases := map[string]struct {
+		placements map[string]int
+		expect     map[string]int
+	}{
+		"nil placements": {
+			placements: nil,
+			expect:     nil,
+		},
+		"release all slices": {
+			placements: map[string]int{"a": 2, "b": 0},
+			expect:     map[string]int{"a": 0, "b": 0},
+		},
+	}
+	for k, tc := range testcases {
+		got := ZeroPlacements(tc.placements)
+		if !reflect.DeepEqual(got, tc.expect) {
+			t.Errorf("test %s: expect %v, got %v", k, tc.expect, got)
+		}
+	}
+}
+
+func TestIsQuotaEmpty(t *testing.T) {
+	if !IsQuotaEmpty(GetMaxQuota(&corev1.ResourceQuotaList{})) {
+		t.Errorf("expect empty quota list to be empty")
+	}
+	if IsQuotaEmpty(corev1.ResourceList{"cpu": resource.MustParse("1")}) {
+		t.Errorf("expect cpu quota not to be empty")
+	}
+}
@@ -21,13 +21,20 @@ import "fmt"
 // GetEtcdDomain returns the dns of etcd service, note that, though the
 // complete etcd svc dns is {etcdSvcName}.{namespace}.svc.{clusterdomain},
 // this EtcdDomain is only used by apiserver that in the same namespace,
-// so the etcdSvcName is adequate
+// so the etcdSvcName is adequate. An empty string is returned if the
+// etcd is not defined.
 func (cv *ClusterVersion) GetEtcdDomain() string {
+	if cv.Spec.ETCD == nil || cv.Spec.ETCD.Service == nil {
+		return ""
+	}
 	return cv.Spec.ETCD.Service.Name
 }
 
 // GetEtcdServers returns the list of hostnames of etcd pods
 func (cv *ClusterVersion) GetEtcdServers() (etcdServers []string) {
+	if cv.Spec.ETCD == nil || cv.Spec.ETCD.StatefulSet == nil {
+		return nil
+	}
 	etcdStsName := cv.Spec.ETCD.StatefulSet.Name
 	replicas := cv.Spec.ETCD.StatefulSet.Spec.Replicas
 	var i int32
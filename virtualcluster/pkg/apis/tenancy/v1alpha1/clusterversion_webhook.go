@@ -0,0 +1,271 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+var cvlog = logf.Log.WithName("clusterversion-webhook")
+
+// the secrets the provisioner creates for each component, whose hashes are
+// added to the pod templates, so the pods must mount them.
+var (
+	etcdRequiredSecrets              = []string{"etcd-ca", "root-ca"}
+	apiServerRequiredSecrets         = []string{"apiserver-ca", "front-proxy-ca", "root-ca", "serviceaccount-rsa"}
+	controllerManagerRequiredSecrets = []string{"controller-manager-kubeconfig", "root-ca", "serviceaccount-rsa"}
+)
+
+func (cv *ClusterVersion) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	cvlog.Info("setup clusterversion validation webhook")
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(cv).
+		Complete()
+}
+
+var _ webhook.Validator = &ClusterVersion{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (cv *ClusterVersion) ValidateCreate() error {
+	cvlog.Info("validate create", "cv-name", cv.Name)
+	return cv.validateClusterVersion(nil)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (cv *ClusterVersion) ValidateUpdate(old runtime.Object) error {
+	cvlog.Info("validate update", "cv-name", cv.Name)
+	return cv.validateClusterVersionUpdate(old)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (cv *ClusterVersion) ValidateDelete() error {
+	cvlog.Info("validate delete", "cv-name", cv.Name)
+	// do nothing for delete request
+	return nil
+}
+
+func (cv *ClusterVersion) validateClusterVersionUpdate(old runtime.Object) error {
+	oldCV, ok := old.(*ClusterVersion)
+	if !ok {
+		return errors.New("fail to assert client.Object to tenancyv1alpha1.ClusterVersion")
+	}
+	// the ClusterVersion is updated along with its metadata, only the fields changed by
+	// the update are validated.
+	if err := cv.validateClusterVersion(&oldCV.Spec); err != nil {
+		return err
+	}
+	// the apiserver can not read the Secrets encrypted at rest once the
+	// encryption is removed, the provider can still be changed.
+	if oldCV.Spec.SecretEncryption != nil && cv.Spec.SecretEncryption == nil {
+		return apierrors.NewInvalid(
+			schema.GroupKind{Group: "tenancy.x-k8s.io", Kind: "ClusterVersion"},
+			cv.Name, field.ErrorList{
+				field.Forbidden(field.NewPath("spec", "secretEncryption"), "can not be removed once it is set"),
+			})
+	}
+	return nil
+}
+
+// validateClusterVersion checks that the components have what the provisioner expects
+// when it complements and deploys them, only the fields that differ from the old spec
+// are validated on update.
+func (cv *ClusterVersion) validateClusterVersion(old *ClusterVersionSpec) error {
+	var allErrs field.ErrorList
+	oldSpec := ClusterVersionSpec{}
+	if old != nil {
+		oldSpec = *old
+	}
+	changed := func(newField, oldField interface{}) bool {
+		return old == nil || !apiequality.Semantic.DeepEqual(newField, oldField)
+	}
+	specPath := field.NewPath("spec")
+
+	switch {
+	case !changed(cv.Spec.APIServer, oldSpec.APIServer):
+	case cv.Spec.APIServer == nil:
+		allErrs = append(allErrs, field.Required(specPath.Child("apiServer"), ""))
+	default:
+		allErrs = append(allErrs, validateComponent(cv.Spec.APIServer, specPath.Child("apiServer"), apiServerRequiredSecrets)...)
+		if cv.Spec.APIServer.Service == nil {
+			allErrs = append(allErrs, field.Required(specPath.Child("apiServer", "service"), ""))
+		}
+	}
+
+	switch {
+	case !changed(cv.Spec.ControllerManager, oldSpec.ControllerManager):
+	case cv.Spec.ControllerManager == nil:
+		allErrs = append(allErrs, field.Required(specPath.Child("controllerManager"), ""))
+	default:
+		allErrs = append(allErrs, validateComponent(cv.Spec.ControllerManager, specPath.Child("controllerManager"), controllerManagerRequiredSecrets)...)
+	}
+
+	if cv.Spec.ETCD != nil && changed(cv.Spec.ETCD, oldSpec.ETCD) {
+		etcdPath := specPath.Child("etcd")
+		allErrs = append(allErrs, validateComponent(cv.Spec.ETCD, etcdPath, etcdRequiredSecrets)...)
+		if cv.Spec.ETCD.StatefulSet != nil && cv.Spec.ETCD.StatefulSet.Spec.Replicas == nil {
+			allErrs = append(allErrs, field.Required(etcdPath.Child("statefulset", "spec", "replicas"), ""))
+		}
+		if cv.Spec.ETCD.Service == nil {
+			allErrs = append(allErrs, field.Required(etcdPath.Child("service"), ""))
+		}
+	}
+
+	// the SQLite datastore depends on the replicas of the apiserver
+	if cv.Spec.Datastore != nil && (changed(cv.Spec.Datastore, oldSpec.Datastore) || changed(cv.Spec.APIServer, oldSpec.APIServer)) {
+		allErrs = append(allErrs, cv.validateDatastore(specPath.Child("datastore"))...)
+	}
+
+	if cv.Spec.SecretEncryption != nil && changed(cv.Spec.SecretEncryption, oldSpec.SecretEncryption) {
+		switch provider := cv.Spec.SecretEncryption.Provider; provider {
+		case "", SecretboxEncryptionProvider, AESGCMEncryptionProvider:
+		default:
+			allErrs = append(allErrs, field.NotSupported(specPath.Child("secretEncryption", "provider"), provider,
+				[]string{string(SecretboxEncryptionProvider), string(AESGCMEncryptionProvider)}))
+		}
+	}
+
+	if cv.Spec.Audit != nil && changed(cv.Spec.Audit, oldSpec.Audit) {
+		allErrs = append(allErrs, cv.validateAudit(specPath.Child("audit"))...)
+	}
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(
+		schema.GroupKind{Group: "tenancy.x-k8s.io", Kind: "ClusterVersion"},
+		cv.Name, allErrs)
+}
+
+func (cv *ClusterVersion) validateDatastore(fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	datastore := cv.Spec.Datastore
+	switch {
+	case datastore.SQLite == nil && datastore.SQL == nil:
+		allErrs = append(allErrs, field.Required(fldPath, "one of sqlite and sql must be set"))
+	case datastore.SQLite != nil && datastore.SQL != nil:
+		allErrs = append(allErrs, field.Forbidden(fldPath, "only one of sqlite and sql can be set"))
+	case datastore.SQLite != nil:
+		if cv.Spec.APIServer != nil && cv.Spec.APIServer.StatefulSet != nil {
+			replicas := cv.Spec.APIServer.StatefulSet.Spec.Replicas
+			if replicas != nil && *replicas > 1 {
+				allErrs = append(allErrs, field.Forbidden(fldPath.Child("sqlite"), "the SQLite datastore needs a single apiserver replica"))
+			}
+		}
+	case datastore.SQL != nil:
+		ref := datastore.SQL.EndpointSecretRef
+		if ref.Name == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("sql", "endpointSecretRef", "name"), ""))
+		}
+		if ref.Namespace == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("sql", "endpointSecretRef", "namespace"), ""))
+		}
+	}
+	return allErrs
+}
+
+func (cv *ClusterVersion) validateAudit(fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	audit := cv.Spec.Audit
+	if audit.PolicyRef.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("policyRef", "name"), ""))
+	}
+	if audit.PolicyRef.Namespace == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("policyRef", "namespace"), ""))
+	}
+	if audit.Log == nil && audit.Webhook == nil {
+		allErrs = append(allErrs, field.Required(fldPath, "at least one of log and webhook must be set"))
+	}
+	if log := audit.Log; log != nil {
+		if log.Path != "" && log.Path != "-" && (!path.IsAbs(log.Path) || path.Dir(log.Path) == "/") {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("log", "path"), log.Path,
+				`must be "-" or an absolute path of a file in a directory`))
+		}
+		if log.MaxAge < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("log", "maxAge"), log.MaxAge, "must not be negative"))
+		}
+		if log.MaxBackups < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("log", "maxBackups"), log.MaxBackups, "must not be negative"))
+		}
+		if log.MaxSize < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("log", "maxSize"), log.MaxSize, "must not be negative"))
+		}
+	}
+	if webhook := audit.Webhook; webhook != nil {
+		if u, err := url.Parse(webhook.URL); err != nil || u.Scheme != "https" || u.Host == "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("webhook", "url"), webhook.URL, "must be an https URL"))
+		}
+	}
+	return allErrs
+}
+
+// validateComponent checks that the StatefulSet of the component has a container and
+// that the secrets in requiredSecrets are mounted by one of its containers.
+func validateComponent(bdl *StatefulSetSvcBundle, fldPath *field.Path, requiredSecrets []string) field.ErrorList {
+	var allErrs field.ErrorList
+	if bdl.StatefulSet == nil {
+		return append(allErrs, field.Required(fldPath.Child("statefulset"), ""))
+	}
+	podSpec := bdl.StatefulSet.Spec.Template.Spec
+	podSpecPath := fldPath.Child("statefulset", "spec", "template", "spec")
+	if len(podSpec.Containers) == 0 {
+		return append(allErrs, field.Required(podSpecPath.Child("containers"), ""))
+	}
+
+	secretVolumes := make(map[string]string, len(podSpec.Volumes))
+	for _, v := range podSpec.Volumes {
+		if v.Secret != nil {
+			secretVolumes[v.Secret.SecretName] = v.Name
+		}
+	}
+	for _, secretName := range requiredSecrets {
+		volumeName, ok := secretVolumes[secretName]
+		if !ok {
+			allErrs = append(allErrs, field.Required(podSpecPath.Child("volumes"),
+				fmt.Sprintf("a volume of secret %s is required", secretName)))
+			continue
+		}
+		if !isVolumeMounted(podSpec.Containers, volumeName) {
+			allErrs = append(allErrs, field.Required(podSpecPath.Child("containers"),
+				fmt.Sprintf("volume %s of secret %s must be mounted", volumeName, secretName)))
+		}
+	}
+	return allErrs
+}
+
+func isVolumeMounted(containers []corev1.Container, volumeName string) bool {
+	for _, c := range containers {
+		for _, m := range c.VolumeMounts {
+			if m.Name == volumeName {
+				return true
+			}
+		}
+	}
+	return false
+}
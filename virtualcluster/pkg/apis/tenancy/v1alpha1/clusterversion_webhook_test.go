@@ -0,0 +1,246 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/utils/pointer"
+)
+
+func loadSampleClusterVersion(t *testing.T, name string) *ClusterVersion {
+	f, err := os.Open(filepath.Join("..", "..", "..", "..", "config", "sampleswithspec", name))
+	if err != nil {
+		t.Fatalf("fail to open %s: %v", name, err)
+	}
+	defer f.Close()
+	cv := &ClusterVersion{}
+	if err := yaml.NewYAMLOrJSONDecoder(f, 4096).Decode(cv); err != nil {
+		t.Fatalf("fail to decode %s: %v", name, err)
+	}
+	return cv
+}
+
+func TestValidateClusterVersion(t *testing.T) {
+	for _, sample := range []string{"clusterversion_v1_nodeport.yaml", "clusterversion_v1_loadbalancer.yaml"} {
+		if err := loadSampleClusterVersion(t, sample).ValidateCreate(); err != nil {
+			t.Errorf("sample %s should be valid, got %v", sample, err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(cv *ClusterVersion)
+		wantErr bool
+	}{
+		{
+			name:   "without etcd",
+			mutate: func(cv *ClusterVersion) { cv.Spec.ETCD = nil },
+		},
+		{
+			name:    "without apiserver",
+			mutate:  func(cv *ClusterVersion) { cv.Spec.APIServer = nil },
+			wantErr: true,
+		},
+		{
+			name:    "without apiserver service",
+			mutate:  func(cv *ClusterVersion) { cv.Spec.APIServer.Service = nil },
+			wantErr: true,
+		},
+		{
+			name:    "without controller-manager statefulset",
+			mutate:  func(cv *ClusterVersion) { cv.Spec.ControllerManager.StatefulSet = nil },
+			wantErr: true,
+		},
+		{
+			name: "without apiserver containers",
+			mutate: func(cv *ClusterVersion) {
+				cv.Spec.APIServer.StatefulSet.Spec.Template.Spec.Containers = nil
+			},
+			wantErr: true,
+		},
+		{
+			name: "without etcd replicas",
+			mutate: func(cv *ClusterVersion) {
+				cv.Spec.ETCD.StatefulSet.Spec.Replicas = nil
+			},
+			wantErr: true,
+		},
+		{
+			name: "without the front-proxy-ca volume",
+			mutate: func(cv *ClusterVersion) {
+				podSpec := &cv.Spec.APIServer.StatefulSet.Spec.Template.Spec
+				var volumes []corev1.Volume
+				for _, v := range podSpec.Volumes {
+					if v.Secret == nil || v.Secret.SecretName != "front-proxy-ca" {
+						volumes = append(volumes, v)
+					}
+				}
+				podSpec.Volumes = volumes
+			},
+			wantErr: true,
+		},
+		{
+			name: "serviceaccount-rsa is not mounted",
+			mutate: func(cv *ClusterVersion) {
+				container := &cv.Spec.ControllerManager.StatefulSet.Spec.Template.Spec.Containers[0]
+				var mounts []corev1.VolumeMount
+				for _, m := range container.VolumeMounts {
+					if m.Name != "serviceaccount-rsa" {
+						mounts = append(mounts, m)
+					}
+				}
+				container.VolumeMounts = mounts
+			},
+			wantErr: true,
+		},
+		{
+			name: "sqlite datastore",
+			mutate: func(cv *ClusterVersion) {
+				cv.Spec.ETCD = nil
+				cv.Spec.Datastore = &KineDatastore{SQLite: &SQLiteDatastore{}}
+			},
+		},
+		{
+			name: "sqlite datastore with multiple apiservers",
+			mutate: func(cv *ClusterVersion) {
+				cv.Spec.APIServer.StatefulSet.Spec.Replicas = pointer.Int32Ptr(3)
+				cv.Spec.Datastore = &KineDatastore{SQLite: &SQLiteDatastore{}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "sql datastore without endpoint secret",
+			mutate: func(cv *ClusterVersion) {
+				cv.Spec.Datastore = &KineDatastore{SQL: &SQLDatastore{}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "datastore without backend",
+			mutate: func(cv *ClusterVersion) {
+				cv.Spec.Datastore = &KineDatastore{}
+			},
+			wantErr: true,
+		},
+		{
+			name: "secret encryption with the default provider",
+			mutate: func(cv *ClusterVersion) {
+				cv.Spec.SecretEncryption = &SecretEncryption{}
+			},
+		},
+		{
+			name: "secret encryption with an unknown provider",
+			mutate: func(cv *ClusterVersion) {
+				cv.Spec.SecretEncryption = &SecretEncryption{Provider: "aescbc"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "audit with a log and a webhook",
+			mutate: func(cv *ClusterVersion) {
+				cv.Spec.Audit = &Audit{
+					PolicyRef: ConfigMapReference{Name: "audit-policy", Namespace: "vc-manager"},
+					Log:       &AuditLogBackend{Path: "/var/log/kubernetes/audit.log"},
+					Webhook:   &AuditWebhookBackend{URL: "https://audit-collector.audit-system.svc/events", TagCluster: true},
+				}
+			},
+		},
+		{
+			name: "audit without backend",
+			mutate: func(cv *ClusterVersion) {
+				cv.Spec.Audit = &Audit{PolicyRef: ConfigMapReference{Name: "audit-policy", Namespace: "vc-manager"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "audit with an http webhook",
+			mutate: func(cv *ClusterVersion) {
+				cv.Spec.Audit = &Audit{
+					PolicyRef: ConfigMapReference{Name: "audit-policy", Namespace: "vc-manager"},
+					Webhook:   &AuditWebhookBackend{URL: "http://audit-collector.audit-system.svc/events"},
+				}
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := loadSampleClusterVersion(t, "clusterversion_v1_nodeport.yaml")
+			cv := old.DeepCopy()
+			tt.mutate(cv)
+			err := cv.ValidateUpdate(old)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateUpdate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateClusterVersionUpdate(t *testing.T) {
+	tests := []struct {
+		name       string
+		encryption *SecretEncryption
+		updated    *SecretEncryption
+		wantErr    bool
+	}{
+		{
+			name:    "add secret encryption",
+			updated: &SecretEncryption{},
+		},
+		{
+			name:       "change the secret encryption provider",
+			encryption: &SecretEncryption{},
+			updated:    &SecretEncryption{Provider: AESGCMEncryptionProvider},
+		},
+		{
+			name:       "remove secret encryption",
+			encryption: &SecretEncryption{},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := loadSampleClusterVersion(t, "clusterversion_v1_nodeport.yaml")
+			old.Spec.SecretEncryption = tt.encryption
+			cv := old.DeepCopy()
+			cv.Spec.SecretEncryption = tt.updated
+			err := cv.ValidateUpdate(old)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateUpdate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateClusterVersionUpdateUnchangedFields(t *testing.T) {
+	old := loadSampleClusterVersion(t, "clusterversion_v1_nodeport.yaml")
+	old.Spec.APIServer.Service = nil
+	cv := old.DeepCopy()
+	cv.Labels = map[string]string{"foo": "bar"}
+	if err := cv.ValidateUpdate(old); err != nil {
+		t.Errorf("the unchanged fields should not be validated, got %v", err)
+	}
+	cv.Spec.APIServer.StatefulSet.Spec.Replicas = pointer.Int32Ptr(3)
+	if err := cv.ValidateUpdate(old); err == nil {
+		t.Errorf("the changed apiserver should be validated")
+	}
+}
@@ -32,8 +32,9 @@ type VirtualClusterSpec struct {
 	// The name of the desired cluster version
 	ClusterVersionName string `json:"clusterVersionName"`
 
-	// The valid period of the tenant cluster PKI, if not set
-	// the PKI will never expire (i.e. 10 years)
+	// The valid period of the tenant cluster certificates, if not set
+	// the certificates are valid for one year and the root ca for 10 years.
+	// The certificates are rotated by the vc-manager before they expire.
 	// +optional
 	PKIExpireDays int64 `json:"pkiExpireDays,omitempty"`
 
@@ -51,6 +52,25 @@ type VirtualClusterSpec struct {
 	// Service CIDRs used by VirtualCluster
 	// +optional
 	ServiceCidr string `json:"serviceCidr,omitempty"`
+
+	// The range of node ports reserved for the VirtualCluster in the super cluster, e.g. 30100-30199.
+	// If set, the node ports of tenant services are allocated within the range and tenants can
+	// request specific ports in it. Otherwise, the node ports are allocated by the super cluster.
+	// +optional
+	NodePortRange string `json:"nodePortRange,omitempty"`
+
+	// The upper bound of the hard limits of the tenant ResourceQuotas synced to the super cluster.
+	// Each hard limit of a synced ResourceQuota is capped by the limit of the same resource here,
+	// the resources not set in the tenant ResourceQuota are not added.
+	// +optional
+	ResourceQuotaCap corev1.ResourceList `json:"resourceQuotaCap,omitempty"`
+
+	// The aggregate ceiling of the resources used by the VirtualCluster across all of its
+	// namespaces in the super cluster. The supported resources are cpu and memory (the sum of
+	// the pod requests), pods and requests.storage (the sum of the PVC requests). The tenant pods
+	// exceeding the ceiling are not synced to the super cluster.
+	// +optional
+	ResourceCeiling corev1.ResourceList `json:"resourceCeiling,omitempty"`
 }
 
 // VirtualClusterStatus defines the observed state of VirtualCluster
@@ -76,6 +96,11 @@ type VirtualClusterStatus struct {
 
 	// Cluster Conditions
 	Conditions []ClusterCondition `json:"conditions,omitempty"`
+
+	// The resources used by the VirtualCluster in the super cluster, reported for the
+	// resources set in the ResourceCeiling.
+	// +optional
+	ResourceUsage corev1.ResourceList `json:"resourceUsage,omitempty"`
 }
 
 type ClusterPhase string
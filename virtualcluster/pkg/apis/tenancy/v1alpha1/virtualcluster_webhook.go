@@ -18,14 +18,22 @@ package v1alpha1
 
 import (
 	"errors"
+	"fmt"
+	"net"
 
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 )
 
 var vclog = logf.Log.WithName("virtualcluster-webhook")
@@ -39,11 +47,22 @@ func (vc *VirtualCluster) SetupWebhookWithManager(mgr ctrl.Manager) error {
 
 var _ webhook.Validator = &VirtualCluster{}
 
+// ResourceCeilingResources are the resources supported by the ResourceCeiling of VirtualCluster.
+var ResourceCeilingResources = []string{
+	string(corev1.ResourceCPU),
+	string(corev1.ResourceMemory),
+	string(corev1.ResourcePods),
+	string(corev1.ResourceRequestsStorage),
+}
+
+// SuperClusterNodePortRange is the node port range of the super cluster, the node port
+// range of a VirtualCluster is reserved within it.
+var SuperClusterNodePortRange = "30000-32767"
+
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type
 func (vc *VirtualCluster) ValidateCreate() error {
 	vclog.Info("validate create", "vc-name", vc.Name)
-	// do nothing for delete request
-	return nil
+	return vc.toInvalidError(vc.validateVirtualClusterSpec(nil))
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
@@ -70,9 +89,125 @@ func (vc *VirtualCluster) validateVirtualClusterUpdate(old runtime.Object) error
 		allErrs = append(allErrs,
 			field.Invalid(field.NewPath("status").Child("phase"),
 				vc.Name, "cannot set virtualcluster.Status.Phase to empty"))
-		return apierrors.NewInvalid(
-			schema.GroupKind{Group: "tenancy.x-k8s.io", Kind: "VirtualCluster"},
-			vc.Name, allErrs)
+		return vc.toInvalidError(allErrs)
+	}
+
+	specPath := field.NewPath("spec")
+	if vc.Spec.ClusterDomain != oldVC.Spec.ClusterDomain {
+		allErrs = append(allErrs,
+			field.Forbidden(specPath.Child("clusterDomain"), "field is immutable"))
+	}
+	if vc.Spec.ServiceCidr != oldVC.Spec.ServiceCidr {
+		allErrs = append(allErrs,
+			field.Forbidden(specPath.Child("serviceCidr"), "field is immutable"))
+	}
+	// the node ports of the synced services are allocated within the range
+	if vc.Spec.NodePortRange != oldVC.Spec.NodePortRange {
+		allErrs = append(allErrs,
+			field.Forbidden(specPath.Child("nodePortRange"), "field is immutable"))
+	}
+	// the clusterversion can only be switched along with an upgrade request
+	if vc.Spec.ClusterVersionName != oldVC.Spec.ClusterVersionName &&
+		vc.Labels[constants.LabelVCReadyForUpgrade] != "true" {
+		allErrs = append(allErrs,
+			field.Forbidden(specPath.Child("clusterVersionName"),
+				"field can only be changed together with the "+constants.LabelVCReadyForUpgrade+"=true label"))
+	}
+	// the controllers update the VirtualCluster without a status subresource, only the
+	// fields changed by the update are validated.
+	allErrs = append(allErrs, vc.validateVirtualClusterSpec(&oldVC.Spec)...)
+	return vc.toInvalidError(allErrs)
+}
+
+// validateVirtualClusterSpec validates the syntax of the fields of the spec, only the
+// fields that differ from the old spec are validated on update.
+func (vc *VirtualCluster) validateVirtualClusterSpec(old *VirtualClusterSpec) field.ErrorList {
+	var allErrs field.ErrorList
+	oldSpec := VirtualClusterSpec{}
+	if old != nil {
+		oldSpec = *old
+	}
+	changed := func(newField, oldField interface{}) bool {
+		return old == nil || !apiequality.Semantic.DeepEqual(newField, oldField)
+	}
+	specPath := field.NewPath("spec")
+	if vc.Spec.ClusterVersionName == "" && changed(vc.Spec.ClusterVersionName, oldSpec.ClusterVersionName) {
+		allErrs = append(allErrs, field.Required(specPath.Child("clusterVersionName"), ""))
+	}
+	if vc.Spec.ClusterDomain != "" && changed(vc.Spec.ClusterDomain, oldSpec.ClusterDomain) {
+		for _, msg := range validation.IsDNS1123Subdomain(vc.Spec.ClusterDomain) {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("clusterDomain"), vc.Spec.ClusterDomain, msg))
+		}
+	}
+	if vc.Spec.ServiceCidr != "" && changed(vc.Spec.ServiceCidr, oldSpec.ServiceCidr) {
+		if _, _, err := net.ParseCIDR(vc.Spec.ServiceCidr); err != nil {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("serviceCidr"), vc.Spec.ServiceCidr, err.Error()))
+		}
+	}
+	if vc.Spec.NodePortRange != "" && changed(vc.Spec.NodePortRange, oldSpec.NodePortRange) {
+		allErrs = append(allErrs, validateNodePortRange(specPath.Child("nodePortRange"), vc.Spec.NodePortRange)...)
+	}
+	if vc.Spec.PKIExpireDays < 0 && changed(vc.Spec.PKIExpireDays, oldSpec.PKIExpireDays) {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("pkiExpireDays"), vc.Spec.PKIExpireDays, "must be greater than or equal to 0"))
+	}
+	if changed(vc.Spec.ResourceQuotaCap, oldSpec.ResourceQuotaCap) {
+		for name, quantity := range vc.Spec.ResourceQuotaCap {
+			if quantity.Sign() < 0 {
+				allErrs = append(allErrs, field.Invalid(specPath.Child("resourceQuotaCap").Key(string(name)), quantity.String(), "must be greater than or equal to 0"))
+			}
+		}
+	}
+	if changed(vc.Spec.ResourceCeiling, oldSpec.ResourceCeiling) {
+		allErrs = append(allErrs, validateResourceCeiling(specPath.Child("resourceCeiling"), vc.Spec.ResourceCeiling)...)
+	}
+	return allErrs
+}
+
+// validateNodePortRange checks that the node port range is reserved within the node port
+// range of the super cluster.
+func validateNodePortRange(fldPath *field.Path, nodePortRange string) field.ErrorList {
+	pr, err := utilnet.ParsePortRange(nodePortRange)
+	if err != nil {
+		return field.ErrorList{field.Invalid(fldPath, nodePortRange, err.Error())}
+	}
+	superRange, err := utilnet.ParsePortRange(SuperClusterNodePortRange)
+	if err != nil {
+		return field.ErrorList{field.InternalError(fldPath, fmt.Errorf("invalid node port range %q of the super cluster: %v", SuperClusterNodePortRange, err))}
+	}
+	if pr.Base < superRange.Base || pr.Base+pr.Size > superRange.Base+superRange.Size {
+		return field.ErrorList{field.Invalid(fldPath, nodePortRange, "must be within the node port range "+superRange.String()+" of the super cluster")}
 	}
 	return nil
 }
+
+func validateResourceCeiling(fldPath *field.Path, ceiling corev1.ResourceList) field.ErrorList {
+	var allErrs field.ErrorList
+	for name, quantity := range ceiling {
+		if !isResourceCeilingResource(name) {
+			allErrs = append(allErrs, field.NotSupported(fldPath, string(name), ResourceCeilingResources))
+			continue
+		}
+		if quantity.Sign() < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Key(string(name)), quantity.String(), "must be greater than or equal to 0"))
+		}
+	}
+	return allErrs
+}
+
+func isResourceCeilingResource(name corev1.ResourceName) bool {
+	for _, r := range ResourceCeilingResources {
+		if string(name) == r {
+			return true
+		}
+	}
+	return false
+}
+
+func (vc *VirtualCluster) toInvalidError(allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(
+		schema.GroupKind{Group: "tenancy.x-k8s.io", Kind: "VirtualCluster"},
+		vc.Name, allErrs)
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+func newWebhookTestVirtualCluster() *VirtualCluster {
+	return &VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "vc",
+			Namespace: "default",
+		},
+		Spec: VirtualClusterSpec{
+			ClusterDomain:      "cluster.local",
+			ClusterVersionName: "cv-sample-np",
+			ServiceCidr:        "10.32.0.0/16",
+		},
+		Status: VirtualClusterStatus{
+			Phase: ClusterRunning,
+		},
+	}
+}
+
+func TestVirtualClusterValidateCreate(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(vc *VirtualCluster)
+		wantErr bool
+	}{
+		{
+			name:   "valid",
+			mutate: func(vc *VirtualCluster) {},
+		},
+		{
+			name:   "defaults",
+			mutate: func(vc *VirtualCluster) { vc.Spec.ClusterDomain, vc.Spec.ServiceCidr = "", "" },
+		},
+		{
+			name:    "without clusterversion",
+			mutate:  func(vc *VirtualCluster) { vc.Spec.ClusterVersionName = "" },
+			wantErr: true,
+		},
+		{
+			name:    "invalid cluster domain",
+			mutate:  func(vc *VirtualCluster) { vc.Spec.ClusterDomain = "Cluster_Local" },
+			wantErr: true,
+		},
+		{
+			name:    "invalid service cidr",
+			mutate:  func(vc *VirtualCluster) { vc.Spec.ServiceCidr = "10.32.0.0" },
+			wantErr: true,
+		},
+		{
+			name:    "invalid node port range",
+			mutate:  func(vc *VirtualCluster) { vc.Spec.NodePortRange = "30100-" },
+			wantErr: true,
+		},
+		{
+			name:   "valid node port range",
+			mutate: func(vc *VirtualCluster) { vc.Spec.NodePortRange = "30100-30199" },
+		},
+		{
+			name:    "node port range out of the super cluster",
+			mutate:  func(vc *VirtualCluster) { vc.Spec.NodePortRange = "32700-32799" },
+			wantErr: true,
+		},
+		{
+			name: "negative resource quota cap",
+			mutate: func(vc *VirtualCluster) {
+				vc.Spec.ResourceQuotaCap = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("-1")}
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid resource ceiling",
+			mutate: func(vc *VirtualCluster) {
+				vc.Spec.ResourceCeiling = corev1.ResourceList{
+					corev1.ResourceCPU:             resource.MustParse("8"),
+					corev1.ResourceMemory:          resource.MustParse("16Gi"),
+					corev1.ResourcePods:            resource.MustParse("100"),
+					corev1.ResourceRequestsStorage: resource.MustParse("100Gi"),
+				}
+			},
+		},
+		{
+			name: "unsupported resource ceiling",
+			mutate: func(vc *VirtualCluster) {
+				vc.Spec.ResourceCeiling = corev1.ResourceList{corev1.ResourceServices: resource.MustParse("10")}
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative resource ceiling",
+			mutate: func(vc *VirtualCluster) {
+				vc.Spec.ResourceCeiling = corev1.ResourceList{corev1.ResourcePods: resource.MustParse("-1")}
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vc := newWebhookTestVirtualCluster()
+			tt.mutate(vc)
+			err := vc.ValidateCreate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVirtualClusterValidateUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(vc *VirtualCluster)
+		wantErr bool
+	}{
+		{
+			name: "update labels",
+			mutate: func(vc *VirtualCluster) {
+				vc.Labels = map[string]string{"foo": "bar"}
+			},
+		},
+		{
+			name:    "clear phase",
+			mutate:  func(vc *VirtualCluster) { vc.Status.Phase = "" },
+			wantErr: true,
+		},
+		{
+			name:    "change cluster domain",
+			mutate:  func(vc *VirtualCluster) { vc.Spec.ClusterDomain = "cluster.foo" },
+			wantErr: true,
+		},
+		{
+			name:    "change service cidr",
+			mutate:  func(vc *VirtualCluster) { vc.Spec.ServiceCidr = "10.33.0.0/16" },
+			wantErr: true,
+		},
+		{
+			name:    "change node port range",
+			mutate:  func(vc *VirtualCluster) { vc.Spec.NodePortRange = "30200-30299" },
+			wantErr: true,
+		},
+		{
+			name:    "change to an invalid field",
+			mutate:  func(vc *VirtualCluster) { vc.Spec.PKIExpireDays = -1 },
+			wantErr: true,
+		},
+		{
+			name:    "change clusterversion",
+			mutate:  func(vc *VirtualCluster) { vc.Spec.ClusterVersionName = "cv-sample-lb" },
+			wantErr: true,
+		},
+		{
+			name: "change clusterversion with an upgrade request",
+			mutate: func(vc *VirtualCluster) {
+				vc.Spec.ClusterVersionName = "cv-sample-lb"
+				vc.Labels = map[string]string{constants.LabelVCReadyForUpgrade: "true"}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := newWebhookTestVirtualCluster()
+			vc := old.DeepCopy()
+			tt.mutate(vc)
+			err := vc.ValidateUpdate(old)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateUpdate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVirtualClusterValidateUpdateUnchangedFields(t *testing.T) {
+	old := newWebhookTestVirtualCluster()
+	old.Spec.PKIExpireDays = -1
+	old.Spec.ResourceCeiling = corev1.ResourceList{corev1.ResourceServices: resource.MustParse("10")}
+	vc := old.DeepCopy()
+	vc.Labels = map[string]string{"foo": "bar"}
+	vc.Status.Phase = ClusterUpdating
+	if err := vc.ValidateUpdate(old); err != nil {
+		t.Errorf("the unchanged fields should not be validated, got %v", err)
+	}
+}
@@ -34,6 +34,13 @@ type Controllers struct {
 	MaxConcurrentReconciles int
 	ProvisionerName         string
 	ProvisionerTimeout      time.Duration
+	// CertRotation enables the certificate rotation of the native virtual clusters.
+	CertRotation    bool
+	CertRenewBefore time.Duration
+	RotateCA        bool
+	CATrustWindow   time.Duration
+	// EncryptionKeyRotation enables the encryption key rotation of the native virtual clusters.
+	EncryptionKeyRotation bool
 }
 
 // SetupWithManager adds all Controllers to the Manager
@@ -62,6 +69,27 @@ func (c *Controllers) SetupWithManager(mgr ctrl.Manager) error {
 		}).SetupWithManager(mgr, opts); err != nil {
 			return err
 		}
+		if c.CertRotation {
+			if err := (&controllers.ReconcileCertRotation{
+				Client:             mgr.GetClient(),
+				Log:                c.Log.WithName("certrotation"),
+				ProvisionerTimeout: c.ProvisionerTimeout,
+				RenewBefore:        c.CertRenewBefore,
+				RotateCA:           c.RotateCA,
+				CATrustWindow:      c.CATrustWindow,
+			}).SetupWithManager(mgr, opts); err != nil {
+				return err
+			}
+		}
+		if c.EncryptionKeyRotation {
+			if err := (&controllers.ReconcileEncryptionKeyRotation{
+				Client:             mgr.GetClient(),
+				Log:                c.Log.WithName("encryptionkeyrotation"),
+				ProvisionerTimeout: c.ProvisionerTimeout,
+			}).SetupWithManager(mgr, opts); err != nil {
+				return err
+			}
+		}
 	}
 
 	if err := (&controllers.ReconcileVirtualCluster{
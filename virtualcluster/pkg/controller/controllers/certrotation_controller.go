@@ -0,0 +1,149 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/controllers/provisioner"
+	vcpki "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/pki"
+	pkiutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/pki"
+)
+
+const (
+	// DefaultCertRenewBefore is how long before expiry the certificates are rotated by default.
+	DefaultCertRenewBefore = 30 * 24 * time.Hour
+	// DefaultCATrustWindow is how long the previous root ca is trusted after a ca rotation by default.
+	DefaultCATrustWindow = 24 * time.Hour
+	// maxCertCheckInterval bounds how long a virtual cluster is not checked for expiring certificates.
+	maxCertCheckInterval = 12 * time.Hour
+)
+
+var _ reconcile.Reconciler = &ReconcileCertRotation{}
+
+// ReconcileCertRotation rotates the certificates of the virtual clusters created by the native provisioner
+// before they expire.
+type ReconcileCertRotation struct {
+	client.Client
+	Log                logr.Logger
+	ProvisionerTimeout time.Duration
+	// RenewBefore is how long before expiry the certificates are rotated. It is capped to a third of
+	// the certificate validity for short-lived certificates.
+	RenewBefore time.Duration
+	// RotateCA enables the rotation of the root ca when it expires within RenewBefore plus the
+	// certificate validity.
+	RotateCA bool
+	// CATrustWindow is how long the previous root ca is trusted after a ca rotation.
+	CATrustWindow time.Duration
+	Provisioner   *provisioner.Native
+}
+
+// SetupWithManager will configure the certificate rotation reconciler
+func (r *ReconcileCertRotation) SetupWithManager(mgr ctrl.Manager, opts controller.Options) error {
+	native, err := provisioner.NewProvisionerNative(mgr, r.Log, r.ProvisionerTimeout)
+	if err != nil {
+		return err
+	}
+	r.Provisioner = native
+	if r.RenewBefore == 0 {
+		r.RenewBefore = DefaultCertRenewBefore
+	}
+	if r.CATrustWindow == 0 {
+		r.CATrustWindow = DefaultCATrustWindow
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("certrotation").
+		WithOptions(opts).
+		For(&tenancyv1alpha1.VirtualCluster{}).
+		Complete(r)
+}
+
+// Reconcile checks the expiry of the certificates of a running VirtualCluster, rotates them if needed
+// and requeues the VirtualCluster for the next check.
+func (r *ReconcileCertRotation) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	vc := &tenancyv1alpha1.VirtualCluster{}
+	if err := r.Get(ctx, request.NamespacedName, vc); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if !vc.ObjectMeta.DeletionTimestamp.IsZero() || vc.Status.Phase != tenancyv1alpha1.ClusterRunning {
+		return reconcile.Result{}, nil
+	}
+
+	expiry, err := r.Provisioner.GetPKIExpiry(ctx, vc)
+	if err != nil {
+		r.Log.Error(err, "fail to get the certificates expiry", "vc", vc.GetName())
+		return reconcile.Result{}, err
+	}
+
+	now := time.Now()
+	renewBefore := r.renewBefore(vc)
+	renewAt := expiry.Certificates.Add(-renewBefore)
+	rotateCA := r.RotateCA && now.After(expiry.RootCA.Add(-renewBefore-certValidity(vc)))
+	switch {
+	case rotateCA || !now.Before(renewAt):
+		r.Log.Info("certificates are expiring, rotating", "vc", vc.GetName(), "notAfter", expiry.Certificates, "rotateCA", rotateCA)
+		if err := r.Provisioner.RotatePKI(ctx, vc, rotateCA, r.CATrustWindow); err != nil {
+			r.Log.Error(err, "fail to rotate certificates", "vc", vc.GetName())
+			return reconcile.Result{}, err
+		}
+		// check again once the new certificates are applied.
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	case !expiry.PreviousRootCAsTrustUntil.IsZero() && !now.Before(expiry.PreviousRootCAsTrustUntil):
+		if err := r.Provisioner.RemovePreviousRootCAs(ctx, vc); err != nil {
+			r.Log.Error(err, "fail to remove previous root cas", "vc", vc.GetName())
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	}
+
+	next := renewAt.Sub(now)
+	if !expiry.PreviousRootCAsTrustUntil.IsZero() && expiry.PreviousRootCAsTrustUntil.Sub(now) < next {
+		next = expiry.PreviousRootCAsTrustUntil.Sub(now)
+	}
+	if next > maxCertCheckInterval {
+		next = maxCertCheckInterval
+	}
+	return reconcile.Result{RequeueAfter: next}, nil
+}
+
+func certValidity(vc *tenancyv1alpha1.VirtualCluster) time.Duration {
+	if validity := vcpki.CertValidity(vc); validity != 0 {
+		return validity
+	}
+	return pkiutil.CertificateValidity
+}
+
+// renewBefore returns how long before expiry the certificates of vc are rotated.
+func (r *ReconcileCertRotation) renewBefore(vc *tenancyv1alpha1.VirtualCluster) time.Duration {
+	if limit := certValidity(vc) / 3; r.RenewBefore > limit {
+		return limit
+	}
+	return r.RenewBefore
+}
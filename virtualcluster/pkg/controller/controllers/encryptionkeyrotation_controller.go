@@ -0,0 +1,91 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/controllers/provisioner"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+// encryptionKeyRotationCheckInterval is the interval to check the apiserver while it is
+// restarted with the rotated encryption keys.
+const encryptionKeyRotationCheckInterval = 30 * time.Second
+
+var _ reconcile.Reconciler = &ReconcileEncryptionKeyRotation{}
+
+// ReconcileEncryptionKeyRotation rotates the key the secrets of the virtual clusters created by
+// the native provisioner are encrypted with, once the rotate-encryption-key annotation changes.
+type ReconcileEncryptionKeyRotation struct {
+	client.Client
+	Log                logr.Logger
+	ProvisionerTimeout time.Duration
+	Provisioner        *provisioner.Native
+}
+
+// SetupWithManager will configure the encryption key rotation reconciler
+func (r *ReconcileEncryptionKeyRotation) SetupWithManager(mgr ctrl.Manager, opts controller.Options) error {
+	native, err := provisioner.NewProvisionerNative(mgr, r.Log, r.ProvisionerTimeout)
+	if err != nil {
+		return err
+	}
+	r.Provisioner = native
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("encryptionkeyrotation").
+		WithOptions(opts).
+		For(&tenancyv1alpha1.VirtualCluster{}).
+		Complete(r)
+}
+
+// Reconcile moves the encryption key rotation of a running VirtualCluster to the next phase,
+// and requeues the VirtualCluster until the rotation is finished.
+func (r *ReconcileEncryptionKeyRotation) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	vc := &tenancyv1alpha1.VirtualCluster{}
+	if err := r.Get(ctx, request.NamespacedName, vc); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if !vc.ObjectMeta.DeletionTimestamp.IsZero() || vc.Status.Phase != tenancyv1alpha1.ClusterRunning {
+		return reconcile.Result{}, nil
+	}
+	if vc.GetAnnotations()[constants.LabelRotateEncryptionKey] == "" {
+		return reconcile.Result{}, nil
+	}
+
+	done, err := r.Provisioner.RotateEncryptionKey(ctx, vc)
+	if err != nil {
+		r.Log.Error(err, "fail to rotate the encryption key", "vc", vc.GetName())
+		return reconcile.Result{}, err
+	}
+	if !done {
+		return reconcile.Result{RequeueAfter: encryptionKeyRotationCheckInterval}, nil
+	}
+	return reconcile.Result{}, nil
+}
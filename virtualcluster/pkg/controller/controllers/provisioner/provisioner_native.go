@@ -141,15 +141,33 @@ func (mpn *Native) applyVirtualCluster(ctx context.Context, cv *tenancyv1alpha1.
 		return err
 	}
 
-	// 3. deploy etcd if defined
-	if applyETCD {
+	// 3. deploy etcd if defined, or replace it with kine if the datastore is defined
+	if cv.Spec.Datastore != nil {
+		err = mpn.applyKineDatastore(ctx, vc, cv)
+		if err != nil {
+			return err
+		}
+	} else if applyETCD && cv.Spec.ETCD != nil {
 		err = mpn.deployComponent(ctx, vc, cv.Spec.ETCD, clusterCAGroup)
 		if err != nil {
 			return err
 		}
 	}
 
-	// 4. deploy apiserver (must be defined always)
+	// 4. deploy apiserver (must be defined always), which encrypts the secrets and audits
+	// the requests if enabled
+	if cv.Spec.SecretEncryption != nil {
+		err = mpn.applySecretEncryption(ctx, vc, cv)
+		if err != nil {
+			return err
+		}
+	}
+	if cv.Spec.Audit != nil {
+		err = mpn.applyAudit(ctx, vc, cv)
+		if err != nil {
+			return err
+		}
+	}
 	err = mpn.deployComponent(ctx, vc, cv.Spec.APIServer, clusterCAGroup)
 	if err != nil {
 		return err
@@ -296,7 +314,7 @@ func (mpn *Native) deployComponent(ctx context.Context, vc *tenancyv1alpha1.Virt
 // for control plane components of the virtual cluster
 func (mpn *Native) createOrUpdatePKISecrets(ctx context.Context, caGroup *vcpki.ClusterCAGroup, namespace string) error {
 	// create secret for root crt/key pair
-	rootSrt := secret.CrtKeyPairToSecret(secret.RootCASecretName, namespace, caGroup.RootCA)
+	rootSrt := rootCASecret(caGroup, namespace)
 	// create secret for apiserver crt/key pair
 	apiserverSrt := secret.CrtKeyPairToSecret(secret.APIServerCASecretName,
 		namespace, caGroup.APIServer)
@@ -335,21 +353,34 @@ func (mpn *Native) createOrUpdatePKISecrets(ctx context.Context, caGroup *vcpki.
 	return nil
 }
 
-// createAndApplyPKI constructs the PKI (all crt/key pair and kubeconfig) for the
-// virtual clusters, and store them as secrets in the meta cluster
-// The method returns the current ClusterCAGroup to use it as annotations for control-plane pods for restart
-func (mpn *Native) createAndApplyPKI(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, cv *tenancyv1alpha1.ClusterVersion, isClusterIP bool) (*vcpki.ClusterCAGroup, error) {
-	ns := conversion.ToClusterKey(vc)
-	caGroup := &vcpki.ClusterCAGroup{}
+// rootCASecret builds the root ca secret, whose crt bundles the root ca with the
+// previous root cas that are still trusted.
+func rootCASecret(caGroup *vcpki.ClusterCAGroup, namespace string) *corev1.Secret {
+	rootSrt := secret.CrtKeyPairToSecret(secret.RootCASecretName, namespace, caGroup.RootCA)
+	if len(caGroup.PreviousRootCAs) == 0 {
+		return rootSrt
+	}
+	for _, ca := range caGroup.PreviousRootCAs {
+		rootSrt.Data[corev1.TLSCertKey] = append(rootSrt.Data[corev1.TLSCertKey], pkiutil.EncodeCertPEM(ca)...)
+	}
+	rootSrt.Annotations = map[string]string{
+		constants.LabelCATrustUntil: caGroup.PreviousRootCAsTrustUntil.UTC().Format(time.RFC3339),
+	}
+	return rootSrt
+}
 
-	var rootCAPair *vcpki.CrtKeyPair
+// loadPKI loads the root ca and the service account key of the virtual cluster from
+// the secrets. The returned ClusterCAGroup has a nil RootCA or ServiceAccountPrivateKey if
+// the corresponding secret does not exist.
+func (mpn *Native) loadPKI(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) (*vcpki.ClusterCAGroup, error) {
+	caGroup := &vcpki.ClusterCAGroup{}
+	ns := conversion.ToClusterKey(vc)
 
-	// reuse rootCa if it is present
 	rootCaSecret := &corev1.Secret{}
-	err := mpn.Get(ctx, client.ObjectKey{Name: secret.RootCASecretName, Namespace: vc.Status.ClusterNamespace}, rootCaSecret)
+	err := mpn.Get(ctx, client.ObjectKey{Name: secret.RootCASecretName, Namespace: ns}, rootCaSecret)
 	switch {
 	case err == nil:
-		rootCACrt, rootCAErr := pkiutil.DecodeCertPEM(rootCaSecret.Data[corev1.TLSCertKey])
+		rootCACrts, rootCAErr := cert.ParseCertsPEM(rootCaSecret.Data[corev1.TLSCertKey])
 		if rootCAErr != nil {
 			return nil, rootCAErr
 		}
@@ -357,53 +388,109 @@ func (mpn *Native) createAndApplyPKI(ctx context.Context, vc *tenancyv1alpha1.Vi
 		if rootCAErr != nil {
 			return nil, rootCAErr
 		}
-		rootCAPair = &vcpki.CrtKeyPair{
-			Crt: rootCACrt,
+		caGroup.RootCA = &vcpki.CrtKeyPair{
+			Crt: rootCACrts[0],
 			Key: rootCAKey,
 		}
-		mpn.Log.Info("rootCA pair is reused from the secret")
-	case apierrors.IsNotFound(err):
-		mpn.Log.Info("rootCA secret is not found. Creating")
-		rootCACrt, rootKey, rootCAErr := pkiutil.NewCertificateAuthority(
-			&pkiutil.CertConfig{
-				Config: cert.Config{
-					CommonName:   "kubernetes",
-					Organization: []string{"kubernetes-sig.kubernetes-sigs/multi-tenancy.virtualcluster"},
-				},
-			})
-		if rootCAErr != nil {
-			return nil, rootCAErr
-		}
-		rootRsaKey, ok := rootKey.(*rsa.PrivateKey)
-		if !ok {
-			return nil, errors.New("fail to assert rsa PrivateKey")
+		if len(rootCACrts) > 1 {
+			trustUntil, rootCAErr := time.Parse(time.RFC3339, rootCaSecret.Annotations[constants.LabelCATrustUntil])
+			if rootCAErr != nil {
+				return nil, fmt.Errorf("invalid %s of secret %s: %v", constants.LabelCATrustUntil, secret.RootCASecretName, rootCAErr)
+			}
+			caGroup.PreviousRootCAs = rootCACrts[1:]
+			caGroup.PreviousRootCAsTrustUntil = trustUntil
 		}
-		rootCAPair = &vcpki.CrtKeyPair{
-			Crt: rootCACrt,
-			Key: rootRsaKey,
-		}
-		mpn.Log.Info("rootCA pair generated")
+	case apierrors.IsNotFound(err):
 	default:
 		mpn.Log.Error(err, "failed to check rootCA secret existence")
 		return nil, err
 	}
-	caGroup.RootCA = rootCAPair
 
-	etcdDomains := append(cv.GetEtcdServers(), cv.GetEtcdDomain())
+	svcAcctSecret := &corev1.Secret{}
+	err = mpn.Get(ctx, client.ObjectKey{Name: secret.ServiceAccountSecretName, Namespace: ns}, svcAcctSecret)
+	switch {
+	case err == nil:
+		svcAcctKey, svcAcctErr := vcpki.DecodePrivateKeyPEM(svcAcctSecret.Data[corev1.TLSPrivateKeyKey])
+		if svcAcctErr != nil {
+			return nil, svcAcctErr
+		}
+		caGroup.ServiceAccountPrivateKey = svcAcctKey
+	case apierrors.IsNotFound(err):
+	default:
+		return nil, err
+	}
+	return caGroup, nil
+}
+
+// newRootCA creates a root ca for the virtual cluster.
+func newRootCA(vc *tenancyv1alpha1.VirtualCluster) (*vcpki.CrtKeyPair, error) {
+	rootCACrt, rootKey, err := pkiutil.NewCertificateAuthority(
+		&pkiutil.CertConfig{
+			Config: cert.Config{
+				CommonName:   "kubernetes",
+				Organization: []string{"kubernetes-sig.kubernetes-sigs/multi-tenancy.virtualcluster"},
+			},
+			Validity: vcpki.CAValidity(vc),
+		})
+	if err != nil {
+		return nil, err
+	}
+	rootRsaKey, ok := rootKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("fail to assert rsa PrivateKey")
+	}
+	return &vcpki.CrtKeyPair{
+		Crt: rootCACrt,
+		Key: rootRsaKey,
+	}, nil
+}
+
+// createAndApplyPKI constructs the PKI (all crt/key pair and kubeconfig) for the
+// virtual clusters, and store them as secrets in the meta cluster
+// The method returns the current ClusterCAGroup to use it as annotations for control-plane pods for restart
+func (mpn *Native) createAndApplyPKI(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, cv *tenancyv1alpha1.ClusterVersion, isClusterIP bool) (*vcpki.ClusterCAGroup, error) {
+	caGroup, err := mpn.loadPKI(ctx, vc)
+	if err != nil {
+		return nil, err
+	}
+	if caGroup.RootCA != nil {
+		mpn.Log.Info("rootCA pair is reused from the secret")
+	} else {
+		mpn.Log.Info("rootCA secret is not found. Creating")
+		caGroup.RootCA, err = newRootCA(vc)
+		if err != nil {
+			return nil, err
+		}
+		mpn.Log.Info("rootCA pair generated")
+	}
+	return mpn.issueAndApplyPKI(ctx, vc, cv, isClusterIP, caGroup)
+}
+
+// issueAndApplyPKI issues the crt/key pairs and kubeconfigs signed by the root ca of caGroup, and
+// store them as secrets in the meta cluster. The service account key is only created if caGroup does not have one.
+func (mpn *Native) issueAndApplyPKI(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, cv *tenancyv1alpha1.ClusterVersion, isClusterIP bool, caGroup *vcpki.ClusterCAGroup) (*vcpki.ClusterCAGroup, error) {
+	ns := conversion.ToClusterKey(vc)
+	rootCAPair := caGroup.RootCA
+	validity := vcpki.CertValidity(vc)
+
+	etcdDomains := cv.GetEtcdServers()
+	if etcdDomain := cv.GetEtcdDomain(); etcdDomain != "" {
+		etcdDomains = append(etcdDomains, etcdDomain)
+	}
 	// We may want to connect to etcd from controllers namespace
 	// So we duplicate etcdDomains here with the namespace
 	for _, etcdDomain := range etcdDomains {
 		etcdDomains = append(etcdDomains, etcdDomain+"."+ns)
 	}
 	// create crt, key for etcd
-	etcdCAPair, etcdCrtErr := vcpki.NewEtcdServerCertAndKey(rootCAPair, etcdDomains)
+	etcdCAPair, etcdCrtErr := vcpki.NewEtcdServerCertAndKey(rootCAPair, etcdDomains, validity)
 	if etcdCrtErr != nil {
 		return nil, etcdCrtErr
 	}
 	caGroup.ETCD = etcdCAPair
 
 	// create crt, key for frontendproxy
-	frontProxyCAPair, frontProxyCrtErr := vcpki.NewFrontProxyClientCertAndKey(rootCAPair)
+	frontProxyCAPair, frontProxyCrtErr := vcpki.NewFrontProxyClientCertAndKey(rootCAPair, validity)
 	if frontProxyCrtErr != nil {
 		return nil, frontProxyCrtErr
 	}
@@ -433,7 +520,7 @@ func (mpn *Native) createAndApplyPKI(ctx context.Context, vc *tenancyv1alpha1.Vi
 	// create kubeconfig for controller-manager
 	ctrlmgrKbCfg, err := kubeconfig.GenerateKubeconfig(
 		"system:kube-controller-manager",
-		vc.Name, finalAPIAddress, []string{}, rootCAPair)
+		vc.Name, finalAPIAddress, []string{}, rootCAPair, validity)
 	if err != nil {
 		return nil, err
 	}
@@ -442,18 +529,20 @@ func (mpn *Native) createAndApplyPKI(ctx context.Context, vc *tenancyv1alpha1.Vi
 	// create kubeconfig for admin user
 	adminKbCfg, err := kubeconfig.GenerateKubeconfig(
 		"admin", vc.Name, finalAPIAddress,
-		[]string{"system:masters"}, rootCAPair)
+		[]string{"system:masters"}, rootCAPair, validity)
 	if err != nil {
 		return nil, err
 	}
 	caGroup.AdminKbCfg = adminKbCfg
 
-	// create rsa key for service-account
-	svcAcctCAPair, err := vcpki.NewServiceAccountSigningKey()
-	if err != nil {
-		return nil, err
+	// create rsa key for service-account, the existing key is kept so that issued tokens stay valid
+	if caGroup.ServiceAccountPrivateKey == nil {
+		svcAcctCAPair, err := vcpki.NewServiceAccountSigningKey()
+		if err != nil {
+			return nil, err
+		}
+		caGroup.ServiceAccountPrivateKey = svcAcctCAPair
 	}
-	caGroup.ServiceAccountPrivateKey = svcAcctCAPair
 
 	// store ca and kubeconfig into secrets
 	genSrtsErr := mpn.createOrUpdatePKISecrets(ctx, caGroup, ns)
@@ -0,0 +1,255 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientcmdv1 "k8s.io/client-go/tools/clientcmd/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+const (
+	// AuditPolicyConfigMapName is the configmap in the root namespace of the virtual
+	// cluster that holds the audit policy of its apiserver
+	AuditPolicyConfigMapName = "audit-policy"
+
+	// auditPolicyKey is the key of the audit policy in the configmaps
+	auditPolicyKey = "policy.yaml"
+	auditPolicyDir = "/etc/kubernetes/audit"
+	// auditWebhookConfigKey is the key of the kubeconfig of the audit webhook in
+	// the audit webhook config secret
+	auditWebhookConfigKey        = "webhook-config.yaml"
+	auditWebhookConfigDir        = "/etc/kubernetes/audit-webhook"
+	auditWebhookConfigVolumeName = "audit-webhook-config"
+	auditLogVolumeName           = "audit-log"
+	auditConfigHashKey           = "audit-config-hash"
+	// auditWebhookName is the name of the cluster, the user and the context in
+	// the kubeconfig of the audit webhook
+	auditWebhookName = "audit-webhook"
+)
+
+// auditWebhookURL returns the URL of the audit webhook, which is tagged with the name
+// and the namespace of vc if required.
+func auditWebhookURL(webhook *tenancyv1alpha1.AuditWebhookBackend, vc *tenancyv1alpha1.VirtualCluster) (string, error) {
+	u, err := url.Parse(webhook.URL)
+	if err != nil {
+		return "", fmt.Errorf("invalid audit webhook URL %s: %v", webhook.URL, err)
+	}
+	if webhook.TagCluster {
+		query := u.Query()
+		query.Set("cluster", vc.GetName())
+		query.Set("namespace", vc.GetNamespace())
+		u.RawQuery = query.Encode()
+	}
+	return u.String(), nil
+}
+
+// genAuditWebhookConfig generates the kubeconfig the apiserver of vc sends the audit
+// events to the webhook with.
+func genAuditWebhookConfig(webhook *tenancyv1alpha1.AuditWebhookBackend, vc *tenancyv1alpha1.VirtualCluster) (string, error) {
+	server, err := auditWebhookURL(webhook, vc)
+	if err != nil {
+		return "", err
+	}
+	config, err := yaml.Marshal(&clientcmdv1.Config{
+		APIVersion: "v1",
+		Kind:       "Config",
+		Clusters: []clientcmdv1.NamedCluster{{
+			Name: auditWebhookName,
+			Cluster: clientcmdv1.Cluster{
+				Server:                   server,
+				CertificateAuthorityData: webhook.CABundle,
+			},
+		}},
+		AuthInfos: []clientcmdv1.NamedAuthInfo{{Name: auditWebhookName}},
+		Contexts: []clientcmdv1.NamedContext{{
+			Name:    auditWebhookName,
+			Context: clientcmdv1.Context{Cluster: auditWebhookName, AuthInfo: auditWebhookName},
+		}},
+		CurrentContext: auditWebhookName,
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(config), nil
+}
+
+// applyAudit copies the audit policy of cv to the root namespace of vc, creates the
+// kubeconfig of the audit webhook if any, and configures the apiserver template of cv
+// to audit the requests with them.
+func (mpn *Native) applyAudit(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, cv *tenancyv1alpha1.ClusterVersion) error {
+	audit := cv.Spec.Audit
+	ref := audit.PolicyRef
+	if ref.Name == "" || ref.Namespace == "" {
+		return fmt.Errorf("policyRef of the audit needs a name and a namespace")
+	}
+	policyConfigMap := &corev1.ConfigMap{}
+	if err := mpn.Get(ctx, client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}, policyConfigMap); err != nil {
+		return err
+	}
+	policy, ok := policyConfigMap.Data[auditPolicyKey]
+	if !ok {
+		return fmt.Errorf("configmap %s/%s has no key %s", ref.Namespace, ref.Name, auditPolicyKey)
+	}
+
+	ns := conversion.ToClusterKey(vc)
+	cm := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      AuditPolicyConfigMapName,
+			Namespace: ns,
+		},
+		Data: map[string]string{
+			auditPolicyKey: policy,
+		},
+	}
+	mpn.Log.Info("applying configmap", "name", cm.Name, "namespace", cm.Namespace)
+	if err := mpn.Patch(ctx, cm, client.Apply, patchOptions); err != nil {
+		return err
+	}
+
+	webhookConfig := ""
+	if audit.Webhook != nil {
+		var err error
+		webhookConfig, err = genAuditWebhookConfig(audit.Webhook, vc)
+		if err != nil {
+			return err
+		}
+		srt := &corev1.Secret{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "Secret",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      secret.AuditWebhookConfigSecretName,
+				Namespace: ns,
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: map[string][]byte{
+				auditWebhookConfigKey: []byte(webhookConfig),
+			},
+		}
+		mpn.Log.Info("applying secret", "name", srt.Name, "namespace", srt.Namespace)
+		if err := mpn.Patch(ctx, srt, client.Apply, patchOptions); err != nil {
+			return err
+		}
+	}
+	return complementAPIServerAudit(cv.Spec.APIServer, audit, secret.GetHash(policy+webhookConfig))
+}
+
+// complementAPIServerAudit mounts the audit policy, and the kubeconfig of the audit
+// webhook if any, into the apiserver and sets the flags of the audit backends. The
+// hash of the audit configuration is added to the pod template so that the apiserver
+// is restarted once it changes.
+func complementAPIServerAudit(apiserverBdl *tenancyv1alpha1.StatefulSetSvcBundle, audit *tenancyv1alpha1.Audit, configHash string) error {
+	podTemplate := &apiserverBdl.StatefulSet.Spec.Template
+	if len(podTemplate.Spec.Containers) == 0 {
+		return fmt.Errorf("apiserver %s has no container", apiserverBdl.Name)
+	}
+	container := &podTemplate.Spec.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      AuditPolicyConfigMapName,
+		MountPath: auditPolicyDir,
+		ReadOnly:  true,
+	})
+	podTemplate.Spec.Volumes = append(podTemplate.Spec.Volumes, corev1.Volume{
+		Name: AuditPolicyConfigMapName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: AuditPolicyConfigMapName},
+			},
+		},
+	})
+	flags := []string{"--audit-policy-file=" + path.Join(auditPolicyDir, auditPolicyKey)}
+
+	if log := audit.Log; log != nil {
+		logPath := log.Path
+		if logPath == "" {
+			logPath = "-"
+		}
+		flags = append(flags, "--audit-log-path="+logPath)
+		if logPath != "-" {
+			container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+				Name:      auditLogVolumeName,
+				MountPath: path.Dir(logPath),
+			})
+			podTemplate.Spec.Volumes = append(podTemplate.Spec.Volumes, corev1.Volume{
+				Name:         auditLogVolumeName,
+				VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+			})
+		}
+		if log.MaxAge > 0 {
+			flags = append(flags, "--audit-log-maxage="+strconv.Itoa(int(log.MaxAge)))
+		}
+		if log.MaxBackups > 0 {
+			flags = append(flags, "--audit-log-maxbackup="+strconv.Itoa(int(log.MaxBackups)))
+		}
+		if log.MaxSize > 0 {
+			flags = append(flags, "--audit-log-maxsize="+strconv.Itoa(int(log.MaxSize)))
+		}
+	}
+
+	if audit.Webhook != nil {
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      auditWebhookConfigVolumeName,
+			MountPath: auditWebhookConfigDir,
+			ReadOnly:  true,
+		})
+		podTemplate.Spec.Volumes = append(podTemplate.Spec.Volumes, corev1.Volume{
+			Name: auditWebhookConfigVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: secret.AuditWebhookConfigSecretName,
+				},
+			},
+		})
+		flags = append(flags, "--audit-webhook-config-file="+path.Join(auditWebhookConfigDir, auditWebhookConfigKey))
+	}
+
+	auditFlags := []string{"--audit-policy-file", "--audit-log-path", "--audit-log-maxage",
+		"--audit-log-maxbackup", "--audit-log-maxsize", "--audit-webhook-config-file"}
+	container.Command = removeFlags(container.Command, auditFlags...)
+	container.Args = removeFlags(container.Args, auditFlags...)
+	if len(container.Args) != 0 {
+		container.Args = append(container.Args, flags...)
+	} else {
+		container.Command = append(container.Command, flags...)
+	}
+
+	annotations := podTemplate.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[auditConfigHashKey] = configHash
+	podTemplate.SetAnnotations(annotations)
+	return nil
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestGenAuditWebhookConfig(t *testing.T) {
+	vc := &tenancyv1alpha1.VirtualCluster{ObjectMeta: metav1.ObjectMeta{Name: "vc-sample-1", Namespace: "default"}}
+	tests := []struct {
+		name       string
+		webhook    tenancyv1alpha1.AuditWebhookBackend
+		wantServer string
+		wantCA     string
+	}{
+		{
+			name:       "untagged webhook",
+			webhook:    tenancyv1alpha1.AuditWebhookBackend{URL: "https://audit-collector.audit-system.svc/events"},
+			wantServer: "https://audit-collector.audit-system.svc/events",
+		},
+		{
+			name: "tagged webhook with a CA bundle",
+			webhook: tenancyv1alpha1.AuditWebhookBackend{
+				URL:        "https://audit-collector.audit-system.svc/events?source=vc",
+				CABundle:   []byte("ca"),
+				TagCluster: true,
+			},
+			wantServer: "https://audit-collector.audit-system.svc/events?cluster=vc-sample-1&namespace=default&source=vc",
+			wantCA:     "Y2E=",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := genAuditWebhookConfig(&tt.webhook, vc)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			kubeconfig := struct {
+				Clusters []struct {
+					Cluster struct {
+						Server string `json:"server"`
+						CA     string `json:"certificate-authority-data"`
+					} `json:"cluster"`
+				} `json:"clusters"`
+			}{}
+			if err := yaml.Unmarshal([]byte(config), &kubeconfig); err != nil {
+				t.Fatalf("invalid webhook config: %v\n%s", err, config)
+			}
+			if len(kubeconfig.Clusters) != 1 {
+				t.Fatalf("expected a single cluster, got\n%s", config)
+			}
+			cluster := kubeconfig.Clusters[0].Cluster
+			if cluster.Server != tt.wantServer || cluster.CA != tt.wantCA {
+				t.Errorf("expected server %s and CA %q, got server %s and CA %q", tt.wantServer, tt.wantCA, cluster.Server, cluster.CA)
+			}
+		})
+	}
+}
+
+func TestComplementAPIServerAudit(t *testing.T) {
+	bdl := &tenancyv1alpha1.StatefulSetSvcBundle{
+		ObjectMeta: metav1.ObjectMeta{Name: "apiserver"},
+		StatefulSet: &appsv1.StatefulSet{
+			Spec: appsv1.StatefulSetSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{
+								Name:    "apiserver",
+								Command: []string{"kube-apiserver"},
+								Args: []string{
+									"--bind-address=0.0.0.0",
+									"--audit-log-path=/audit.log",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	audit := &tenancyv1alpha1.Audit{
+		Log:     &tenancyv1alpha1.AuditLogBackend{Path: "/var/log/kubernetes/audit.log", MaxBackups: 3},
+		Webhook: &tenancyv1alpha1.AuditWebhookBackend{URL: "https://audit-collector.audit-system.svc/events"},
+	}
+	if err := complementAPIServerAudit(bdl, audit, "hash"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	template := bdl.StatefulSet.Spec.Template
+	expectedArgs := []string{
+		"--bind-address=0.0.0.0",
+		"--audit-policy-file=/etc/kubernetes/audit/policy.yaml",
+		"--audit-log-path=/var/log/kubernetes/audit.log",
+		"--audit-log-maxbackup=3",
+		"--audit-webhook-config-file=/etc/kubernetes/audit-webhook/webhook-config.yaml",
+	}
+	if !reflect.DeepEqual(template.Spec.Containers[0].Args, expectedArgs) {
+		t.Errorf("expected args %v, got %v", expectedArgs, template.Spec.Containers[0].Args)
+	}
+	mounts := map[string]string{}
+	for _, m := range template.Spec.Containers[0].VolumeMounts {
+		mounts[m.Name] = m.MountPath
+	}
+	expectedMounts := map[string]string{
+		AuditPolicyConfigMapName:     auditPolicyDir,
+		auditLogVolumeName:           "/var/log/kubernetes",
+		auditWebhookConfigVolumeName: auditWebhookConfigDir,
+	}
+	if !reflect.DeepEqual(mounts, expectedMounts) {
+		t.Errorf("expected mounts %v, got %v", expectedMounts, mounts)
+	}
+	if len(template.Spec.Volumes) != 3 {
+		t.Errorf("expected the volumes of the audit configuration, got %+v", template.Spec.Volumes)
+	}
+	if template.Annotations[auditConfigHashKey] != "hash" {
+		t.Errorf("expected the hash of the audit configuration, got %v", template.Annotations)
+	}
+
+	bdl.StatefulSet.Spec.Template.Spec.Containers[0].Args = nil
+	bdl.StatefulSet.Spec.Template.Spec.Containers[0].VolumeMounts = nil
+	bdl.StatefulSet.Spec.Template.Spec.Volumes = nil
+	if err := complementAPIServerAudit(bdl, &tenancyv1alpha1.Audit{Log: &tenancyv1alpha1.AuditLogBackend{}}, "hash"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	command := strings.Join(bdl.StatefulSet.Spec.Template.Spec.Containers[0].Command, " ")
+	if !strings.HasSuffix(command, "--audit-log-path=-") {
+		t.Errorf("expected the audit events on the standard output, got %s", command)
+	}
+	if volumes := bdl.StatefulSet.Spec.Template.Spec.Volumes; len(volumes) != 1 {
+		t.Errorf("expected only the audit policy volume, got %+v", volumes)
+	}
+
+	bdl.StatefulSet.Spec.Template.Spec.Containers = nil
+	if err := complementAPIServerAudit(bdl, audit, "hash"); err == nil {
+		t.Errorf("expected error for the apiserver without container")
+	}
+}
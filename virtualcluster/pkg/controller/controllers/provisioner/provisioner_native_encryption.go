@@ -0,0 +1,413 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apiserverconfigv1 "k8s.io/apiserver/pkg/apis/config/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+const (
+	// encryptionConfigKey is the key of the EncryptionConfiguration in the
+	// encryption config secret
+	encryptionConfigKey        = "encryption-config.yaml"
+	encryptionConfigDir        = "/etc/kubernetes/encryption"
+	encryptionConfigVolumeName = "encryption-config"
+	encryptionConfigHashKey    = secret.EncryptionConfigSecretName + "-hash"
+	// encryptionKeySize is the size of the keys in bytes, aesgcm uses AES-256
+	encryptionKeySize = 32
+	// reencryptPageSize is the number of secrets listed at a time while
+	// they are re-encrypted
+	reencryptPageSize = 500
+
+	// encryptionKeyAdded is the phase of a key rotation in which the new key
+	// is added as a read-only key, so that all the apiservers can decrypt
+	// with it before any of them encrypts with it
+	encryptionKeyAdded = "KeyAdded"
+	// encryptionKeyPromoted is the phase of a key rotation in which the new
+	// key encrypts the secrets, the previous keys are removed once the
+	// secrets are re-encrypted
+	encryptionKeyPromoted = "KeyPromoted"
+)
+
+// encryptionKey is a key of the EncryptionConfiguration along with its provider
+type encryptionKey struct {
+	Provider tenancyv1alpha1.SecretEncryptionProvider
+	apiserverconfigv1.Key
+}
+
+// encryptionProvider returns the provider the new keys of se are generated for
+func encryptionProvider(se *tenancyv1alpha1.SecretEncryption) tenancyv1alpha1.SecretEncryptionProvider {
+	if se.Provider == "" {
+		return tenancyv1alpha1.SecretboxEncryptionProvider
+	}
+	return se.Provider
+}
+
+// newEncryptionKey generates a random key of provider, which is named after the
+// latest of the existing keys, e.g. key2 follows key1.
+func newEncryptionKey(provider tenancyv1alpha1.SecretEncryptionProvider, keys []encryptionKey) (encryptionKey, error) {
+	latest := 0
+	for _, key := range keys {
+		if i, err := strconv.Atoi(strings.TrimPrefix(key.Name, "key")); err == nil && i > latest {
+			latest = i
+		}
+	}
+	secret := make([]byte, encryptionKeySize)
+	if _, err := rand.Read(secret); err != nil {
+		return encryptionKey{}, err
+	}
+	return encryptionKey{
+		Provider: provider,
+		Key: apiserverconfigv1.Key{
+			Name:   fmt.Sprintf("key%d", latest+1),
+			Secret: base64.StdEncoding.EncodeToString(secret),
+		},
+	}, nil
+}
+
+// genEncryptionConfig generates the EncryptionConfiguration that encrypts the secrets
+// with the first key and decrypts them with any of the keys. The identity provider
+// comes last so that the secrets stored before the encryption is enabled can be read.
+func genEncryptionConfig(keys []encryptionKey) ([]byte, error) {
+	var providers []apiserverconfigv1.ProviderConfiguration
+	for i, key := range keys {
+		if i == 0 || key.Provider != keys[i-1].Provider {
+			providers = append(providers, apiserverconfigv1.ProviderConfiguration{})
+		}
+		provider := &providers[len(providers)-1]
+		switch key.Provider {
+		case tenancyv1alpha1.SecretboxEncryptionProvider:
+			if provider.Secretbox == nil {
+				provider.Secretbox = &apiserverconfigv1.SecretboxConfiguration{}
+			}
+			provider.Secretbox.Keys = append(provider.Secretbox.Keys, key.Key)
+		case tenancyv1alpha1.AESGCMEncryptionProvider:
+			if provider.AESGCM == nil {
+				provider.AESGCM = &apiserverconfigv1.AESConfiguration{}
+			}
+			provider.AESGCM.Keys = append(provider.AESGCM.Keys, key.Key)
+		default:
+			return nil, fmt.Errorf("unknown encryption provider %s", key.Provider)
+		}
+	}
+	providers = append(providers, apiserverconfigv1.ProviderConfiguration{
+		Identity: &apiserverconfigv1.IdentityConfiguration{},
+	})
+	return yaml.Marshal(&apiserverconfigv1.EncryptionConfiguration{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: apiserverconfigv1.SchemeGroupVersion.String(),
+			Kind:       "EncryptionConfiguration",
+		},
+		Resources: []apiserverconfigv1.ResourceConfiguration{
+			{
+				Resources: []string{"secrets"},
+				Providers: providers,
+			},
+		},
+	})
+}
+
+// parseEncryptionKeys reads the keys of the EncryptionConfiguration in order.
+func parseEncryptionKeys(data []byte) ([]encryptionKey, error) {
+	config := &apiserverconfigv1.EncryptionConfiguration{}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("invalid encryption config: %v", err)
+	}
+	var keys []encryptionKey
+	for _, resource := range config.Resources {
+		for _, provider := range resource.Providers {
+			switch {
+			case provider.Secretbox != nil:
+				for _, key := range provider.Secretbox.Keys {
+					keys = append(keys, encryptionKey{Provider: tenancyv1alpha1.SecretboxEncryptionProvider, Key: key})
+				}
+			case provider.AESGCM != nil:
+				for _, key := range provider.AESGCM.Keys {
+					keys = append(keys, encryptionKey{Provider: tenancyv1alpha1.AESGCMEncryptionProvider, Key: key})
+				}
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("encryption config has no key")
+	}
+	return keys, nil
+}
+
+// rotateEncryptionKeys moves a key rotation to the next phase, and returns the keys
+// and the phase after it. A new key of provider is added as a read-only key first,
+// then it is promoted to encrypt the secrets, and the previous keys are removed once
+// the secrets are re-encrypted with it.
+func rotateEncryptionKeys(keys []encryptionKey, phase string, provider tenancyv1alpha1.SecretEncryptionProvider) ([]encryptionKey, string, error) {
+	switch phase {
+	case "":
+		key, err := newEncryptionKey(provider, keys)
+		if err != nil {
+			return nil, "", err
+		}
+		return append(append([]encryptionKey{}, keys...), key), encryptionKeyAdded, nil
+	case encryptionKeyAdded:
+		latest := keys[len(keys)-1]
+		return append([]encryptionKey{latest}, keys[:len(keys)-1]...), encryptionKeyPromoted, nil
+	case encryptionKeyPromoted:
+		return keys[:1], "", nil
+	default:
+		return nil, "", fmt.Errorf("unknown phase %s of the encryption key rotation", phase)
+	}
+}
+
+// encryptionConfigSecret encapsulates the EncryptionConfiguration into a secret object
+func encryptionConfigSecret(namespace string, config []byte, annotations map[string]string) *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: corev1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        secret.EncryptionConfigSecretName,
+			Namespace:   namespace,
+			Annotations: annotations,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			encryptionConfigKey: config,
+		},
+	}
+}
+
+// applySecretEncryption creates the encryption config secret of vc with a new key if it
+// is not found, and configures the apiserver template of cv to encrypt the secrets with
+// it. The keys of an existing secret are only changed by a key rotation.
+func (mpn *Native) applySecretEncryption(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, cv *tenancyv1alpha1.ClusterVersion) error {
+	ns := conversion.ToClusterKey(vc)
+	srt := &corev1.Secret{}
+	err := mpn.Get(ctx, client.ObjectKey{Name: secret.EncryptionConfigSecretName, Namespace: ns}, srt)
+	switch {
+	case err == nil:
+	case apierrors.IsNotFound(err):
+		key, err := newEncryptionKey(encryptionProvider(cv.Spec.SecretEncryption), nil)
+		if err != nil {
+			return err
+		}
+		config, err := genEncryptionConfig([]encryptionKey{key})
+		if err != nil {
+			return err
+		}
+		srt = encryptionConfigSecret(ns, config, nil)
+		mpn.Log.Info("applying secret", "name", srt.Name, "namespace", srt.Namespace)
+		if err := mpn.Patch(ctx, srt, client.Apply, patchOptions); err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+	return complementAPIServerEncryption(cv.Spec.APIServer, secret.GetHash(string(srt.Data[encryptionConfigKey])))
+}
+
+// complementAPIServerEncryption mounts the encryption config secret into the apiserver
+// and points the apiserver to it. The hash of the config is added to the pod template
+// so that the apiserver is restarted once the keys change.
+func complementAPIServerEncryption(apiserverBdl *tenancyv1alpha1.StatefulSetSvcBundle, configHash string) error {
+	podTemplate := &apiserverBdl.StatefulSet.Spec.Template
+	if len(podTemplate.Spec.Containers) == 0 {
+		return fmt.Errorf("apiserver %s has no container", apiserverBdl.Name)
+	}
+	container := &podTemplate.Spec.Containers[0]
+	flag := "--encryption-provider-config=" + path.Join(encryptionConfigDir, encryptionConfigKey)
+	container.Command = removeFlags(container.Command, "--encryption-provider-config")
+	container.Args = removeFlags(container.Args, "--encryption-provider-config")
+	if len(container.Args) != 0 {
+		container.Args = append(container.Args, flag)
+	} else {
+		container.Command = append(container.Command, flag)
+	}
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      encryptionConfigVolumeName,
+		MountPath: encryptionConfigDir,
+		ReadOnly:  true,
+	})
+	podTemplate.Spec.Volumes = append(podTemplate.Spec.Volumes, corev1.Volume{
+		Name: encryptionConfigVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: secret.EncryptionConfigSecretName,
+			},
+		},
+	})
+
+	annotations := podTemplate.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[encryptionConfigHashKey] = configHash
+	podTemplate.SetAnnotations(annotations)
+	return nil
+}
+
+// RotateEncryptionKey moves the rotation of the key the secrets of vc are encrypted with to
+// the next phase once the apiserver runs with the current keys, and restarts the apiserver
+// with the rotated keys. A rotation is requested by changing the constants.LabelRotateEncryptionKey
+// annotation of vc. It returns true if no rotation is in progress.
+func (mpn *Native) RotateEncryptionKey(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) (bool, error) {
+	cv, err := mpn.fetchClusterVersion(vc)
+	if err != nil {
+		return false, err
+	}
+	if cv.Spec.SecretEncryption == nil {
+		mpn.Log.Info("secret encryption is not enabled, skip the key rotation", "vc", vc.GetName(), "clusterversion", cv.GetName())
+		return true, nil
+	}
+
+	ns := conversion.ToClusterKey(vc)
+	srt := &corev1.Secret{}
+	if err := mpn.Get(ctx, client.ObjectKey{Name: secret.EncryptionConfigSecretName, Namespace: ns}, srt); err != nil {
+		return false, err
+	}
+	requested := vc.GetAnnotations()[constants.LabelRotateEncryptionKey]
+	rotated := srt.Annotations[constants.LabelEncryptionKeyRotated]
+	phase := srt.Annotations[constants.LabelEncryptionKeyRotationPhase]
+	if phase == "" && (requested == "" || requested == rotated) {
+		return true, nil
+	}
+
+	apiserver := &appsv1.StatefulSet{}
+	if err := mpn.Get(ctx, client.ObjectKey{Name: cv.Spec.APIServer.Name, Namespace: ns}, apiserver); err != nil {
+		return false, err
+	}
+	if !isStatefulSetRolledOut(apiserver) ||
+		apiserver.Spec.Template.Annotations[encryptionConfigHashKey] != secret.GetHash(string(srt.Data[encryptionConfigKey])) {
+		mpn.Log.Info("waiting for the apiserver to run with the encryption keys", "vc", vc.GetName(), "phase", phase)
+		return false, nil
+	}
+
+	keys, err := parseEncryptionKeys(srt.Data[encryptionConfigKey])
+	if err != nil {
+		return false, err
+	}
+	if phase == encryptionKeyPromoted {
+		mpn.Log.Info("re-encrypting secrets with the new key", "vc", vc.GetName())
+		tenantClient, err := mpn.tenantClient(ctx, ns)
+		if err != nil {
+			return false, err
+		}
+		if err := reencryptSecrets(ctx, tenantClient); err != nil {
+			return false, err
+		}
+	}
+	keys, phase, err = rotateEncryptionKeys(keys, phase, encryptionProvider(cv.Spec.SecretEncryption))
+	if err != nil {
+		return false, err
+	}
+	config, err := genEncryptionConfig(keys)
+	if err != nil {
+		return false, err
+	}
+
+	annotations := map[string]string{}
+	if phase != "" {
+		annotations[constants.LabelEncryptionKeyRotationPhase] = phase
+	} else {
+		rotated = requested
+	}
+	if rotated != "" {
+		annotations[constants.LabelEncryptionKeyRotated] = rotated
+	}
+	mpn.Log.Info("rotating encryption key", "vc", vc.GetName(), "phase", phase)
+	if err := mpn.Patch(ctx, encryptionConfigSecret(ns, config, annotations), client.Apply, patchOptions); err != nil {
+		return false, err
+	}
+
+	// restart the apiserver to load the rotated keys
+	patch := client.MergeFrom(apiserver.DeepCopy())
+	apiserver.Spec.Template.Annotations[encryptionConfigHashKey] = secret.GetHash(string(config))
+	if err := mpn.Patch(ctx, apiserver, patch); err != nil {
+		return false, err
+	}
+	return phase == "", nil
+}
+
+// isStatefulSetRolledOut checks if all the replicas of sts run the latest revision and are ready.
+func isStatefulSetRolledOut(sts *appsv1.StatefulSet) bool {
+	replicas := int32(1)
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+	return sts.Status.ObservedGeneration >= sts.Generation &&
+		sts.Status.UpdatedReplicas == replicas &&
+		sts.Status.ReadyReplicas == replicas
+}
+
+// tenantClient creates a client of the tenant control plane with its admin kubeconfig.
+func (mpn *Native) tenantClient(ctx context.Context, namespace string) (kubernetes.Interface, error) {
+	adminSecret := &corev1.Secret{}
+	if err := mpn.Get(ctx, client.ObjectKey{Name: secret.AdminSecretName, Namespace: namespace}, adminSecret); err != nil {
+		return nil, err
+	}
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(adminSecret.Data[secret.AdminSecretName])
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(restConfig)
+}
+
+// reencryptSecrets rewrites all the secrets of the tenant control plane, so that the
+// apiserver stores them encrypted with the current key. The apiserver writes an
+// unchanged secret to the storage if it was decrypted with another key or was not
+// encrypted.
+func reencryptSecrets(ctx context.Context, cli kubernetes.Interface) error {
+	opts := metav1.ListOptions{Limit: reencryptPageSize}
+	for {
+		secrets, err := cli.CoreV1().Secrets(metav1.NamespaceAll).List(ctx, opts)
+		if err != nil {
+			return err
+		}
+		for i := range secrets.Items {
+			srt := &secrets.Items[i]
+			// a secret that is deleted or updated since it is listed is already
+			// stored with the current key
+			_, err := cli.CoreV1().Secrets(srt.Namespace).Update(ctx, srt, metav1.UpdateOptions{})
+			if err != nil && !apierrors.IsNotFound(err) && !apierrors.IsConflict(err) {
+				return fmt.Errorf("failed to re-encrypt secret %s/%s: %v", srt.Namespace, srt.Name, err)
+			}
+		}
+		if secrets.Continue == "" {
+			return nil
+		}
+		opts.Continue = secrets.Continue
+	}
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+)
+
+func TestEncryptionConfig(t *testing.T) {
+	key1, err := newEncryptionKey(tenancyv1alpha1.AESGCMEncryptionProvider, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	key2, err := newEncryptionKey(tenancyv1alpha1.SecretboxEncryptionProvider, []encryptionKey{key1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key1.Name != "key1" || key2.Name != "key2" || key1.Secret == key2.Secret {
+		t.Fatalf("expected distinct keys key1 and key2, got %s and %s", key1.Name, key2.Name)
+	}
+
+	keys := []encryptionKey{key2, key1}
+	config, err := genEncryptionConfig(keys)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the secretbox key encrypts, the aesgcm key and the identity provider only decrypt
+	secretbox, aesgcm, identity := strings.Index(string(config), "secretbox:"), strings.Index(string(config), "aesgcm:"), strings.Index(string(config), "identity:")
+	if secretbox < 0 || aesgcm < secretbox || identity < aesgcm {
+		t.Errorf("expected the providers secretbox, aesgcm and identity in order, got\n%s", config)
+	}
+	got, err := parseEncryptionKeys(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, keys) {
+		t.Errorf("expected keys %+v, got %+v", keys, got)
+	}
+
+	if _, err := parseEncryptionKeys([]byte("kind: EncryptionConfiguration")); err == nil {
+		t.Errorf("expected error for the config without keys")
+	}
+}
+
+func TestRotateEncryptionKeys(t *testing.T) {
+	key1, err := newEncryptionKey(tenancyv1alpha1.SecretboxEncryptionProvider, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	keys, phase, err := rotateEncryptionKeys([]encryptionKey{key1}, "", tenancyv1alpha1.SecretboxEncryptionProvider)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if phase != encryptionKeyAdded || len(keys) != 2 || keys[0] != key1 || keys[1].Name != "key2" {
+		t.Fatalf("expected key2 to be added after key1, got phase %s, keys %+v", phase, keys)
+	}
+	key2 := keys[1]
+
+	keys, phase, err = rotateEncryptionKeys(keys, phase, tenancyv1alpha1.SecretboxEncryptionProvider)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if phase != encryptionKeyPromoted || !reflect.DeepEqual(keys, []encryptionKey{key2, key1}) {
+		t.Fatalf("expected key2 to be promoted, got phase %s, keys %+v", phase, keys)
+	}
+
+	keys, phase, err = rotateEncryptionKeys(keys, phase, tenancyv1alpha1.SecretboxEncryptionProvider)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if phase != "" || !reflect.DeepEqual(keys, []encryptionKey{key2}) {
+		t.Fatalf("expected key1 to be removed, got phase %s, keys %+v", phase, keys)
+	}
+
+	if _, _, err := rotateEncryptionKeys(keys, "Unknown", tenancyv1alpha1.SecretboxEncryptionProvider); err == nil {
+		t.Errorf("expected error for an unknown phase")
+	}
+}
+
+func TestComplementAPIServerEncryption(t *testing.T) {
+	bdl := &tenancyv1alpha1.StatefulSetSvcBundle{
+		ObjectMeta: metav1.ObjectMeta{Name: "apiserver"},
+		StatefulSet: &appsv1.StatefulSet{
+			Spec: appsv1.StatefulSetSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{
+								Name:    "apiserver",
+								Command: []string{"kube-apiserver"},
+								Args: []string{
+									"--bind-address=0.0.0.0",
+									"--encryption-provider-config=/etc/encryption.yaml",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if err := complementAPIServerEncryption(bdl, "hash"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	template := bdl.StatefulSet.Spec.Template
+	expectedArgs := []string{"--bind-address=0.0.0.0", "--encryption-provider-config=/etc/kubernetes/encryption/encryption-config.yaml"}
+	if !reflect.DeepEqual(template.Spec.Containers[0].Args, expectedArgs) {
+		t.Errorf("expected args %v, got %v", expectedArgs, template.Spec.Containers[0].Args)
+	}
+	if mounts := template.Spec.Containers[0].VolumeMounts; len(mounts) != 1 || mounts[0].MountPath != encryptionConfigDir {
+		t.Errorf("expected the encryption config to be mounted, got %+v", mounts)
+	}
+	if volumes := template.Spec.Volumes; len(volumes) != 1 || volumes[0].Secret == nil || volumes[0].Secret.SecretName != "encryption-config" {
+		t.Errorf("expected the encryption config volume, got %+v", volumes)
+	}
+	if template.Annotations[encryptionConfigHashKey] != "hash" {
+		t.Errorf("expected the hash of the encryption config, got %v", template.Annotations)
+	}
+
+	bdl.StatefulSet.Spec.Template.Spec.Containers = nil
+	if err := complementAPIServerEncryption(bdl, "hash"); err == nil {
+		t.Errorf("expected error for the apiserver without container")
+	}
+}
+
+func TestReencryptSecrets(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "secret-1", Namespace: "default"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "secret-2", Namespace: "kube-system"}},
+	)
+	if err := reencryptSecrets(context.TODO(), client); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	updated := map[string]bool{}
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "update" && action.GetResource().Resource == "secrets" {
+			updated[action.GetNamespace()] = true
+		}
+	}
+	if !updated["default"] || !updated["kube-system"] {
+		t.Errorf("expected all secrets to be updated, got updates in %v", updated)
+	}
+}
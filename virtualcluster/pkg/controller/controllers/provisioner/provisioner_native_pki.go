@@ -0,0 +1,186 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/secret"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	pkiutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/pki"
+)
+
+// PKIExpiry describes when the PKI of a virtual cluster expires.
+type PKIExpiry struct {
+	// Certificates is the earliest expiry of the certificates signed by the root ca.
+	Certificates time.Time
+	// RootCA is the expiry of the root ca.
+	RootCA time.Time
+	// PreviousRootCAsTrustUntil is set if the root ca was rotated and the previous
+	// root cas are still trusted.
+	PreviousRootCAsTrustUntil time.Time
+}
+
+// GetPKIExpiry reads the expiry of the certificates of vc from the secrets.
+func (mpn *Native) GetPKIExpiry(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) (*PKIExpiry, error) {
+	caGroup, err := mpn.loadPKI(ctx, vc)
+	if err != nil {
+		return nil, err
+	}
+	if caGroup.RootCA == nil {
+		return nil, fmt.Errorf("secret %s of virtualcluster %s not found", secret.RootCASecretName, vc.Name)
+	}
+	expiry := &PKIExpiry{
+		RootCA:                    caGroup.RootCA.Crt.NotAfter,
+		PreviousRootCAsTrustUntil: caGroup.PreviousRootCAsTrustUntil,
+	}
+
+	ns := conversion.ToClusterKey(vc)
+	observe := func(crt *x509.Certificate) {
+		if expiry.Certificates.IsZero() || crt.NotAfter.Before(expiry.Certificates) {
+			expiry.Certificates = crt.NotAfter
+		}
+	}
+	for _, name := range []string{secret.APIServerCASecretName, secret.ETCDCASecretName, secret.FrontProxyCASecretName} {
+		srt := &corev1.Secret{}
+		if err := mpn.Get(ctx, client.ObjectKey{Name: name, Namespace: ns}, srt); err != nil {
+			return nil, err
+		}
+		crt, err := pkiutil.DecodeCertPEM(srt.Data[corev1.TLSCertKey])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode certificate of secret %s: %v", name, err)
+		}
+		observe(crt)
+	}
+	for _, name := range []string{secret.ControllerManagerSecretName, secret.AdminSecretName} {
+		srt := &corev1.Secret{}
+		if err := mpn.Get(ctx, client.ObjectKey{Name: name, Namespace: ns}, srt); err != nil {
+			return nil, err
+		}
+		kubeconfig, err := clientcmd.Load(srt.Data[name])
+		if err != nil {
+			return nil, fmt.Errorf("failed to load kubeconfig of secret %s: %v", name, err)
+		}
+		for _, authInfo := range kubeconfig.AuthInfos {
+			if len(authInfo.ClientCertificateData) == 0 {
+				continue
+			}
+			crt, err := pkiutil.DecodeCertPEM(authInfo.ClientCertificateData)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode client certificate of secret %s: %v", name, err)
+			}
+			observe(crt)
+		}
+	}
+	return expiry, nil
+}
+
+// RotatePKI re-issues the certificates and kubeconfigs of vc and rolls the control plane. If rotateCA
+// is set, a new root ca is issued as well and the previous root cas are trusted for caTrustWindow so
+// that the components keep talking to each other during the rollout.
+func (mpn *Native) RotatePKI(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster, rotateCA bool, caTrustWindow time.Duration) error {
+	cv, err := mpn.fetchClusterVersion(vc)
+	if err != nil {
+		return err
+	}
+	caGroup, err := mpn.loadPKI(ctx, vc)
+	if err != nil {
+		return err
+	}
+	if caGroup.RootCA == nil {
+		return fmt.Errorf("secret %s of virtualcluster %s not found", secret.RootCASecretName, vc.Name)
+	}
+
+	if rotateCA {
+		mpn.Log.Info("rotating rootCA", "vc", vc.GetName())
+		caGroup.PreviousRootCAs = append([]*x509.Certificate{caGroup.RootCA.Crt}, caGroup.PreviousRootCAs...)
+		caGroup.PreviousRootCAsTrustUntil = time.Now().Add(caTrustWindow)
+		caGroup.RootCA, err = newRootCA(vc)
+		if err != nil {
+			return err
+		}
+	}
+
+	mpn.Log.Info("rotating certificates", "vc", vc.GetName())
+	isClusterIP := cv.Spec.APIServer.Service != nil && cv.Spec.APIServer.Service.Spec.Type == corev1.ServiceTypeClusterIP
+	if _, err := mpn.issueAndApplyPKI(ctx, vc, cv, isClusterIP, caGroup); err != nil {
+		return err
+	}
+	return mpn.rolloutControlPlane(ctx, vc)
+}
+
+// RemovePreviousRootCAs stops trusting the root cas replaced by a ca rotation and rolls the control plane.
+func (mpn *Native) RemovePreviousRootCAs(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) error {
+	caGroup, err := mpn.loadPKI(ctx, vc)
+	if err != nil {
+		return err
+	}
+	if caGroup.RootCA == nil || len(caGroup.PreviousRootCAs) == 0 {
+		return nil
+	}
+	mpn.Log.Info("removing previous rootCAs", "vc", vc.GetName())
+	caGroup.PreviousRootCAs = nil
+	if err := mpn.Patch(ctx, rootCASecret(caGroup, conversion.ToClusterKey(vc)), client.Apply, patchOptions); err != nil {
+		return err
+	}
+	return mpn.rolloutControlPlane(ctx, vc)
+}
+
+// rolloutControlPlane restarts the control plane pods to load the new certificates, and bumps the pki
+// revision of vc so that the syncer reconnects to the tenant control plane with the new admin kubeconfig.
+func (mpn *Native) rolloutControlPlane(ctx context.Context, vc *tenancyv1alpha1.VirtualCluster) error {
+	revision := time.Now().UTC().Format(time.RFC3339)
+	ns := conversion.ToClusterKey(vc)
+
+	stsList := &appsv1.StatefulSetList{}
+	if err := mpn.List(ctx, stsList, client.InNamespace(ns)); err != nil {
+		return err
+	}
+	for i := range stsList.Items {
+		sts := &stsList.Items[i]
+		mpn.Log.Info("rolling out StatefulSet for new certificates", "statefulset", sts.GetName(), "namespace", ns)
+		patch := client.MergeFrom(sts.DeepCopy())
+		annotations := sts.Spec.Template.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[constants.LabelPKIRevision] = revision
+		sts.Spec.Template.SetAnnotations(annotations)
+		if err := mpn.Patch(ctx, sts, patch); err != nil {
+			return err
+		}
+	}
+
+	patch := client.MergeFrom(vc.DeepCopy())
+	annotations := vc.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[constants.LabelPKIRevision] = revision
+	vc.SetAnnotations(annotations)
+	return mpn.Patch(ctx, vc, patch)
+}
@@ -26,6 +26,7 @@ import (
 	"net"
 	"strings"
 	"text/template"
+	"time"
 
 	vcpki "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/controller/pki"
 )
@@ -54,9 +55,10 @@ preferences: {}
 `
 )
 
-// GenerateKubeconfig generates kubeconfig for given user
-func GenerateKubeconfig(user, clusterName, apiserverDomain string, groups []string, rootCA *vcpki.CrtKeyPair) (string, error) {
-	caPair, err := vcpki.NewClientCrtAndKey(user, rootCA, groups)
+// GenerateKubeconfig generates kubeconfig for given user, the client certificate is valid for validity
+// or the default validity if it is zero.
+func GenerateKubeconfig(user, clusterName, apiserverDomain string, groups []string, rootCA *vcpki.CrtKeyPair, validity time.Duration) (string, error) {
+	caPair, err := vcpki.NewClientCrtAndKey(user, rootCA, groups, validity)
 	if err != nil {
 		return "", err
 	}
@@ -24,6 +24,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"time"
 
 	"k8s.io/client-go/util/cert"
 
@@ -52,6 +53,26 @@ type ClusterCAGroup struct {
 	CtrlMgrKbCfg             string // the kubeconfig used by controller-manager
 	AdminKbCfg               string // the kubeconfig used by admin user
 	ServiceAccountPrivateKey *rsa.PrivateKey
+	// PreviousRootCAs are the root cas replaced by a ca rotation, which are
+	// still trusted until PreviousRootCAsTrustUntil.
+	PreviousRootCAs           []*x509.Certificate
+	PreviousRootCAsTrustUntil time.Time
+}
+
+// CertValidity returns the validity of the certificates issued for vc, or zero to use the default validity.
+func CertValidity(vc *tenancyv1alpha1.VirtualCluster) time.Duration {
+	if vc.Spec.PKIExpireDays <= 0 {
+		return 0
+	}
+	return time.Duration(vc.Spec.PKIExpireDays) * 24 * time.Hour
+}
+
+// CAValidity returns the validity of the root ca issued for vc, which outlives the certificates it signs.
+func CAValidity(vc *tenancyv1alpha1.VirtualCluster) time.Duration {
+	if validity := CertValidity(vc); validity > pkiutil.CAValidity {
+		return validity
+	}
+	return pkiutil.CAValidity
 }
 
 // NewAPIServerCrtAndKey creates crt and key for apiserver using ca.
@@ -90,6 +111,7 @@ func NewAPIServerCrtAndKey(ca *CrtKeyPair, vc *tenancyv1alpha1.VirtualCluster, a
 			AltNames:   *altNames,
 			Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
 		},
+		Validity: CertValidity(vc),
 	}
 
 	apiCert, apiKey, err := pkiutil.NewCertAndKey(ca.Crt, ca.Key, config)
@@ -107,13 +129,14 @@ func NewAPIServerCrtAndKey(ca *CrtKeyPair, vc *tenancyv1alpha1.VirtualCluster, a
 
 // NewAPIServerKubeletClientCertAndKey creates certificate for the apiservers to connect to the
 // kubelets securely, signed by the ca.
-func NewAPIServerKubeletClientCertAndKey(ca *CrtKeyPair) (*x509.Certificate, *rsa.PrivateKey, error) {
+func NewAPIServerKubeletClientCertAndKey(ca *CrtKeyPair, validity time.Duration) (*x509.Certificate, *rsa.PrivateKey, error) {
 	config := &pkiutil.CertConfig{
 		Config: cert.Config{
 			CommonName:   "kube-apiserver-kubelet-client",
 			Organization: []string{"system:masters"},
 			Usages:       []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
 		},
+		Validity: validity,
 	}
 	apiClientCert, apiClientKey, err := pkiutil.NewCertAndKey(ca.Crt, ca.Key, config)
 	if err != nil {
@@ -129,7 +152,7 @@ func NewAPIServerKubeletClientCertAndKey(ca *CrtKeyPair) (*x509.Certificate, *rs
 }
 
 // NewEtcdServerCertAndKey creates new crt-key pair using ca for etcd
-func NewEtcdServerCertAndKey(ca *CrtKeyPair, etcdDomains []string) (*CrtKeyPair, error) {
+func NewEtcdServerCertAndKey(ca *CrtKeyPair, etcdDomains []string, validity time.Duration) (*CrtKeyPair, error) {
 	// create AltNames with defaults DNSNames/IPs
 	altNames := &cert.AltNames{
 		DNSNames: etcdDomains,
@@ -143,6 +166,7 @@ func NewEtcdServerCertAndKey(ca *CrtKeyPair, etcdDomains []string) (*CrtKeyPair,
 			// all peers will use this crt-key pair as well
 			Usages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
 		},
+		Validity: validity,
 	}
 	etcdServerCert, etcdServerKey, err := pkiutil.NewCertAndKey(ca.Crt, ca.Key, config)
 	if err != nil {
@@ -159,13 +183,14 @@ func NewEtcdServerCertAndKey(ca *CrtKeyPair, etcdDomains []string) (*CrtKeyPair,
 
 // NewEtcdHealthcheckClientCertAndKey creates certificate for liveness probes to healthcheck etcd,
 // signed by the given ca.
-func NewEtcdHealthcheckClientCertAndKey(ca *CrtKeyPair) (*x509.Certificate, *rsa.PrivateKey, error) {
+func NewEtcdHealthcheckClientCertAndKey(ca *CrtKeyPair, validity time.Duration) (*x509.Certificate, *rsa.PrivateKey, error) {
 	config := &pkiutil.CertConfig{
 		Config: cert.Config{
 			CommonName:   "kube-etcd-healthcheck-client",
 			Organization: []string{"system:masters"},
 			Usages:       []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
 		},
+		Validity: validity,
 	}
 	etcdHealcheckClientCert, etcdHealcheckClientKey, err := pkiutil.NewCertAndKey(ca.Crt, ca.Key, config)
 	if err != nil {
@@ -192,12 +217,13 @@ func NewServiceAccountSigningKey() (*rsa.PrivateKey, error) {
 }
 
 // NewFrontProxyClientCertAndKey creates crt-key pair for proxy client using ca.
-func NewFrontProxyClientCertAndKey(ca *CrtKeyPair) (*CrtKeyPair, error) {
+func NewFrontProxyClientCertAndKey(ca *CrtKeyPair, validity time.Duration) (*CrtKeyPair, error) {
 	config := &pkiutil.CertConfig{
 		Config: cert.Config{
 			CommonName: "front-proxy-client",
 			Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
 		},
+		Validity: validity,
 	}
 	frontProxyClientCert, frontProxyClientKey, err := pkiutil.NewCertAndKey(ca.Crt, ca.Key, config)
 	if err != nil {
@@ -211,13 +237,14 @@ func NewFrontProxyClientCertAndKey(ca *CrtKeyPair) (*CrtKeyPair, error) {
 }
 
 // NewClientCrtAndKey creates crt-key pair for client
-func NewClientCrtAndKey(user string, ca *CrtKeyPair, groups []string) (*CrtKeyPair, error) {
+func NewClientCrtAndKey(user string, ca *CrtKeyPair, groups []string, validity time.Duration) (*CrtKeyPair, error) {
 	config := &pkiutil.CertConfig{
 		Config: cert.Config{
 			CommonName:   user,
 			Organization: groups,
 			Usages:       []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
 		},
+		Validity: validity,
 	}
 
 	crt, key, err := pkiutil.NewCertAndKey(ca.Crt, ca.Key, config)
@@ -0,0 +1,78 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pki
+
+import (
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/util/cert"
+
+	tenancyv1alpha1 "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	pkiutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/pki"
+)
+
+func TestCertificateValidity(t *testing.T) {
+	for name, tc := range map[string]struct {
+		pkiExpireDays    int64
+		expectedValidity time.Duration
+		expectedCA       time.Duration
+	}{
+		"default validity": {
+			expectedValidity: pkiutil.CertificateValidity,
+			expectedCA:       pkiutil.CAValidity,
+		},
+		"short validity": {
+			pkiExpireDays:    30,
+			expectedValidity: 30 * 24 * time.Hour,
+			expectedCA:       pkiutil.CAValidity,
+		},
+		"validity longer than ca": {
+			pkiExpireDays:    365 * 20,
+			expectedValidity: 365 * 20 * 24 * time.Hour,
+			expectedCA:       365 * 20 * 24 * time.Hour,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			vc := &tenancyv1alpha1.VirtualCluster{Spec: tenancyv1alpha1.VirtualClusterSpec{PKIExpireDays: tc.pkiExpireDays}}
+
+			caCrt, caKey, err := pkiutil.NewCertificateAuthority(&pkiutil.CertConfig{
+				Config:   cert.Config{CommonName: "kubernetes"},
+				Validity: CAValidity(vc),
+			})
+			if err != nil {
+				t.Fatalf("unexpected error creating ca: %v", err)
+			}
+			assertValidity(t, "ca", caCrt.NotAfter, tc.expectedCA)
+
+			ca := &CrtKeyPair{Crt: caCrt, Key: caKey.(*rsa.PrivateKey)}
+			client, err := NewClientCrtAndKey("admin", ca, []string{"system:masters"}, CertValidity(vc))
+			if err != nil {
+				t.Fatalf("unexpected error creating client certificate: %v", err)
+			}
+			assertValidity(t, "client certificate", client.Crt.NotAfter, tc.expectedValidity)
+		})
+	}
+}
+
+func assertValidity(t *testing.T, name string, notAfter time.Time, expected time.Duration) {
+	t.Helper()
+	if got := time.Until(notAfter); got > expected || got < expected-time.Minute {
+		t.Errorf("expected %s to be valid for %v, got %v", name, expected, got)
+	}
+}
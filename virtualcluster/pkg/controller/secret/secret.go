@@ -43,6 +43,10 @@ const (
 	AdminSecretName = "admin-kubeconfig" // #nosec G101 -- This is a path to secrets
 	// ServiceAccountSecretName name of the secret with ServiceAccount rsa
 	ServiceAccountSecretName = "serviceaccount-rsa"
+	// EncryptionConfigSecretName name of the secret with the EncryptionConfiguration of the apiserver
+	EncryptionConfigSecretName = "encryption-config"
+	// AuditWebhookConfigSecretName name of the secret with the kubeconfig of the audit webhook of the apiserver
+	AuditWebhookConfigSecretName = "audit-webhook-config"
 )
 
 // GetHash hashes object to sha256 for annotations
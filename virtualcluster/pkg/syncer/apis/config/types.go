@@ -76,6 +76,10 @@ type SyncerConfiguration struct {
 	// is used for the feature VNodeProviderPodIP
 	VNAgentLabelSelector string
 
+	// NamespaceNamingStrategy is the name of the strategy that maps tenant namespaces to
+	// super cluster namespaces. vn-agent must be configured with the same strategy.
+	NamespaceNamingStrategy string
+
 	// FeatureGates enabled by the user.
 	FeatureGates map[string]bool
 
@@ -87,6 +91,57 @@ type SyncerConfiguration struct {
 
 	// The DNSOptions are the DNS options in resolv.conf that is attached to pod
 	DNSOptions []corev1.PodDNSConfigOption
+
+	// EventInvolvedObjectKinds is the list of kinds of the involved objects whose events are back
+	// populated to the tenant control planes. Defaults to Pod and Service.
+	EventInvolvedObjectKinds []string
+
+	// EventReasons is the list of reasons of the events back populated to the tenant control planes.
+	// Events of all reasons are back populated if it is empty.
+	EventReasons []string
+
+	// GenericSyncingResources defines the resources synced by the generic syncer, e.g., the custom
+	// resources that do not have a dedicated syncer.
+	GenericSyncingResources []GenericSyncingResource
+}
+
+// SyncDirection is the direction in which the generic syncer syncs a resource.
+type SyncDirection string
+
+const (
+	// SyncDirectionDownward syncs the objects created in tenant control planes to the super control plane,
+	// and back populates their status.
+	SyncDirectionDownward SyncDirection = "Downward"
+	// SyncDirectionUpward syncs the objects created in the super control plane to the tenant control plane
+	// that owns their namespaces.
+	SyncDirectionUpward SyncDirection = "Upward"
+	// SyncDirectionBoth syncs the objects in both directions based on where they are created.
+	SyncDirectionBoth SyncDirection = "Both"
+)
+
+// GenericSyncingResource describes a namespaced resource synced by the generic syncer.
+type GenericSyncingResource struct {
+	// Group, Version and Kind of the resource, e.g., cert-manager.io, v1 and Certificate.
+	Group   string `json:"group"`
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+	// Resource is the plural resource name, e.g., certificates.
+	Resource string `json:"resource"`
+
+	// Direction defaults to Downward.
+	Direction SyncDirection `json:"direction,omitempty"`
+
+	// NamespaceRefPaths are the paths of the fields that refer to a namespace, e.g., spec.secretRef.namespace.
+	// A path segment ending with "[]" iterates over a list, e.g., spec.sources[].namespace.
+	NamespaceRefPaths []string `json:"namespaceRefPaths,omitempty"`
+
+	// NamespacedNameRefPaths are the paths of the fields that refer to an object as <namespace>/<name>,
+	// e.g., metadata.annotations.cert-manager\.io/inject-ca-from. Use "\." to escape a dot in a path segment.
+	NamespacedNameRefPaths []string `json:"namespacedNameRefPaths,omitempty"`
+
+	// StatusPaths are the paths of the fields back populated from super control plane objects to tenant
+	// control plane objects. Defaults to status.
+	StatusPaths []string `json:"statusPaths,omitempty"`
 }
 
 // SyncerLeaderElectionConfiguration expands LeaderElectionConfiguration
@@ -31,10 +31,14 @@ const (
 	LabelUID = "tenancy.x-k8s.io/uid"
 	// LabelNamespace records which cluster namespace this resource belongs to.
 	LabelNamespace = "tenancy.x-k8s.io/namespace"
+	// LabelSuperUID is the uid of the super control plane object that a tenant object is synced from.
+	LabelSuperUID = "tenancy.x-k8s.io/super.uid"
 	// LabelOwnerReferences is the ownerReferences of the object in tenant context.
 	LabelOwnerReferences = "tenancy.x-k8s.io/ownerReferences"
 	// LabelClusterIP is the cluster ip of the corresponding service in tenant namespace.
 	LabelClusterIP = "tenancy.x-k8s.io/clusterIP"
+	// LabelSuperResourceQuotaStatus is the status of the corresponding resourcequota in super control plane.
+	LabelSuperResourceQuotaStatus = "tenancy.x-k8s.io/super.resourcequota.status"
 	// LabelSecretName is the service account token secret name in tenant namespace.
 	LabelSecretName = "tenancy.x-k8s.io/secret.name" // #nosec G101 -- This is a label key
 	// LabelAdminKubeConfig is the kubeconfig in base64 format for tenant control plane.
@@ -50,6 +54,21 @@ const (
 	// LabelVCRootNS means the namespace is the rootns created by vc-manager.
 	LabelVCRootNS = "tenancy.x-k8s.io/vcrootns"
 
+	// LabelPKIRevision is updated on the VC CR and the control plane pod templates each time the certificates of
+	// the tenant control plane are rotated, so that the control plane pods and the syncer pick up the new certificates.
+	LabelPKIRevision = "tenancy.x-k8s.io/pki-revision"
+	// LabelCATrustUntil records on the root ca secret until when the previous root cas are still trusted.
+	LabelCATrustUntil = "tenancy.x-k8s.io/ca-trust-until"
+
+	// LabelRotateEncryptionKey is set on the VC CR to rotate the key the Secrets of the tenant control plane are
+	// encrypted with, a rotation starts each time the value changes.
+	LabelRotateEncryptionKey = "tenancy.x-k8s.io/rotate-encryption-key"
+	// LabelEncryptionKeyRotated records on the encryption config secret the value of LabelRotateEncryptionKey
+	// the last finished rotation was requested with.
+	LabelEncryptionKeyRotated = "tenancy.x-k8s.io/encryption-key-rotated"
+	// LabelEncryptionKeyRotationPhase records on the encryption config secret the phase of the ongoing key rotation.
+	LabelEncryptionKeyRotationPhase = "tenancy.x-k8s.io/encryption-key-rotation-phase"
+
 	// LabelVCReadyForUpgrade is set to "true" when the cluster is ready for the upgrade being applied
 	// (use featuregate.VirtualClusterApplyUpdate to enable it in the provisioner)
 	LabelVCReadyForUpgrade = "tenancy.x-k8s.io/ready-for-upgrade"
@@ -67,6 +86,11 @@ const (
 	// LabelTenantIgnoreSync is used by resources that do not need to be synced.
 	LabelTenantIgnoreSync = "tenancy.x-k8s.io/ignore-sync"
 
+	// LabelSyncerStatus is the label of the configmaps holding the syncer status in tenant control plane.
+	LabelSyncerStatus = "tenancy.x-k8s.io/syncer-status"
+	// TenantSyncerStatusNamespace is the namespace the syncer status is published to in tenant control plane.
+	TenantSyncerStatusNamespace = "vc-syncer-status"
+
 	// UwsControllerWorkerHigh is the quantity of the worker routine for a resource that generates high number of uws requests.
 	UwsControllerWorkerHigh = 10
 	// UwsControllerWorkerLow is the quantity of the worker routine for a resource that generates low number of uws requests.
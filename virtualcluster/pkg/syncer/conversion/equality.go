@@ -17,6 +17,7 @@ limitations under the License.
 package conversion
 
 import (
+	"encoding/json"
 	"strings"
 
 	v1 "k8s.io/api/core/v1"
@@ -758,6 +759,72 @@ func (e vcEquality) CheckUWPVCStatusEquality(pObj, vObj *v1.PersistentVolumeClai
 	return updated
 }
 
+// CheckResourceQuotaEquality checks whether the super control plane ResourceQuota is the tenant one
+// capped by the ResourceQuotaCap of the VirtualCluster.
+func (e vcEquality) CheckResourceQuotaEquality(pObj, vObj *v1.ResourceQuota) *v1.ResourceQuota {
+	var updated *v1.ResourceQuota
+	updatedMeta := e.CheckDWObjectMetaEquality(&pObj.ObjectMeta, &vObj.ObjectMeta)
+	if updatedMeta != nil {
+		if updated == nil {
+			updated = pObj.DeepCopy()
+		}
+		updated.ObjectMeta = *updatedMeta
+	}
+
+	vSpec := vObj.Spec.DeepCopy()
+	if e.vc != nil {
+		vSpec.Hard = CapResourceList(vSpec.Hard, e.vc.Spec.ResourceQuotaCap)
+	}
+	if !equality.Semantic.DeepEqual(pObj.Spec, *vSpec) {
+		if updated == nil {
+			updated = pObj.DeepCopy()
+		}
+		updated.Spec = *vSpec
+	}
+	return updated
+}
+
+// CheckUWResourceQuotaStatusEquality checks whether the tenant ResourceQuota carries the status
+// computed by the super control plane. The status is reported in an annotation rather than in the
+// tenant status, which stays owned by the tenant resourcequota controller.
+func (e vcEquality) CheckUWResourceQuotaStatusEquality(pObj, vObj *v1.ResourceQuota) *v1.ResourceQuota {
+	status, err := json.Marshal(pObj.Status)
+	if err != nil {
+		klog.Errorf("failed to marshal status of resourcequota %s/%s: %v", pObj.Namespace, pObj.Name, err)
+		return nil
+	}
+	if vObj.Annotations[constants.LabelSuperResourceQuotaStatus] == string(status) {
+		return nil
+	}
+	updated := vObj.DeepCopy()
+	if updated.Annotations == nil {
+		updated.Annotations = make(map[string]string)
+	}
+	updated.Annotations[constants.LabelSuperResourceQuotaStatus] = string(status)
+	return updated
+}
+
+// CheckLimitRangeEquality checks whether the super LimitRange has the metadata and spec of the
+// tenant LimitRange, and returns an updated copy of the super object if not.
+func (e vcEquality) CheckLimitRangeEquality(pObj, vObj *v1.LimitRange) *v1.LimitRange {
+	var updated *v1.LimitRange
+	updatedMeta := e.CheckDWObjectMetaEquality(&pObj.ObjectMeta, &vObj.ObjectMeta)
+	if updatedMeta != nil {
+		if updated == nil {
+			updated = pObj.DeepCopy()
+		}
+		updated.ObjectMeta = *updatedMeta
+	}
+
+	if !equality.Semantic.DeepEqual(pObj.Spec, vObj.Spec) {
+		if updated == nil {
+			updated = pObj.DeepCopy()
+		}
+		updated.Spec = *vObj.Spec.DeepCopy()
+	}
+	return updated
+}
+
 func (e vcEquality) CheckPVSpecEquality(pObj, vObj *v1.PersistentVolumeSpec) *v1.PersistentVolumeSpec {
 	var updatedPVSpec *v1.PersistentVolumeSpec
 	pCopy := pObj.DeepCopy()
@@ -33,7 +33,6 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
-	"k8s.io/apimachinery/pkg/util/validation"
 	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
 	listersv1 "k8s.io/client-go/listers/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -58,13 +57,10 @@ func ToClusterKey(vc *v1alpha1.VirtualCluster) string {
 	return vc.GetNamespace() + "-" + hex.EncodeToString(digest[0:])[0:6] + "-" + vc.GetName()
 }
 
+// ToSuperClusterNamespace returns the namespace in super control plane for a tenant namespace,
+// using the strategy selected by SetNamespaceNamingStrategy.
 func ToSuperClusterNamespace(cluster, ns string) string {
-	targetNamespace := strings.Join([]string{cluster, ns}, "-")
-	if len(targetNamespace) > validation.DNS1123LabelMaxLength {
-		digest := sha256.Sum256([]byte(targetNamespace))
-		return targetNamespace[0:57] + "-" + hex.EncodeToString(digest[0:])[0:5]
-	}
-	return targetNamespace
+	return getCurrentNamingStrategy().SuperClusterNamespace(cluster, ns)
 }
 
 // GetVirtualNamespace is used to find the corresponding namespace in tenant control plane for objects created in super control plane originally, e.g., events.
@@ -122,6 +118,22 @@ func GetConfigMapName(name string) (string, string) {
 	return name, name
 }
 
+// CapResourceList returns the hard limits capped by the limit of the same resource in limits.
+// The resources not in hard are not added.
+func CapResourceList(hard, limits v1.ResourceList) v1.ResourceList {
+	if len(limits) == 0 || hard == nil {
+		return hard
+	}
+	capped := make(v1.ResourceList, len(hard))
+	for name, quantity := range hard {
+		if limit, ok := limits[name]; ok && quantity.Cmp(limit) > 0 {
+			quantity = limit.DeepCopy()
+		}
+		capped[name] = quantity
+	}
+	return capped
+}
+
 type objectConversion struct {
 	config *config.SyncerConfiguration
 	mcc    mc.MultiClusterInterface
@@ -292,8 +304,15 @@ func BuildVirtualEvent(cluster string, pEvent *v1.Event, vObj client.Object) *v1
 	vEvent.InvolvedObject.Namespace = vObj.GetNamespace()
 	vEvent.InvolvedObject.UID = vObj.GetUID()
 	vEvent.InvolvedObject.ResourceVersion = ""
+	// the related object of events.k8s.io/v1 events refers to an object of the super control plane,
+	// it is set by the caller once the tenant object is found.
+	vEvent.Related = nil
 
-	vEvent.Message = strings.ReplaceAll(vEvent.Message, cluster+"-", "")
+	// the message refers to the super cluster namespace, which is named by the naming strategy
+	// and does not necessarily start with the cluster key.
+	if vNamespace := vObj.GetNamespace(); vNamespace != "" {
+		vEvent.Message = strings.ReplaceAll(vEvent.Message, ToSuperClusterNamespace(cluster, vNamespace), vNamespace)
+	}
 	vEvent.Message = strings.ReplaceAll(vEvent.Message, cluster, "")
 
 	return vEvent
@@ -0,0 +1,140 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversion
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// NamespaceNamingPrefix names a super cluster namespace <cluster key>-<namespace>, truncated with
+	// a short hash when the name is too long. It is the default strategy.
+	NamespaceNamingPrefix = "prefix"
+	// NamespaceNamingHash names a super cluster namespace <namespace>-<digest>, where the digest is
+	// computed from both the cluster key and the tenant namespace.
+	NamespaceNamingHash = "hash"
+
+	// hashDigestLength is the number of hex characters of the digest used by the hash strategy.
+	hashDigestLength = 20
+)
+
+// NamespaceNamingStrategy maps a tenant namespace to the namespace in the super cluster.
+// A strategy must be deterministic: the syncer, vn-agent and other components compute
+// the super cluster namespace independently and must agree on the result.
+type NamespaceNamingStrategy interface {
+	SuperClusterNamespace(cluster, namespace string) string
+}
+
+// NamespaceNamingFunc is an adapter to use an ordinary function as a NamespaceNamingStrategy.
+type NamespaceNamingFunc func(cluster, namespace string) string
+
+func (f NamespaceNamingFunc) SuperClusterNamespace(cluster, namespace string) string {
+	return f(cluster, namespace)
+}
+
+var (
+	namingLock       sync.RWMutex
+	namingStrategies = map[string]NamespaceNamingStrategy{
+		NamespaceNamingPrefix: NamespaceNamingFunc(prefixNamespaceNaming),
+		NamespaceNamingHash:   NamespaceNamingFunc(hashNamespaceNaming),
+	}
+	currentNamingStrategy NamespaceNamingStrategy = NamespaceNamingFunc(prefixNamespaceNaming)
+)
+
+// RegisterNamespaceNamingStrategy makes a naming strategy available by name, e.g., a strategy
+// backed by a registry of pre-allocated names.
+func RegisterNamespaceNamingStrategy(name string, strategy NamespaceNamingStrategy) {
+	namingLock.Lock()
+	defer namingLock.Unlock()
+	namingStrategies[name] = strategy
+}
+
+// GetNamespaceNamingStrategy returns the registered naming strategy with the given name.
+func GetNamespaceNamingStrategy(name string) (NamespaceNamingStrategy, error) {
+	namingLock.RLock()
+	defer namingLock.RUnlock()
+	strategy, ok := namingStrategies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown namespace naming strategy %q, options are %s", name, strings.Join(namespaceNamingStrategyNames(), ", "))
+	}
+	return strategy, nil
+}
+
+// SetNamespaceNamingStrategy sets the naming strategy used by ToSuperClusterNamespace.
+func SetNamespaceNamingStrategy(name string) error {
+	strategy, err := GetNamespaceNamingStrategy(name)
+	if err != nil {
+		return err
+	}
+	namingLock.Lock()
+	defer namingLock.Unlock()
+	currentNamingStrategy = strategy
+	return nil
+}
+
+// NamespaceNamingStrategyNames returns the names of all registered naming strategies.
+func NamespaceNamingStrategyNames() []string {
+	namingLock.RLock()
+	defer namingLock.RUnlock()
+	return namespaceNamingStrategyNames()
+}
+
+func namespaceNamingStrategyNames() []string {
+	names := make([]string, 0, len(namingStrategies))
+	for name := range namingStrategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func getCurrentNamingStrategy() NamespaceNamingStrategy {
+	namingLock.RLock()
+	defer namingLock.RUnlock()
+	return currentNamingStrategy
+}
+
+// prefixNamespaceNaming is the legacy naming. Note that the names are ambiguous, e.g.,
+// cluster "a-b" with namespace "c" and cluster "a" with namespace "b-c" map to the same name.
+func prefixNamespaceNaming(cluster, ns string) string {
+	targetNamespace := strings.Join([]string{cluster, ns}, "-")
+	if len(targetNamespace) > validation.DNS1123LabelMaxLength {
+		digest := sha256.Sum256([]byte(targetNamespace))
+		return targetNamespace[0:57] + "-" + hex.EncodeToString(digest[0:])[0:5]
+	}
+	return targetNamespace
+}
+
+// hashNamespaceNaming keeps the tenant namespace as a readable prefix and appends a digest of
+// "<cluster>/<namespace>". Neither the cluster key nor the namespace can contain "/", so
+// different pairs never share the digest input.
+func hashNamespaceNaming(cluster, ns string) string {
+	digest := sha256.Sum256([]byte(cluster + "/" + ns))
+	suffix := hex.EncodeToString(digest[0:])[0:hashDigestLength]
+	prefix := ns
+	if maxPrefix := validation.DNS1123LabelMaxLength - hashDigestLength - 1; len(prefix) > maxPrefix {
+		prefix = strings.TrimRight(prefix[0:maxPrefix], "-")
+	}
+	return prefix + "-" + suffix
+}
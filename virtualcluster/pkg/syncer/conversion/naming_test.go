@@ -0,0 +1,158 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversion
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func TestPrefixNamespaceNaming(t *testing.T) {
+	for name, tc := range map[string]struct {
+		cluster  string
+		ns       string
+		expected string
+	}{
+		"short name": {
+			cluster:  "ns-fd1b34-name",
+			ns:       "default",
+			expected: "ns-fd1b34-name-default",
+		},
+		"long name": {
+			cluster:  "ns-fd1b34-name",
+			ns:       strings.Repeat("a", 60),
+			expected: "ns-fd1b34-name-" + strings.Repeat("a", 42) + "-fc0a6",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got := prefixNamespaceNaming(tc.cluster, tc.ns)
+			if got != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, got)
+			}
+			if errs := validation.IsDNS1123Label(got); len(errs) != 0 {
+				t.Errorf("invalid namespace name %s: %v", got, errs)
+			}
+		})
+	}
+}
+
+func TestHashNamespaceNaming(t *testing.T) {
+	if prefixNamespaceNaming("a-b", "c") != prefixNamespaceNaming("a", "b-c") {
+		t.Fatalf("expect the prefix strategy to be ambiguous")
+	}
+	if hashNamespaceNaming("a-b", "c") == hashNamespaceNaming("a", "b-c") {
+		t.Errorf("expect different names for a-b/c and a/b-c")
+	}
+	if hashNamespaceNaming("a", "b") != hashNamespaceNaming("a", "b") {
+		t.Errorf("expect the hash strategy to be deterministic")
+	}
+
+	for name, ns := range map[string]string{
+		"short name":              "default",
+		"long name":               strings.Repeat("a", 63),
+		"dash at truncation edge": strings.Repeat("a", 41) + "-bbbbbbbb",
+	} {
+		t.Run(name, func(t *testing.T) {
+			got := hashNamespaceNaming("ns-fd1b34-name", ns)
+			if errs := validation.IsDNS1123Label(got); len(errs) != 0 {
+				t.Errorf("invalid namespace name %s: %v", got, errs)
+			}
+			if !strings.HasPrefix(ns, got[0:len(got)-hashDigestLength-1]) {
+				t.Errorf("expect %s to start with the tenant namespace %s", got, ns)
+			}
+		})
+	}
+}
+
+// resetNamespaceNaming restores the default naming strategy and unregisters the strategies
+// registered by the test once it finishes.
+func resetNamespaceNaming(t *testing.T) {
+	namingLock.RLock()
+	registered := make(map[string]NamespaceNamingStrategy, len(namingStrategies))
+	for name, strategy := range namingStrategies {
+		registered[name] = strategy
+	}
+	namingLock.RUnlock()
+	t.Cleanup(func() {
+		namingLock.Lock()
+		namingStrategies = registered
+		namingLock.Unlock()
+		if err := SetNamespaceNamingStrategy(NamespaceNamingPrefix); err != nil {
+			t.Fatalf("failed to reset naming strategy: %v", err)
+		}
+	})
+}
+
+func TestSetNamespaceNamingStrategy(t *testing.T) {
+	resetNamespaceNaming(t)
+
+	if got := ToSuperClusterNamespace("a", "b"); got != "a-b" {
+		t.Errorf("expect the prefix strategy by default, got %s", got)
+	}
+
+	if err := SetNamespaceNamingStrategy("unknown"); err == nil {
+		t.Errorf("expect an error for an unknown strategy")
+	}
+
+	if err := SetNamespaceNamingStrategy(NamespaceNamingHash); err != nil {
+		t.Fatalf("failed to set naming strategy: %v", err)
+	}
+	if got := ToSuperClusterNamespace("a", "b"); got != hashNamespaceNaming("a", "b") {
+		t.Errorf("expect the hash strategy, got %s", got)
+	}
+
+	registry := map[string]string{"a/b": "registered"}
+	RegisterNamespaceNamingStrategy("registry", NamespaceNamingFunc(func(cluster, namespace string) string {
+		return registry[cluster+"/"+namespace]
+	}))
+	if err := SetNamespaceNamingStrategy("registry"); err != nil {
+		t.Fatalf("failed to set naming strategy: %v", err)
+	}
+	if got := ToSuperClusterNamespace("a", "b"); got != "registered" {
+		t.Errorf("expect the registered strategy, got %s", got)
+	}
+}
+
+func TestBuildVirtualEvent(t *testing.T) {
+	for _, strategy := range []string{NamespaceNamingPrefix, NamespaceNamingHash} {
+		t.Run(strategy, func(t *testing.T) {
+			resetNamespaceNaming(t)
+			if err := SetNamespaceNamingStrategy(strategy); err != nil {
+				t.Fatalf("failed to set naming strategy: %v", err)
+			}
+			cluster := "ns-fd1b34-name"
+			pNamespace := ToSuperClusterNamespace(cluster, "default")
+			pEvent := &v1.Event{
+				ObjectMeta: metav1.ObjectMeta{Namespace: pNamespace, Name: "pod.1"},
+				Message:    "Successfully assigned " + pNamespace + "/pod to node",
+			}
+			vPod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod", UID: "uid"}}
+
+			vEvent := BuildVirtualEvent(cluster, pEvent, vPod)
+			if expected := "Successfully assigned default/pod to node"; vEvent.Message != expected {
+				t.Errorf("expected message %q, got %q", expected, vEvent.Message)
+			}
+			if vEvent.Namespace != "default" || vEvent.InvolvedObject.UID != "uid" {
+				t.Errorf("expected the event to refer to the tenant pod, got %v", vEvent.InvolvedObject)
+			}
+		})
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/syncstatus"
 )
 
 // HandlerFuncs is an adaptor to let you easily specify as many or
@@ -90,7 +91,7 @@ func DefaultDifferFilter(knownClusterSet sets.String) func(obj ClusterObject) bo
 	return func(obj ClusterObject) bool {
 		// vObj
 		if obj.OwnerCluster != "" {
-			return knownClusterSet.Has(obj.OwnerCluster)
+			return knownClusterSet.Has(obj.OwnerCluster) && !syncstatus.IsStatusNamespace(obj.GetNamespace())
 		}
 
 		// pObj
@@ -32,6 +32,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol/differ"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/syncstatus"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 )
@@ -57,6 +58,8 @@ func (c *controller) PatrollerDo() {
 		return
 	}
 
+	syncstatus.DefaultRecorder.StartScan("ConfigMap")
+
 	pConfigMaps, err := c.configMapLister.List(util.GetSuperClusterListerLabelsSelector())
 	if err != nil {
 		klog.Errorf("error listing configmaps from super control plane informer cache: %v", err)
@@ -122,6 +125,7 @@ func (c *controller) PatrollerDo() {
 		updated := conversion.Equality(c.Config, vc).CheckConfigMapEquality(pCM, vCM)
 		if updated != nil {
 			atomic.AddUint64(&numMissMatchedConfigMaps, 1)
+			syncstatus.DefaultRecorder.IncMismatch("ConfigMap", vObj.GetOwnerCluster(), vObj.GetNamespace(), "MissMatchedConfigMaps")
 			klog.Warningf("ConfigMap %s diff in super&tenant control plane", pObj.Key)
 		}
 	}
@@ -142,4 +146,5 @@ func (c *controller) PatrollerDo() {
 	})
 
 	metrics.CheckerMissMatchStats.WithLabelValues("MissMatchedConfigMaps").Set(float64(numMissMatchedConfigMaps))
+	syncstatus.DefaultRecorder.FinishScan("ConfigMap")
 }
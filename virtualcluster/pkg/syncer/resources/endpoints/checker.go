@@ -29,6 +29,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol/differ"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/syncstatus"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 )
 
@@ -57,6 +58,7 @@ func (c *controller) PatrollerDo() {
 
 	numMissingEndPoints = 0
 	numMissMatchedEndPoints = 0
+	syncstatus.DefaultRecorder.StartScan("Endpoints")
 
 	pList, err := c.endpointsLister.List(util.GetSuperClusterListerLabelsSelector())
 	if err != nil {
@@ -90,6 +92,7 @@ func (c *controller) PatrollerDo() {
 	d := differ.HandlerFuncs{}
 	d.AddFunc = func(vObj differ.ClusterObject) {
 		atomic.AddUint64(&numMissingEndPoints, 1)
+		syncstatus.DefaultRecorder.IncMismatch("Endpoints", vObj.OwnerCluster, vObj.GetNamespace(), "MissingEndPoints")
 		if err := c.MultiClusterController.RequeueObject(vObj.OwnerCluster, vObj); err != nil {
 			klog.Errorf("error requeue vEndpoints %s: %v", vObj.Key, err)
 		} else {
@@ -102,6 +105,7 @@ func (c *controller) PatrollerDo() {
 		updated := conversion.Equality(c.Config, nil).CheckEndpointsEquality(p, v)
 		if updated != nil {
 			atomic.AddUint64(&numMissMatchedEndPoints, 1)
+			syncstatus.DefaultRecorder.IncMismatch("Endpoints", vObj.OwnerCluster, vObj.GetNamespace(), "MissMatchedEndPoints")
 			if err := c.MultiClusterController.RequeueObject(vObj.OwnerCluster, vObj); err != nil {
 				klog.Errorf("error requeue vEndpoints %s: %v", vObj.Key, err)
 			} else {
@@ -117,4 +121,5 @@ func (c *controller) PatrollerDo() {
 
 	metrics.CheckerMissMatchStats.WithLabelValues("MissingEndPoints").Set(float64(numMissingEndPoints))
 	metrics.CheckerMissMatchStats.WithLabelValues("MissMatchedEndPoints").Set(float64(numMissMatchedEndPoints))
+	syncstatus.DefaultRecorder.FinishScan("Endpoints")
 }
@@ -18,9 +18,11 @@ package event
 
 import (
 	"fmt"
+	"sort"
 
 	corev1 "k8s.io/api/core/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/informers"
 	coreinformers "k8s.io/client-go/informers/core/v1"
 	clientset "k8s.io/client-go/kubernetes"
@@ -60,6 +62,42 @@ type controller struct {
 	nsSynced    cache.InformerSynced
 
 	acceptedEventObj map[string]client.Object
+	// acceptedReasons are the reasons of the back populated events, all reasons
+	// are accepted if it is empty.
+	acceptedReasons sets.String
+}
+
+// supportedInvolvedObjects are the kinds of the involved objects whose events can be
+// back populated, the involved objects are looked up in the tenant control planes.
+var supportedInvolvedObjects = map[string]client.Object{
+	"ConfigMap":             &corev1.ConfigMap{},
+	"Endpoints":             &corev1.Endpoints{},
+	"PersistentVolumeClaim": &corev1.PersistentVolumeClaim{},
+	"Pod":                   &corev1.Pod{},
+	"Secret":                &corev1.Secret{},
+	"Service":               &corev1.Service{},
+	"ServiceAccount":        &corev1.ServiceAccount{},
+}
+
+// defaultInvolvedObjectKinds are the kinds of the involved objects whose events are
+// back populated if the syncer configuration does not specify any.
+var defaultInvolvedObjectKinds = []string{"Pod", "Service"}
+
+// DefaultInvolvedObjectKinds returns the kinds of the involved objects whose events are
+// back populated by default.
+func DefaultInvolvedObjectKinds() []string {
+	return append([]string(nil), defaultInvolvedObjectKinds...)
+}
+
+// SupportedInvolvedObjectKinds returns the kinds of the involved objects whose events
+// can be back populated.
+func SupportedInvolvedObjectKinds() []string {
+	kinds := make([]string, 0, len(supportedInvolvedObjects))
+	for kind := range supportedInvolvedObjects {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
 }
 
 func NewEventController(config *config.SyncerConfiguration,
@@ -72,12 +110,22 @@ func NewEventController(config *config.SyncerConfiguration,
 		BaseResourceSyncer: manager.BaseResourceSyncer{
 			Config: config,
 		},
-		client:   clientSet.CoreV1(),
-		informer: informer.Core().V1(),
-		acceptedEventObj: map[string]client.Object{
-			"Pod":     &corev1.Pod{},
-			"Service": &corev1.Service{},
-		},
+		client:           clientSet.CoreV1(),
+		informer:         informer.Core().V1(),
+		acceptedEventObj: map[string]client.Object{},
+		acceptedReasons:  sets.NewString(config.EventReasons...),
+	}
+
+	kinds := config.EventInvolvedObjectKinds
+	if len(kinds) == 0 {
+		kinds = defaultInvolvedObjectKinds
+	}
+	for _, kind := range kinds {
+		obj, ok := supportedInvolvedObjects[kind]
+		if !ok {
+			return nil, fmt.Errorf("unsupported event involved object kind %s, supported kinds are %v", kind, SupportedInvolvedObjectKinds())
+		}
+		c.acceptedEventObj[kind] = obj
 	}
 
 	var err error
@@ -119,6 +167,9 @@ func NewEventController(config *config.SyncerConfiguration,
 			},
 			Handler: cache.ResourceEventHandlerFuncs{
 				AddFunc: c.enqueueEvent,
+				UpdateFunc: func(oldObj, newObj interface{}) {
+					c.enqueueEvent(newObj)
+				},
 			},
 		})
 
@@ -126,8 +177,10 @@ func NewEventController(config *config.SyncerConfiguration,
 }
 
 func (c *controller) assignAcceptedEvent(e *corev1.Event) bool {
-	_, accepted := c.acceptedEventObj[e.InvolvedObject.Kind]
-	return accepted
+	if _, accepted := c.acceptedEventObj[e.InvolvedObject.Kind]; !accepted {
+		return false
+	}
+	return c.acceptedReasons.Len() == 0 || c.acceptedReasons.Has(e.Reason)
 }
 
 func (c *controller) enqueueEvent(obj interface{}) {
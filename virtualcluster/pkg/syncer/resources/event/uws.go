@@ -22,6 +22,7 @@ import (
 
 	pkgerr "github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -56,6 +57,12 @@ func (c *controller) BackPopulate(key string) error {
 		return fmt.Errorf("could not find pEvent %s/%s in controller cache: %v", pNamespace, pName, err)
 	}
 
+	vInvolvedObjectType, accepted := c.acceptedEventObj[pEvent.InvolvedObject.Kind]
+	if !accepted || !c.assignAcceptedEvent(pEvent) {
+		klog.V(4).Infof("drop event %s/%s whose involved object kind or reason is not accepted", pNamespace, pName)
+		return nil
+	}
+
 	clusterName, tenantNS, err := conversion.GetVirtualNamespace(c.nsLister, pNamespace)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
@@ -73,12 +80,6 @@ func (c *controller) BackPopulate(key string) error {
 		return pkgerr.Wrapf(err, "failed to create client from cluster %s config", clusterName)
 	}
 
-	vInvolvedObjectType, accepted := c.acceptedEventObj[pEvent.InvolvedObject.Kind]
-	if !accepted {
-		klog.Warningf("unexpected event %+v in uws", pEvent)
-		return nil
-	}
-
 	vInvolvedObject := vInvolvedObjectType.DeepCopyObject().(client.Object)
 	if err := c.MultiClusterController.Get(clusterName, tenantNS, pEvent.InvolvedObject.Name, vInvolvedObject); err != nil {
 		if apierrors.IsNotFound(err) {
@@ -90,13 +91,64 @@ func (c *controller) BackPopulate(key string) error {
 
 	// TODO(christopherhein): We should mutate this instead and raise apierrors if anything happens.
 	vEvent := conversion.BuildVirtualEvent(clusterName, pEvent, vInvolvedObject)
+	if pEvent.Related != nil {
+		vEvent.Related = c.buildVirtualRelated(clusterName, pEvent.Related)
+	}
 
-	if err = c.MultiClusterController.Get(clusterName, tenantNS, vEvent.Name, &corev1.Event{}); err != nil {
+	existingEvent := &corev1.Event{}
+	if err = c.MultiClusterController.Get(clusterName, tenantNS, vEvent.Name, existingEvent); err != nil {
 		if apierrors.IsNotFound(err) {
 			_, err = tenantClient.CoreV1().Events(tenantNS).Create(context.TODO(), vEvent, metav1.CreateOptions{})
 			return err
 		}
 		return err
 	}
-	return nil
+
+	// the super control plane aggregates repeated events into the same event, or the
+	// same series for events.k8s.io/v1 events, so the occurrences are back populated.
+	updatedEvent := aggregateEvent(existingEvent, vEvent)
+	if updatedEvent == nil {
+		return nil
+	}
+	_, err = tenantClient.CoreV1().Events(tenantNS).Update(context.TODO(), updatedEvent, metav1.UpdateOptions{})
+	return err
+}
+
+// buildVirtualRelated returns the reference to the tenant object of the related object of an
+// events.k8s.io/v1 event, or nil if the related object is not found in the tenant control plane.
+func (c *controller) buildVirtualRelated(clusterName string, related *corev1.ObjectReference) *corev1.ObjectReference {
+	relatedType, ok := supportedInvolvedObjects[related.Kind]
+	if !ok {
+		return nil
+	}
+	relatedCluster, vNamespace, err := conversion.GetVirtualNamespace(c.nsLister, related.Namespace)
+	if err != nil || relatedCluster != clusterName || vNamespace == "" {
+		return nil
+	}
+	vRelatedObject := relatedType.DeepCopyObject().(client.Object)
+	if err := c.MultiClusterController.Get(clusterName, vNamespace, related.Name, vRelatedObject); err != nil {
+		return nil
+	}
+	vRelated := related.DeepCopy()
+	vRelated.Namespace = vNamespace
+	vRelated.UID = vRelatedObject.GetUID()
+	vRelated.ResourceVersion = ""
+	return vRelated
+}
+
+// aggregateEvent returns a copy of vEvent updated with the occurrences recorded in
+// the newly built event, or nil if vEvent is up to date.
+func aggregateEvent(vEvent, newEvent *corev1.Event) *corev1.Event {
+	if vEvent.Count == newEvent.Count &&
+		vEvent.LastTimestamp.Equal(&newEvent.LastTimestamp) &&
+		vEvent.Message == newEvent.Message &&
+		equality.Semantic.DeepEqual(vEvent.Series, newEvent.Series) {
+		return nil
+	}
+	updated := vEvent.DeepCopy()
+	updated.Count = newEvent.Count
+	updated.LastTimestamp = newEvent.LastTimestamp
+	updated.Message = newEvent.Message
+	updated.Series = newEvent.Series.DeepCopy()
+	return updated
 }
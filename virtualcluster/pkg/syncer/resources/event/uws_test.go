@@ -20,17 +20,20 @@ import (
 	"encoding/json"
 	"strings"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	core "k8s.io/client-go/testing"
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
 	util "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/test"
 )
 
@@ -70,6 +73,46 @@ func fakeEvent(name, namespace string, involvedObject corev1.ObjectReference) *c
 	}
 }
 
+func fakeRepeatedEvent(name, namespace, reason string, count int32, lastTimestamp metav1.Time, involvedObject corev1.ObjectReference) *corev1.Event {
+	event := fakeEvent(name, namespace, involvedObject)
+	event.Reason = reason
+	event.Count = count
+	event.LastTimestamp = lastTimestamp
+	return event
+}
+
+func fakeSeriesEvent(name, namespace string, count int32, lastObservedTime metav1.MicroTime, involvedObject corev1.ObjectReference) *corev1.Event {
+	event := fakeEvent(name, namespace, involvedObject)
+	event.Series = &corev1.EventSeries{
+		Count:            count,
+		LastObservedTime: lastObservedTime,
+	}
+	return event
+}
+
+// withRelated sets the related object of an events.k8s.io/v1 event.
+func withRelated(event *corev1.Event, related *corev1.ObjectReference) *corev1.Event {
+	event.Related = related
+	return event
+}
+
+// withResourceVersion sets the resource version the fake tenant client assigns to
+// the existing objects.
+func withResourceVersion(event *corev1.Event) *corev1.Event {
+	event.ResourceVersion = "999"
+	return event
+}
+
+func tenantPVC(name, namespace, uid string) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			UID:       types.UID(uid),
+		},
+	}
+}
+
 func tenantPod(name, namespace, uid string) *corev1.Pod {
 	return &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -113,12 +156,17 @@ func TestUWEvent(t *testing.T) {
 
 	defaultClusterKey := conversion.ToClusterKey(testTenant)
 	superDefaultNSName := conversion.ToSuperClusterNamespace(defaultClusterKey, "default")
+	firstSeen := metav1.NewTime(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))
+	lastSeen := metav1.NewTime(firstSeen.Add(time.Minute))
 
 	testcases := map[string]struct {
 		ExistingObjectInSuper  []runtime.Object
 		ExistingObjectInTenant []runtime.Object
 		EnqueuedKey            string
+		InvolvedObjectKinds    []string
+		Reasons                []string
 		ExpectedCreatedObject  []runtime.Object
+		ExpectedUpdatedObject  []runtime.Object
 		ExpectedNoOperation    bool
 		ExpectedError          string
 	}{
@@ -189,6 +237,36 @@ func TestUWEvent(t *testing.T) {
 				fakeEvent("event", "default", makeObjectReference("Service", "default", "svc", "12345")),
 			},
 		},
+		"pEvent exists with a related object found in tenant": {
+			ExistingObjectInSuper: []runtime.Object{
+				withRelated(fakeSeriesEvent("event", superDefaultNSName, 2, metav1.NewMicroTime(lastSeen.Time), makeObjectReference("Pod", superDefaultNSName, "pod", "23456")),
+					&corev1.ObjectReference{Kind: "Service", Namespace: superDefaultNSName, Name: "svc", UID: "34567"}),
+				superNamespace(superDefaultNSName, defaultClusterKey, "default"),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				tenantPod("pod", "default", "12345"),
+				tenantService("svc", "default", "45678"),
+			},
+			EnqueuedKey: superDefaultNSName + "/event",
+			ExpectedCreatedObject: []runtime.Object{
+				withRelated(fakeSeriesEvent("event", "default", 2, metav1.NewMicroTime(lastSeen.Time), makeObjectReference("Pod", "default", "pod", "12345")),
+					&corev1.ObjectReference{Kind: "Service", Namespace: "default", Name: "svc", UID: "45678"}),
+			},
+		},
+		"pEvent exists with a related object not found in tenant": {
+			ExistingObjectInSuper: []runtime.Object{
+				withRelated(fakeEvent("event", superDefaultNSName, makeObjectReference("Pod", superDefaultNSName, "pod", "23456")),
+					&corev1.ObjectReference{Kind: "Node", Name: "node-1"}),
+				superNamespace(superDefaultNSName, defaultClusterKey, "default"),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				tenantPod("pod", "default", "12345"),
+			},
+			EnqueuedKey: superDefaultNSName + "/event",
+			ExpectedCreatedObject: []runtime.Object{
+				fakeEvent("event", "default", makeObjectReference("Pod", "default", "pod", "12345")),
+			},
+		},
 		"pEvent exists and vEvent exists": {
 			ExistingObjectInSuper: []runtime.Object{
 				fakeEvent("event", superDefaultNSName, makeObjectReference("Pod", superDefaultNSName, "pod", "23456")),
@@ -201,11 +279,86 @@ func TestUWEvent(t *testing.T) {
 			EnqueuedKey:         superDefaultNSName + "/event",
 			ExpectedNoOperation: true,
 		},
+		"pEvent exists and vEvent exists with stale count": {
+			ExistingObjectInSuper: []runtime.Object{
+				fakeRepeatedEvent("event", superDefaultNSName, "BackOff", 5, lastSeen, makeObjectReference("Pod", superDefaultNSName, "pod", "23456")),
+				superNamespace(superDefaultNSName, defaultClusterKey, "default"),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				tenantPod("pod", "default", "12345"),
+				fakeRepeatedEvent("event", "default", "BackOff", 1, firstSeen, makeObjectReference("Pod", "default", "pod", "12345")),
+			},
+			EnqueuedKey: superDefaultNSName + "/event",
+			ExpectedUpdatedObject: []runtime.Object{
+				withResourceVersion(fakeRepeatedEvent("event", "default", "BackOff", 5, lastSeen, makeObjectReference("Pod", "default", "pod", "12345"))),
+			},
+		},
+		"pEvent exists and vEvent exists with stale series": {
+			ExistingObjectInSuper: []runtime.Object{
+				fakeSeriesEvent("event", superDefaultNSName, 3, metav1.NewMicroTime(lastSeen.Time), makeObjectReference("Pod", superDefaultNSName, "pod", "23456")),
+				superNamespace(superDefaultNSName, defaultClusterKey, "default"),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				tenantPod("pod", "default", "12345"),
+				fakeSeriesEvent("event", "default", 2, metav1.NewMicroTime(firstSeen.Time), makeObjectReference("Pod", "default", "pod", "12345")),
+			},
+			EnqueuedKey: superDefaultNSName + "/event",
+			ExpectedUpdatedObject: []runtime.Object{
+				withResourceVersion(fakeSeriesEvent("event", "default", 3, metav1.NewMicroTime(lastSeen.Time), makeObjectReference("Pod", "default", "pod", "12345"))),
+			},
+		},
+		"pEvent exists but the reason is not accepted": {
+			ExistingObjectInSuper: []runtime.Object{
+				fakeRepeatedEvent("event", superDefaultNSName, "Pulled", 1, firstSeen, makeObjectReference("Pod", superDefaultNSName, "pod", "23456")),
+				superNamespace(superDefaultNSName, defaultClusterKey, "default"),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				tenantPod("pod", "default", "12345"),
+			},
+			EnqueuedKey:         superDefaultNSName + "/event",
+			Reasons:             []string{"BackOff", "FailedScheduling"},
+			ExpectedNoOperation: true,
+		},
+		"pEvent exists with an accepted reason": {
+			ExistingObjectInSuper: []runtime.Object{
+				fakeRepeatedEvent("event", superDefaultNSName, "FailedScheduling", 1, firstSeen, makeObjectReference("Pod", superDefaultNSName, "pod", "23456")),
+				superNamespace(superDefaultNSName, defaultClusterKey, "default"),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				tenantPod("pod", "default", "12345"),
+			},
+			EnqueuedKey: superDefaultNSName + "/event",
+			Reasons:     []string{"BackOff", "FailedScheduling"},
+			ExpectedCreatedObject: []runtime.Object{
+				fakeRepeatedEvent("event", "default", "FailedScheduling", 1, firstSeen, makeObjectReference("Pod", "default", "pod", "12345")),
+			},
+		},
+		"pEvent exists with a configured kind, type pvc": {
+			ExistingObjectInSuper: []runtime.Object{
+				fakeEvent("event", superDefaultNSName, makeObjectReference("PersistentVolumeClaim", superDefaultNSName, "pvc", "23456")),
+				superNamespace(superDefaultNSName, defaultClusterKey, "default"),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				tenantPVC("pvc", "default", "12345"),
+			},
+			EnqueuedKey:         superDefaultNSName + "/event",
+			InvolvedObjectKinds: []string{"Pod", "PersistentVolumeClaim"},
+			ExpectedCreatedObject: []runtime.Object{
+				fakeEvent("event", "default", makeObjectReference("PersistentVolumeClaim", "default", "pvc", "12345")),
+			},
+		},
 	}
 
 	for k, tc := range testcases {
 		t.Run(k, func(t *testing.T) {
-			actions, reconcileErr, err := util.RunUpwardSync(NewEventController, testTenant, tc.ExistingObjectInSuper, tc.ExistingObjectInTenant, tc.EnqueuedKey, nil)
+			modifier := func(rs manager.ResourceSyncer) {
+				c := rs.(*controller)
+				c.acceptedReasons = sets.NewString(tc.Reasons...)
+				for _, kind := range tc.InvolvedObjectKinds {
+					c.acceptedEventObj[kind] = supportedInvolvedObjects[kind]
+				}
+			}
+			actions, reconcileErr, err := util.RunUpwardSync(NewEventController, testTenant, tc.ExistingObjectInSuper, tc.ExistingObjectInTenant, tc.EnqueuedKey, modifier)
 			if err != nil {
 				t.Errorf("%s: error running upward sync: %v", k, err)
 				return
@@ -250,6 +403,26 @@ func TestUWEvent(t *testing.T) {
 					t.Errorf("%s: Expect created Event %+v but not found", k, obj)
 				}
 			}
+
+			for _, obj := range tc.ExpectedUpdatedObject {
+				matched := false
+				for _, action := range actions {
+					if !action.Matches("update", "Events") {
+						continue
+					}
+					actionObj := action.(core.UpdateAction).GetObject()
+					if !equality.Semantic.DeepEqual(obj, actionObj) {
+						exp, _ := json.Marshal(obj)
+						got, _ := json.Marshal(actionObj)
+						t.Errorf("%s: Expected updated Event is %v, got %v", k, string(exp), string(got))
+					}
+					matched = true
+					break
+				}
+				if !matched {
+					t.Errorf("%s: Expect updated Event %+v but not found", k, obj)
+				}
+			}
 		})
 	}
 }
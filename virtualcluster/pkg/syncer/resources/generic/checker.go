@@ -0,0 +1,175 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generic
+
+import (
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol/differ"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
+)
+
+func (c *controller) StartPatrol(stopCh <-chan struct{}) error {
+	defer utilruntime.HandleCrash()
+
+	if !cache.WaitForCacheSync(stopCh, c.synced, c.nsSynced) {
+		return fmt.Errorf("failed to wait for caches to sync before starting %s checker", c.resource.Kind)
+	}
+	c.Patroller.Start(stopCh)
+	return nil
+}
+
+// PatrollerDo checks to see if the generic resources in super control plane informer cache and tenant control plane
+// keep consistency.
+func (c *controller) PatrollerDo() {
+	clusterNames := c.MultiClusterController.GetClusterNames()
+	if len(clusterNames) == 0 {
+		klog.V(5).Infof("super cluster has no tenant control planes, giving up periodic checker: %s", c.resource.Kind)
+		return
+	}
+
+	objs, err := c.lister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("error listing %s from super control plane informer cache: %v", c.resource.Kind, err)
+		return
+	}
+	pSet := differ.NewDiffSet()
+	var pOriginals []*unstructured.Unstructured
+	for _, obj := range objs {
+		pObj := obj.(*unstructured.Unstructured)
+		if isDownwardSynced(pObj) {
+			pSet.Insert(differ.ClusterObject{Object: pObj, Key: differ.DefaultClusterObjectKey(pObj, "")})
+		} else {
+			pOriginals = append(pOriginals, pObj)
+		}
+	}
+
+	knownClusterSet := sets.NewString(clusterNames...)
+	vSet := differ.NewDiffSet()
+	var vCopies []differ.ClusterObject
+	for _, cluster := range clusterNames {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(c.gvk.GroupVersion().WithKind(c.resource.Kind + "List"))
+		if err := c.MultiClusterController.List(cluster, list); err != nil {
+			klog.Errorf("error listing %s from cluster %s informer cache: %v", c.resource.Kind, cluster, err)
+			knownClusterSet.Delete(cluster)
+			continue
+		}
+
+		for i := range list.Items {
+			vObj := differ.ClusterObject{
+				Object:       &list.Items[i],
+				OwnerCluster: cluster,
+				Key:          differ.DefaultClusterObjectKey(&list.Items[i], cluster),
+			}
+			if isUpwardSynced(&list.Items[i]) {
+				vCopies = append(vCopies, vObj)
+				continue
+			}
+			vSet.Insert(vObj)
+		}
+	}
+
+	if c.syncDownward() {
+		c.checkDownward(vSet, pSet, knownClusterSet)
+	}
+	if c.syncUpward() {
+		c.checkUpward(pOriginals, vCopies)
+	}
+}
+
+func (c *controller) checkDownward(vSet, pSet differ.Differ, knownClusterSet sets.String) {
+	objDiffer := differ.HandlerFuncs{}
+	objDiffer.AddFunc = func(vObj differ.ClusterObject) {
+		if err := c.MultiClusterController.RequeueObject(vObj.OwnerCluster, vObj.Object); err != nil {
+			klog.Errorf("error requeue %s %v/%v in cluster %s: %v", c.resource.Kind, vObj.GetNamespace(), vObj.GetName(), vObj.GetOwnerCluster(), err)
+		} else {
+			metrics.CheckerRemedyStats.WithLabelValues("RequeuedTenant" + c.resource.Kind).Inc()
+		}
+	}
+	objDiffer.UpdateFunc = func(vObj, pObj differ.ClusterObject) {
+		v := vObj.Object.(*unstructured.Unstructured)
+		p := pObj.Object.(*unstructured.Unstructured)
+
+		if p.GetAnnotations()[constants.LabelUID] != string(v.GetUID()) {
+			klog.Errorf("Found %s %s delegated UID is different from tenant object.", c.resource.Kind, pObj.Key)
+			objDiffer.OnDelete(pObj)
+			return
+		}
+		vc, err := util.GetVirtualClusterObject(c.MultiClusterController, vObj.GetOwnerCluster())
+		if err != nil {
+			klog.Errorf("fail to get cluster spec : %s", vObj.GetOwnerCluster())
+			return
+		}
+		if c.checkDWEquality(vc, vObj.GetOwnerCluster(), p, v) != nil {
+			klog.Warningf("%s %s diff in super&tenant control plane", c.resource.Kind, pObj.Key)
+			if err := c.MultiClusterController.RequeueObject(vObj.OwnerCluster, v); err != nil {
+				klog.Errorf("error requeue %s %v/%v in cluster %s: %v", c.resource.Kind, v.GetNamespace(), v.GetName(), vObj.GetOwnerCluster(), err)
+			}
+		}
+	}
+	objDiffer.DeleteFunc = func(pObj differ.ClusterObject) {
+		p := pObj.Object.(*unstructured.Unstructured)
+		if err := c.deleteSuperObject(p.GetAnnotations()[constants.LabelCluster], p); err != nil {
+			klog.Errorf("error deleting %s %s in super control plane: %v", c.resource.Kind, pObj.Key, err)
+		} else {
+			metrics.CheckerRemedyStats.WithLabelValues("DeletedOrphanSuperControlPlane" + c.resource.Kind).Inc()
+		}
+	}
+
+	vSet.Difference(pSet, differ.FilteringHandler{
+		Handler:    objDiffer,
+		FilterFunc: differ.DefaultDifferFilter(knownClusterSet),
+	})
+}
+
+// checkUpward requeues the super control plane objects that are synced upward, and removes the tenant
+// copies whose super control plane objects are gone.
+func (c *controller) checkUpward(pOriginals []*unstructured.Unstructured, vCopies []differ.ClusterObject) {
+	for _, pObj := range pOriginals {
+		c.enqueueObject(pObj)
+	}
+
+	for _, vObj := range vCopies {
+		v := vObj.Object.(*unstructured.Unstructured)
+		namespace := conversion.ToSuperClusterNamespace(vObj.GetOwnerCluster(), v.GetNamespace())
+		obj, err := c.lister.ByNamespace(namespace).Get(v.GetName())
+		if err == nil && string(obj.(*unstructured.Unstructured).GetUID()) == v.GetAnnotations()[constants.LabelSuperUID] {
+			continue
+		}
+		if err != nil && !apierrors.IsNotFound(err) {
+			klog.Errorf("error getting %s %s from super control plane informer cache: %v", c.resource.Kind, vObj.Key, err)
+			continue
+		}
+		if err := c.deleteVirtualObject(vObj.GetOwnerCluster(), v); err != nil {
+			klog.Errorf("error deleting orphan tenant %s %s/%s in cluster %s: %v", c.resource.Kind, v.GetNamespace(), v.GetName(), vObj.GetOwnerCluster(), err)
+		} else {
+			metrics.CheckerRemedyStats.WithLabelValues("DeletedOrphanTenant" + c.resource.Kind).Inc()
+		}
+	}
+}
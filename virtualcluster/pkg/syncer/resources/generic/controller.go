@@ -0,0 +1,235 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generic
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
+	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
+	uw "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/uwcontroller"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/errors"
+	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/plugin"
+)
+
+func init() {
+	plugin.SyncerResourceRegister.Register(&plugin.Registration{
+		ID: "generic",
+		InitFn: func(ctx *plugin.InitContext) (interface{}, error) {
+			cfg := ctx.Config.(*config.SyncerConfiguration)
+			if len(cfg.GenericSyncingResources) == 0 {
+				return []manager.ResourceSyncer{}, nil
+			}
+			dynamicClient, err := dynamic.NewForConfig(cfg.RestConfig)
+			if err != nil {
+				return nil, err
+			}
+			dynamicInformer := dynamicinformer.NewDynamicSharedInformerFactory(dynamicClient, 0)
+			return NewGenericControllers(cfg, dynamicClient, dynamicInformer, ctx.Informer, manager.ResourceSyncerOptions{})
+		},
+	})
+}
+
+type controller struct {
+	manager.BaseResourceSyncer
+	resource config.GenericSyncingResource
+	gvk      schema.GroupVersionKind
+	// super control plane dynamic client
+	client dynamic.NamespaceableResourceInterface
+	// super control plane informer/listers/synced functions
+	informerFactory dynamicinformer.DynamicSharedInformerFactory
+	lister          cache.GenericLister
+	synced          cache.InformerSynced
+	nsLister        listersv1.NamespaceLister
+	nsSynced        cache.InformerSynced
+}
+
+// NewGenericControllers creates a syncer for each of the configured generic syncing resources.
+func NewGenericControllers(config *config.SyncerConfiguration,
+	dynamicClient dynamic.Interface,
+	dynamicInformer dynamicinformer.DynamicSharedInformerFactory,
+	informer informers.SharedInformerFactory,
+	options manager.ResourceSyncerOptions) ([]manager.ResourceSyncer, error) {
+	syncers := make([]manager.ResourceSyncer, 0, len(config.GenericSyncingResources))
+	for _, resource := range config.GenericSyncingResources {
+		s, err := NewGenericController(config, resource, dynamicClient, dynamicInformer, informer, options)
+		if err != nil {
+			return nil, err
+		}
+		syncers = append(syncers, s)
+	}
+	return syncers, nil
+}
+
+// NewGenericController creates a syncer for a namespaced resource based on its configuration.
+func NewGenericController(config *config.SyncerConfiguration,
+	resource config.GenericSyncingResource,
+	dynamicClient dynamic.Interface,
+	dynamicInformer dynamicinformer.DynamicSharedInformerFactory,
+	informer informers.SharedInformerFactory,
+	options manager.ResourceSyncerOptions) (manager.ResourceSyncer, error) {
+	resource, err := defaultGenericSyncingResource(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	gvr := schema.GroupVersionResource{Group: resource.Group, Version: resource.Version, Resource: resource.Resource}
+	c := &controller{
+		BaseResourceSyncer: manager.BaseResourceSyncer{
+			Config: config,
+		},
+		resource:        resource,
+		gvk:             schema.GroupVersionKind{Group: resource.Group, Version: resource.Version, Kind: resource.Kind},
+		client:          dynamicClient.Resource(gvr),
+		informerFactory: dynamicInformer,
+	}
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(c.gvk.GroupVersion().WithKind(resource.Kind + "List"))
+	c.MultiClusterController, err = mc.NewMCController(c.newObject(), list, c, mc.WithOptions(options.MCOptions))
+	if err != nil {
+		return nil, err
+	}
+
+	genericInformer := dynamicInformer.ForResource(gvr)
+	c.lister = genericInformer.Lister()
+	c.nsLister = informer.Core().V1().Namespaces().Lister()
+	if options.IsFake {
+		c.synced = func() bool { return true }
+		c.nsSynced = func() bool { return true }
+	} else {
+		c.synced = genericInformer.Informer().HasSynced
+		c.nsSynced = informer.Core().V1().Namespaces().Informer().HasSynced
+	}
+
+	c.UpwardController, err = uw.NewUWController(c.newObject(), c, uw.WithOptions(options.UWOptions))
+	if err != nil {
+		return nil, err
+	}
+
+	c.Patroller, err = pa.NewPatroller(c.newObject(), c, pa.WithOptions(options.PatrolOptions))
+	if err != nil {
+		return nil, err
+	}
+
+	genericInformer.Informer().AddEventHandler(
+		cache.FilteringResourceEventHandler{
+			FilterFunc: func(obj interface{}) bool {
+				switch t := obj.(type) {
+				case *unstructured.Unstructured:
+					return c.isBackPopulateObject(t)
+				case cache.DeletedFinalStateUnknown:
+					if e, ok := t.Obj.(*unstructured.Unstructured); ok {
+						return c.isBackPopulateObject(e)
+					}
+					utilruntime.HandleError(fmt.Errorf("unable to convert object %v to *unstructured.Unstructured", obj))
+					return false
+				default:
+					utilruntime.HandleError(fmt.Errorf("unable to handle object in super control plane %s controller: %v", resource.Kind, obj))
+					return false
+				}
+			},
+			Handler: cache.ResourceEventHandlerFuncs{
+				AddFunc: c.enqueueObject,
+				UpdateFunc: func(oldObj, newObj interface{}) {
+					if newObj.(*unstructured.Unstructured).GetResourceVersion() != oldObj.(*unstructured.Unstructured).GetResourceVersion() {
+						c.enqueueObject(newObj)
+					}
+				},
+				DeleteFunc: c.enqueueObject,
+			},
+		})
+	return c, nil
+}
+
+func defaultGenericSyncingResource(resource config.GenericSyncingResource) (config.GenericSyncingResource, error) {
+	if resource.Version == "" || resource.Kind == "" || resource.Resource == "" {
+		return resource, fmt.Errorf("generic syncing resource %+v must specify version, kind and resource", resource)
+	}
+	switch resource.Direction {
+	case "":
+		resource.Direction = config.SyncDirectionDownward
+	case config.SyncDirectionDownward, config.SyncDirectionUpward, config.SyncDirectionBoth:
+	default:
+		return resource, fmt.Errorf("unknown sync direction %q of %s", resource.Direction, resource.Kind)
+	}
+	if len(resource.StatusPaths) == 0 {
+		resource.StatusPaths = []string{"status"}
+	}
+	return resource, nil
+}
+
+func (c *controller) newObject() *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(c.gvk)
+	return obj
+}
+
+func (c *controller) syncDownward() bool {
+	return c.resource.Direction != config.SyncDirectionUpward
+}
+
+func (c *controller) syncUpward() bool {
+	return c.resource.Direction != config.SyncDirectionDownward
+}
+
+// isDownwardSynced returns true if the super control plane object is created by the syncer for a tenant object.
+func isDownwardSynced(pObj client.Object) bool {
+	return pObj.GetAnnotations()[constants.LabelCluster] != ""
+}
+
+// isUpwardSynced returns true if the tenant object is created by the syncer for a super control plane object.
+func isUpwardSynced(vObj client.Object) bool {
+	return vObj.GetAnnotations()[constants.LabelSuperUID] != ""
+}
+
+func (c *controller) isBackPopulateObject(pObj *unstructured.Unstructured) bool {
+	if isDownwardSynced(pObj) {
+		return c.syncDownward()
+	}
+	return c.syncUpward()
+}
+
+func (c *controller) enqueueObject(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't get key for object %v: %v", obj, err))
+		return
+	}
+	c.UpwardController.AddToQueue(key)
+}
+
+func (c *controller) tenantClient(clusterName string) (client.Client, error) {
+	cluster := c.MultiClusterController.GetCluster(clusterName)
+	if cluster == nil {
+		return nil, errors.NewClusterNotFound(clusterName)
+	}
+	return cluster.GetDelegatingClient()
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generic
+
+import (
+	"context"
+	"fmt"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
+)
+
+// metadataFields are the top level fields that are not compared as the object content.
+var metadataFields = map[string]bool{
+	"apiVersion": true,
+	"kind":       true,
+	"metadata":   true,
+	"status":     true,
+}
+
+func (c *controller) StartDWS(stopCh <-chan struct{}) error {
+	c.informerFactory.Start(stopCh)
+	if !cache.WaitForCacheSync(stopCh, c.synced, c.nsSynced) {
+		return fmt.Errorf("failed to wait for caches to sync %s", c.resource.Kind)
+	}
+	return c.MultiClusterController.Start(stopCh)
+}
+
+// The reconcile logic for tenant control plane generic resource informer
+func (c *controller) Reconcile(request reconciler.Request) (reconciler.Result, error) {
+	if !c.syncDownward() {
+		return reconciler.Result{}, nil
+	}
+	klog.V(4).Infof("reconcile %s %s/%s event for cluster %s", c.resource.Kind, request.Namespace, request.Name, request.ClusterName)
+
+	targetNamespace := conversion.ToSuperClusterNamespace(request.ClusterName, request.Namespace)
+	var pObj *unstructured.Unstructured
+	obj, err := c.lister.ByNamespace(targetNamespace).Get(request.Name)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return reconciler.Result{Requeue: true}, err
+		}
+	} else {
+		pObj = obj.(*unstructured.Unstructured)
+		if !isDownwardSynced(pObj) {
+			// the super control plane object is not created by the syncer, leave it alone.
+			return reconciler.Result{}, nil
+		}
+	}
+
+	vObj := c.newObject()
+	vExists := true
+	if err := c.MultiClusterController.Get(request.ClusterName, request.Namespace, request.Name, vObj); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return reconciler.Result{Requeue: true}, err
+		}
+		vExists = false
+	}
+	if vExists && isUpwardSynced(vObj) {
+		// the tenant object is a copy of a super control plane object.
+		return reconciler.Result{}, nil
+	}
+
+	switch {
+	case vExists && pObj == nil:
+		err := c.reconcileCreate(request.ClusterName, targetNamespace, request.UID, vObj)
+		if err != nil {
+			klog.Errorf("failed reconcile %s %s/%s CREATE of cluster %s %v", c.resource.Kind, request.Namespace, request.Name, request.ClusterName, err)
+			return reconciler.Result{Requeue: true}, err
+		}
+	case !vExists && pObj != nil:
+		err := c.reconcileRemove(request.ClusterName, targetNamespace, request.UID, request.Name, pObj)
+		if err != nil {
+			klog.Errorf("failed reconcile %s %s/%s DELETE of cluster %s %v", c.resource.Kind, request.Namespace, request.Name, request.ClusterName, err)
+			return reconciler.Result{Requeue: true}, err
+		}
+	case vExists && pObj != nil:
+		err := c.reconcileUpdate(request.ClusterName, targetNamespace, request.UID, pObj, vObj)
+		if err != nil {
+			klog.Errorf("failed reconcile %s %s/%s UPDATE of cluster %s %v", c.resource.Kind, request.Namespace, request.Name, request.ClusterName, err)
+			return reconciler.Result{Requeue: true}, err
+		}
+	default:
+		// object is gone.
+	}
+	return reconciler.Result{}, nil
+}
+
+// toSuperObject rewrites the configured namespace references of a tenant object to the super control plane namespaces.
+func (c *controller) toSuperObject(clusterName string, obj *unstructured.Unstructured) {
+	toSuper := func(ns string) string {
+		return conversion.ToSuperClusterNamespace(clusterName, ns)
+	}
+	for _, path := range c.resource.NamespaceRefPaths {
+		rewriteStringFields(obj.Object, path, toSuper)
+	}
+	for _, path := range c.resource.NamespacedNameRefPaths {
+		rewriteStringFields(obj.Object, path, namespacedNameFunc(toSuper))
+	}
+}
+
+func (c *controller) reconcileCreate(clusterName, targetNamespace, requestUID string, vObj *unstructured.Unstructured) error {
+	newObj, err := c.Conversion().BuildSuperClusterObject(clusterName, vObj)
+	if err != nil {
+		return err
+	}
+	pObj := newObj.(*unstructured.Unstructured)
+	unstructured.RemoveNestedField(pObj.Object, "status")
+	c.toSuperObject(clusterName, pObj)
+
+	_, err = c.client.Namespace(targetNamespace).Create(context.TODO(), pObj, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		existing, getErr := c.client.Namespace(targetNamespace).Get(context.TODO(), pObj.GetName(), metav1.GetOptions{})
+		if getErr != nil {
+			return getErr
+		}
+		if existing.GetAnnotations()[constants.LabelUID] == requestUID {
+			klog.Infof("%s %s/%s of cluster %s already exist in super control plane", c.resource.Kind, targetNamespace, pObj.GetName(), clusterName)
+			return nil
+		}
+		return fmt.Errorf("%s %s/%s exists but its delegated object UID is different", c.resource.Kind, targetNamespace, pObj.GetName())
+	}
+	return err
+}
+
+func (c *controller) reconcileUpdate(clusterName, targetNamespace, requestUID string, pObj, vObj *unstructured.Unstructured) error {
+	if pObj.GetAnnotations()[constants.LabelUID] != requestUID {
+		return fmt.Errorf("%s %s/%s delegated UID is different from updated object", c.resource.Kind, targetNamespace, pObj.GetName())
+	}
+	vc, err := util.GetVirtualClusterObject(c.MultiClusterController, clusterName)
+	if err != nil {
+		return err
+	}
+	updated := c.checkDWEquality(vc, clusterName, pObj, vObj)
+	if updated != nil {
+		_, err = c.client.Namespace(targetNamespace).Update(context.TODO(), updated, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkDWEquality returns the updated super control plane object if its labels, annotations or
+// content differ from the tenant object, or nil if they are equal.
+func (c *controller) checkDWEquality(vc *v1alpha1.VirtualCluster, clusterName string, pObj, vObj *unstructured.Unstructured) *unstructured.Unstructured {
+	var updated *unstructured.Unstructured
+
+	// compare against the tenant object with its namespace references rewritten, since they may be in annotations.
+	expected := vObj.DeepCopy()
+	c.toSuperObject(clusterName, expected)
+
+	pMeta := &metav1.ObjectMeta{GenerateName: pObj.GetGenerateName(), Labels: pObj.GetLabels(), Annotations: pObj.GetAnnotations(), ClusterName: pObj.GetClusterName()}
+	vMeta := &metav1.ObjectMeta{GenerateName: expected.GetGenerateName(), Labels: expected.GetLabels(), Annotations: expected.GetAnnotations(), ClusterName: expected.GetClusterName()}
+	if updatedMeta := conversion.Equality(c.Config, vc).CheckDWObjectMetaEquality(pMeta, vMeta); updatedMeta != nil {
+		updated = pObj.DeepCopy()
+		updated.SetGenerateName(updatedMeta.GenerateName)
+		updated.SetLabels(updatedMeta.Labels)
+		updated.SetAnnotations(updatedMeta.Annotations)
+		updated.SetClusterName(updatedMeta.ClusterName)
+	}
+
+	keys := make(map[string]bool)
+	for k := range expected.Object {
+		keys[k] = true
+	}
+	for k := range pObj.Object {
+		keys[k] = true
+	}
+	for k := range keys {
+		if metadataFields[k] {
+			continue
+		}
+		ev, eok := expected.Object[k]
+		pv, pok := pObj.Object[k]
+		if eok == pok && apiequality.Semantic.DeepEqual(ev, pv) {
+			continue
+		}
+		if updated == nil {
+			updated = pObj.DeepCopy()
+		}
+		if eok {
+			updated.Object[k] = runtime.DeepCopyJSONValue(ev)
+		} else {
+			delete(updated.Object, k)
+		}
+	}
+	return updated
+}
+
+func (c *controller) reconcileRemove(clusterName, targetNamespace, requestUID, name string, pObj *unstructured.Unstructured) error {
+	if pObj.GetAnnotations()[constants.LabelUID] != requestUID {
+		return fmt.Errorf("to be deleted %s %s/%s delegated UID is different from deleted object", c.resource.Kind, targetNamespace, name)
+	}
+	return c.deleteSuperObject(clusterName, pObj)
+}
+
+func (c *controller) deleteSuperObject(clusterName string, pObj *unstructured.Unstructured) error {
+	uid := pObj.GetUID()
+	opts := metav1.DeleteOptions{
+		PropagationPolicy: &constants.DefaultDeletionPolicy,
+		Preconditions:     &metav1.Preconditions{UID: &uid},
+	}
+	err := c.client.Namespace(pObj.GetNamespace()).Delete(context.TODO(), pObj.GetName(), opts)
+	if apierrors.IsNotFound(err) {
+		klog.Warningf("%s %s/%s of cluster %s not found in super control plane", c.resource.Kind, pObj.GetNamespace(), pObj.GetName(), clusterName)
+		return nil
+	}
+	return err
+}
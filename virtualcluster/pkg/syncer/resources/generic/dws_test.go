@@ -0,0 +1,336 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generic
+
+import (
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/informers"
+	clientset "k8s.io/client-go/kubernetes"
+	core "k8s.io/client-go/testing"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	vcinformers "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
+	util "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/test"
+)
+
+var (
+	certificateResource = config.GenericSyncingResource{
+		Group:                  "cert-manager.io",
+		Version:                "v1",
+		Kind:                   "Certificate",
+		Resource:               "certificates",
+		NamespacedNameRefPaths: []string{`metadata.annotations.cert-manager\.io/inject-ca-from`},
+		NamespaceRefPaths:      []string{"spec.issuerRef.namespace"},
+	}
+	certificateGVR = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+)
+
+func tenantCertificate(name, namespace, uid string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"secretName": name,
+			"issuerRef": map[string]interface{}{
+				"name":      "issuer",
+				"namespace": namespace,
+			},
+		},
+	}}
+	obj.SetAPIVersion("cert-manager.io/v1")
+	obj.SetKind("Certificate")
+	obj.SetName(name)
+	obj.SetNamespace(namespace)
+	obj.SetUID(types.UID(uid))
+	obj.SetAnnotations(map[string]string{"cert-manager.io/inject-ca-from": namespace + "/ca"})
+	return obj
+}
+
+func superCertificate(name, namespace, uid, clusterKey string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"secretName": name,
+			"issuerRef": map[string]interface{}{
+				"name":      "issuer",
+				"namespace": namespace,
+			},
+		},
+	}}
+	obj.SetAPIVersion("cert-manager.io/v1")
+	obj.SetKind("Certificate")
+	obj.SetName(name)
+	obj.SetNamespace(namespace)
+	obj.SetAnnotations(map[string]string{
+		constants.LabelUID:               uid,
+		constants.LabelCluster:           clusterKey,
+		constants.LabelNamespace:         "default",
+		"cert-manager.io/inject-ca-from": namespace + "/ca",
+	})
+	return obj
+}
+
+// newFakeController returns a constructor of a generic controller backed by a fake dynamic client.
+func newFakeController(dynamicClient *fakedynamic.FakeDynamicClient, existingObjectInSuper []runtime.Object) manager.ResourceSyncerNew {
+	return func(cfg *config.SyncerConfiguration,
+		client clientset.Interface,
+		informer informers.SharedInformerFactory,
+		vcClient vcclient.Interface,
+		vcInformer vcinformers.VirtualClusterInformer,
+		options manager.ResourceSyncerOptions) (manager.ResourceSyncer, error) {
+		dynamicInformer := dynamicinformer.NewDynamicSharedInformerFactory(dynamicClient, 0)
+		for _, each := range existingObjectInSuper {
+			_ = dynamicInformer.ForResource(certificateGVR).Informer().GetStore().Add(each)
+		}
+		return NewGenericController(cfg, certificateResource, dynamicClient, dynamicInformer, informer, options)
+	}
+}
+
+func runDownwardSync(testTenant *v1alpha1.VirtualCluster, existingObjectInSuper, existingObjectInTenant []runtime.Object, enqueueObject runtime.Object) ([]core.Action, error, error) {
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{certificateGVR: "CertificateList"}, existingObjectInSuper...)
+	_, reconcileErr, err := util.RunDownwardSync(newFakeController(dynamicClient, existingObjectInSuper), testTenant, nil, existingObjectInTenant, enqueueObject, nil)
+	var actions []core.Action
+	for _, action := range dynamicClient.Actions() {
+		if action.GetVerb() != "list" && action.GetVerb() != "watch" {
+			actions = append(actions, action)
+		}
+	}
+	return actions, reconcileErr, err
+}
+
+func TestDWCertificateCreation(t *testing.T) {
+	testTenant := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Spec: v1alpha1.VirtualClusterSpec{},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+
+	defaultClusterKey := conversion.ToClusterKey(testTenant)
+	superDefaultNSName := conversion.ToSuperClusterNamespace(defaultClusterKey, "default")
+
+	testcases := map[string]struct {
+		ExistingObjectInSuper  []runtime.Object
+		ExistingObjectInTenant []runtime.Object
+		ExpectedCreatedPObject []string
+		ExpectedNoOperation    bool
+		ExpectedError          string
+	}{
+		"new certificate": {
+			ExistingObjectInTenant: []runtime.Object{
+				tenantCertificate("cert-1", "default", "12345"),
+			},
+			ExpectedCreatedPObject: []string{superDefaultNSName + "/cert-1"},
+		},
+		"new certificate but already exists": {
+			ExistingObjectInSuper: []runtime.Object{
+				superCertificate("cert-2", superDefaultNSName, "12345", defaultClusterKey),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				tenantCertificate("cert-2", "default", "12345"),
+			},
+			ExpectedNoOperation: true,
+		},
+		"new certificate but existing different uid one": {
+			ExistingObjectInSuper: []runtime.Object{
+				superCertificate("cert-3", superDefaultNSName, "123456", defaultClusterKey),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				tenantCertificate("cert-3", "default", "12345"),
+			},
+			ExpectedError: "delegated UID is different",
+		},
+		"certificate copied from super": {
+			ExistingObjectInTenant: []runtime.Object{
+				func() runtime.Object {
+					obj := tenantCertificate("cert-4", "default", "12345")
+					obj.SetAnnotations(map[string]string{constants.LabelSuperUID: "54321"})
+					return obj
+				}(),
+			},
+			ExpectedNoOperation: true,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			actions, reconcileErr, err := runDownwardSync(testTenant, tc.ExistingObjectInSuper, tc.ExistingObjectInTenant, tc.ExistingObjectInTenant[0])
+			if err != nil {
+				t.Errorf("%s: error running downward sync: %v", k, err)
+				return
+			}
+
+			if tc.ExpectedNoOperation {
+				if len(actions) != 0 {
+					t.Errorf("%s: Expect no operation, got %v", k, actions)
+				}
+				return
+			}
+
+			if reconcileErr != nil {
+				if tc.ExpectedError == "" {
+					t.Errorf("expected no error, but got \"%v\"", reconcileErr)
+				} else if !strings.Contains(reconcileErr.Error(), tc.ExpectedError) {
+					t.Errorf("expected error msg \"%s\", but got \"%v\"", tc.ExpectedError, reconcileErr)
+				}
+				return
+			} else if tc.ExpectedError != "" {
+				t.Errorf("expected error msg \"%s\", but got empty", tc.ExpectedError)
+			}
+
+			if len(tc.ExpectedCreatedPObject) != len(actions) {
+				t.Errorf("%s: Expected to create certificate %#v. Actual actions were: %#v", k, tc.ExpectedCreatedPObject, actions)
+				return
+			}
+			for i, expectedName := range tc.ExpectedCreatedPObject {
+				action := actions[i]
+				if !action.Matches("create", "certificates") {
+					t.Errorf("%s: Unexpected action %s", k, action)
+					continue
+				}
+				created := action.(core.CreateAction).GetObject().(*unstructured.Unstructured)
+				fullName := created.GetNamespace() + "/" + created.GetName()
+				if fullName != expectedName {
+					t.Errorf("%s: Expected %s to be created, got %s", k, expectedName, fullName)
+				}
+				if ns, _, _ := unstructured.NestedString(created.Object, "spec", "issuerRef", "namespace"); ns != superDefaultNSName {
+					t.Errorf("%s: Expected issuer namespace %s, got %s", k, superDefaultNSName, ns)
+				}
+				if ref := created.GetAnnotations()["cert-manager.io/inject-ca-from"]; ref != superDefaultNSName+"/ca" {
+					t.Errorf("%s: Expected inject-ca-from %s/ca, got %s", k, superDefaultNSName, ref)
+				}
+			}
+		})
+	}
+}
+
+func TestDWCertificateDeletion(t *testing.T) {
+	testTenant := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Spec: v1alpha1.VirtualClusterSpec{},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+
+	defaultClusterKey := conversion.ToClusterKey(testTenant)
+	superDefaultNSName := conversion.ToSuperClusterNamespace(defaultClusterKey, "default")
+
+	testcases := map[string]struct {
+		ExistingObjectInSuper  []runtime.Object
+		EnqueueObject          *unstructured.Unstructured
+		ExpectedDeletedPObject []string
+		ExpectedNoOperation    bool
+		ExpectedError          string
+	}{
+		"delete certificate": {
+			ExistingObjectInSuper: []runtime.Object{
+				superCertificate("cert-1", superDefaultNSName, "12345", defaultClusterKey),
+			},
+			EnqueueObject:          tenantCertificate("cert-1", "default", "12345"),
+			ExpectedDeletedPObject: []string{superDefaultNSName + "/cert-1"},
+		},
+		"delete certificate but already gone": {
+			EnqueueObject:       tenantCertificate("cert-2", "default", "12345"),
+			ExpectedNoOperation: true,
+		},
+		"delete certificate but existing different uid one": {
+			ExistingObjectInSuper: []runtime.Object{
+				superCertificate("cert-3", superDefaultNSName, "123456", defaultClusterKey),
+			},
+			EnqueueObject: tenantCertificate("cert-3", "default", "12345"),
+			ExpectedError: "delegated UID is different",
+		},
+		"super certificate not created by syncer": {
+			ExistingObjectInSuper: []runtime.Object{
+				func() runtime.Object {
+					obj := superCertificate("cert-4", superDefaultNSName, "12345", defaultClusterKey)
+					obj.SetAnnotations(nil)
+					return obj
+				}(),
+			},
+			EnqueueObject:       tenantCertificate("cert-4", "default", "12345"),
+			ExpectedNoOperation: true,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			actions, reconcileErr, err := runDownwardSync(testTenant, tc.ExistingObjectInSuper, nil, tc.EnqueueObject)
+			if err != nil {
+				t.Errorf("%s: error running downward sync: %v", k, err)
+				return
+			}
+
+			if tc.ExpectedNoOperation {
+				if len(actions) != 0 {
+					t.Errorf("%s: Expect no operation, got %v", k, actions)
+				}
+				return
+			}
+
+			if reconcileErr != nil {
+				if tc.ExpectedError == "" {
+					t.Errorf("expected no error, but got \"%v\"", reconcileErr)
+				} else if !strings.Contains(reconcileErr.Error(), tc.ExpectedError) {
+					t.Errorf("expected error msg \"%s\", but got \"%v\"", tc.ExpectedError, reconcileErr)
+				}
+				return
+			} else if tc.ExpectedError != "" {
+				t.Errorf("expected error msg \"%s\", but got empty", tc.ExpectedError)
+			}
+
+			if len(tc.ExpectedDeletedPObject) != len(actions) {
+				t.Errorf("%s: Expected to delete certificate %#v. Actual actions were: %#v", k, tc.ExpectedDeletedPObject, actions)
+				return
+			}
+			for i, expectedName := range tc.ExpectedDeletedPObject {
+				action := actions[i]
+				if !action.Matches("delete", "certificates") {
+					t.Errorf("%s: Unexpected action %s", k, action)
+					continue
+				}
+				deleted := action.(core.DeleteAction)
+				fullName := deleted.GetNamespace() + "/" + deleted.GetName()
+				if fullName != expectedName {
+					t.Errorf("%s: Expected %s to be deleted, got %s", k, expectedName, fullName)
+				}
+			}
+		})
+	}
+}
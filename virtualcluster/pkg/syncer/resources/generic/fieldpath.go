@@ -0,0 +1,120 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generic
+
+import (
+	"strings"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// splitPath splits a field path into segments by ".". A "\." in the path is kept as a dot of the segment.
+func splitPath(path string) []string {
+	var segments []string
+	var current strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path) && path[i+1] == '.':
+			current.WriteByte('.')
+			i++
+		case path[i] == '.':
+			segments = append(segments, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(path[i])
+		}
+	}
+	return append(segments, current.String())
+}
+
+// rewriteStringFields replaces every non-empty string field addressed by the path with the result of fn.
+// A segment ending with "[]" addresses every element of a list.
+func rewriteStringFields(obj map[string]interface{}, path string, fn func(string) string) {
+	rewriteFields(obj, splitPath(path), fn)
+}
+
+func rewriteFields(obj map[string]interface{}, segments []string, fn func(string) string) {
+	if len(segments) == 0 {
+		return
+	}
+	key := strings.TrimSuffix(segments[0], "[]")
+	value, ok := obj[key]
+	if !ok {
+		return
+	}
+
+	if key != segments[0] {
+		items, ok := value.([]interface{})
+		if !ok {
+			return
+		}
+		for i, item := range items {
+			if len(segments) == 1 {
+				if s, ok := item.(string); ok && s != "" {
+					items[i] = fn(s)
+				}
+				continue
+			}
+			if m, ok := item.(map[string]interface{}); ok {
+				rewriteFields(m, segments[1:], fn)
+			}
+		}
+		return
+	}
+
+	if len(segments) == 1 {
+		if s, ok := value.(string); ok && s != "" {
+			obj[key] = fn(s)
+		}
+		return
+	}
+	if m, ok := value.(map[string]interface{}); ok {
+		rewriteFields(m, segments[1:], fn)
+	}
+}
+
+// namespacedNameFunc applies the namespace mapping to a <namespace>/<name> reference.
+func namespacedNameFunc(namespaceFn func(string) string) func(string) string {
+	return func(ref string) string {
+		parts := strings.SplitN(ref, "/", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return ref
+		}
+		return namespaceFn(parts[0]) + "/" + parts[1]
+	}
+}
+
+// copyField sets the field addressed by the path in dst to the one in src, or removes it if src does not have it.
+// It returns true if dst is changed.
+func copyField(dst, src map[string]interface{}, path string) bool {
+	segments := splitPath(path)
+	srcValue, srcFound, _ := unstructured.NestedFieldNoCopy(src, segments...)
+	dstValue, dstFound, _ := unstructured.NestedFieldNoCopy(dst, segments...)
+	if !srcFound {
+		if dstFound {
+			unstructured.RemoveNestedField(dst, segments...)
+			return true
+		}
+		return false
+	}
+	if dstFound && apiequality.Semantic.DeepEqual(srcValue, dstValue) {
+		return false
+	}
+	return unstructured.SetNestedField(dst, runtime.DeepCopyJSONValue(srcValue), segments...) == nil
+}
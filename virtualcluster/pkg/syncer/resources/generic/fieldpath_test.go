@@ -0,0 +1,126 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generic
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitPath(t *testing.T) {
+	for path, expected := range map[string][]string{
+		"spec.issuerRef.namespace":                             {"spec", "issuerRef", "namespace"},
+		`metadata.annotations.cert-manager\.io/inject-ca-from`: {"metadata", "annotations", "cert-manager.io/inject-ca-from"},
+		"spec.sources[].namespace":                             {"spec", "sources[]", "namespace"},
+	} {
+		if got := splitPath(path); !reflect.DeepEqual(got, expected) {
+			t.Errorf("%s: expected %v, got %v", path, expected, got)
+		}
+	}
+}
+
+func TestRewriteStringFields(t *testing.T) {
+	toSuper := func(ns string) string { return "super-" + ns }
+
+	for name, tc := range map[string]struct {
+		obj      map[string]interface{}
+		path     string
+		fn       func(string) string
+		expected map[string]interface{}
+	}{
+		"nested field": {
+			obj:      map[string]interface{}{"spec": map[string]interface{}{"namespace": "default"}},
+			path:     "spec.namespace",
+			fn:       toSuper,
+			expected: map[string]interface{}{"spec": map[string]interface{}{"namespace": "super-default"}},
+		},
+		"empty field": {
+			obj:      map[string]interface{}{"spec": map[string]interface{}{"namespace": ""}},
+			path:     "spec.namespace",
+			fn:       toSuper,
+			expected: map[string]interface{}{"spec": map[string]interface{}{"namespace": ""}},
+		},
+		"missing field": {
+			obj:      map[string]interface{}{"spec": map[string]interface{}{}},
+			path:     "spec.namespace",
+			fn:       toSuper,
+			expected: map[string]interface{}{"spec": map[string]interface{}{}},
+		},
+		"list of objects": {
+			obj: map[string]interface{}{"spec": map[string]interface{}{"sources": []interface{}{
+				map[string]interface{}{"namespace": "a"},
+				map[string]interface{}{"name": "b"},
+			}}},
+			path: "spec.sources[].namespace",
+			fn:   toSuper,
+			expected: map[string]interface{}{"spec": map[string]interface{}{"sources": []interface{}{
+				map[string]interface{}{"namespace": "super-a"},
+				map[string]interface{}{"name": "b"},
+			}}},
+		},
+		"list of strings": {
+			obj:      map[string]interface{}{"spec": map[string]interface{}{"namespaces": []interface{}{"a", "b"}}},
+			path:     "spec.namespaces[]",
+			fn:       toSuper,
+			expected: map[string]interface{}{"spec": map[string]interface{}{"namespaces": []interface{}{"super-a", "super-b"}}},
+		},
+		"namespaced name": {
+			obj: map[string]interface{}{"metadata": map[string]interface{}{"annotations": map[string]interface{}{
+				"cert-manager.io/inject-ca-from": "default/ca",
+			}}},
+			path: `metadata.annotations.cert-manager\.io/inject-ca-from`,
+			fn:   namespacedNameFunc(toSuper),
+			expected: map[string]interface{}{"metadata": map[string]interface{}{"annotations": map[string]interface{}{
+				"cert-manager.io/inject-ca-from": "super-default/ca",
+			}}},
+		},
+		"name without namespace": {
+			obj:      map[string]interface{}{"spec": map[string]interface{}{"ref": "ca"}},
+			path:     "spec.ref",
+			fn:       namespacedNameFunc(toSuper),
+			expected: map[string]interface{}{"spec": map[string]interface{}{"ref": "ca"}},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rewriteStringFields(tc.obj, tc.path, tc.fn)
+			if !reflect.DeepEqual(tc.obj, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, tc.obj)
+			}
+		})
+	}
+}
+
+func TestCopyField(t *testing.T) {
+	src := map[string]interface{}{"status": map[string]interface{}{"ready": true}}
+
+	dst := map[string]interface{}{}
+	if !copyField(dst, src, "status") {
+		t.Errorf("expect status to be copied")
+	}
+	if !reflect.DeepEqual(dst, src) {
+		t.Errorf("expected %v, got %v", src, dst)
+	}
+	if copyField(dst, src, "status") {
+		t.Errorf("expect no change when status is equal")
+	}
+	if !copyField(dst, map[string]interface{}{}, "status") {
+		t.Errorf("expect status to be removed")
+	}
+	if _, found := dst["status"]; found {
+		t.Errorf("expect status to be removed, got %v", dst)
+	}
+}
@@ -0,0 +1,258 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generic
+
+import (
+	"context"
+	"fmt"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+func (c *controller) StartUWS(stopCh <-chan struct{}) error {
+	if !cache.WaitForCacheSync(stopCh, c.synced, c.nsSynced) {
+		return fmt.Errorf("failed to wait for caches to sync %s", c.resource.Kind)
+	}
+	return c.UpwardController.Start(stopCh)
+}
+
+// BackPopulate copies the status of the super control plane objects synced from tenants back to the tenants,
+// and copies the super control plane objects to the tenants if the resource is synced upward.
+func (c *controller) BackPopulate(key string) error {
+	pNamespace, pName, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return fmt.Errorf("invalid resource key %v: %v", key, err)
+	}
+
+	obj, err := c.lister.ByNamespace(pNamespace).Get(pName)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		if c.syncUpward() {
+			return c.removeVirtualCopy(pNamespace, pName)
+		}
+		return nil
+	}
+	pObj := obj.(*unstructured.Unstructured)
+
+	if isDownwardSynced(pObj) {
+		if !c.syncDownward() {
+			return nil
+		}
+		return c.backPopulateStatus(pObj)
+	}
+	if !c.syncUpward() {
+		return nil
+	}
+	return c.syncUpwardObject(pObj)
+}
+
+// backPopulateStatus copies the configured status fields of a super control plane object to its tenant object.
+func (c *controller) backPopulateStatus(pObj *unstructured.Unstructured) error {
+	clusterName, vNamespace := conversion.GetVirtualOwner(pObj)
+	if clusterName == "" || vNamespace == "" {
+		return nil
+	}
+	vObj := c.newObject()
+	if err := c.MultiClusterController.Get(clusterName, vNamespace, pObj.GetName(), vObj); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("could not find %s %s/%s in controller cache: %v", c.resource.Kind, vNamespace, pObj.GetName(), err)
+	}
+	if pObj.GetAnnotations()[constants.LabelUID] != string(vObj.GetUID()) {
+		return fmt.Errorf("%s %s/%s delegated UID is different from tenant object", c.resource.Kind, pObj.GetNamespace(), pObj.GetName())
+	}
+
+	updated := vObj.DeepCopy()
+	changed := false
+	for _, path := range c.resource.StatusPaths {
+		if copyField(updated.Object, pObj.Object, path) {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	tenantClient, err := c.tenantClient(clusterName)
+	if err != nil {
+		return err
+	}
+	return c.updateVirtualObject(tenantClient, updated)
+}
+
+// updateVirtualObject updates a tenant object through the status subresource, or the object itself if the
+// resource does not have a status subresource.
+func (c *controller) updateVirtualObject(tenantClient client.Client, vObj *unstructured.Unstructured) error {
+	err := tenantClient.Status().Update(context.TODO(), vObj)
+	if apierrors.IsNotFound(err) {
+		err = tenantClient.Update(context.TODO(), vObj)
+	}
+	return err
+}
+
+// syncUpwardObject creates or updates the tenant copy of a super control plane object.
+func (c *controller) syncUpwardObject(pObj *unstructured.Unstructured) error {
+	clusterName, vNamespace, err := conversion.GetVirtualNamespace(c.nsLister, pObj.GetNamespace())
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if clusterName == "" || vNamespace == "" {
+		// the object is not in a tenant namespace.
+		return nil
+	}
+
+	expected := c.buildVirtualObject(vNamespace, pObj)
+	vObj := c.newObject()
+	if err := c.MultiClusterController.Get(clusterName, vNamespace, pObj.GetName(), vObj); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		tenantClient, err := c.tenantClient(clusterName)
+		if err != nil {
+			return err
+		}
+		if err := tenantClient.Create(context.TODO(), expected); err != nil {
+			if apierrors.IsAlreadyExists(err) {
+				return nil
+			}
+			return err
+		}
+		if _, found := expected.Object["status"]; found {
+			// status is dropped on creation if the resource has the status subresource.
+			return c.updateVirtualObject(tenantClient, expected)
+		}
+		return nil
+	}
+
+	if vObj.GetAnnotations()[constants.LabelSuperUID] != string(pObj.GetUID()) {
+		klog.Warningf("%s %s/%s of cluster %s is not synced from the super control plane, skip", c.resource.Kind, vNamespace, pObj.GetName(), clusterName)
+		return nil
+	}
+
+	updated := vObj.DeepCopy()
+	changed := false
+	if !apiequality.Semantic.DeepEqual(vObj.GetLabels(), expected.GetLabels()) {
+		updated.SetLabels(expected.GetLabels())
+		changed = true
+	}
+	for k := range expected.Object {
+		if k != "metadata" && copyField(updated.Object, expected.Object, k) {
+			changed = true
+		}
+	}
+	for k := range vObj.Object {
+		if _, found := expected.Object[k]; !found && !metadataFields[k] {
+			delete(updated.Object, k)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	tenantClient, err := c.tenantClient(clusterName)
+	if err != nil {
+		return err
+	}
+	if err := tenantClient.Update(context.TODO(), updated); err != nil {
+		return err
+	}
+	if _, found := expected.Object["status"]; found {
+		return c.updateVirtualObject(tenantClient, updated)
+	}
+	return nil
+}
+
+// buildVirtualObject builds the tenant copy of a super control plane object.
+func (c *controller) buildVirtualObject(vNamespace string, pObj *unstructured.Unstructured) *unstructured.Unstructured {
+	vObj := pObj.DeepCopy()
+	conversion.ResetMetadata(vObj)
+	vObj.SetNamespace(vNamespace)
+	anno := vObj.GetAnnotations()
+	if anno == nil {
+		anno = make(map[string]string)
+	}
+	anno[constants.LabelSuperUID] = string(pObj.GetUID())
+	vObj.SetAnnotations(anno)
+
+	toTenant := func(ns string) string {
+		_, namespace, err := conversion.GetVirtualNamespace(c.nsLister, ns)
+		if err != nil || namespace == "" {
+			return ns
+		}
+		return namespace
+	}
+	for _, path := range c.resource.NamespaceRefPaths {
+		rewriteStringFields(vObj.Object, path, toTenant)
+	}
+	for _, path := range c.resource.NamespacedNameRefPaths {
+		rewriteStringFields(vObj.Object, path, namespacedNameFunc(toTenant))
+	}
+	return vObj
+}
+
+// removeVirtualCopy deletes the tenant copy of a deleted super control plane object.
+func (c *controller) removeVirtualCopy(pNamespace, pName string) error {
+	clusterName, vNamespace, err := conversion.GetVirtualNamespace(c.nsLister, pNamespace)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if clusterName == "" || vNamespace == "" {
+		return nil
+	}
+	vObj := c.newObject()
+	if err := c.MultiClusterController.Get(clusterName, vNamespace, pName, vObj); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if !isUpwardSynced(vObj) {
+		return nil
+	}
+	return c.deleteVirtualObject(clusterName, vObj)
+}
+
+func (c *controller) deleteVirtualObject(clusterName string, vObj *unstructured.Unstructured) error {
+	tenantClient, err := c.tenantClient(clusterName)
+	if err != nil {
+		return err
+	}
+	uid := vObj.GetUID()
+	err = tenantClient.Delete(context.TODO(), vObj, client.Preconditions{UID: &uid}, client.PropagationPolicy(constants.DefaultDeletionPolicy))
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
@@ -32,6 +32,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/syncstatus"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 )
 
@@ -60,6 +61,7 @@ func (c *controller) PatrollerDo() {
 	numSpecMissMatchedIngresses = 0
 	numStatusMissMatchedIngresses = 0
 	numUWMetaMissMatchedIngresses = 0
+	syncstatus.DefaultRecorder.StartScan("Ingress")
 
 	for _, clusterName := range clusterNames {
 		wg.Add(1)
@@ -106,6 +108,7 @@ func (c *controller) PatrollerDo() {
 	metrics.CheckerMissMatchStats.WithLabelValues("SpecMissMatchedIngresses").Set(float64(numSpecMissMatchedIngresses))
 	metrics.CheckerMissMatchStats.WithLabelValues("StatusMissMatchedIngresses").Set(float64(numStatusMissMatchedIngresses))
 	metrics.CheckerMissMatchStats.WithLabelValues("UWMetaMissMatchedIngresses").Set(float64(numUWMetaMissMatchedIngresses))
+	syncstatus.DefaultRecorder.FinishScan("Ingress")
 }
 
 func (c *controller) checkIngressesOfTenantCluster(clusterName string) {
@@ -146,6 +149,7 @@ func (c *controller) checkIngressesOfTenantCluster(clusterName string) {
 		updatedIngress := conversion.Equality(c.Config, vc).CheckIngressEquality(pIngress, &ingList.Items[i])
 		if updatedIngress != nil {
 			atomic.AddUint64(&numSpecMissMatchedIngresses, 1)
+			syncstatus.DefaultRecorder.IncMismatch("Ingress", clusterName, vIngress.Namespace, "SpecMissMatchedIngresses")
 			klog.Warningf("spec of ingress %v/%v diff in super&tenant control plane", vIngress.Namespace, vIngress.Name)
 			if err := c.MultiClusterController.RequeueObject(clusterName, &ingList.Items[i]); err != nil {
 				klog.Errorf("error requeue vingress %v/%v in cluster %s: %v", vIngress.Namespace, vIngress.Name, clusterName, err)
@@ -158,12 +162,14 @@ func (c *controller) checkIngressesOfTenantCluster(clusterName string) {
 		updatedMeta := conversion.Equality(c.Config, vc).CheckUWObjectMetaEquality(&pIngress.ObjectMeta, &ingList.Items[i].ObjectMeta)
 		if updatedMeta != nil {
 			atomic.AddUint64(&numUWMetaMissMatchedIngresses, 1)
+			syncstatus.DefaultRecorder.IncMismatch("Ingress", clusterName, vIngress.Namespace, "UWMetaMissMatchedIngresses")
 			enqueue = true
 			klog.Warningf("UWObjectMeta of vIngress %v/%v diff in super&tenant control plane", vIngress.Namespace, vIngress.Name)
 		}
 		if !equality.Semantic.DeepEqual(vIngress.Status, pIngress.Status) {
 			enqueue = true
 			atomic.AddUint64(&numStatusMissMatchedIngresses, 1)
+			syncstatus.DefaultRecorder.IncMismatch("Ingress", clusterName, vIngress.Namespace, "StatusMissMatchedIngresses")
 			klog.Warningf("Status of vIngress %v/%v diff in super&tenant control plane", vIngress.Namespace, vIngress.Name)
 		}
 		if enqueue {
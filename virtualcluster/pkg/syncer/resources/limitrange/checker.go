@@ -0,0 +1,134 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package limitrange
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol/differ"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/syncstatus"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
+)
+
+var numMissMatchedLimitRanges uint64
+
+func (c *controller) StartPatrol(stopCh <-chan struct{}) error {
+	if !cache.WaitForCacheSync(stopCh, c.limitRangeSynced) {
+		return fmt.Errorf("failed to wait for caches to sync before starting LimitRange checker")
+	}
+	c.Patroller.Start(stopCh)
+	return nil
+}
+
+// PatrollerDo check if limitranges keep consistency between super
+// control plane and tenant control planes.
+func (c *controller) PatrollerDo() {
+	clusterNames := c.MultiClusterController.GetClusterNames()
+	if len(clusterNames) == 0 {
+		klog.V(5).Infof("super cluster has no tenant control planes, giving up periodic checker: %s", "limitrange")
+		return
+	}
+
+	numMissMatchedLimitRanges = 0
+	syncstatus.DefaultRecorder.StartScan("LimitRange")
+
+	pList, err := c.limitRangeLister.List(util.GetSuperClusterListerLabelsSelector())
+	if err != nil {
+		klog.Errorf("error listing limitrange from super control plane informer cache: %v", err)
+		return
+	}
+	pSet := differ.NewDiffSet()
+	for _, p := range pList {
+		pSet.Insert(differ.ClusterObject{Object: p, Key: differ.DefaultClusterObjectKey(p, "")})
+	}
+
+	knownClusterSet := sets.NewString(clusterNames...)
+	vSet := differ.NewDiffSet()
+	for _, cluster := range clusterNames {
+		vList := &corev1.LimitRangeList{}
+		if err := c.MultiClusterController.List(cluster, vList); err != nil {
+			klog.Errorf("error listing limitrange from cluster %s informer cache: %v", cluster, err)
+			knownClusterSet.Delete(cluster)
+			continue
+		}
+
+		for i := range vList.Items {
+			vSet.Insert(differ.ClusterObject{
+				Object:       &vList.Items[i],
+				OwnerCluster: cluster,
+				Key:          differ.DefaultClusterObjectKey(&vList.Items[i], cluster),
+			})
+		}
+	}
+
+	d := differ.HandlerFuncs{}
+	d.AddFunc = func(vObj differ.ClusterObject) {
+		if err := c.MultiClusterController.RequeueObject(vObj.OwnerCluster, vObj.Object); err != nil {
+			klog.Errorf("error requeue vLimitRange %s in cluster %s: %v", vObj.Key, vObj.GetOwnerCluster(), err)
+		} else {
+			metrics.CheckerRemedyStats.WithLabelValues("RequeuedTenantLimitRanges").Inc()
+		}
+	}
+	d.UpdateFunc = func(vObj, pObj differ.ClusterObject) {
+		v := vObj.Object.(*corev1.LimitRange)
+		p := pObj.Object.(*corev1.LimitRange)
+
+		if p.Annotations[constants.LabelUID] != string(v.UID) {
+			klog.Warningf("Found pLimitRange %s delegated UID is different from tenant object", pObj.Key)
+			d.OnDelete(pObj)
+			return
+		}
+		vc, err := util.GetVirtualClusterObject(c.MultiClusterController, vObj.GetOwnerCluster())
+		if err != nil {
+			klog.Errorf("fail to get cluster spec : %s", vObj.GetOwnerCluster())
+			return
+		}
+		if conversion.Equality(c.Config, vc).CheckLimitRangeEquality(p, v) != nil {
+			atomic.AddUint64(&numMissMatchedLimitRanges, 1)
+			syncstatus.DefaultRecorder.IncMismatch("LimitRange", vObj.GetOwnerCluster(), vObj.GetNamespace(), "MissMatchedLimitRanges")
+			klog.Warningf("spec of limitrange %s diff in super&tenant control plane", pObj.Key)
+		}
+	}
+	d.DeleteFunc = func(pObj differ.ClusterObject) {
+		deleteOptions := &metav1.DeleteOptions{}
+		deleteOptions.Preconditions = metav1.NewUIDPreconditions(string(pObj.GetUID()))
+		if err = c.limitRangeClient.LimitRanges(pObj.GetNamespace()).Delete(context.TODO(), pObj.GetName(), *deleteOptions); err != nil {
+			klog.Errorf("error deleting pLimitRange %s in super control plane: %v", pObj.Key, err)
+		} else {
+			metrics.CheckerRemedyStats.WithLabelValues("DeletedOrphanSuperControlPlaneLimitRanges").Inc()
+		}
+	}
+
+	vSet.Difference(pSet, differ.FilteringHandler{
+		Handler:    d,
+		FilterFunc: differ.DefaultDifferFilter(knownClusterSet),
+	})
+
+	metrics.CheckerMissMatchStats.WithLabelValues("MissMatchedLimitRanges").Set(float64(numMissMatchedLimitRanges))
+	syncstatus.DefaultRecorder.FinishScan("LimitRange")
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package limitrange
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
+)
+
+func (c *controller) StartDWS(stopCh <-chan struct{}) error {
+	if !cache.WaitForCacheSync(stopCh, c.limitRangeSynced) {
+		return fmt.Errorf("failed to wait for caches to sync")
+	}
+	return c.MultiClusterController.Start(stopCh)
+}
+
+// The reconcile logic for tenant control plane limitrange informer
+func (c *controller) Reconcile(request reconciler.Request) (reconciler.Result, error) {
+	klog.V(4).Infof("reconcile limitrange %s/%s event for cluster %s", request.Namespace, request.Name, request.ClusterName)
+
+	targetNamespace := conversion.ToSuperClusterNamespace(request.ClusterName, request.Namespace)
+	pLimitRange, err := c.limitRangeLister.LimitRanges(targetNamespace).Get(request.Name)
+	pExists := true
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return reconciler.Result{Requeue: true}, err
+		}
+		pExists = false
+	}
+	vExists := true
+	vLimitRange := &corev1.LimitRange{}
+	if err := c.MultiClusterController.Get(request.ClusterName, request.Namespace, request.Name, vLimitRange); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return reconciler.Result{Requeue: true}, err
+		}
+		vExists = false
+	}
+
+	switch {
+	case vExists && !pExists:
+		err := c.reconcileLimitRangeCreate(request.ClusterName, targetNamespace, request.UID, vLimitRange)
+		if err != nil {
+			klog.Errorf("failed reconcile limitrange %s/%s CREATE of cluster %s %v", request.Namespace, request.Name, request.ClusterName, err)
+			return reconciler.Result{Requeue: true}, err
+		}
+	case !vExists && pExists:
+		err := c.reconcileLimitRangeRemove(request.ClusterName, targetNamespace, request.UID, request.Name, pLimitRange)
+		if err != nil {
+			klog.Errorf("failed reconcile limitrange %s/%s DELETE of cluster %s %v", request.Namespace, request.Name, request.ClusterName, err)
+			return reconciler.Result{Requeue: true}, err
+		}
+	case vExists && pExists:
+		err := c.reconcileLimitRangeUpdate(request.ClusterName, targetNamespace, request.UID, pLimitRange, vLimitRange)
+		if err != nil {
+			klog.Errorf("failed reconcile limitrange %s/%s UPDATE of cluster %s %v", request.Namespace, request.Name, request.ClusterName, err)
+			return reconciler.Result{Requeue: true}, err
+		}
+	default:
+		// object is gone.
+	}
+	return reconciler.Result{}, nil
+}
+
+func (c *controller) reconcileLimitRangeCreate(clusterName, targetNamespace, requestUID string, limitRange *corev1.LimitRange) error {
+	newObj, err := c.Conversion().BuildSuperClusterObject(clusterName, limitRange)
+	if err != nil {
+		return err
+	}
+
+	pLimitRange, err := c.limitRangeClient.LimitRanges(targetNamespace).Create(context.TODO(), newObj.(*corev1.LimitRange), metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		if pLimitRange.Annotations[constants.LabelUID] == requestUID {
+			klog.Infof("limitrange %s/%s of cluster %s already exist in super control plane", targetNamespace, limitRange.Name, clusterName)
+			return nil
+		}
+		return fmt.Errorf("pLimitRange %s/%s exists but its delegated object UID is different", targetNamespace, pLimitRange.Name)
+	}
+	return err
+}
+
+func (c *controller) reconcileLimitRangeUpdate(clusterName, targetNamespace, requestUID string, pLimitRange, vLimitRange *corev1.LimitRange) error {
+	if pLimitRange.Annotations[constants.LabelUID] != requestUID {
+		return fmt.Errorf("pLimitRange %s/%s delegated UID is different from updated object", targetNamespace, pLimitRange.Name)
+	}
+	vc, err := util.GetVirtualClusterObject(c.MultiClusterController, clusterName)
+	if err != nil {
+		return err
+	}
+	updatedLimitRange := conversion.Equality(c.Config, vc).CheckLimitRangeEquality(pLimitRange, vLimitRange)
+	if updatedLimitRange != nil {
+		_, err = c.limitRangeClient.LimitRanges(targetNamespace).Update(context.TODO(), updatedLimitRange, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *controller) reconcileLimitRangeRemove(clusterName, targetNamespace, requestUID, name string, pLimitRange *corev1.LimitRange) error {
+	if pLimitRange.Annotations[constants.LabelUID] != requestUID {
+		return fmt.Errorf("to be deleted pLimitRange %s/%s delegated UID is different from deleted object", targetNamespace, pLimitRange.Name)
+	}
+	opts := &metav1.DeleteOptions{
+		PropagationPolicy: &constants.DefaultDeletionPolicy,
+	}
+	err := c.limitRangeClient.LimitRanges(targetNamespace).Delete(context.TODO(), name, *opts)
+	if apierrors.IsNotFound(err) {
+		klog.Warningf("limitrange %s/%s of cluster %s not found in super control plane", targetNamespace, name, clusterName)
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,327 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package limitrange
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	core "k8s.io/client-go/testing"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	util "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/test"
+)
+
+func tenantLimitRange(name, namespace, uid string) *corev1.LimitRange {
+	return &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			UID:       types.UID(uid),
+		},
+	}
+}
+
+func superLimitRange(name, namespace, uid, clusterKey string) *corev1.LimitRange {
+	return &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Annotations: map[string]string{
+				constants.LabelUID:       uid,
+				constants.LabelCluster:   clusterKey,
+				constants.LabelNamespace: "default",
+			},
+		},
+	}
+}
+
+func applyMaxToLimitRange(limitRange *corev1.LimitRange, max corev1.ResourceList) *corev1.LimitRange {
+	limitRange.Spec.Limits = []corev1.LimitRangeItem{
+		{
+			Type: corev1.LimitTypeContainer,
+			Max:  max.DeepCopy(),
+		},
+	}
+	return limitRange
+}
+
+func TestDWLimitRangeCreation(t *testing.T) {
+	testTenant := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Spec: v1alpha1.VirtualClusterSpec{},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+
+	defaultClusterKey := conversion.ToClusterKey(testTenant)
+	superDefaultNSName := conversion.ToSuperClusterNamespace(defaultClusterKey, "default")
+
+	max := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}
+
+	testcases := map[string]struct {
+		ExistingObjectInSuper     []runtime.Object
+		ExistingObjectInTenant    []runtime.Object
+		ExpectedCreatedLimitRange []string
+		ExpectedError             string
+	}{
+		"new limitrange": {
+			ExistingObjectInSuper: []runtime.Object{},
+			ExistingObjectInTenant: []runtime.Object{
+				applyMaxToLimitRange(tenantLimitRange("limitrange-1", "default", "12345"), max),
+			},
+			ExpectedCreatedLimitRange: []string{superDefaultNSName + "/limitrange-1"},
+		},
+		"new limitrange but existing different uid one": {
+			ExistingObjectInSuper: []runtime.Object{
+				superLimitRange("limitrange-1", superDefaultNSName, "123456", defaultClusterKey),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				tenantLimitRange("limitrange-1", "default", "12345"),
+			},
+			ExpectedCreatedLimitRange: []string{},
+			ExpectedError:             "delegated UID is different",
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			actions, reconcileErr, err := util.RunDownwardSync(NewLimitRangeController, testTenant, tc.ExistingObjectInSuper, tc.ExistingObjectInTenant, tc.ExistingObjectInTenant[0], nil)
+			if err != nil {
+				t.Errorf("%s: error running downward sync: %v", k, err)
+				return
+			}
+
+			if reconcileErr != nil {
+				if tc.ExpectedError == "" {
+					t.Errorf("expected no error, but got \"%v\"", reconcileErr)
+				} else if !strings.Contains(reconcileErr.Error(), tc.ExpectedError) {
+					t.Errorf("expected error msg \"%s\", but got \"%v\"", tc.ExpectedError, reconcileErr)
+				}
+			} else {
+				if tc.ExpectedError != "" {
+					t.Errorf("expected error msg \"%s\", but got empty", tc.ExpectedError)
+				}
+			}
+
+			if len(tc.ExpectedCreatedLimitRange) != len(actions) {
+				t.Errorf("%s: Expected to create limitrange %#v. Actual actions were: %#v", k, tc.ExpectedCreatedLimitRange, actions)
+				return
+			}
+			for i, expectedName := range tc.ExpectedCreatedLimitRange {
+				action := actions[i]
+				if !action.Matches("create", "limitranges") {
+					t.Errorf("%s: Unexpected action %s", k, action)
+				}
+				created := action.(core.CreateAction).GetObject().(*corev1.LimitRange)
+				fullName := created.Namespace + "/" + created.Name
+				if fullName != expectedName {
+					t.Errorf("%s: Expected %s to be created, got %s", k, expectedName, fullName)
+				}
+			}
+		})
+	}
+}
+
+func TestDWLimitRangeDeletion(t *testing.T) {
+	testTenant := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Spec: v1alpha1.VirtualClusterSpec{},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+
+	defaultClusterKey := conversion.ToClusterKey(testTenant)
+	superDefaultNSName := conversion.ToSuperClusterNamespace(defaultClusterKey, "default")
+
+	testcases := map[string]struct {
+		ExistingObjectInSuper     []runtime.Object
+		EnqueueObject             *corev1.LimitRange
+		ExpectedDeletedLimitRange []string
+		ExpectedError             string
+	}{
+		"delete limitrange": {
+			ExistingObjectInSuper: []runtime.Object{
+				superLimitRange("limitrange-1", superDefaultNSName, "12345", defaultClusterKey),
+			},
+			EnqueueObject:             tenantLimitRange("limitrange-1", "default", "12345"),
+			ExpectedDeletedLimitRange: []string{superDefaultNSName + "/limitrange-1"},
+		},
+		"delete limitrange but already gone": {
+			ExistingObjectInSuper:     []runtime.Object{},
+			EnqueueObject:             tenantLimitRange("limitrange-1", "default", "12345"),
+			ExpectedDeletedLimitRange: []string{},
+		},
+		"delete limitrange but existing different uid one": {
+			ExistingObjectInSuper: []runtime.Object{
+				superLimitRange("limitrange-1", superDefaultNSName, "123456", defaultClusterKey),
+			},
+			EnqueueObject:             tenantLimitRange("limitrange-1", "default", "12345"),
+			ExpectedDeletedLimitRange: []string{},
+			ExpectedError:             "delegated UID is different",
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			actions, reconcileErr, err := util.RunDownwardSync(NewLimitRangeController, testTenant, tc.ExistingObjectInSuper, nil, tc.EnqueueObject, nil)
+			if err != nil {
+				t.Errorf("%s: error running downward sync: %v", k, err)
+				return
+			}
+
+			if reconcileErr != nil {
+				if tc.ExpectedError == "" {
+					t.Errorf("expected no error, but got \"%v\"", reconcileErr)
+				} else if !strings.Contains(reconcileErr.Error(), tc.ExpectedError) {
+					t.Errorf("expected error msg \"%s\", but got \"%v\"", tc.ExpectedError, reconcileErr)
+				}
+			} else {
+				if tc.ExpectedError != "" {
+					t.Errorf("expected error msg \"%s\", but got empty", tc.ExpectedError)
+				}
+			}
+
+			if len(tc.ExpectedDeletedLimitRange) != len(actions) {
+				t.Errorf("%s: Expected to delete limitrange %#v. Actual actions were: %#v", k, tc.ExpectedDeletedLimitRange, actions)
+				return
+			}
+			for i, expectedName := range tc.ExpectedDeletedLimitRange {
+				action := actions[i]
+				if !action.Matches("delete", "limitranges") {
+					t.Errorf("%s: Unexpected action %s", k, action)
+				}
+				fullName := action.(core.DeleteAction).GetNamespace() + "/" + action.(core.DeleteAction).GetName()
+				if fullName != expectedName {
+					t.Errorf("%s: Expected %s to be deleted, got %s", k, expectedName, fullName)
+				}
+			}
+		})
+	}
+}
+
+func TestDWLimitRangeUpdate(t *testing.T) {
+	testTenant := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Spec: v1alpha1.VirtualClusterSpec{},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+
+	defaultClusterKey := conversion.ToClusterKey(testTenant)
+	superDefaultNSName := conversion.ToSuperClusterNamespace(defaultClusterKey, "default")
+
+	max1 := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}
+	max2 := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("3")}
+
+	testcases := map[string]struct {
+		ExistingObjectInSuper     []runtime.Object
+		ExistingObjectInTenant    []runtime.Object
+		ExpectedUpdatedLimitRange []runtime.Object
+		ExpectedError             string
+	}{
+		"no diff": {
+			ExistingObjectInSuper: []runtime.Object{
+				applyMaxToLimitRange(superLimitRange("limitrange-1", superDefaultNSName, "12345", defaultClusterKey), max1),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				applyMaxToLimitRange(tenantLimitRange("limitrange-1", "default", "12345"), max1),
+			},
+			ExpectedUpdatedLimitRange: []runtime.Object{},
+		},
+		"diff in max limits": {
+			ExistingObjectInSuper: []runtime.Object{
+				applyMaxToLimitRange(superLimitRange("limitrange-1", superDefaultNSName, "12345", defaultClusterKey), max1),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				applyMaxToLimitRange(tenantLimitRange("limitrange-1", "default", "12345"), max2),
+			},
+			ExpectedUpdatedLimitRange: []runtime.Object{
+				applyMaxToLimitRange(superLimitRange("limitrange-1", superDefaultNSName, "12345", defaultClusterKey), max2),
+			},
+		},
+		"diff exists but uid is wrong": {
+			ExistingObjectInSuper: []runtime.Object{
+				applyMaxToLimitRange(superLimitRange("limitrange-1", superDefaultNSName, "12345", defaultClusterKey), max1),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				applyMaxToLimitRange(tenantLimitRange("limitrange-1", "default", "123456"), max2),
+			},
+			ExpectedUpdatedLimitRange: []runtime.Object{},
+			ExpectedError:             "delegated UID is different",
+		},
+	}
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			actions, reconcileErr, err := util.RunDownwardSync(NewLimitRangeController, testTenant, tc.ExistingObjectInSuper, tc.ExistingObjectInTenant, tc.ExistingObjectInTenant[0], nil)
+			if err != nil {
+				t.Errorf("%s: error running downward sync: %v", k, err)
+				return
+			}
+
+			if reconcileErr != nil {
+				if tc.ExpectedError == "" {
+					t.Errorf("expected no error, but got \"%v\"", reconcileErr)
+				} else if !strings.Contains(reconcileErr.Error(), tc.ExpectedError) {
+					t.Errorf("expected error msg \"%s\", but got \"%v\"", tc.ExpectedError, reconcileErr)
+				}
+			} else {
+				if tc.ExpectedError != "" {
+					t.Errorf("expected error msg \"%s\", but got empty", tc.ExpectedError)
+				}
+			}
+
+			if len(tc.ExpectedUpdatedLimitRange) != len(actions) {
+				t.Errorf("%s: Expected to update limitrange %#v. Actual actions were: %#v", k, tc.ExpectedUpdatedLimitRange, actions)
+				return
+			}
+			for i, obj := range tc.ExpectedUpdatedLimitRange {
+				action := actions[i]
+				if !action.Matches("update", "limitranges") {
+					t.Errorf("%s: Unexpected action %s", k, action)
+				}
+				actionObj := action.(core.UpdateAction).GetObject()
+				if !equality.Semantic.DeepEqual(obj, actionObj) {
+					t.Errorf("%s: Expected updated limitrange is %v, got %v", k, obj, actionObj)
+				}
+			}
+		})
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol/differ"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/syncstatus"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 )
 
@@ -54,6 +55,7 @@ func (c *controller) PatrollerDo() {
 	}
 
 	numMissMatchedPVCs = 0
+	syncstatus.DefaultRecorder.StartScan("PersistentVolumeClaim")
 
 	pList, err := c.pvcLister.List(util.GetSuperClusterListerLabelsSelector())
 	if err != nil {
@@ -109,6 +111,7 @@ func (c *controller) PatrollerDo() {
 		updatedPVC := conversion.Equality(c.Config, vc).CheckPVCEquality(p, v)
 		if updatedPVC != nil {
 			atomic.AddUint64(&numMissMatchedPVCs, 1)
+			syncstatus.DefaultRecorder.IncMismatch("PersistentVolumeClaim", vObj.GetOwnerCluster(), vObj.GetNamespace(), "MissMatchedPVCs")
 			klog.Warningf("spec of pvc %s diff in super&tenant control plane", pObj.Key)
 		}
 
@@ -133,4 +136,5 @@ func (c *controller) PatrollerDo() {
 	})
 
 	metrics.CheckerMissMatchStats.WithLabelValues("MissMatchedPVCs").Set(float64(numMissMatchedPVCs))
+	syncstatus.DefaultRecorder.FinishScan("PersistentVolumeClaim")
 }
@@ -37,6 +37,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol/differ"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/syncstatus"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 	utilconstants "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
@@ -145,6 +146,7 @@ func (c *controller) PatrollerDo() {
 	numStatusMissMatchedPods = 0
 	numSpecMissMatchedPods = 0
 	numUWMetaMissMatchedPods = 0
+	syncstatus.DefaultRecorder.StartScan("Pod")
 
 	pList, err := c.podLister.List(util.GetSuperClusterListerLabelsSelector())
 	if err != nil {
@@ -218,6 +220,7 @@ func (c *controller) PatrollerDo() {
 	metrics.CheckerMissMatchStats.WithLabelValues("StatusMissMatchedPods").Set(float64(numStatusMissMatchedPods))
 	metrics.CheckerMissMatchStats.WithLabelValues("SpecMissMatchedPods").Set(float64(numSpecMissMatchedPods))
 	metrics.CheckerMissMatchStats.WithLabelValues("UWMetaMissMatchedPods").Set(float64(numUWMetaMissMatchedPods))
+	syncstatus.DefaultRecorder.FinishScan("Pod")
 
 	for _, clusterName := range clusterNames {
 		wg.Add(1)
@@ -275,6 +278,7 @@ func (c *controller) differUpdateFunc(vObj differ.ClusterObject, pObj differ.Clu
 
 	if conversion.Equality(c.Config, vc).CheckPodEquality(pPod, vPod) != nil {
 		atomic.AddUint64(&numSpecMissMatchedPods, 1)
+		syncstatus.DefaultRecorder.IncMismatch("Pod", clusterName, vPod.Namespace, "SpecMissMatchedPods")
 		klog.Warningf("spec of pod %s diff in super&tenant control plane", pObj.Key)
 		if err := c.MultiClusterController.RequeueObject(clusterName, vPod); err != nil {
 			klog.Errorf("error requeue vPod %s: %v", vObj.Key, err)
@@ -285,6 +289,7 @@ func (c *controller) differUpdateFunc(vObj differ.ClusterObject, pObj differ.Clu
 
 	if conversion.CheckDWPodConditionEquality(pPod, vPod) != nil {
 		atomic.AddUint64(&numSpecMissMatchedPods, 1)
+		syncstatus.DefaultRecorder.IncMismatch("Pod", clusterName, vPod.Namespace, "SpecMissMatchedPods")
 		klog.Warningf("DWStatus of pod %s diff in super&tenant control plane", pObj.Key)
 		if err := c.MultiClusterController.RequeueObject(clusterName, vPod); err != nil {
 			klog.Errorf("error requeue vpod %v/%v in cluster %s: %v", vPod.Namespace, vPod.Name, clusterName, err)
@@ -295,6 +300,7 @@ func (c *controller) differUpdateFunc(vObj differ.ClusterObject, pObj differ.Clu
 
 	if conversion.Equality(c.Config, nil).CheckUWPodStatusEquality(pPod, vPod) != nil {
 		atomic.AddUint64(&numStatusMissMatchedPods, 1)
+		syncstatus.DefaultRecorder.IncMismatch("Pod", clusterName, vPod.Namespace, "StatusMissMatchedPods")
 		klog.Warningf("status of pod %v/%v diff in super&tenant control plane", pPod.Namespace, pPod.Name)
 		if assignedPod(pPod) {
 			c.enqueuePod(pPod)
@@ -303,6 +309,7 @@ func (c *controller) differUpdateFunc(vObj differ.ClusterObject, pObj differ.Clu
 
 	if conversion.Equality(c.Config, vc).CheckUWObjectMetaEquality(&pPod.ObjectMeta, &vPod.ObjectMeta) != nil {
 		atomic.AddUint64(&numUWMetaMissMatchedPods, 1)
+		syncstatus.DefaultRecorder.IncMismatch("Pod", clusterName, vPod.Namespace, "UWMetaMissMatchedPods")
 		klog.Warningf("UWObjectMeta of pod %v/%v diff in super&tenant control plane", vPod.Namespace, vPod.Name)
 		if assignedPod(pPod) {
 			c.enqueuePod(pPod)
@@ -78,7 +78,7 @@ type controller struct {
 	vNodeGCGracePeriod time.Duration
 	// vnodeProvider manages vnode object.
 	vnodeProvider provider.VirtualNodeProvider
-	plugin        validationplugin.Interface
+	plugins       []validationplugin.Interface
 	podMutators   []conversion.PodMutator
 }
 
@@ -119,21 +119,6 @@ func NewPodController(config *config.SyncerConfiguration,
 		return nil, err
 	}
 
-	// check registered validation plugin
-	rs := validationplugin.ValidationRegister.List()
-	c.plugin = nil
-	for _, r := range rs {
-		if r.ID == validationplugin.QuotaValidationPluginName {
-			quotaplugin, err := r.Init(nil).Instance()
-			if err != nil {
-				klog.Errorf("initialize validation plugin with err %v", err)
-				return nil, err
-			}
-			c.plugin = quotaplugin.(validationplugin.Interface)
-			c.plugin.ContextInit(c.MultiClusterController, options.IsFake)
-		}
-	}
-
 	initContext := &plugin.InitContext{
 		Context:    context.Background(),
 		Config:     config,
@@ -143,6 +128,24 @@ func NewPodController(config *config.SyncerConfiguration,
 		VCInformer: vcInformer,
 	}
 
+	// check registered validation plugins, the quota plugin and the resource
+	// ceiling plugin are looked up separately so that they don't replace each other.
+	rs := validationplugin.ValidationRegister.List()
+	for _, r := range rs {
+		if r.ID != validationplugin.QuotaValidationPluginName &&
+			r.ID != validationplugin.ResourceCeilingValidationPluginName {
+			continue
+		}
+		instance, err := r.Init(initContext).Instance()
+		if err != nil {
+			klog.Errorf("initialize validation plugin %s with err %v", r.ID, err)
+			return nil, err
+		}
+		p := instance.(validationplugin.Interface)
+		p.ContextInit(c.MultiClusterController, options.IsFake)
+		c.plugins = append(c.plugins, p)
+	}
+
 	mutatorList := mutatorplugin.MutatorRegister.List()
 	for _, r := range mutatorList {
 		mutator, err := r.Init(initContext).Instance()
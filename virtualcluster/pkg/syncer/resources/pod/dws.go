@@ -214,17 +214,17 @@ func (c *controller) reconcilePodCreate(clusterName, targetNamespace, requestUID
 	}
 
 	// Validation plugin processing
-	if c.plugin != nil {
+	for _, p := range c.plugins {
 		pluginstart := time.Now()
-		if c.plugin.Enabled() {
+		if p.Enabled() {
 			// Serialize pod creation for each tenant
-			t := c.plugin.GetTenantLocker(clusterName)
+			t := p.GetTenantLocker(clusterName)
 			if t == nil {
 				return apierrors.NewBadRequest("cannot get tenant")
 			}
 			t.Cond.Lock()
 			defer t.Cond.Unlock()
-			if !c.plugin.Validation(newObj, clusterName) {
+			if !p.Validation(newObj, clusterName) {
 				// put pod aside, not to try to create it again.
 				klog.Errorf("validation failed for virtual cluster namespace %v, no pod sync", targetNamespace)
 				recordOperationDuration("validation_plugin", pluginstart)
@@ -39,6 +39,9 @@ type Tenant struct {
 
 const (
 	QuotaValidationPluginName = "quota"
+	// ResourceCeilingValidationPluginName is the builtin plugin enforcing the
+	// ResourceCeiling of the VirtualCluster, which runs along with the quota plugin.
+	ResourceCeilingValidationPluginName = "resourceceiling"
 )
 
 var ValidationRegister uplugin.ResourceRegister
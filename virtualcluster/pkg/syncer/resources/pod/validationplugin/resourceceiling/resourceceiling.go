@@ -0,0 +1,389 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package resourceceiling implements the pod validation plugin that enforces the
+// ResourceCeiling of each VirtualCluster, i.e., the aggregate cpu, memory, pods and
+// PVC storage used by the tenant across all of its namespaces in the super cluster.
+package resourceceiling
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/resources/pod/validationplugin"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
+	uplugin "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/plugin"
+)
+
+const (
+	// usageUpdatePeriod is how often the usage in the VirtualCluster status is refreshed.
+	usageUpdatePeriod = time.Minute
+	// assumeTTL bounds how long a validated pod is counted before it shows up in the
+	// super cluster informer cache, e.g. when its creation failed.
+	assumeTTL = 30 * time.Second
+)
+
+func init() {
+	validationplugin.ValidationRegister.Register(&uplugin.Registration{
+		ID: validationplugin.ResourceCeilingValidationPluginName,
+		InitFn: func(ctx *uplugin.InitContext) (interface{}, error) {
+			return NewResourceCeilingPlugin(ctx)
+		},
+	})
+}
+
+type assumedPod struct {
+	requests corev1.ResourceList
+	expire   time.Time
+}
+
+type ResourceCeilingPlugin struct {
+	sync.Mutex
+	tenants map[string]*validationplugin.Tenant
+	// assumed are the validated pods of each tenant that are not in the informer cache yet.
+	assumed map[string]map[string]assumedPod
+
+	mc       *mc.MultiClusterController
+	isFake   bool
+	vcClient vcclient.Interface
+
+	podLister listersv1.PodLister
+	podSynced cache.InformerSynced
+	pvcLister listersv1.PersistentVolumeClaimLister
+	pvcSynced cache.InformerSynced
+}
+
+var _ validationplugin.Interface = &ResourceCeilingPlugin{}
+
+func NewResourceCeilingPlugin(ctx *uplugin.InitContext) (*ResourceCeilingPlugin, error) {
+	if ctx == nil || ctx.Informer == nil {
+		return nil, fmt.Errorf("resource ceiling plugin requires the super cluster informers")
+	}
+	p := &ResourceCeilingPlugin{
+		tenants:   make(map[string]*validationplugin.Tenant),
+		assumed:   make(map[string]map[string]assumedPod),
+		vcClient:  ctx.VCClient,
+		podLister: ctx.Informer.Core().V1().Pods().Lister(),
+		podSynced: ctx.Informer.Core().V1().Pods().Informer().HasSynced,
+		pvcLister: ctx.Informer.Core().V1().PersistentVolumeClaims().Lister(),
+		pvcSynced: ctx.Informer.Core().V1().PersistentVolumeClaims().Informer().HasSynced,
+	}
+	return p, nil
+}
+
+func (p *ResourceCeilingPlugin) ContextInit(mccontroller *mc.MultiClusterController, isFake bool) {
+	p.mc = mccontroller
+	p.isFake = isFake
+	if isFake {
+		p.podSynced = func() bool { return true }
+		p.pvcSynced = func() bool { return true }
+		return
+	}
+	go wait.Forever(p.updateUsage, usageUpdatePeriod)
+}
+
+func (p *ResourceCeilingPlugin) Enabled() bool {
+	return featuregate.DefaultFeatureGate.Enabled(featuregate.TenantResourceCeiling)
+}
+
+// GetTenantLocker returns the lock serializing the pod creation of the tenant.
+func (p *ResourceCeilingPlugin) GetTenantLocker(clusterName string) *validationplugin.Tenant {
+	p.Lock()
+	defer p.Unlock()
+	t, ok := p.tenants[clusterName]
+	if !ok {
+		t = &validationplugin.Tenant{
+			ClusterName: clusterName,
+			Cond:        &sync.Mutex{},
+		}
+		p.tenants[clusterName] = t
+	}
+	return t
+}
+
+// Validation returns false if creating the pod in the super cluster exceeds the
+// ResourceCeiling of the VirtualCluster, in which case a warning event is recorded
+// for the tenant pod. It is called with the tenant lock held.
+func (p *ResourceCeilingPlugin) Validation(obj client.Object, clusterName string) bool {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return true
+	}
+	vc, err := util.GetVirtualClusterObject(p.mc, clusterName)
+	if err != nil {
+		klog.Errorf("failed to get virtualcluster of cluster %s: %v", clusterName, err)
+		return true
+	}
+	ceiling := vc.Spec.ResourceCeiling
+	if len(ceiling) == 0 {
+		return true
+	}
+
+	used, err := p.usage(clusterName, vc)
+	if err != nil {
+		klog.Errorf("failed to compute the resource usage of cluster %s: %v", clusterName, err)
+		return true
+	}
+
+	requests := podRequests(pod)
+	if exceeded := exceededResources(ceiling, used, requests, mountsPVC(pod)); len(exceeded) > 0 {
+		vNamespace := pod.Annotations[constants.LabelNamespace]
+		klog.Warningf("pod %s/%s of cluster %s exceeds the resource ceiling", vNamespace, pod.Name, clusterName)
+		if err := p.mc.Eventf(clusterName, &corev1.ObjectReference{
+			Kind:      "Pod",
+			Namespace: vNamespace,
+			Name:      pod.Name,
+			UID:       types.UID(pod.Annotations[constants.LabelUID]),
+		}, corev1.EventTypeWarning, "ExceededResourceCeiling", "exceeded resource ceiling of the virtual cluster: requested: %s, used: %s, limited: %s",
+			formatResources(exceeded, requests), formatResources(exceeded, used), formatResources(exceeded, ceiling)); err != nil {
+			klog.Errorf("failed to record event for pod %s/%s of cluster %s: %v", vNamespace, pod.Name, clusterName, err)
+		}
+		return false
+	}
+
+	p.assume(clusterName, pod.Namespace+"/"+pod.Name, requests)
+	return true
+}
+
+func (p *ResourceCeilingPlugin) assume(clusterName, key string, requests corev1.ResourceList) {
+	p.Lock()
+	defer p.Unlock()
+	if _, ok := p.assumed[clusterName]; !ok {
+		p.assumed[clusterName] = make(map[string]assumedPod)
+	}
+	p.assumed[clusterName][key] = assumedPod{requests: requests, expire: time.Now().Add(assumeTTL)}
+}
+
+// usage returns the resources used by the VirtualCluster in the super cluster,
+// including the validated pods not in the informer cache yet.
+func (p *ResourceCeilingPlugin) usage(clusterName string, vc *v1alpha1.VirtualCluster) (corev1.ResourceList, error) {
+	selector := labels.SelectorFromSet(labels.Set{
+		constants.LabelVCName:      vc.Name,
+		constants.LabelVCNamespace: vc.Namespace,
+	})
+	used := corev1.ResourceList{}
+
+	pods, err := p.podLister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	seen := sets.NewString()
+	for _, pod := range pods {
+		seen.Insert(pod.Namespace + "/" + pod.Name)
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		addResourceList(used, podRequests(pod))
+	}
+
+	p.Lock()
+	now := time.Now()
+	for key, assumed := range p.assumed[clusterName] {
+		if seen.Has(key) || now.After(assumed.expire) {
+			delete(p.assumed[clusterName], key)
+			continue
+		}
+		addResourceList(used, assumed.requests)
+	}
+	p.Unlock()
+
+	pvcs, err := p.pvcLister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, pvc := range pvcs {
+		if storage, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
+			addResourceList(used, corev1.ResourceList{corev1.ResourceRequestsStorage: storage})
+		}
+	}
+	return used, nil
+}
+
+// updateUsage refreshes the usage in the status of the VirtualClusters that have a ResourceCeiling.
+func (p *ResourceCeilingPlugin) updateUsage() {
+	if !p.Enabled() || !p.podSynced() || !p.pvcSynced() {
+		return
+	}
+	clusterNames := p.mc.GetClusterNames()
+	p.forgetIdleTenants(clusterNames)
+
+	for _, clusterName := range clusterNames {
+		vc, err := util.GetVirtualClusterObject(p.mc, clusterName)
+		if err != nil {
+			continue
+		}
+		var used corev1.ResourceList
+		if len(vc.Spec.ResourceCeiling) > 0 {
+			usage, err := p.usage(clusterName, vc)
+			if err != nil {
+				klog.Errorf("failed to compute the resource usage of cluster %s: %v", clusterName, err)
+				continue
+			}
+			used = corev1.ResourceList{}
+			for name := range vc.Spec.ResourceCeiling {
+				used[name] = usage[name]
+			}
+		}
+		if len(used) == 0 && len(vc.Status.ResourceUsage) == 0 {
+			continue
+		}
+		if equality.Semantic.DeepEqual(used, vc.Status.ResourceUsage) {
+			continue
+		}
+		if err := p.setResourceUsage(vc.Namespace, vc.Name, used); err != nil {
+			klog.Errorf("failed to update the resource usage of virtualcluster %s/%s: %v", vc.Namespace, vc.Name, err)
+		}
+	}
+}
+
+func (p *ResourceCeilingPlugin) setResourceUsage(namespace, name string, used corev1.ResourceList) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		vc, err := p.vcClient.TenancyV1alpha1().VirtualClusters(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if equality.Semantic.DeepEqual(used, vc.Status.ResourceUsage) {
+			return nil
+		}
+		vc.Status.ResourceUsage = used
+		_, err = p.vcClient.TenancyV1alpha1().VirtualClusters(namespace).Update(vc)
+		return err
+	})
+}
+
+// forgetIdleTenants removes the states of the tenants that are not managed anymore.
+func (p *ResourceCeilingPlugin) forgetIdleTenants(clusterNames []string) {
+	known := sets.NewString(clusterNames...)
+	p.Lock()
+	defer p.Unlock()
+	for clusterName := range p.tenants {
+		if !known.Has(clusterName) {
+			delete(p.tenants, clusterName)
+		}
+	}
+	for clusterName := range p.assumed {
+		if !known.Has(clusterName) {
+			delete(p.assumed, clusterName)
+		}
+	}
+}
+
+// podRequests returns the cpu and memory requested by the pod, i.e., the larger of the sum of
+// the containers and the largest init container plus the pod overhead, and one pod.
+func podRequests(pod *corev1.Pod) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+	for _, container := range pod.Spec.Containers {
+		addResourceList(requests, computeResources(container.Resources.Requests))
+	}
+	for _, container := range pod.Spec.InitContainers {
+		for name, quantity := range computeResources(container.Resources.Requests) {
+			if current, ok := requests[name]; !ok || quantity.Cmp(current) > 0 {
+				requests[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	addResourceList(requests, computeResources(pod.Spec.Overhead))
+	addResourceList(requests, corev1.ResourceList{corev1.ResourcePods: *resource.NewQuantity(1, resource.DecimalSI)})
+	return requests
+}
+
+func computeResources(list corev1.ResourceList) corev1.ResourceList {
+	result := corev1.ResourceList{}
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		if quantity, ok := list[name]; ok {
+			result[name] = quantity.DeepCopy()
+		}
+	}
+	return result
+}
+
+func mountsPVC(pod *corev1.Pod) bool {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// exceededResources returns the resources of the ceiling that creating the pod exceeds.
+// The PVC storage is not requested by the pod, so a pod is only rejected for it if the pod
+// mounts a PVC while the storage is already over the ceiling.
+func exceededResources(ceiling, used, requests corev1.ResourceList, mountsPVC bool) []corev1.ResourceName {
+	var exceeded []corev1.ResourceName
+	for name, limit := range ceiling {
+		total := used[name].DeepCopy()
+		switch name {
+		case corev1.ResourceRequestsStorage:
+			if !mountsPVC {
+				continue
+			}
+		default:
+			request, ok := requests[name]
+			if !ok {
+				continue
+			}
+			total.Add(request)
+		}
+		if total.Cmp(limit) > 0 {
+			exceeded = append(exceeded, name)
+		}
+	}
+	sort.Slice(exceeded, func(i, j int) bool { return exceeded[i] < exceeded[j] })
+	return exceeded
+}
+
+func formatResources(names []corev1.ResourceName, list corev1.ResourceList) string {
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		quantity := list[name]
+		parts = append(parts, fmt.Sprintf("%s=%s", name, quantity.String()))
+	}
+	return strings.Join(parts, ",")
+}
+
+func addResourceList(list, add corev1.ResourceList) {
+	for name, quantity := range add {
+		if current, ok := list[name]; ok {
+			current.Add(quantity)
+			list[name] = current
+		} else {
+			list[name] = quantity.DeepCopy()
+		}
+	}
+}
@@ -0,0 +1,335 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourceceiling
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+	fakeClient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	fakevcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned/fake"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+	util "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/test"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/cluster"
+	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
+	uplugin "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/plugin"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
+)
+
+type fakeReconciler struct{}
+
+func (r *fakeReconciler) Reconcile(reconciler.Request) (reconciler.Result, error) {
+	return reconciler.Result{}, nil
+}
+
+func superPod(name, namespace, cpu string, phase corev1.PodPhase) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				constants.LabelVCName:      "test",
+				constants.LabelVCNamespace: "tenant-1",
+			},
+			Annotations: map[string]string{
+				constants.LabelNamespace: "default",
+				constants.LabelUID:       "12345",
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "c",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+					},
+				},
+			},
+		},
+		Status: corev1.PodStatus{Phase: phase},
+	}
+}
+
+func superPVC(name, namespace, storage string) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				constants.LabelVCName:      "test",
+				constants.LabelVCNamespace: "tenant-1",
+			},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(storage)},
+			},
+		},
+	}
+}
+
+func withPVCVolume(pod *corev1.Pod) *corev1.Pod {
+	pod.Spec.Volumes = []corev1.Volume{
+		{
+			Name: "data",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "pvc-1"},
+			},
+		},
+	}
+	return pod
+}
+
+func newTestPlugin(t *testing.T, vc *v1alpha1.VirtualCluster, superObjs []runtime.Object) (*ResourceCeilingPlugin, *fake.Clientset, string) {
+	tenantClientset := fake.NewSimpleClientset()
+	tenantCluster := cluster.NewFakeTenantCluster(vc, tenantClientset, fakeClient.NewClientBuilder().Build())
+
+	superInformer := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	p, err := NewResourceCeilingPlugin(&uplugin.InitContext{
+		Informer: superInformer,
+		VCClient: fakevcclient.NewSimpleClientset(vc),
+	})
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+	for _, obj := range superObjs {
+		if err := superInformer.InformerFor(obj, nil).GetStore().Add(obj); err != nil {
+			t.Fatalf("failed to add object to informer: %v", err)
+		}
+	}
+
+	mcc, err := mc.NewMCController(&corev1.Pod{}, &corev1.PodList{}, &fakeReconciler{})
+	if err != nil {
+		t.Fatalf("failed to create mc controller: %v", err)
+	}
+	if err := mcc.RegisterClusterResource(tenantCluster, mc.WatchOptions{}); err != nil {
+		t.Fatalf("failed to register cluster: %v", err)
+	}
+	p.ContextInit(mcc, true)
+	return p, tenantClientset, tenantCluster.GetClusterName()
+}
+
+func TestValidation(t *testing.T) {
+	vc := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Spec: v1alpha1.VirtualClusterSpec{
+			ResourceCeiling: corev1.ResourceList{
+				corev1.ResourceCPU:             resource.MustParse("2"),
+				corev1.ResourcePods:            resource.MustParse("3"),
+				corev1.ResourceRequestsStorage: resource.MustParse("10Gi"),
+			},
+		},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+	superNS := conversion.ToSuperClusterNamespace(conversion.ToClusterKey(vc), "default")
+
+	testcases := map[string]struct {
+		ExistingObjectInSuper []runtime.Object
+		Pod                   *corev1.Pod
+		ExpectedValid         bool
+	}{
+		"within the ceiling": {
+			ExistingObjectInSuper: []runtime.Object{
+				superPod("pod-1", superNS, "1", corev1.PodRunning),
+			},
+			Pod:           superPod("pod-2", superNS, "500m", ""),
+			ExpectedValid: true,
+		},
+		"exceeding the cpu": {
+			ExistingObjectInSuper: []runtime.Object{
+				superPod("pod-1", superNS, "1500m", corev1.PodRunning),
+			},
+			Pod:           superPod("pod-2", superNS, "1", ""),
+			ExpectedValid: false,
+		},
+		"completed pods are not counted": {
+			ExistingObjectInSuper: []runtime.Object{
+				superPod("pod-1", superNS, "1500m", corev1.PodSucceeded),
+			},
+			Pod:           superPod("pod-2", superNS, "1", ""),
+			ExpectedValid: true,
+		},
+		"exceeding the pods": {
+			ExistingObjectInSuper: []runtime.Object{
+				superPod("pod-1", superNS, "0", corev1.PodRunning),
+				superPod("pod-2", superNS, "0", corev1.PodRunning),
+				superPod("pod-3", superNS, "0", corev1.PodRunning),
+			},
+			Pod:           superPod("pod-4", superNS, "0", ""),
+			ExpectedValid: false,
+		},
+		"storage over the ceiling without pvc volume": {
+			ExistingObjectInSuper: []runtime.Object{
+				superPVC("pvc-1", superNS, "20Gi"),
+			},
+			Pod:           superPod("pod-1", superNS, "1", ""),
+			ExpectedValid: true,
+		},
+		"storage over the ceiling with pvc volume": {
+			ExistingObjectInSuper: []runtime.Object{
+				superPVC("pvc-1", superNS, "20Gi"),
+			},
+			Pod:           withPVCVolume(superPod("pod-1", superNS, "1", "")),
+			ExpectedValid: false,
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			p, tenantClientset, clusterName := newTestPlugin(t, vc, tc.ExistingObjectInSuper)
+			valid := p.Validation(tc.Pod, clusterName)
+			if valid != tc.ExpectedValid {
+				t.Errorf("expected validation result %v, got %v", tc.ExpectedValid, valid)
+			}
+
+			var events []*corev1.Event
+			for _, action := range tenantClientset.Actions() {
+				if action.Matches("create", "events") {
+					events = append(events, action.(core.CreateAction).GetObject().(*corev1.Event))
+				}
+			}
+			if tc.ExpectedValid && len(events) != 0 {
+				t.Errorf("expected no event, got %v", events)
+			}
+			if !tc.ExpectedValid {
+				if len(events) != 1 || events[0].Reason != "ExceededResourceCeiling" || events[0].InvolvedObject.Namespace != "default" {
+					t.Errorf("expected an ExceededResourceCeiling event in the tenant namespace, got %v", events)
+				}
+			}
+		})
+	}
+}
+
+func TestValidationCountsAssumedPods(t *testing.T) {
+	vc := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Spec: v1alpha1.VirtualClusterSpec{
+			ResourceCeiling: corev1.ResourceList{
+				corev1.ResourcePods: resource.MustParse("1"),
+			},
+		},
+	}
+	superNS := conversion.ToSuperClusterNamespace(conversion.ToClusterKey(vc), "default")
+
+	p, _, clusterName := newTestPlugin(t, vc, nil)
+	if !p.Validation(superPod("pod-1", superNS, "1", ""), clusterName) {
+		t.Fatalf("expected the first pod to be valid")
+	}
+	// the first pod is not in the informer cache yet
+	if p.Validation(superPod("pod-2", superNS, "1", ""), clusterName) {
+		t.Errorf("expected the second pod to exceed the ceiling")
+	}
+}
+
+func TestUpdateUsage(t *testing.T) {
+	defer util.SetFeatureGateDuringTest(t, featuregate.DefaultFeatureGate, featuregate.TenantResourceCeiling, true)()
+
+	vc := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Spec: v1alpha1.VirtualClusterSpec{
+			ResourceCeiling: corev1.ResourceList{
+				corev1.ResourceCPU:             resource.MustParse("4"),
+				corev1.ResourceRequestsStorage: resource.MustParse("10Gi"),
+			},
+		},
+	}
+	superNS := conversion.ToSuperClusterNamespace(conversion.ToClusterKey(vc), "default")
+
+	p, _, _ := newTestPlugin(t, vc, []runtime.Object{
+		superPod("pod-1", superNS, "1", corev1.PodRunning),
+		superPod("pod-2", superNS, "500m", corev1.PodRunning),
+		superPVC("pvc-1", superNS, "5Gi"),
+	})
+	p.updateUsage()
+
+	updated, err := p.vcClient.TenancyV1alpha1().VirtualClusters(vc.Namespace).Get(vc.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get virtualcluster: %v", err)
+	}
+	expected := corev1.ResourceList{
+		corev1.ResourceCPU:             resource.MustParse("1500m"),
+		corev1.ResourceRequestsStorage: resource.MustParse("5Gi"),
+	}
+	if len(updated.Status.ResourceUsage) != len(expected) {
+		t.Fatalf("expected usage %v, got %v", expected, updated.Status.ResourceUsage)
+	}
+	for name, value := range expected {
+		quantity := updated.Status.ResourceUsage[name]
+		if quantity.Cmp(value) != 0 {
+			t.Errorf("expected %s %s, got %s", name, value.String(), quantity.String())
+		}
+	}
+}
+
+func TestPodRequests(t *testing.T) {
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{
+				{Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+					corev1.ResourceCPU: resource.MustParse("2"),
+				}}},
+			},
+			Containers: []corev1.Container{
+				{Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("500m"),
+					corev1.ResourceMemory: resource.MustParse("1Gi"),
+				}}},
+				{Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("500m"),
+					corev1.ResourceMemory: resource.MustParse("1Gi"),
+				}}},
+			},
+			Overhead: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("100Mi")},
+		},
+	}
+	requests := podRequests(pod)
+	expected := map[corev1.ResourceName]string{
+		corev1.ResourceCPU:    "2",
+		corev1.ResourceMemory: "2148Mi",
+		corev1.ResourcePods:   "1",
+	}
+	for name, value := range expected {
+		quantity := requests[name]
+		if quantity.Cmp(resource.MustParse(value)) != 0 {
+			t.Errorf("expected %s %s, got %s", name, value, quantity.String())
+		}
+	}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcequota
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol/differ"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/syncstatus"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
+)
+
+var numMissMatchedResourceQuotas uint64
+
+func (c *controller) StartPatrol(stopCh <-chan struct{}) error {
+	if !cache.WaitForCacheSync(stopCh, c.quotaSynced) {
+		return fmt.Errorf("failed to wait for caches to sync before starting ResourceQuota checker")
+	}
+	c.Patroller.Start(stopCh)
+	return nil
+}
+
+// PatrollerDo check if resourcequotas keep consistency between super
+// control plane and tenant control planes.
+func (c *controller) PatrollerDo() {
+	clusterNames := c.MultiClusterController.GetClusterNames()
+	if len(clusterNames) == 0 {
+		klog.V(5).Infof("super cluster has no tenant control planes, giving up periodic checker: %s", "resourcequota")
+		return
+	}
+
+	numMissMatchedResourceQuotas = 0
+	syncstatus.DefaultRecorder.StartScan("ResourceQuota")
+
+	pList, err := c.quotaLister.List(util.GetSuperClusterListerLabelsSelector())
+	if err != nil {
+		klog.Errorf("error listing resourcequota from super control plane informer cache: %v", err)
+		return
+	}
+	pSet := differ.NewDiffSet()
+	for _, p := range pList {
+		pSet.Insert(differ.ClusterObject{Object: p, Key: differ.DefaultClusterObjectKey(p, "")})
+	}
+
+	knownClusterSet := sets.NewString(clusterNames...)
+	vSet := differ.NewDiffSet()
+	for _, cluster := range clusterNames {
+		vList := &corev1.ResourceQuotaList{}
+		if err := c.MultiClusterController.List(cluster, vList); err != nil {
+			klog.Errorf("error listing resourcequota from cluster %s informer cache: %v", cluster, err)
+			knownClusterSet.Delete(cluster)
+			continue
+		}
+
+		for i := range vList.Items {
+			vSet.Insert(differ.ClusterObject{
+				Object:       &vList.Items[i],
+				OwnerCluster: cluster,
+				Key:          differ.DefaultClusterObjectKey(&vList.Items[i], cluster),
+			})
+		}
+	}
+
+	d := differ.HandlerFuncs{}
+	d.AddFunc = func(vObj differ.ClusterObject) {
+		if err := c.MultiClusterController.RequeueObject(vObj.OwnerCluster, vObj.Object); err != nil {
+			klog.Errorf("error requeue vResourceQuota %s in cluster %s: %v", vObj.Key, vObj.GetOwnerCluster(), err)
+		} else {
+			metrics.CheckerRemedyStats.WithLabelValues("RequeuedTenantResourceQuotas").Inc()
+		}
+	}
+	d.UpdateFunc = func(vObj, pObj differ.ClusterObject) {
+		v := vObj.Object.(*corev1.ResourceQuota)
+		p := pObj.Object.(*corev1.ResourceQuota)
+
+		if p.Annotations[constants.LabelUID] != string(v.UID) {
+			klog.Warningf("Found pResourceQuota %s delegated UID is different from tenant object", pObj.Key)
+			d.OnDelete(pObj)
+			return
+		}
+		vc, err := util.GetVirtualClusterObject(c.MultiClusterController, vObj.GetOwnerCluster())
+		if err != nil {
+			klog.Errorf("fail to get cluster spec : %s", vObj.GetOwnerCluster())
+			return
+		}
+		// the cap in the VirtualCluster may be changed, requeue the tenant object to apply it.
+		if conversion.Equality(c.Config, vc).CheckResourceQuotaEquality(p, v) != nil {
+			atomic.AddUint64(&numMissMatchedResourceQuotas, 1)
+			syncstatus.DefaultRecorder.IncMismatch("ResourceQuota", vObj.GetOwnerCluster(), vObj.GetNamespace(), "MissMatchedResourceQuotas")
+			klog.Warningf("spec of resourcequota %s diff in super&tenant control plane", pObj.Key)
+			if err := c.MultiClusterController.RequeueObject(vObj.GetOwnerCluster(), v); err != nil {
+				klog.Errorf("error requeue vResourceQuota %s in cluster %s: %v", vObj.Key, vObj.GetOwnerCluster(), err)
+			} else {
+				metrics.CheckerRemedyStats.WithLabelValues("RequeuedTenantResourceQuotas").Inc()
+			}
+		}
+
+		if conversion.Equality(c.Config, vc).CheckUWResourceQuotaStatusEquality(p, v) != nil {
+			klog.Warningf("status of resourcequota %v/%v diff in super&tenant control plane", p.Namespace, p.Name)
+			c.enqueueResourceQuota(p)
+		}
+	}
+	d.DeleteFunc = func(pObj differ.ClusterObject) {
+		deleteOptions := &metav1.DeleteOptions{}
+		deleteOptions.Preconditions = metav1.NewUIDPreconditions(string(pObj.GetUID()))
+		if err = c.quotaClient.ResourceQuotas(pObj.GetNamespace()).Delete(context.TODO(), pObj.GetName(), *deleteOptions); err != nil {
+			klog.Errorf("error deleting pResourceQuota %s in super control plane: %v", pObj.Key, err)
+		} else {
+			metrics.CheckerRemedyStats.WithLabelValues("DeletedOrphanSuperControlPlaneResourceQuotas").Inc()
+		}
+	}
+
+	vSet.Difference(pSet, differ.FilteringHandler{
+		Handler:    d,
+		FilterFunc: differ.DefaultDifferFilter(knownClusterSet),
+	})
+
+	metrics.CheckerMissMatchStats.WithLabelValues("MissMatchedResourceQuotas").Set(float64(numMissMatchedResourceQuotas))
+	syncstatus.DefaultRecorder.FinishScan("ResourceQuota")
+}
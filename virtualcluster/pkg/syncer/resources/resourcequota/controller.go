@@ -0,0 +1,130 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcequota
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/informers"
+	clientset "k8s.io/client-go/kubernetes"
+	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	vcclient "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/clientset/versioned"
+	vcinformers "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/apis/config"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
+	pa "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol"
+	uw "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/uwcontroller"
+	mc "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/mccontroller"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/plugin"
+)
+
+func init() {
+	plugin.SyncerResourceRegister.Register(&plugin.Registration{
+		ID: "resourcequota",
+		InitFn: func(ctx *plugin.InitContext) (interface{}, error) {
+			return NewResourceQuotaController(ctx.Config.(*config.SyncerConfiguration), ctx.Client, ctx.Informer, ctx.VCClient, ctx.VCInformer, manager.ResourceSyncerOptions{})
+		},
+		Disable: true,
+	})
+}
+
+type controller struct {
+	manager.BaseResourceSyncer
+	// super control plane resourcequota client
+	quotaClient v1core.ResourceQuotasGetter
+	// super control plane resourcequota lister
+	quotaLister listersv1.ResourceQuotaLister
+	quotaSynced cache.InformerSynced
+}
+
+func NewResourceQuotaController(config *config.SyncerConfiguration,
+	client clientset.Interface,
+	informer informers.SharedInformerFactory,
+	vcClient vcclient.Interface,
+	vcInformer vcinformers.VirtualClusterInformer,
+	options manager.ResourceSyncerOptions) (manager.ResourceSyncer, error) {
+	c := &controller{
+		BaseResourceSyncer: manager.BaseResourceSyncer{
+			Config: config,
+		},
+		quotaClient: client.CoreV1(),
+	}
+
+	var err error
+	c.MultiClusterController, err = mc.NewMCController(&corev1.ResourceQuota{}, &corev1.ResourceQuotaList{}, c, mc.WithOptions(options.MCOptions))
+	if err != nil {
+		return nil, err
+	}
+
+	c.quotaLister = informer.Core().V1().ResourceQuotas().Lister()
+	if options.IsFake {
+		c.quotaSynced = func() bool { return true }
+	} else {
+		c.quotaSynced = informer.Core().V1().ResourceQuotas().Informer().HasSynced
+	}
+
+	c.UpwardController, err = uw.NewUWController(&corev1.ResourceQuota{}, c, uw.WithOptions(options.UWOptions))
+	if err != nil {
+		return nil, err
+	}
+
+	c.Patroller, err = pa.NewPatroller(&corev1.ResourceQuota{}, c, pa.WithOptions(options.PatrolOptions))
+	if err != nil {
+		return nil, err
+	}
+
+	informer.Core().V1().ResourceQuotas().Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				newQuota := newObj.(*corev1.ResourceQuota)
+				oldQuota := oldObj.(*corev1.ResourceQuota)
+				if newQuota.ResourceVersion != oldQuota.ResourceVersion {
+					c.enqueueResourceQuota(newQuota)
+				}
+			},
+		},
+	)
+	return c, nil
+}
+
+func (c *controller) enqueueResourceQuota(obj interface{}) {
+	quota, ok := obj.(*corev1.ResourceQuota)
+	if !ok {
+		return
+	}
+
+	clusterName, _ := conversion.GetVirtualOwner(quota)
+	if clusterName == "" {
+		return
+	}
+
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("couldn't get key for object %v: %v", obj, err))
+		return
+	}
+
+	klog.V(4).Infof("enqueue ResourceQuota %s", key)
+	c.UpwardController.AddToQueue(key)
+}
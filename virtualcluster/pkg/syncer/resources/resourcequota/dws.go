@@ -0,0 +1,147 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcequota
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/reconciler"
+)
+
+func (c *controller) StartDWS(stopCh <-chan struct{}) error {
+	if !cache.WaitForCacheSync(stopCh, c.quotaSynced) {
+		return fmt.Errorf("failed to wait for caches to sync")
+	}
+	return c.MultiClusterController.Start(stopCh)
+}
+
+// The reconcile logic for tenant control plane resourcequota informer
+func (c *controller) Reconcile(request reconciler.Request) (reconciler.Result, error) {
+	klog.V(4).Infof("reconcile resourcequota %s/%s event for cluster %s", request.Namespace, request.Name, request.ClusterName)
+
+	targetNamespace := conversion.ToSuperClusterNamespace(request.ClusterName, request.Namespace)
+	pQuota, err := c.quotaLister.ResourceQuotas(targetNamespace).Get(request.Name)
+	pExists := true
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return reconciler.Result{Requeue: true}, err
+		}
+		pExists = false
+	}
+	vExists := true
+	vQuota := &corev1.ResourceQuota{}
+	if err := c.MultiClusterController.Get(request.ClusterName, request.Namespace, request.Name, vQuota); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return reconciler.Result{Requeue: true}, err
+		}
+		vExists = false
+	}
+
+	switch {
+	case vExists && !pExists:
+		err := c.reconcileResourceQuotaCreate(request.ClusterName, targetNamespace, request.UID, vQuota)
+		if err != nil {
+			klog.Errorf("failed reconcile resourcequota %s/%s CREATE of cluster %s %v", request.Namespace, request.Name, request.ClusterName, err)
+			return reconciler.Result{Requeue: true}, err
+		}
+	case !vExists && pExists:
+		err := c.reconcileResourceQuotaRemove(request.ClusterName, targetNamespace, request.UID, request.Name, pQuota)
+		if err != nil {
+			klog.Errorf("failed reconcile resourcequota %s/%s DELETE of cluster %s %v", request.Namespace, request.Name, request.ClusterName, err)
+			return reconciler.Result{Requeue: true}, err
+		}
+	case vExists && pExists:
+		err := c.reconcileResourceQuotaUpdate(request.ClusterName, targetNamespace, request.UID, pQuota, vQuota)
+		if err != nil {
+			klog.Errorf("failed reconcile resourcequota %s/%s UPDATE of cluster %s %v", request.Namespace, request.Name, request.ClusterName, err)
+			return reconciler.Result{Requeue: true}, err
+		}
+	default:
+		// object is gone.
+	}
+	return reconciler.Result{}, nil
+}
+
+func (c *controller) reconcileResourceQuotaCreate(clusterName, targetNamespace, requestUID string, quota *corev1.ResourceQuota) error {
+	vc, err := util.GetVirtualClusterObject(c.MultiClusterController, clusterName)
+	if err != nil {
+		return err
+	}
+
+	newObj, err := c.Conversion().BuildSuperClusterObject(clusterName, quota)
+	if err != nil {
+		return err
+	}
+
+	pQuota := newObj.(*corev1.ResourceQuota)
+	// the hard limits are capped by the operator, the usage is computed by the super control plane.
+	pQuota.Spec.Hard = conversion.CapResourceList(pQuota.Spec.Hard, vc.Spec.ResourceQuotaCap)
+	pQuota.Status = corev1.ResourceQuotaStatus{}
+
+	pQuota, err = c.quotaClient.ResourceQuotas(targetNamespace).Create(context.TODO(), pQuota, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		if pQuota.Annotations[constants.LabelUID] == requestUID {
+			klog.Infof("resourcequota %s/%s of cluster %s already exist in super control plane", targetNamespace, pQuota.Name, clusterName)
+			return nil
+		}
+		return fmt.Errorf("pResourceQuota %s/%s exists but its delegated object UID is different", targetNamespace, pQuota.Name)
+	}
+	return err
+}
+
+func (c *controller) reconcileResourceQuotaUpdate(clusterName, targetNamespace, requestUID string, pQuota, vQuota *corev1.ResourceQuota) error {
+	if pQuota.Annotations[constants.LabelUID] != requestUID {
+		return fmt.Errorf("pResourceQuota %s/%s delegated UID is different from updated object", targetNamespace, pQuota.Name)
+	}
+	vc, err := util.GetVirtualClusterObject(c.MultiClusterController, clusterName)
+	if err != nil {
+		return err
+	}
+	updatedQuota := conversion.Equality(c.Config, vc).CheckResourceQuotaEquality(pQuota, vQuota)
+	if updatedQuota != nil {
+		_, err = c.quotaClient.ResourceQuotas(targetNamespace).Update(context.TODO(), updatedQuota, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *controller) reconcileResourceQuotaRemove(clusterName, targetNamespace, requestUID, name string, pQuota *corev1.ResourceQuota) error {
+	if pQuota.Annotations[constants.LabelUID] != requestUID {
+		return fmt.Errorf("to be deleted pResourceQuota %s/%s delegated UID is different from deleted object", targetNamespace, pQuota.Name)
+	}
+	opts := &metav1.DeleteOptions{
+		PropagationPolicy: &constants.DefaultDeletionPolicy,
+	}
+	err := c.quotaClient.ResourceQuotas(targetNamespace).Delete(context.TODO(), name, *opts)
+	if apierrors.IsNotFound(err) {
+		klog.Warningf("resourcequota %s/%s of cluster %s not found in super control plane", targetNamespace, name, clusterName)
+		return nil
+	}
+	return err
+}
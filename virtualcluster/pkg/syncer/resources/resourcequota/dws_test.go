@@ -0,0 +1,374 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcequota
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	core "k8s.io/client-go/testing"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	util "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/test"
+)
+
+func tenantResourceQuota(name, namespace, uid string) *corev1.ResourceQuota {
+	return &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			UID:       types.UID(uid),
+		},
+	}
+}
+
+func superResourceQuota(name, namespace, uid, clusterKey string) *corev1.ResourceQuota {
+	return &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Annotations: map[string]string{
+				constants.LabelUID:       uid,
+				constants.LabelCluster:   clusterKey,
+				constants.LabelNamespace: "default",
+			},
+		},
+	}
+}
+
+func applyHardToResourceQuota(quota *corev1.ResourceQuota, hard corev1.ResourceList) *corev1.ResourceQuota {
+	quota.Spec.Hard = hard.DeepCopy()
+	return quota
+}
+
+func TestDWResourceQuotaCreation(t *testing.T) {
+	testTenant := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Spec: v1alpha1.VirtualClusterSpec{
+			ResourceQuotaCap: corev1.ResourceList{
+				corev1.ResourceCPU:  resource.MustParse("4"),
+				corev1.ResourcePods: resource.MustParse("20"),
+			},
+		},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+
+	defaultClusterKey := conversion.ToClusterKey(testTenant)
+	superDefaultNSName := conversion.ToSuperClusterNamespace(defaultClusterKey, "default")
+
+	testcases := map[string]struct {
+		ExistingObjectInSuper  []runtime.Object
+		ExistingObjectInTenant []runtime.Object
+		ExpectedCreatedQuota   []string
+		ExpectedHard           corev1.ResourceList
+		ExpectedError          string
+	}{
+		"new resourcequota": {
+			ExistingObjectInSuper: []runtime.Object{},
+			ExistingObjectInTenant: []runtime.Object{
+				applyHardToResourceQuota(tenantResourceQuota("quota-1", "default", "12345"), corev1.ResourceList{
+					corev1.ResourceCPU: resource.MustParse("2"),
+				}),
+			},
+			ExpectedCreatedQuota: []string{superDefaultNSName + "/quota-1"},
+			ExpectedHard: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("2"),
+			},
+		},
+		"new resourcequota exceeding the cap": {
+			ExistingObjectInSuper: []runtime.Object{},
+			ExistingObjectInTenant: []runtime.Object{
+				applyHardToResourceQuota(tenantResourceQuota("quota-1", "default", "12345"), corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("8"),
+					corev1.ResourceMemory: resource.MustParse("8Gi"),
+				}),
+			},
+			ExpectedCreatedQuota: []string{superDefaultNSName + "/quota-1"},
+			ExpectedHard: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("4"),
+				corev1.ResourceMemory: resource.MustParse("8Gi"),
+			},
+		},
+		"new resourcequota but existing different uid one": {
+			ExistingObjectInSuper: []runtime.Object{
+				superResourceQuota("quota-1", superDefaultNSName, "123456", defaultClusterKey),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				tenantResourceQuota("quota-1", "default", "12345"),
+			},
+			ExpectedCreatedQuota: []string{},
+			ExpectedError:        "delegated UID is different",
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			actions, reconcileErr, err := util.RunDownwardSync(NewResourceQuotaController, testTenant, tc.ExistingObjectInSuper, tc.ExistingObjectInTenant, tc.ExistingObjectInTenant[0], nil)
+			if err != nil {
+				t.Errorf("%s: error running downward sync: %v", k, err)
+				return
+			}
+
+			if reconcileErr != nil {
+				if tc.ExpectedError == "" {
+					t.Errorf("expected no error, but got \"%v\"", reconcileErr)
+				} else if !strings.Contains(reconcileErr.Error(), tc.ExpectedError) {
+					t.Errorf("expected error msg \"%s\", but got \"%v\"", tc.ExpectedError, reconcileErr)
+				}
+			} else {
+				if tc.ExpectedError != "" {
+					t.Errorf("expected error msg \"%s\", but got empty", tc.ExpectedError)
+				}
+			}
+
+			if len(tc.ExpectedCreatedQuota) != len(actions) {
+				t.Errorf("%s: Expected to create resourcequota %#v. Actual actions were: %#v", k, tc.ExpectedCreatedQuota, actions)
+				return
+			}
+			for i, expectedName := range tc.ExpectedCreatedQuota {
+				action := actions[i]
+				if !action.Matches("create", "resourcequotas") {
+					t.Errorf("%s: Unexpected action %s", k, action)
+				}
+				created := action.(core.CreateAction).GetObject().(*corev1.ResourceQuota)
+				fullName := created.Namespace + "/" + created.Name
+				if fullName != expectedName {
+					t.Errorf("%s: Expected %s to be created, got %s", k, expectedName, fullName)
+				}
+				if !equality.Semantic.DeepEqual(created.Spec.Hard, tc.ExpectedHard) {
+					t.Errorf("%s: Expected hard limits %v, got %v", k, tc.ExpectedHard, created.Spec.Hard)
+				}
+			}
+		})
+	}
+}
+
+func TestDWResourceQuotaDeletion(t *testing.T) {
+	testTenant := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Spec: v1alpha1.VirtualClusterSpec{},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+
+	defaultClusterKey := conversion.ToClusterKey(testTenant)
+	superDefaultNSName := conversion.ToSuperClusterNamespace(defaultClusterKey, "default")
+
+	testcases := map[string]struct {
+		ExistingObjectInSuper []runtime.Object
+		EnqueueObject         *corev1.ResourceQuota
+		ExpectedDeletedQuota  []string
+		ExpectedError         string
+	}{
+		"delete resourcequota": {
+			ExistingObjectInSuper: []runtime.Object{
+				superResourceQuota("quota-1", superDefaultNSName, "12345", defaultClusterKey),
+			},
+			EnqueueObject:        tenantResourceQuota("quota-1", "default", "12345"),
+			ExpectedDeletedQuota: []string{superDefaultNSName + "/quota-1"},
+		},
+		"delete resourcequota but already gone": {
+			ExistingObjectInSuper: []runtime.Object{},
+			EnqueueObject:         tenantResourceQuota("quota-1", "default", "12345"),
+			ExpectedDeletedQuota:  []string{},
+		},
+		"delete resourcequota but existing different uid one": {
+			ExistingObjectInSuper: []runtime.Object{
+				superResourceQuota("quota-1", superDefaultNSName, "123456", defaultClusterKey),
+			},
+			EnqueueObject:        tenantResourceQuota("quota-1", "default", "12345"),
+			ExpectedDeletedQuota: []string{},
+			ExpectedError:        "delegated UID is different",
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			actions, reconcileErr, err := util.RunDownwardSync(NewResourceQuotaController, testTenant, tc.ExistingObjectInSuper, nil, tc.EnqueueObject, nil)
+			if err != nil {
+				t.Errorf("%s: error running downward sync: %v", k, err)
+				return
+			}
+
+			if reconcileErr != nil {
+				if tc.ExpectedError == "" {
+					t.Errorf("expected no error, but got \"%v\"", reconcileErr)
+				} else if !strings.Contains(reconcileErr.Error(), tc.ExpectedError) {
+					t.Errorf("expected error msg \"%s\", but got \"%v\"", tc.ExpectedError, reconcileErr)
+				}
+			} else {
+				if tc.ExpectedError != "" {
+					t.Errorf("expected error msg \"%s\", but got empty", tc.ExpectedError)
+				}
+			}
+
+			if len(tc.ExpectedDeletedQuota) != len(actions) {
+				t.Errorf("%s: Expected to delete resourcequota %#v. Actual actions were: %#v", k, tc.ExpectedDeletedQuota, actions)
+				return
+			}
+			for i, expectedName := range tc.ExpectedDeletedQuota {
+				action := actions[i]
+				if !action.Matches("delete", "resourcequotas") {
+					t.Errorf("%s: Unexpected action %s", k, action)
+				}
+				fullName := action.(core.DeleteAction).GetNamespace() + "/" + action.(core.DeleteAction).GetName()
+				if fullName != expectedName {
+					t.Errorf("%s: Expected %s to be deleted, got %s", k, expectedName, fullName)
+				}
+			}
+		})
+	}
+}
+
+func TestDWResourceQuotaUpdate(t *testing.T) {
+	testTenant := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Spec: v1alpha1.VirtualClusterSpec{
+			ResourceQuotaCap: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("4"),
+			},
+		},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+
+	defaultClusterKey := conversion.ToClusterKey(testTenant)
+	superDefaultNSName := conversion.ToSuperClusterNamespace(defaultClusterKey, "default")
+
+	hard1 := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}
+	hard2 := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("3")}
+	hard3 := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("8")}
+	capped := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")}
+
+	testcases := map[string]struct {
+		ExistingObjectInSuper  []runtime.Object
+		ExistingObjectInTenant []runtime.Object
+		ExpectedUpdatedQuota   []runtime.Object
+		ExpectedError          string
+	}{
+		"no diff": {
+			ExistingObjectInSuper: []runtime.Object{
+				applyHardToResourceQuota(superResourceQuota("quota-1", superDefaultNSName, "12345", defaultClusterKey), hard1),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				applyHardToResourceQuota(tenantResourceQuota("quota-1", "default", "12345"), hard1),
+			},
+			ExpectedUpdatedQuota: []runtime.Object{},
+		},
+		"diff in hard limits": {
+			ExistingObjectInSuper: []runtime.Object{
+				applyHardToResourceQuota(superResourceQuota("quota-1", superDefaultNSName, "12345", defaultClusterKey), hard1),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				applyHardToResourceQuota(tenantResourceQuota("quota-1", "default", "12345"), hard2),
+			},
+			ExpectedUpdatedQuota: []runtime.Object{
+				applyHardToResourceQuota(superResourceQuota("quota-1", superDefaultNSName, "12345", defaultClusterKey), hard2),
+			},
+		},
+		"diff in hard limits exceeding the cap": {
+			ExistingObjectInSuper: []runtime.Object{
+				applyHardToResourceQuota(superResourceQuota("quota-1", superDefaultNSName, "12345", defaultClusterKey), hard1),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				applyHardToResourceQuota(tenantResourceQuota("quota-1", "default", "12345"), hard3),
+			},
+			ExpectedUpdatedQuota: []runtime.Object{
+				applyHardToResourceQuota(superResourceQuota("quota-1", superDefaultNSName, "12345", defaultClusterKey), capped),
+			},
+		},
+		"capped hard limits": {
+			ExistingObjectInSuper: []runtime.Object{
+				applyHardToResourceQuota(superResourceQuota("quota-1", superDefaultNSName, "12345", defaultClusterKey), capped),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				applyHardToResourceQuota(tenantResourceQuota("quota-1", "default", "12345"), hard3),
+			},
+			ExpectedUpdatedQuota: []runtime.Object{},
+		},
+		"diff exists but uid is wrong": {
+			ExistingObjectInSuper: []runtime.Object{
+				applyHardToResourceQuota(superResourceQuota("quota-1", superDefaultNSName, "12345", defaultClusterKey), hard1),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				applyHardToResourceQuota(tenantResourceQuota("quota-1", "default", "123456"), hard2),
+			},
+			ExpectedUpdatedQuota: []runtime.Object{},
+			ExpectedError:        "delegated UID is different",
+		},
+	}
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			actions, reconcileErr, err := util.RunDownwardSync(NewResourceQuotaController, testTenant, tc.ExistingObjectInSuper, tc.ExistingObjectInTenant, tc.ExistingObjectInTenant[0], nil)
+			if err != nil {
+				t.Errorf("%s: error running downward sync: %v", k, err)
+				return
+			}
+
+			if reconcileErr != nil {
+				if tc.ExpectedError == "" {
+					t.Errorf("expected no error, but got \"%v\"", reconcileErr)
+				} else if !strings.Contains(reconcileErr.Error(), tc.ExpectedError) {
+					t.Errorf("expected error msg \"%s\", but got \"%v\"", tc.ExpectedError, reconcileErr)
+				}
+			} else {
+				if tc.ExpectedError != "" {
+					t.Errorf("expected error msg \"%s\", but got empty", tc.ExpectedError)
+				}
+			}
+
+			if len(tc.ExpectedUpdatedQuota) != len(actions) {
+				t.Errorf("%s: Expected to update resourcequota %#v. Actual actions were: %#v", k, tc.ExpectedUpdatedQuota, actions)
+				return
+			}
+			for i, obj := range tc.ExpectedUpdatedQuota {
+				action := actions[i]
+				if !action.Matches("update", "resourcequotas") {
+					t.Errorf("%s: Unexpected action %s", k, action)
+				}
+				actionObj := action.(core.UpdateAction).GetObject()
+				if !equality.Semantic.DeepEqual(obj, actionObj) {
+					t.Errorf("%s: Expected updated resourcequota is %v, got %v", k, obj, actionObj)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcequota
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+)
+
+// StartUWS starts the upward syncer
+// and blocks until an empty struct is sent to the stop channel.
+func (c *controller) StartUWS(stopCh <-chan struct{}) error {
+	if !cache.WaitForCacheSync(stopCh, c.quotaSynced) {
+		return fmt.Errorf("failed to wait for caches to sync resourcequota")
+	}
+	return c.UpwardController.Start(stopCh)
+}
+
+// BackPopulate populates the status of the super control plane ResourceQuota, i.e., the effective
+// hard limits and the usage enforced by the super control plane, to an annotation of the tenant ResourceQuota.
+func (c *controller) BackPopulate(key string) error {
+	pNamespace, pName, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("invalid resource key %v: %v", key, err))
+		return nil
+	}
+
+	pQuota, err := c.quotaLister.ResourceQuotas(pNamespace).Get(pName)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	clusterName, vNamespace := conversion.GetVirtualOwner(pQuota)
+	if clusterName == "" || vNamespace == "" {
+		return nil
+	}
+
+	tenantClient, err := c.MultiClusterController.GetClusterClient(clusterName)
+	if err != nil {
+		return fmt.Errorf("failed to create client from cluster %s config: %w", clusterName, err)
+	}
+
+	vQuota := &corev1.ResourceQuota{}
+	if err := c.MultiClusterController.Get(clusterName, vNamespace, pName, vQuota); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if pQuota.Annotations[constants.LabelUID] != string(vQuota.UID) {
+		klog.Warningf("BackPopulated pResourceQuota %s delegated UID is different from tenant object", key)
+		return nil
+	}
+
+	updatedQuota := conversion.Equality(c.Config, nil).CheckUWResourceQuotaStatusEquality(pQuota, vQuota)
+	if updatedQuota != nil {
+		_, err = tenantClient.CoreV1().ResourceQuotas(vNamespace).Update(context.TODO(), updatedQuota, metav1.UpdateOptions{})
+		if err != nil {
+			klog.Errorf("failed to update tenant cluster %s resourcequota %s/%s, %v", clusterName, vNamespace, pName, err)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcequota
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	core "k8s.io/client-go/testing"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
+	util "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/test"
+)
+
+var quotaStatus = corev1.ResourceQuotaStatus{
+	Hard: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+	Used: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+}
+
+func applyStatusToResourceQuota(quota *corev1.ResourceQuota, status corev1.ResourceQuotaStatus) *corev1.ResourceQuota {
+	quota.Status = *status.DeepCopy()
+	return quota
+}
+
+func applyStatusAnnotationToResourceQuota(quota *corev1.ResourceQuota, status corev1.ResourceQuotaStatus) *corev1.ResourceQuota {
+	data, _ := json.Marshal(status)
+	if quota.Annotations == nil {
+		quota.Annotations = make(map[string]string)
+	}
+	quota.Annotations[constants.LabelSuperResourceQuotaStatus] = string(data)
+	return quota
+}
+
+func TestUWResourceQuotaUpdate(t *testing.T) {
+	testTenant := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+
+	defaultClusterKey := conversion.ToClusterKey(testTenant)
+	superDefaultNSName := conversion.ToSuperClusterNamespace(defaultClusterKey, "default")
+
+	testcases := map[string]struct {
+		ExistingObjectInSuper  []runtime.Object
+		ExistingObjectInTenant []runtime.Object
+		EnqueuedKey            string
+		ExpectedUpdatedStatus  []corev1.ResourceQuotaStatus
+		ExpectedError          string
+	}{
+		"pResourceQuota exists, vResourceQuota exists with no diff": {
+			ExistingObjectInSuper: []runtime.Object{
+				applyStatusToResourceQuota(superResourceQuota("quota-1", superDefaultNSName, "12345", defaultClusterKey), quotaStatus),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				applyStatusAnnotationToResourceQuota(tenantResourceQuota("quota-1", "default", "12345"), quotaStatus),
+			},
+			EnqueuedKey: superDefaultNSName + "/quota-1",
+		},
+		"pResourceQuota exists, vResourceQuota exists with the tenant status only": {
+			ExistingObjectInSuper: []runtime.Object{
+				applyStatusToResourceQuota(superResourceQuota("quota-1", superDefaultNSName, "12345", defaultClusterKey), quotaStatus),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				applyStatusToResourceQuota(tenantResourceQuota("quota-1", "default", "12345"), quotaStatus),
+			},
+			EnqueuedKey:           superDefaultNSName + "/quota-1",
+			ExpectedUpdatedStatus: []corev1.ResourceQuotaStatus{quotaStatus},
+		},
+		"pResourceQuota exists, but vResourceQuota does not exist": {
+			ExistingObjectInSuper: []runtime.Object{
+				applyStatusToResourceQuota(superResourceQuota("quota-1", superDefaultNSName, "12345", defaultClusterKey), quotaStatus),
+			},
+			ExistingObjectInTenant: []runtime.Object{},
+			EnqueuedKey:            superDefaultNSName + "/quota-1",
+		},
+		"vResourceQuota exists, but pResourceQuota does not exist": {
+			ExistingObjectInSuper: []runtime.Object{},
+			ExistingObjectInTenant: []runtime.Object{
+				tenantResourceQuota("quota-1", "default", "12345"),
+			},
+			EnqueuedKey: superDefaultNSName + "/quota-1",
+		},
+		"pResourceQuota exists, vResourceQuota exists with different status": {
+			ExistingObjectInSuper: []runtime.Object{
+				applyStatusToResourceQuota(superResourceQuota("quota-1", superDefaultNSName, "12345", defaultClusterKey), quotaStatus),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				tenantResourceQuota("quota-1", "default", "12345"),
+			},
+			EnqueuedKey:           superDefaultNSName + "/quota-1",
+			ExpectedUpdatedStatus: []corev1.ResourceQuotaStatus{quotaStatus},
+		},
+		"pResourceQuota exists, vResourceQuota exists with different uid": {
+			ExistingObjectInSuper: []runtime.Object{
+				applyStatusToResourceQuota(superResourceQuota("quota-1", superDefaultNSName, "12345", defaultClusterKey), quotaStatus),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				tenantResourceQuota("quota-1", "default", "123456"),
+			},
+			EnqueuedKey: superDefaultNSName + "/quota-1",
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			actions, reconcileErr, err := util.RunUpwardSync(NewResourceQuotaController, testTenant, tc.ExistingObjectInSuper, tc.ExistingObjectInTenant, tc.EnqueuedKey, nil)
+			if err != nil {
+				t.Errorf("%s: error running upward sync: %v", k, err)
+				return
+			}
+
+			if reconcileErr != nil {
+				if tc.ExpectedError == "" {
+					t.Errorf("expected no error, but got \"%v\"", reconcileErr)
+				} else if !strings.Contains(reconcileErr.Error(), tc.ExpectedError) {
+					t.Errorf("expected error msg \"%s\", but got \"%v\"", tc.ExpectedError, reconcileErr)
+				}
+			} else {
+				if tc.ExpectedError != "" {
+					t.Errorf("expected error msg \"%s\", but got empty", tc.ExpectedError)
+				}
+			}
+
+			var updated []*corev1.ResourceQuota
+			for _, action := range actions {
+				if action.Matches("update", "resourcequotas") {
+					if action.GetSubresource() != "" {
+						t.Errorf("%s: Expected the tenant status to be left to the tenant, got an update of %s", k, action.GetSubresource())
+					}
+					updated = append(updated, action.(core.UpdateAction).GetObject().(*corev1.ResourceQuota))
+				}
+			}
+			if len(updated) != len(tc.ExpectedUpdatedStatus) {
+				t.Errorf("%s: Expected to update %d resourcequota, got %d", k, len(tc.ExpectedUpdatedStatus), len(updated))
+				return
+			}
+			for i, status := range tc.ExpectedUpdatedStatus {
+				if updated[i].Namespace+"/"+updated[i].Name != "default/quota-1" {
+					t.Errorf("%s: Expected default/quota-1 to be updated, got %s/%s", k, updated[i].Namespace, updated[i].Name)
+				}
+				var got corev1.ResourceQuotaStatus
+				if err := json.Unmarshal([]byte(updated[i].Annotations[constants.LabelSuperResourceQuotaStatus]), &got); err != nil {
+					t.Errorf("%s: failed to unmarshal the super status annotation: %v", k, err)
+					continue
+				}
+				if !equality.Semantic.DeepEqual(got, status) {
+					t.Errorf("%s: Expected status %v, got %v", k, status, got)
+				}
+			}
+		})
+	}
+}
@@ -32,6 +32,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/syncstatus"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 )
 
@@ -57,6 +58,7 @@ func (c *controller) PatrollerDo() {
 	var wg sync.WaitGroup
 	numMissMatchedOpaqueSecrets = 0
 	numMissMatchedSASecrets = 0
+	syncstatus.DefaultRecorder.StartScan("Secret")
 
 	for _, clusterName := range clusterNames {
 		wg.Add(1)
@@ -118,6 +120,7 @@ func (c *controller) PatrollerDo() {
 
 	metrics.CheckerMissMatchStats.WithLabelValues("MissMatchedOpaqueSecrets").Set(float64(numMissMatchedOpaqueSecrets))
 	metrics.CheckerMissMatchStats.WithLabelValues("MissMatchedSASecrets").Set(float64(numMissMatchedSASecrets))
+	syncstatus.DefaultRecorder.FinishScan("Secret")
 }
 
 func (c *controller) checkSecretOfTenantCluster(clusterName string) {
@@ -165,6 +168,7 @@ func (c *controller) checkSecretOfTenantCluster(clusterName string) {
 		updatedSecret := conversion.Equality(c.Config, vc).CheckSecretEquality(pSecret, &secretList.Items[i])
 		if updatedSecret != nil {
 			atomic.AddUint64(&numMissMatchedOpaqueSecrets, 1)
+			syncstatus.DefaultRecorder.IncMismatch("Secret", clusterName, vSecret.Namespace, "MissMatchedOpaqueSecrets")
 			klog.Warningf("spec of secret %v/%v diff in super&tenant control plane", vSecret.Namespace, vSecret.Name)
 		}
 	}
@@ -205,6 +209,7 @@ func (c *controller) checkServiceAccountTokenTypeSecretOfTenantCluster(clusterNa
 	updatedSecret := conversion.Equality(c.Config, vc).CheckSecretEquality(secretList[0], vSecret)
 	if updatedSecret != nil {
 		atomic.AddUint64(&numMissMatchedSASecrets, 1)
+		syncstatus.DefaultRecorder.IncMismatch("Secret", clusterName, vSecret.Namespace, "MissMatchedSASecrets")
 		klog.Warningf("spec of service account token type secret %v/%v diff in super&tenant control plane", vSecret.Namespace, vSecret.Name)
 	}
 }
@@ -32,6 +32,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/patrol/differ"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/syncstatus"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util"
 )
 
@@ -59,6 +60,7 @@ func (c *controller) PatrollerDo() {
 	numSpecMissMatchedServices = 0
 	numStatusMissMatchedServices = 0
 	numUWMetaMissMatchedServices = 0
+	syncstatus.DefaultRecorder.StartScan("Service")
 
 	pList, err := c.serviceLister.List(util.GetSuperClusterListerLabelsSelector())
 	if err != nil {
@@ -115,6 +117,7 @@ func (c *controller) PatrollerDo() {
 		updatedService := conversion.Equality(c.Config, vc).CheckServiceEquality(p, v)
 		if updatedService != nil {
 			atomic.AddUint64(&numSpecMissMatchedServices, 1)
+			syncstatus.DefaultRecorder.IncMismatch("Service", vObj.GetOwnerCluster(), vObj.GetNamespace(), "SpecMissMatchedServices")
 			klog.Warningf("spec of service %s diff in super&tenant control plane", pObj.Key)
 			d.OnAdd(vObj)
 			return
@@ -125,12 +128,14 @@ func (c *controller) PatrollerDo() {
 			updatedMeta := conversion.Equality(c.Config, vc).CheckUWObjectMetaEquality(&p.ObjectMeta, &v.ObjectMeta)
 			if updatedMeta != nil {
 				atomic.AddUint64(&numUWMetaMissMatchedServices, 1)
+				syncstatus.DefaultRecorder.IncMismatch("Service", vObj.GetOwnerCluster(), vObj.GetNamespace(), "UWMetaMissMatchedServices")
 				enqueue = true
 				klog.Warningf("UWObjectMeta of service %s diff in super&tenant control plane", pObj.Key)
 			}
 			if !equality.Semantic.DeepEqual(p.Status, v.Status) {
 				enqueue = true
 				atomic.AddUint64(&numStatusMissMatchedServices, 1)
+				syncstatus.DefaultRecorder.IncMismatch("Service", vObj.GetOwnerCluster(), vObj.GetNamespace(), "StatusMissMatchedServices")
 				klog.Warningf("Status of service %s diff in super&tenant control plane", pObj)
 			}
 			if enqueue {
@@ -155,4 +160,5 @@ func (c *controller) PatrollerDo() {
 	metrics.CheckerMissMatchStats.WithLabelValues("SpecMissMatchedServices").Set(float64(numSpecMissMatchedServices))
 	metrics.CheckerMissMatchStats.WithLabelValues("StatusMissMatchedServices").Set(float64(numStatusMissMatchedServices))
 	metrics.CheckerMissMatchStats.WithLabelValues("UWMetaMissMatchedServices").Set(float64(numUWMetaMissMatchedServices))
+	syncstatus.DefaultRecorder.FinishScan("Service")
 }
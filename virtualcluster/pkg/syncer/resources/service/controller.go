@@ -52,8 +52,9 @@ type controller struct {
 	// super control plane service client
 	serviceClient v1core.ServicesGetter
 	// super control plane informer/listers/synced functions
-	serviceLister listersv1.ServiceLister
-	serviceSynced cache.InformerSynced
+	serviceLister  listersv1.ServiceLister
+	serviceIndexer cache.Indexer
+	serviceSynced  cache.InformerSynced
 }
 
 func NewServiceController(config *config.SyncerConfiguration,
@@ -76,6 +77,10 @@ func NewServiceController(config *config.SyncerConfiguration,
 	}
 
 	c.serviceLister = informer.Core().V1().Services().Lister()
+	if err := informer.Core().V1().Services().Informer().AddIndexers(cache.Indexers{nodePortIndex: nodePortIndexFunc}); err != nil {
+		return nil, err
+	}
+	c.serviceIndexer = informer.Core().V1().Services().Informer().GetIndexer()
 	if options.IsFake {
 		c.serviceSynced = func() bool { return true }
 	} else {
@@ -125,7 +130,8 @@ func NewServiceController(config *config.SyncerConfiguration,
 }
 
 func isBackPopulateService(svc *corev1.Service) bool {
-	return svc.Spec.Type == corev1.ServiceTypeLoadBalancer || svc.Spec.Type == corev1.ServiceTypeClusterIP
+	return svc.Spec.Type == corev1.ServiceTypeLoadBalancer || svc.Spec.Type == corev1.ServiceTypeClusterIP ||
+		svc.Spec.Type == corev1.ServiceTypeNodePort
 }
 
 func (c *controller) enqueueService(obj interface{}) {
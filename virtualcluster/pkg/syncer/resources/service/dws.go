@@ -92,6 +92,21 @@ func (c *controller) reconcileServiceCreate(clusterName, targetNamespace, reques
 	pService := newObj.(*corev1.Service)
 	conversion.VC(nil, "").Service(pService).Mutate(service)
 
+	vc, err := util.GetVirtualClusterObject(c.MultiClusterController, clusterName)
+	if err != nil {
+		return err
+	}
+	allocator, err := c.newNodePortAllocator(vc)
+	if err != nil {
+		return err
+	}
+	if allocator != nil {
+		clearNodePorts(pService)
+		if err := c.assignNodePorts(clusterName, allocator, pService, service); err != nil {
+			return err
+		}
+	}
+
 	pService, err = c.serviceClient.Services(targetNamespace).Create(context.TODO(), pService, metav1.CreateOptions{})
 	if apierrors.IsAlreadyExists(err) {
 		if pService.Annotations[constants.LabelUID] == requestUID {
@@ -100,9 +115,40 @@ func (c *controller) reconcileServiceCreate(clusterName, targetNamespace, reques
 		}
 		return fmt.Errorf("pService %s/%s exists but its delegated object UID is different", targetNamespace, pService.Name)
 	}
+	if allocator != nil && isNodePortConflict(err) {
+		// the node ports may be taken by services created after the lister was synced.
+		c.serviceEventf(clusterName, service, corev1.EventTypeWarning, reasonNodePortConflict, "Error creating service in super cluster: %v", err)
+	}
 	return err
 }
 
+// assignNodePorts assigns the node ports of pService with the allocator and
+// reports the result to the tenant.
+func (c *controller) assignNodePorts(clusterName string, allocator *nodePortAllocator, pService, vService *corev1.Service) error {
+	if err := allocator.assignNodePorts(pService, vService); err != nil {
+		c.serviceEventf(clusterName, vService, corev1.EventTypeWarning, reasonNodePortConflict, "Error allocating node ports: %v", err)
+		return err
+	}
+	for reason, msgs := range allocator.events {
+		for _, msg := range msgs {
+			c.serviceEventf(clusterName, vService, corev1.EventTypeNormal, reason, msg)
+		}
+	}
+	return nil
+}
+
+func (c *controller) serviceEventf(clusterName string, vService *corev1.Service, eventType, reason, messageFmt string, args ...interface{}) {
+	err := c.MultiClusterController.Eventf(clusterName, &corev1.ObjectReference{
+		Kind:      "Service",
+		Name:      vService.Name,
+		Namespace: vService.Namespace,
+		UID:       vService.UID,
+	}, eventType, reason, messageFmt, args...)
+	if err != nil {
+		klog.Warningf("failed to record event for service %s/%s of cluster %s: %v", vService.Namespace, vService.Name, clusterName, err)
+	}
+}
+
 func (c *controller) reconcileServiceUpdate(clusterName, targetNamespace, requestUID string, pService, vService *corev1.Service) error {
 	if pService.Annotations[constants.LabelUID] != requestUID {
 		return fmt.Errorf("pService %s/%s delegated UID is different from updated object", targetNamespace, pService.Name)
@@ -114,7 +160,21 @@ func (c *controller) reconcileServiceUpdate(clusterName, targetNamespace, reques
 	}
 	updated := conversion.Equality(c.Config, vc).CheckServiceEquality(pService, vService)
 	if updated != nil {
+		// the update may add ports or change the type to NodePort or LoadBalancer,
+		// whose node ports have to be in the range of the virtual cluster as well.
+		allocator, err := c.newNodePortAllocator(vc)
+		if err != nil {
+			return err
+		}
+		if allocator != nil {
+			if err := c.assignNodePorts(clusterName, allocator, updated, vService); err != nil {
+				return err
+			}
+		}
 		_, err = c.serviceClient.Services(targetNamespace).Update(context.TODO(), updated, metav1.UpdateOptions{})
+		if allocator != nil && isNodePortConflict(err) {
+			c.serviceEventf(clusterName, vService, corev1.EventTypeWarning, reasonNodePortConflict, "Error updating service in super cluster: %v", err)
+		}
 		if err != nil {
 			return err
 		}
@@ -22,9 +22,11 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	core "k8s.io/client-go/testing"
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
@@ -165,6 +167,249 @@ func TestDWServiceCreation(t *testing.T) {
 	}
 }
 
+func nodePortService(svc *corev1.Service, nodePorts ...int32) *corev1.Service {
+	svc.Spec.Type = corev1.ServiceTypeNodePort
+	for i, p := range nodePorts {
+		svc.Spec.Ports = append(svc.Spec.Ports, corev1.ServicePort{Port: int32(80 + i), NodePort: p})
+	}
+	return svc
+}
+
+func TestDWServiceNodePortAllocation(t *testing.T) {
+	testTenant := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Spec: v1alpha1.VirtualClusterSpec{
+			NodePortRange: "30100-30102",
+		},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+
+	defaultClusterKey := conversion.ToClusterKey(testTenant)
+	superDefaultNSName := conversion.ToSuperClusterNamespace(defaultClusterKey, "default")
+
+	testcases := map[string]struct {
+		NodePortRange          string
+		ExistingObjectInSuper  []runtime.Object
+		ExistingObjectInTenant *corev1.Service
+
+		ExpectedNodePorts []int32
+		ExpectedError     string
+	}{
+		"no node port range": {
+			ExistingObjectInTenant: nodePortService(tenantService("svc-1", "default", "12345"), 30101),
+			ExpectedNodePorts:      []int32{0},
+		},
+		"requested ports in range": {
+			NodePortRange:          "30100-30102",
+			ExistingObjectInTenant: nodePortService(tenantService("svc-1", "default", "12345"), 30101, 30100),
+			ExpectedNodePorts:      []int32{30101, 30100},
+		},
+		"requested ports out of range": {
+			NodePortRange: "30100-30102",
+			ExistingObjectInSuper: []runtime.Object{
+				nodePortService(superService("svc-2", superDefaultNSName, "23456", defaultClusterKey), 30100),
+			},
+			ExistingObjectInTenant: nodePortService(tenantService("svc-1", "default", "12345"), 31000, 0),
+			ExpectedNodePorts:      []int32{30101, 30102},
+		},
+		"requested port is allocated": {
+			NodePortRange: "30100-30102",
+			ExistingObjectInSuper: []runtime.Object{
+				nodePortService(superService("svc-2", superDefaultNSName, "23456", defaultClusterKey), 30101),
+			},
+			ExistingObjectInTenant: nodePortService(tenantService("svc-1", "default", "12345"), 30101),
+			ExpectedError:          "node port 30101 is already allocated",
+		},
+		"node port range is exhausted": {
+			NodePortRange:          "30100-30101",
+			ExistingObjectInTenant: nodePortService(tenantService("svc-1", "default", "12345"), 0, 0, 0),
+			ExpectedError:          "is exhausted",
+		},
+		"invalid node port range": {
+			NodePortRange:          "30100-",
+			ExistingObjectInTenant: nodePortService(tenantService("svc-1", "default", "12345"), 30100),
+			ExpectedError:          "invalid node port range",
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			tenant := testTenant.DeepCopy()
+			tenant.Spec.NodePortRange = tc.NodePortRange
+			actions, reconcileErr, err := util.RunDownwardSync(NewServiceController,
+				tenant,
+				tc.ExistingObjectInSuper,
+				[]runtime.Object{tc.ExistingObjectInTenant},
+				tc.ExistingObjectInTenant,
+				nil)
+			if err != nil {
+				t.Errorf("%s: error running downward sync: %v", k, err)
+				return
+			}
+
+			if reconcileErr != nil {
+				if tc.ExpectedError == "" {
+					t.Errorf("expected no error, but got \"%v\"", reconcileErr)
+				} else if !strings.Contains(reconcileErr.Error(), tc.ExpectedError) {
+					t.Errorf("expected error msg \"%s\", but got \"%v\"", tc.ExpectedError, reconcileErr)
+				}
+				return
+			}
+			if tc.ExpectedError != "" {
+				t.Errorf("expected error msg \"%s\", but got empty", tc.ExpectedError)
+				return
+			}
+
+			if len(actions) != 1 || !actions[0].Matches("create", "services") {
+				t.Errorf("%s: expected to create service, got actions %#v", k, actions)
+				return
+			}
+			createdSVC := actions[0].(core.CreateAction).GetObject().(*corev1.Service)
+			var nodePorts []int32
+			for _, p := range createdSVC.Spec.Ports {
+				nodePorts = append(nodePorts, p.NodePort)
+			}
+			if !equality.Semantic.DeepEqual(nodePorts, tc.ExpectedNodePorts) {
+				t.Errorf("%s: expected node ports %v, got %v", k, tc.ExpectedNodePorts, nodePorts)
+			}
+		})
+	}
+}
+
+func TestDWServiceNodePortUpdate(t *testing.T) {
+	testTenant := &v1alpha1.VirtualCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "tenant-1",
+			UID:       "7374a172-c35d-45b1-9c8e-bf5c5b614937",
+		},
+		Status: v1alpha1.VirtualClusterStatus{
+			Phase: v1alpha1.ClusterRunning,
+		},
+	}
+
+	defaultClusterKey := conversion.ToClusterKey(testTenant)
+	superDefaultNSName := conversion.ToSuperClusterNamespace(defaultClusterKey, "default")
+
+	clusterIPService := func(svc *corev1.Service) *corev1.Service {
+		svc.Spec.Type = corev1.ServiceTypeClusterIP
+		svc.Spec.Ports = []corev1.ServicePort{{Port: 80}}
+		return svc
+	}
+
+	testcases := map[string]struct {
+		NodePortRange          string
+		ExistingObjectInSuper  *corev1.Service
+		ExistingObjectInTenant *corev1.Service
+
+		ExpectedNodePorts []int32
+		ExpectedError     string
+	}{
+		"type changed to NodePort": {
+			NodePortRange:          "30100-30102",
+			ExistingObjectInSuper:  clusterIPService(superService("svc-1", superDefaultNSName, "12345", defaultClusterKey)),
+			ExistingObjectInTenant: nodePortService(tenantService("svc-1", "default", "12345"), 31000),
+			ExpectedNodePorts:      []int32{30100},
+		},
+		"port added": {
+			NodePortRange:          "30100-30102",
+			ExistingObjectInSuper:  nodePortService(superService("svc-1", superDefaultNSName, "12345", defaultClusterKey), 30100),
+			ExistingObjectInTenant: nodePortService(tenantService("svc-1", "default", "12345"), 30100, 0),
+			ExpectedNodePorts:      []int32{30100, 30101},
+		},
+		"node port range is exhausted": {
+			NodePortRange:          "30100-30100",
+			ExistingObjectInSuper:  nodePortService(superService("svc-1", superDefaultNSName, "12345", defaultClusterKey), 30100),
+			ExistingObjectInTenant: nodePortService(tenantService("svc-1", "default", "12345"), 30100, 0),
+			ExpectedError:          "is exhausted",
+		},
+		"no node port range": {
+			ExistingObjectInSuper:  clusterIPService(superService("svc-1", superDefaultNSName, "12345", defaultClusterKey)),
+			ExistingObjectInTenant: nodePortService(tenantService("svc-1", "default", "12345"), 31000),
+			ExpectedNodePorts:      []int32{0},
+		},
+	}
+
+	for k, tc := range testcases {
+		t.Run(k, func(t *testing.T) {
+			tenant := testTenant.DeepCopy()
+			tenant.Spec.NodePortRange = tc.NodePortRange
+			actions, reconcileErr, err := util.RunDownwardSync(NewServiceController,
+				tenant,
+				[]runtime.Object{tc.ExistingObjectInSuper},
+				[]runtime.Object{tc.ExistingObjectInTenant},
+				tc.ExistingObjectInTenant,
+				nil)
+			if err != nil {
+				t.Errorf("%s: error running downward sync: %v", k, err)
+				return
+			}
+
+			if reconcileErr != nil {
+				if tc.ExpectedError == "" {
+					t.Errorf("expected no error, but got \"%v\"", reconcileErr)
+				} else if !strings.Contains(reconcileErr.Error(), tc.ExpectedError) {
+					t.Errorf("expected error msg \"%s\", but got \"%v\"", tc.ExpectedError, reconcileErr)
+				}
+				return
+			}
+			if tc.ExpectedError != "" {
+				t.Errorf("expected error msg \"%s\", but got empty", tc.ExpectedError)
+				return
+			}
+
+			if len(actions) != 1 || !actions[0].Matches("update", "services") {
+				t.Errorf("%s: expected to update service, got actions %#v", k, actions)
+				return
+			}
+			updatedSVC := actions[0].(core.UpdateAction).GetObject().(*corev1.Service)
+			var nodePorts []int32
+			for _, p := range updatedSVC.Spec.Ports {
+				nodePorts = append(nodePorts, p.NodePort)
+			}
+			if !equality.Semantic.DeepEqual(nodePorts, tc.ExpectedNodePorts) {
+				t.Errorf("%s: expected node ports %v, got %v", k, tc.ExpectedNodePorts, nodePorts)
+			}
+		})
+	}
+}
+
+func TestIsNodePortConflict(t *testing.T) {
+	gk := corev1.SchemeGroupVersion.WithKind("Service").GroupKind()
+	testcases := map[string]struct {
+		err      error
+		expected bool
+	}{
+		"node port allocated": {
+			err:      apierrors.NewInvalid(gk, "svc-1", field.ErrorList{field.Invalid(field.NewPath("spec", "ports").Index(0).Child("nodePort"), 30100, "provided port is already allocated")}),
+			expected: true,
+		},
+		"health check node port allocated": {
+			err:      apierrors.NewInvalid(gk, "svc-1", field.ErrorList{field.Invalid(field.NewPath("spec", "healthCheckNodePort"), 30100, "provided port is already allocated")}),
+			expected: true,
+		},
+		"other invalid field": {
+			err:      apierrors.NewInvalid(gk, "svc-1", field.ErrorList{field.Invalid(field.NewPath("spec", "clusterIP"), "10.0.0.1", "provided IP is already allocated")}),
+			expected: false,
+		},
+		"not invalid": {
+			err:      apierrors.NewAlreadyExists(corev1.Resource("services"), "svc-1"),
+			expected: false,
+		},
+	}
+	for k, tc := range testcases {
+		if got := isNodePortConflict(tc.err); got != tc.expected {
+			t.Errorf("%s: expected %v, got %v", k, tc.expected, got)
+		}
+	}
+}
+
 func TestDWServiceDeletion(t *testing.T) {
 	testTenant := &v1alpha1.VirtualCluster{
 		ObjectMeta: metav1.ObjectMeta{
@@ -0,0 +1,220 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/apis/tenancy/v1alpha1"
+)
+
+const (
+	reasonNodePortConflict   = "NodePortConflict"
+	reasonNodePortReassigned = "NodePortReassigned"
+
+	// nodePortIndex indexes the super cluster services by the node ports they use.
+	nodePortIndex = "nodePort"
+)
+
+// nodePortIndexFunc returns the node ports and the health check node port of a service.
+func nodePortIndexFunc(obj interface{}) ([]string, error) {
+	svc, ok := obj.(*corev1.Service)
+	if !ok {
+		return nil, nil
+	}
+	var ports []string
+	for _, p := range svc.Spec.Ports {
+		if p.NodePort != 0 {
+			ports = append(ports, strconv.Itoa(int(p.NodePort)))
+		}
+	}
+	if svc.Spec.HealthCheckNodePort != 0 {
+		ports = append(ports, strconv.Itoa(int(svc.Spec.HealthCheckNodePort)))
+	}
+	return ports, nil
+}
+
+// nodePortAllocator assigns the node ports of a super cluster service within the
+// node port range reserved for the virtual cluster.
+type nodePortAllocator struct {
+	portRange *utilnet.PortRange
+	// indexer holds the super cluster services indexed by nodePortIndex.
+	indexer cache.Indexer
+	// assigned are the ports taken by the service being allocated.
+	assigned sets.Int
+	// events are the messages to be reported to the tenant, keyed by reason.
+	events map[string][]string
+}
+
+func (c *controller) newNodePortAllocator(vc *v1alpha1.VirtualCluster) (*nodePortAllocator, error) {
+	if vc == nil || vc.Spec.NodePortRange == "" {
+		return nil, nil
+	}
+	pr, err := utilnet.ParsePortRange(vc.Spec.NodePortRange)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node port range %q of cluster %s: %v", vc.Spec.NodePortRange, vc.Name, err)
+	}
+	return &nodePortAllocator{portRange: pr, indexer: c.serviceIndexer, assigned: sets.NewInt(), events: make(map[string][]string)}, nil
+}
+
+func (a *nodePortAllocator) isUsed(port int) (bool, error) {
+	if a.assigned.Has(port) {
+		return true, nil
+	}
+	keys, err := a.indexer.IndexKeys(nodePortIndex, strconv.Itoa(port))
+	if err != nil {
+		return false, err
+	}
+	return len(keys) > 0, nil
+}
+
+// allocate honors the requested node port if it is within the range and returns
+// a free port in the range otherwise.
+func (a *nodePortAllocator) allocate(requested int32) (int32, error) {
+	if requested != 0 && a.portRange.Contains(int(requested)) {
+		used, err := a.isUsed(int(requested))
+		if err != nil {
+			return 0, err
+		}
+		if used {
+			return 0, fmt.Errorf("node port %d is already allocated", requested)
+		}
+		a.assigned.Insert(int(requested))
+		return requested, nil
+	}
+	for p := a.portRange.Base; p < a.portRange.Base+a.portRange.Size; p++ {
+		used, err := a.isUsed(p)
+		if err != nil {
+			return 0, err
+		}
+		if !used {
+			a.assigned.Insert(p)
+			if requested != 0 {
+				a.events[reasonNodePortReassigned] = append(a.events[reasonNodePortReassigned],
+					fmt.Sprintf("node port %d is out of range %s, use %d instead", requested, a.portRange, p))
+			}
+			return int32(p), nil
+		}
+	}
+	return 0, fmt.Errorf("node port range %s is exhausted", a.portRange)
+}
+
+// isNodePortConflict returns true if the super cluster rejected the service because
+// one of its node ports is invalid, e.g., allocated by another service.
+func isNodePortConflict(err error) bool {
+	if !apierrors.IsInvalid(err) {
+		return false
+	}
+	status, ok := err.(apierrors.APIStatus)
+	if !ok || status.Status().Details == nil {
+		return false
+	}
+	for _, cause := range status.Status().Details.Causes {
+		if cause.Type != metav1.CauseTypeFieldValueInvalid {
+			continue
+		}
+		if strings.HasSuffix(cause.Field, ".nodePort") || cause.Field == "spec.healthCheckNodePort" {
+			return true
+		}
+	}
+	return false
+}
+
+func needsNodePorts(svc *corev1.Service) bool {
+	switch svc.Spec.Type {
+	case corev1.ServiceTypeNodePort:
+		return true
+	case corev1.ServiceTypeLoadBalancer:
+		return svc.Spec.AllocateLoadBalancerNodePorts == nil || *svc.Spec.AllocateLoadBalancerNodePorts
+	default:
+		return false
+	}
+}
+
+func needsHealthCheckNodePort(svc *corev1.Service) bool {
+	return svc.Spec.Type == corev1.ServiceTypeLoadBalancer &&
+		svc.Spec.ExternalTrafficPolicy == corev1.ServiceExternalTrafficPolicyTypeLocal
+}
+
+// assignNodePorts sets the node ports of pService that are not assigned yet based
+// on the ports requested in vService, e.g., all the ports of a new service, the
+// ports added by an update or the ports of a service whose type is changed to
+// NodePort or LoadBalancer.
+func (a *nodePortAllocator) assignNodePorts(pService, vService *corev1.Service) error {
+	if needsNodePorts(pService) {
+		for i := range pService.Spec.Ports {
+			if pService.Spec.Ports[i].NodePort != 0 {
+				continue
+			}
+			var requested int32
+			if i < len(vService.Spec.Ports) {
+				requested = vService.Spec.Ports[i].NodePort
+			}
+			port, err := a.allocate(requested)
+			if err != nil {
+				return err
+			}
+			pService.Spec.Ports[i].NodePort = port
+		}
+	}
+	if needsHealthCheckNodePort(pService) && pService.Spec.HealthCheckNodePort == 0 {
+		port, err := a.allocate(vService.Spec.HealthCheckNodePort)
+		if err != nil {
+			return err
+		}
+		pService.Spec.HealthCheckNodePort = port
+	}
+	return nil
+}
+
+// clearNodePorts resets the node ports of svc, so that they are all assigned
+// by the allocator.
+func clearNodePorts(svc *corev1.Service) {
+	for i := range svc.Spec.Ports {
+		svc.Spec.Ports[i].NodePort = 0
+	}
+	svc.Spec.HealthCheckNodePort = 0
+}
+
+// backPopulateNodePorts copies the node ports allocated in the super cluster to vService.
+// It returns true if vService is changed.
+func backPopulateNodePorts(pService, vService *corev1.Service) bool {
+	changed := false
+	for i := range vService.Spec.Ports {
+		if i >= len(pService.Spec.Ports) {
+			break
+		}
+		if pService.Spec.Ports[i].NodePort != 0 && vService.Spec.Ports[i].NodePort != pService.Spec.Ports[i].NodePort {
+			vService.Spec.Ports[i].NodePort = pService.Spec.Ports[i].NodePort
+			changed = true
+		}
+	}
+	if pService.Spec.HealthCheckNodePort != 0 && vService.Spec.HealthCheckNodePort != pService.Spec.HealthCheckNodePort {
+		vService.Spec.HealthCheckNodePort = pService.Spec.HealthCheckNodePort
+		changed = true
+	}
+	return changed
+}
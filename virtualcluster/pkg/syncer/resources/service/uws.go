@@ -112,8 +112,21 @@ func (c *controller) BackPopulate(key string) error {
 			// Add clusterIP to ExternalIPs if it hasn't been set on purpose
 			newService.Spec.ExternalIPs = []string{updatedMeta.Annotations[constants.LabelSuperClusterIP]}
 		}
+	}
+
+	// The node ports are allocated in super control plane, let the tenant see the actual ones.
+	if newService != nil {
+		backPopulateNodePorts(pService, newService)
+	} else if candidate := vService.DeepCopy(); backPopulateNodePorts(pService, candidate) {
+		newService = candidate
+	}
+
+	if newService != nil {
 		if _, err = tenantClient.CoreV1().Services(vService.Namespace).Update(context.TODO(), newService, metav1.UpdateOptions{}); err != nil {
-			return fmt.Errorf("failed to back populate service %s/%s meta update for cluster %s: %v", vService.Namespace, vService.Name, clusterName, err)
+			if isNodePortConflict(err) {
+				c.serviceEventf(clusterName, vService, corev1.EventTypeWarning, reasonNodePortConflict, "Error back populating node ports: %v", err)
+			}
+			return fmt.Errorf("failed to back populate service %s/%s update for cluster %s: %v", vService.Namespace, vService.Name, clusterName, err)
 		}
 	}
 
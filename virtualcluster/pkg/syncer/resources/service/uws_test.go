@@ -119,6 +119,18 @@ func TestUWService(t *testing.T) {
 				applyLoadBalancerToService(tenantService("svc", "default", "12345"), "1.1.1.1"),
 			},
 		},
+		"pService exists, vService exists with different node ports": {
+			ExistingObjectInSuper: []runtime.Object{
+				nodePortService(superService("svc", superDefaultNSName, "12345", defaultClusterKey), 30100),
+			},
+			ExistingObjectInTenant: []runtime.Object{
+				nodePortService(tenantService("svc", "default", "12345"), 31000),
+			},
+			EnqueuedKey: superDefaultNSName + "/svc",
+			ExpectedUpdatedObject: []runtime.Object{
+				nodePortService(tenantService("svc", "default", "12345"), 30100),
+			},
+		},
 	}
 
 	for k, tc := range testcases {
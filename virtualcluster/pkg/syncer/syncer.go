@@ -50,6 +50,7 @@ import (
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/conversion"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/manager"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/syncstatus"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/cluster"
 	utilconst "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
@@ -79,6 +80,8 @@ type Syncer struct {
 	// clusterSet holds the cluster collection in which cluster is running.
 	mu         sync.Mutex
 	clusterSet map[string]mc.ClusterInterface
+	// pkiRevisions holds the pki revision of the admin kubeconfig each cluster in clusterSet is connected with.
+	pkiRevisions map[string]string
 }
 
 type virtualclusterGetter struct {
@@ -111,13 +114,14 @@ func New(
 	recorder record.EventRecorder,
 ) (*Syncer, error) {
 	syncer := &Syncer{
-		config:      config,
-		metaClient:  metaClusterClient,
-		superClient: superClusterClient,
-		recorder:    recorder,
-		queue:       workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "virtual_cluster"),
-		workers:     constants.UwsControllerWorkerLow,
-		clusterSet:  make(map[string]mc.ClusterInterface),
+		config:       config,
+		metaClient:   metaClusterClient,
+		superClient:  superClusterClient,
+		recorder:     recorder,
+		queue:        workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "virtual_cluster"),
+		workers:      constants.UwsControllerWorkerLow,
+		clusterSet:   make(map[string]mc.ClusterInterface),
+		pkiRevisions: make(map[string]string),
 	}
 
 	// Handle VirtualCluster add&delete
@@ -162,10 +166,15 @@ func New(
 			return nil, err
 		}
 
-		s, ok := instance.(manager.ResourceSyncer)
-		if ok {
+		switch s := instance.(type) {
+		case manager.ResourceSyncer:
 			multiClusterControllerManager.AddResourceSyncer(s)
-		} else {
+		case []manager.ResourceSyncer:
+			// a plugin may provide a syncer for each of the resources it is configured with.
+			for _, each := range s {
+				multiClusterControllerManager.AddResourceSyncer(each)
+			}
+		default:
 			klog.Warningf("unrecognized plugin %q", p.ID)
 		}
 	}
@@ -233,6 +242,9 @@ func (s *Syncer) Run(stopChan <-chan struct{}) {
 		}
 	}()
 	go wait.Until(s.healthPatrol, 1*time.Minute, stopChan)
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.TenantSyncerStatus) {
+		go wait.Until(s.publishSyncerStatus, 1*time.Minute, stopChan)
+	}
 	go func() {
 		defer utilruntime.HandleCrash()
 		defer s.queue.ShutDown()
@@ -324,6 +336,7 @@ func (s *Syncer) removeCluster(key string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	delete(s.pkiRevisions, key)
 	vc, exist := s.clusterSet[key]
 	if !exist {
 		// already deleted
@@ -339,6 +352,7 @@ func (s *Syncer) removeCluster(key string) {
 	for _, clusterChangeListener := range listener.Listeners {
 		clusterChangeListener.RemoveCluster(vc)
 	}
+	syncstatus.DefaultRecorder.RemoveCluster(vc.GetClusterName())
 
 	delete(s.clusterSet, key)
 }
@@ -347,12 +361,19 @@ func (s *Syncer) removeCluster(key string) {
 func (s *Syncer) addCluster(key string, vc *v1alpha1.VirtualCluster) error {
 	klog.Infof("Add cluster %s", key)
 
+	pkiRevision := vc.GetAnnotations()[constants.LabelPKIRevision]
 	s.mu.Lock()
-	if _, exist := s.clusterSet[key]; exist {
-		s.mu.Unlock()
+	_, exist := s.clusterSet[key]
+	rotated := exist && s.pkiRevisions[key] != pkiRevision
+	s.mu.Unlock()
+	if exist && !rotated {
 		return nil
 	}
-	s.mu.Unlock()
+	if rotated {
+		// the certificates are rotated, reconnect with the new admin kubeconfig.
+		klog.Infof("pki of cluster %s is rotated, reconnecting", key)
+		s.removeCluster(key)
+	}
 
 	clusterName := conversion.ToClusterKey(vc)
 
@@ -372,6 +393,7 @@ func (s *Syncer) addCluster(key string, vc *v1alpha1.VirtualCluster) error {
 
 	s.mu.Lock()
 	s.clusterSet[key] = tenantCluster
+	s.pkiRevisions[key] = pkiRevision
 	s.mu.Unlock()
 
 	go s.runCluster(tenantCluster, vc)
@@ -461,3 +483,27 @@ func (s *Syncer) checkTenantClusterHealth(cluster mc.ClusterInterface) {
 		UID:       types.UID(uid),
 	}, corev1.EventTypeWarning, "ClusterUnHealth", "VirtualCluster %v unhealth: %v", cluster.GetClusterName(), discoveryErr.Error())
 }
+
+// publishSyncerStatus publishes the sync failures of each tenant namespace inside the tenant control planes.
+func (s *Syncer) publishSyncerStatus() {
+	s.mu.Lock()
+	clusters := make([]mc.ClusterInterface, 0, len(s.clusterSet))
+	for _, c := range s.clusterSet {
+		if c != nil {
+			clusters = append(clusters, c)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, cluster := range clusters {
+		cs, err := cluster.GetClientSet()
+		if err != nil {
+			klog.Warningf("[publishSyncerStatus] fails to get cluster %v clientset: %v", cluster.GetClusterName(), err)
+			continue
+		}
+		statuses := syncstatus.DefaultRecorder.ClusterStatus(cluster.GetClusterName())
+		if err := syncstatus.Publish(context.TODO(), cs, statuses); err != nil {
+			klog.Warningf("[publishSyncerStatus] fails to publish syncer status to cluster %v: %v", cluster.GetClusterName(), err)
+		}
+	}
+}
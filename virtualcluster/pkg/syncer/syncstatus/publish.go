@@ -0,0 +1,132 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncstatus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	clientset "k8s.io/client-go/kubernetes"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+const (
+	PendingKey    = "pending"
+	DroppedKey    = "dropped"
+	RejectedKey   = "rejected"
+	MismatchesKey = "mismatches"
+)
+
+// Publish writes the summary of each tenant namespace to the ConfigMap named after the namespace
+// in TenantSyncerStatusNamespace, and removes the ConfigMaps of the namespaces that have no sync
+// failures anymore.
+func Publish(ctx context.Context, client clientset.Interface, statuses map[string]*NamespaceStatus) error {
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: constants.TenantSyncerStatusNamespace,
+		},
+	}
+	if _, err := client.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create namespace %s: %v", constants.TenantSyncerStatusNamespace, err)
+	}
+
+	cmClient := client.CoreV1().ConfigMaps(constants.TenantSyncerStatusNamespace)
+	cmList, err := cmClient.List(ctx, metav1.ListOptions{LabelSelector: constants.LabelSyncerStatus + "=true"})
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]*corev1.ConfigMap, len(cmList.Items))
+	for i := range cmList.Items {
+		existing[cmList.Items[i].Name] = &cmList.Items[i]
+	}
+
+	var errs []error
+	for namespace, status := range statuses {
+		data, err := status.toConfigMapData()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		cm, ok := existing[namespace]
+		delete(existing, namespace)
+		if !ok {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      namespace,
+					Namespace: constants.TenantSyncerStatusNamespace,
+					Labels: map[string]string{
+						constants.LabelSyncerStatus: "true",
+					},
+				},
+				Data: data,
+			}
+			if _, err := cmClient.Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if apiequality.Semantic.DeepEqual(cm.Data, data) {
+			continue
+		}
+		cm.Data = data
+		if _, err := cmClient.Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// the namespaces that have no sync failures anymore
+	for name := range existing {
+		if err := cmClient.Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (s *NamespaceStatus) toConfigMapData() (map[string]string, error) {
+	data := make(map[string]string)
+	for key, value := range map[string]interface{}{
+		PendingKey:    s.Pending,
+		DroppedKey:    s.Dropped,
+		RejectedKey:   s.Rejected,
+		MismatchesKey: s.Mismatches,
+	} {
+		switch v := value.(type) {
+		case []Object:
+			if len(v) == 0 {
+				continue
+			}
+		case map[string]int:
+			if len(v) == 0 {
+				continue
+			}
+		}
+		b, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		data[key] = string(b)
+	}
+	return data, nil
+}
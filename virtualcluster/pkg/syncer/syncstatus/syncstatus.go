@@ -0,0 +1,328 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package syncstatus keeps track of the tenant objects the syncer fails to sync, so that the
+// tenants can see a per-namespace summary of the sync failures inside their virtual clusters.
+package syncstatus
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
+)
+
+// maxObjectsPerList bounds each object list in a namespace summary, so that the summary
+// always fits in a ConfigMap.
+const maxObjectsPerList = 50
+
+// reasonSyncFailed is the reason of the sync failures that are not caused by an API error.
+const reasonSyncFailed = "SyncFailed"
+
+type state int
+
+const (
+	// statePending means the dws request failed and is queued for retry.
+	statePending state = iota
+	// stateDropped means the dws request is dropped after MaxReconcileRetryAttempts.
+	stateDropped
+	// stateRejected means the super control plane rejected the object, e.g. by an admission plugin.
+	stateRejected
+)
+
+// Object is a tenant object the syncer fails to sync. The Reason is the reason of the API error
+// returned by the super control plane, e.g. Forbidden, rather than the error message.
+type Object struct {
+	Kind     string      `json:"kind"`
+	Name     string      `json:"name"`
+	Reason   string      `json:"reason,omitempty"`
+	Attempts int         `json:"attempts,omitempty"`
+	Since    metav1.Time `json:"since"`
+}
+
+// NamespaceStatus is the summary of the sync failures of a tenant namespace.
+type NamespaceStatus struct {
+	// Pending are the objects whose dws requests failed and are waiting for retry.
+	Pending []Object `json:"pending,omitempty"`
+	// Dropped are the objects whose dws requests are dropped after too many failures.
+	Dropped []Object `json:"dropped,omitempty"`
+	// Rejected are the objects the super control plane rejected.
+	Rejected []Object `json:"rejected,omitempty"`
+	// Mismatches are the mismatch counts found by the last scan of the checkers.
+	Mismatches map[string]int `json:"mismatches,omitempty"`
+}
+
+type objectKey struct {
+	cluster   string
+	namespace string
+	kind      string
+	name      string
+}
+
+type objectStatus struct {
+	state    state
+	reason   string
+	attempts int
+	since    time.Time
+}
+
+// Recorder records the sync failures of the tenant objects.
+type Recorder struct {
+	mu      sync.Mutex
+	objects map[objectKey]*objectStatus
+	// mismatches holds the last scan result of each checker.
+	mismatches map[string]*mismatchCounter
+	// scans holds the result of the ongoing scan of each checker.
+	scans map[string]*mismatchCounter
+}
+
+// DefaultRecorder is the Recorder shared by the dws controllers and the checkers of the syncer.
+var DefaultRecorder = NewRecorder()
+
+// NewRecorder returns an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{
+		objects:    make(map[objectKey]*objectStatus),
+		mismatches: make(map[string]*mismatchCounter),
+		scans:      make(map[string]*mismatchCounter),
+	}
+}
+
+// RecordPending records that the dws request of the object failed and is queued for retry.
+func (r *Recorder) RecordPending(kind, cluster, namespace, name string, attempts int, err error) {
+	r.record(objectKey{cluster: cluster, namespace: namespace, kind: kind, name: name}, statePending, attempts, err)
+}
+
+// RecordDropped records that the dws request of the object is dropped after too many failures.
+func (r *Recorder) RecordDropped(kind, cluster, namespace, name string, attempts int, err error) {
+	r.record(objectKey{cluster: cluster, namespace: namespace, kind: kind, name: name}, stateDropped, attempts, err)
+}
+
+// RecordRejected records that the super control plane rejected the object.
+func (r *Recorder) RecordRejected(kind, cluster, namespace, name string, err error) {
+	r.record(objectKey{cluster: cluster, namespace: namespace, kind: kind, name: name}, stateRejected, 0, err)
+}
+
+func (r *Recorder) record(key objectKey, s state, attempts int, err error) {
+	// the summary is per namespace, cluster scoped objects are not tracked.
+	if key.namespace == "" {
+		return
+	}
+	reason := reasonForError(err)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status, ok := r.objects[key]
+	if !ok || status.state != s {
+		status = &objectStatus{state: s, since: time.Now()}
+		r.objects[key] = status
+	}
+	status.reason = reason
+	status.attempts = attempts
+}
+
+// reasonForError maps the error to a fixed reason. The error message is not published, since it
+// names the super control plane namespaces and objects.
+func reasonForError(err error) string {
+	if err == nil {
+		return ""
+	}
+	var apiStatus apierrors.APIStatus
+	if errors.As(err, &apiStatus) {
+		switch status := apiStatus.Status(); {
+		case status.Reason != metav1.StatusReasonUnknown:
+			return string(status.Reason)
+		case status.Code == http.StatusForbidden:
+			return string(metav1.StatusReasonForbidden)
+		case status.Code == http.StatusBadRequest:
+			return string(metav1.StatusReasonBadRequest)
+		}
+	}
+	return reasonSyncFailed
+}
+
+// Forget removes the object once it is synced.
+func (r *Recorder) Forget(kind, cluster, namespace, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.objects, objectKey{cluster: cluster, namespace: namespace, kind: kind, name: name})
+}
+
+// RemoveCluster removes everything recorded for the cluster.
+func (r *Recorder) RemoveCluster(cluster string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key := range r.objects {
+		if key.cluster == cluster {
+			delete(r.objects, key)
+		}
+	}
+	for _, m := range r.mismatches {
+		m.removeCluster(cluster)
+	}
+	for _, m := range r.scans {
+		m.removeCluster(cluster)
+	}
+}
+
+// StartScan starts a new scan of the checker. The mismatches counted during the scan replace the
+// result of the last scan once FinishScan is called.
+func (r *Recorder) StartScan(checker string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.scans[checker] = newMismatchCounter()
+}
+
+// IncMismatch counts a mismatch of the tenant object in the namespace of the cluster found by the
+// ongoing scan of the checker.
+func (r *Recorder) IncMismatch(checker, cluster, namespace, counter string) {
+	r.mu.Lock()
+	scan, ok := r.scans[checker]
+	r.mu.Unlock()
+	if !ok {
+		return
+	}
+	scan.inc(cluster, namespace, counter)
+}
+
+// FinishScan replaces the last scan result of the checker with the ongoing scan.
+func (r *Recorder) FinishScan(checker string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if scan, ok := r.scans[checker]; ok {
+		r.mismatches[checker] = scan
+		delete(r.scans, checker)
+	}
+}
+
+// ClusterStatus returns the summary of each tenant namespace of the cluster that has sync failures.
+func (r *Recorder) ClusterStatus(cluster string) map[string]*NamespaceStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make(map[string]*NamespaceStatus)
+	get := func(namespace string) *NamespaceStatus {
+		if _, ok := result[namespace]; !ok {
+			result[namespace] = &NamespaceStatus{}
+		}
+		return result[namespace]
+	}
+
+	for key, status := range r.objects {
+		if key.cluster != cluster {
+			continue
+		}
+		ns := get(key.namespace)
+		obj := Object{
+			Kind:     key.kind,
+			Name:     key.name,
+			Reason:   status.reason,
+			Attempts: status.attempts,
+			Since:    metav1.NewTime(status.since),
+		}
+		switch status.state {
+		case statePending:
+			ns.Pending = append(ns.Pending, obj)
+		case stateDropped:
+			ns.Dropped = append(ns.Dropped, obj)
+		case stateRejected:
+			ns.Rejected = append(ns.Rejected, obj)
+		}
+	}
+
+	for _, m := range r.mismatches {
+		for namespace, counts := range m.clusterCounts(cluster) {
+			ns := get(namespace)
+			if ns.Mismatches == nil {
+				ns.Mismatches = make(map[string]int)
+			}
+			for counter, n := range counts {
+				ns.Mismatches[counter] += n
+			}
+		}
+	}
+
+	for _, ns := range result {
+		ns.Pending = sortAndTruncate(ns.Pending)
+		ns.Dropped = sortAndTruncate(ns.Dropped)
+		ns.Rejected = sortAndTruncate(ns.Rejected)
+	}
+	return result
+}
+
+func sortAndTruncate(objects []Object) []Object {
+	sort.Slice(objects, func(i, j int) bool {
+		if objects[i].Kind != objects[j].Kind {
+			return objects[i].Kind < objects[j].Kind
+		}
+		return objects[i].Name < objects[j].Name
+	})
+	if len(objects) > maxObjectsPerList {
+		objects = objects[:maxObjectsPerList]
+	}
+	return objects
+}
+
+// mismatchCounter counts the mismatches a checker finds in one scan by cluster, namespace
+// and counter name.
+type mismatchCounter struct {
+	mu     sync.Mutex
+	counts map[string]map[string]map[string]int
+}
+
+func newMismatchCounter() *mismatchCounter {
+	return &mismatchCounter{counts: make(map[string]map[string]map[string]int)}
+}
+
+func (m *mismatchCounter) inc(cluster, namespace, counter string) {
+	if cluster == "" || namespace == "" {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.counts[cluster]; !ok {
+		m.counts[cluster] = make(map[string]map[string]int)
+	}
+	if _, ok := m.counts[cluster][namespace]; !ok {
+		m.counts[cluster][namespace] = make(map[string]int)
+	}
+	m.counts[cluster][namespace][counter]++
+}
+
+func (m *mismatchCounter) clusterCounts(cluster string) map[string]map[string]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counts[cluster]
+}
+
+func (m *mismatchCounter) removeCluster(cluster string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.counts, cluster)
+}
+
+// IsStatusNamespace returns true if the tenant namespace is the one the syncer status is
+// published to, which is not synced to the super control plane.
+func IsStatusNamespace(namespace string) bool {
+	return featuregate.DefaultFeatureGate.Enabled(featuregate.TenantSyncerStatus) &&
+		namespace == constants.TenantSyncerStatusNamespace
+}
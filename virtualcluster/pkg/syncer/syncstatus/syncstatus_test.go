@@ -0,0 +1,151 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncstatus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
+)
+
+func TestClusterStatus(t *testing.T) {
+	r := NewRecorder()
+	r.RecordPending("Pod", "cluster1", "default", "pod-1", 2, errors.New("timeout"))
+	r.RecordPending("Pod", "cluster1", "default", "pod-2", 1, errors.New("timeout"))
+	r.RecordDropped("ConfigMap", "cluster1", "default", "cm-1", 16, errors.New("conflict"))
+	r.RecordRejected("Service", "cluster1", "test", "svc-1",
+		apierrors.NewForbidden(schema.GroupResource{Resource: "services"}, "svc-1", errors.New("denied by webhook in cluster1-test")))
+	r.RecordRejected("Namespace", "cluster1", "", "cluster-scoped", errors.New("ignored"))
+	r.RecordPending("Pod", "cluster2", "default", "pod-1", 1, errors.New("timeout"))
+	r.Forget("Pod", "cluster1", "default", "pod-2")
+
+	r.StartScan("Service")
+	r.IncMismatch("Service", "cluster1", "test", "SpecMissMatchedServices")
+	r.IncMismatch("Service", "cluster1", "test", "SpecMissMatchedServices")
+	r.IncMismatch("Service", "cluster2", "test", "SpecMissMatchedServices")
+	r.FinishScan("Service")
+	// the ongoing scan is not reported until it finishes
+	r.StartScan("Service")
+	r.IncMismatch("Service", "cluster1", "test", "SpecMissMatchedServices")
+
+	statuses := r.ClusterStatus("cluster1")
+	if len(statuses) != 2 {
+		t.Fatalf("expect 2 namespaces, got %d", len(statuses))
+	}
+	ns := statuses["default"]
+	if len(ns.Pending) != 1 || ns.Pending[0].Name != "pod-1" || ns.Pending[0].Attempts != 2 || ns.Pending[0].Reason != reasonSyncFailed {
+		t.Errorf("unexpected pending objects %+v", ns.Pending)
+	}
+	if len(ns.Dropped) != 1 || ns.Dropped[0].Name != "cm-1" {
+		t.Errorf("unexpected dropped objects %+v", ns.Dropped)
+	}
+	ns = statuses["test"]
+	if len(ns.Rejected) != 1 || ns.Rejected[0].Reason != string(metav1.StatusReasonForbidden) {
+		t.Errorf("unexpected rejected objects %+v", ns.Rejected)
+	}
+	if ns.Mismatches["SpecMissMatchedServices"] != 2 {
+		t.Errorf("expect 2 mismatches, got %v", ns.Mismatches)
+	}
+
+	// a failure after retries moves the object to the dropped list
+	r.RecordDropped("Pod", "cluster1", "default", "pod-1", 16, errors.New("timeout"))
+	ns = r.ClusterStatus("cluster1")["default"]
+	if len(ns.Pending) != 0 || len(ns.Dropped) != 2 {
+		t.Errorf("expect pod-1 to be dropped, got pending %+v, dropped %+v", ns.Pending, ns.Dropped)
+	}
+
+	r.RemoveCluster("cluster1")
+	if statuses := r.ClusterStatus("cluster1"); len(statuses) != 0 {
+		t.Errorf("expect the cluster to be removed, got %+v", statuses)
+	}
+	if statuses := r.ClusterStatus("cluster2"); len(statuses) != 2 {
+		t.Errorf("expect cluster2 to be kept, got %+v", statuses)
+	}
+}
+
+func TestClusterStatusTruncate(t *testing.T) {
+	r := NewRecorder()
+	for i := 0; i < maxObjectsPerList+10; i++ {
+		r.RecordPending("Pod", "cluster1", "default", fmt.Sprintf("pod-%03d", i), 1, errors.New("timeout"))
+	}
+	pending := r.ClusterStatus("cluster1")["default"].Pending
+	if len(pending) != maxObjectsPerList || pending[0].Name != "pod-000" {
+		t.Errorf("expect %d sorted objects, got %d", maxObjectsPerList, len(pending))
+	}
+}
+
+func TestPublish(t *testing.T) {
+	stale := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stale",
+			Namespace: constants.TenantSyncerStatusNamespace,
+			Labels:    map[string]string{constants.LabelSyncerStatus: "true"},
+		},
+	}
+	client := fake.NewSimpleClientset(stale)
+
+	statuses := map[string]*NamespaceStatus{
+		"default": {
+			Rejected:   []Object{{Kind: "Pod", Name: "pod-1", Reason: "denied by webhook"}},
+			Mismatches: map[string]int{"SpecMissMatchedPods": 1},
+		},
+	}
+	if err := Publish(context.TODO(), client, statuses); err != nil {
+		t.Fatalf("fail to publish: %v", err)
+	}
+	if _, err := client.CoreV1().Namespaces().Get(context.TODO(), constants.TenantSyncerStatusNamespace, metav1.GetOptions{}); err != nil {
+		t.Errorf("expect the status namespace to be created: %v", err)
+	}
+	cmClient := client.CoreV1().ConfigMaps(constants.TenantSyncerStatusNamespace)
+	if _, err := cmClient.Get(context.TODO(), "stale", metav1.GetOptions{}); err == nil {
+		t.Errorf("expect the stale configmap to be deleted")
+	}
+	cm, err := cmClient.Get(context.TODO(), "default", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expect the configmap of namespace default: %v", err)
+	}
+	if _, ok := cm.Data[PendingKey]; ok {
+		t.Errorf("expect no pending key, got %v", cm.Data)
+	}
+	var rejected []Object
+	if err := json.Unmarshal([]byte(cm.Data[RejectedKey]), &rejected); err != nil || len(rejected) != 1 || rejected[0].Name != "pod-1" {
+		t.Errorf("unexpected rejected objects %s: %v", cm.Data[RejectedKey], err)
+	}
+
+	// update the summary
+	statuses["default"].Rejected = nil
+	if err := Publish(context.TODO(), client, statuses); err != nil {
+		t.Fatalf("fail to publish: %v", err)
+	}
+	cm, err = cmClient.Get(context.TODO(), "default", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expect the configmap of namespace default: %v", err)
+	}
+	if _, ok := cm.Data[RejectedKey]; ok {
+		t.Errorf("expect the rejected key to be removed, got %v", cm.Data)
+	}
+}
@@ -99,6 +99,14 @@ const (
 	// Although rare, this situation can arise due to potential bugs and race conditions.
 	// This feature allows users to perform separate investigation and resolution.
 	SyncTenantPVCStatusPhase = "SyncTenantPVCStatusPhase"
+
+	// TenantSyncerStatus is an experimental feature that allows the syncer to publish
+	// a per-namespace summary of the sync failures inside each tenant cluster.
+	TenantSyncerStatus = "TenantSyncerStatus"
+
+	// TenantResourceCeiling is an experimental feature that allows the syncer to reject
+	// the tenant pods exceeding the ResourceCeiling of the VirtualCluster.
+	TenantResourceCeiling = "TenantResourceCeiling"
 )
 
 var defaultFeatures = FeatureList{
@@ -116,6 +124,8 @@ var defaultFeatures = FeatureList{
 	VServiceExternalIP:              {Default: false},
 	KubeAPIAccessSupport:            {Default: false},
 	SyncTenantPVCStatusPhase:        {Default: false},
+	TenantSyncerStatus:              {Default: false},
+	TenantResourceCeiling:           {Default: false},
 }
 
 type Feature string
@@ -41,6 +41,7 @@ import (
 
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/constants"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/metrics"
+	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/syncstatus"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/featuregate"
 	"sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/syncer/util/scheme"
 	utilconstants "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/constants"
@@ -425,6 +426,14 @@ func (c *MultiClusterController) processNextWorkItem() bool {
 		return true
 	}
 
+	if featuregate.DefaultFeatureGate.Enabled(featuregate.TenantSyncerStatus) {
+		if c.requestNamespace(req) == constants.TenantSyncerStatusNamespace {
+			// the syncer status published to the tenant cluster is not synced.
+			c.Queue.Forget(obj)
+			return true
+		}
+	}
+
 	if featuregate.DefaultFeatureGate.Enabled(featuregate.SuperClusterPooling) {
 		if c.FilterObjectFromSchedulingResult(req) {
 			c.Queue.Forget(req)
@@ -441,6 +450,7 @@ func (c *MultiClusterController) processNextWorkItem() bool {
 	result, err := c.Reconciler.Reconcile(req)
 	if err == nil {
 		metrics.RecordDWSOperationStatus(c.objectKind, req.ClusterName, utilconstants.StatusCodeOK)
+		syncstatus.DefaultRecorder.Forget(c.objectKind, req.ClusterName, c.requestNamespace(req), req.Name)
 		if result.RequeueAfter > 0 {
 			c.Queue.AddAfter(req, result.RequeueAfter)
 		} else if result.Requeue {
@@ -458,6 +468,7 @@ func (c *MultiClusterController) processNextWorkItem() bool {
 		if code := apierr.Status().Code; code == http.StatusBadRequest || code == http.StatusForbidden {
 			metrics.RecordDWSOperationStatus(c.objectKind, req.ClusterName, utilconstants.StatusCodeBadRequest)
 			klog.Errorf("%s dws request is rejected: %v", c.name, err)
+			syncstatus.DefaultRecorder.RecordRejected(c.objectKind, req.ClusterName, c.requestNamespace(req), req.Name, err)
 			c.Queue.Forget(obj)
 			return true
 		}
@@ -466,24 +477,29 @@ func (c *MultiClusterController) processNextWorkItem() bool {
 	// exceed max retry
 	if c.Queue.NumRequeues(obj) >= utilconstants.MaxReconcileRetryAttempts {
 		metrics.RecordDWSOperationStatus(c.objectKind, req.ClusterName, utilconstants.StatusCodeExceedMaxRetryAttempts)
+		syncstatus.DefaultRecorder.RecordDropped(c.objectKind, req.ClusterName, c.requestNamespace(req), req.Name, c.Queue.NumRequeues(obj)+1, err)
 		c.Queue.Forget(obj)
 		klog.Warningf("%s dws request is dropped due to reaching max retry limit: %+v", c.name, obj)
 		return true
 	}
 
 	metrics.RecordDWSOperationStatus(c.objectKind, req.ClusterName, utilconstants.StatusCodeError)
+	syncstatus.DefaultRecorder.RecordPending(c.objectKind, req.ClusterName, c.requestNamespace(req), req.Name, c.Queue.NumRequeues(obj)+1, err)
 	c.Queue.AddRateLimited(req)
 	klog.Errorf("%s dws request reconcile failed: %v", req, err)
 	return true
 }
 
-func (c *MultiClusterController) FilterObjectFromSchedulingResult(req reconciler.Request) bool {
-	var nsName string
+// requestNamespace returns the tenant namespace the request belongs to.
+func (c *MultiClusterController) requestNamespace(req reconciler.Request) string {
 	if c.objectKind == "Namespace" {
-		nsName = req.Name
-	} else {
-		nsName = req.Namespace
+		return req.Name
 	}
+	return req.Namespace
+}
+
+func (c *MultiClusterController) FilterObjectFromSchedulingResult(req reconciler.Request) bool {
+	nsName := c.requestNamespace(req)
 
 	if filterSuperClusterRelatedObject(c, req.ClusterName, nsName) {
 		return true
@@ -512,6 +528,9 @@ func filterSuperClusterRelatedObject(c *MultiClusterController, clusterName, nsN
 	return false
 }
 
+// filterSuperClusterSchedulePod filters out the pods that are not scheduled to this super cluster. A pod is
+// scheduled either by the slices of its namespace or individually if its namespace has no quota, in both
+// cases the scheduler records the result in the pod annotation.
 func filterSuperClusterSchedulePod(c *MultiClusterController, req reconciler.Request) bool {
 	pod := &corev1.Pod{}
 	if err := c.Get(req.ClusterName, req.Namespace, req.Name, pod); err != nil {
@@ -527,6 +546,8 @@ func filterSuperClusterSchedulePod(c *MultiClusterController, req reconciler.Req
 	return cname != utilconstants.SuperClusterID
 }
 
+// IsNamespaceScheduledToCluster checks whether the cluster is in the namespace placements. A cluster with
+// zero slices is still a placement since it hosts the individually scheduled pods of the namespace.
 func IsNamespaceScheduledToCluster(obj client.Object, clusterID string) error {
 	placements := make(map[string]int)
 	clist, ok := obj.GetAnnotations()[utilconstants.LabelScheduledPlacements]
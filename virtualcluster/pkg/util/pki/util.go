@@ -45,14 +45,18 @@ const (
 	RSAPrivateKeyBlockType = "RSA PRIVATE KEY"
 	rsaKeySize             = 2048
 
-	// CertificateValidity defines the validity for all the signed certificates generated by this package
+	// CertificateValidity defines the default validity for the signed certificates generated by this package
 	CertificateValidity = time.Hour * 24 * 365
+	// CAValidity defines the default validity for the certificate authorities generated by this package
+	CAValidity = CertificateValidity * 10
 )
 
-// CertConfig is a wrapper around certutil.Config extending it with PublicKeyAlgorithm.
+// CertConfig is a wrapper around certutil.Config extending it with PublicKeyAlgorithm and Validity.
 type CertConfig struct {
 	certutil.Config
 	PublicKeyAlgorithm x509.PublicKeyAlgorithm
+	// Validity is the validity of the certificate, CertificateValidity or CAValidity is used if not set.
+	Validity time.Duration
 }
 
 // NewCertificateAuthority creates new certificate and private key for the certificate authority
@@ -62,7 +66,7 @@ func NewCertificateAuthority(config *CertConfig) (*x509.Certificate, crypto.Sign
 		return nil, nil, errors.Wrap(err, "unable to create private key while generating CA certificate")
 	}
 
-	cert, err := certutil.NewSelfSignedCACert(config.Config, key)
+	cert, err := NewSelfSignedCACert(config, key)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "unable to create self-signed CA certificate")
 	}
@@ -70,6 +74,36 @@ func NewCertificateAuthority(config *CertConfig) (*x509.Certificate, crypto.Sign
 	return cert, key, nil
 }
 
+// NewSelfSignedCACert creates a CA certificate valid for config.Validity, or CAValidity if not set.
+func NewSelfSignedCACert(config *CertConfig, key crypto.Signer) (*x509.Certificate, error) {
+	validity := config.Validity
+	if validity == 0 {
+		validity = CAValidity
+	}
+	serial, err := cryptorand.Int(cryptorand.Reader, new(big.Int).SetInt64(math.MaxInt64))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	tmpl := x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:   config.CommonName,
+			Organization: config.Organization,
+		},
+		NotBefore:             now.UTC(),
+		NotAfter:              now.Add(validity).UTC(),
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	certDERBytes, err := x509.CreateCertificate(cryptorand.Reader, &tmpl, &tmpl, key.Public(), key)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(certDERBytes)
+}
+
 // NewCertAndKey creates new certificate and key by passing the certificate authority certificate and key
 func NewCertAndKey(caCert *x509.Certificate, caKey crypto.Signer, config *CertConfig) (*x509.Certificate, crypto.Signer, error) {
 	key, err := NewPrivateKey(config.PublicKeyAlgorithm)
@@ -107,6 +141,10 @@ func NewSignedCert(cfg *CertConfig, key crypto.Signer, caCert *x509.Certificate,
 		return nil, errors.New("must specify at least one ExtKeyUsage")
 	}
 
+	validity := cfg.Validity
+	if validity == 0 {
+		validity = CertificateValidity
+	}
 	certTmpl := x509.Certificate{
 		Subject: pkix.Name{
 			CommonName:   cfg.CommonName,
@@ -116,7 +154,7 @@ func NewSignedCert(cfg *CertConfig, key crypto.Signer, caCert *x509.Certificate,
 		IPAddresses:  cfg.AltNames.IPs,
 		SerialNumber: serial,
 		NotBefore:    caCert.NotBefore,
-		NotAfter:     time.Now().Add(CertificateValidity).UTC(),
+		NotAfter:     time.Now().Add(validity).UTC(),
 		KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
 		ExtKeyUsage:  cfg.Usages,
 	}
@@ -136,6 +174,7 @@ func EncodeCertPEM(cert *x509.Certificate) []byte {
 	return pem.EncodeToMemory(&block)
 }
 
+// DecodeCertPEM decodes the first certificate of PEM-encoded data
 func DecodeCertPEM(raw []byte) (*x509.Certificate, error) {
 	block, _ := pem.Decode(raw)
 	if block == nil {
@@ -0,0 +1,310 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package virtualcluster
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/keyutil"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	pkiutil "sigs.k8s.io/cluster-api-provider-nested/virtualcluster/pkg/util/pki"
+)
+
+const (
+	// CertModeSelfSigned makes every replica generate its own CA and serving certificate at startup.
+	CertModeSelfSigned = "self-signed"
+	// CertModeSecret keeps the CA and the serving certificate in a Secret shared by all replicas.
+	// The leader renews them before they expire and injects the CA bundle into the
+	// ValidatingWebhookConfiguration.
+	CertModeSecret = "secret"
+	// CertModeCertManager serves the certificate that cert-manager issues into the Secret. The
+	// Certificate must have the same name as the Secret, so the cert-manager CA injector can
+	// inject the CA bundle into the ValidatingWebhookConfiguration.
+	CertModeCertManager = "cert-manager"
+
+	// VCWebhookCertSecretName is the default Secret holding the serving certificate.
+	VCWebhookCertSecretName = "virtualcluster-webhook-certs" // #nosec G101 -- This is a secret name
+
+	caCertSecretKey = "ca.crt"
+	caKeySecretKey  = "ca.key"
+
+	certManagerInjectCAFromAnnotation = "cert-manager.io/inject-ca-from"
+
+	certSyncInterval   = time.Minute
+	certRotateInterval = 10 * time.Minute
+	certWaitTimeout    = 5 * time.Minute
+)
+
+// CertOptions configures how the serving certificate of the webhook server is provisioned.
+type CertOptions struct {
+	// Mode is one of CertModeSelfSigned, CertModeSecret and CertModeCertManager.
+	Mode string
+	// SecretName is the Secret in the webhook service namespace that holds the certificate,
+	// used by CertModeSecret and CertModeCertManager.
+	SecretName string
+	// RenewBefore is how long before expiry the certificates are renewed in CertModeSecret.
+	RenewBefore time.Duration
+}
+
+// certSecretSyncer writes the serving certificate in the Secret to the cert dir of the webhook
+// server, which reloads the files once they change. It runs on every replica.
+type certSecretSyncer struct {
+	reader  client.Reader
+	secret  types.NamespacedName
+	certDir string
+}
+
+func (s *certSecretSyncer) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		srt := &corev1.Secret{}
+		if err := s.reader.Get(ctx, s.secret, srt); err != nil {
+			log.Error(err, "fail to get the webhook certificate secret", "secret", s.secret)
+			return
+		}
+		if err := writeCertFiles(srt, s.certDir); err != nil {
+			log.Error(err, "fail to write the webhook certificate", "secret", s.secret)
+		}
+	}, certSyncInterval)
+	return nil
+}
+
+func (s *certSecretSyncer) NeedLeaderElection() bool {
+	return false
+}
+
+// certRotator renews the certificates in the Secret before they expire and keeps the CA bundle
+// of the ValidatingWebhookConfiguration in sync. It only runs on the leader.
+type certRotator struct {
+	client      client.Client
+	reader      client.Reader
+	secret      types.NamespacedName
+	renewBefore time.Duration
+}
+
+func (r *certRotator) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := r.rotate(ctx); err != nil {
+			log.Error(err, "fail to rotate the webhook certificate", "secret", r.secret)
+		}
+	}, certRotateInterval)
+	return nil
+}
+
+func (r *certRotator) NeedLeaderElection() bool {
+	return true
+}
+
+func (r *certRotator) rotate(ctx context.Context) error {
+	srt := &corev1.Secret{}
+	if err := r.reader.Get(ctx, r.secret, srt); err != nil {
+		return err
+	}
+	data, renewed, err := renewCertSecretData(srt.Data, time.Now(), r.renewBefore)
+	if err != nil {
+		return err
+	}
+	if renewed {
+		srt.Data = data
+		if err := r.client.Update(ctx, srt); err != nil {
+			return err
+		}
+		log.Info("renewed the webhook certificate", "secret", r.secret)
+	}
+	return injectCABundle(ctx, r.client, r.reader, srt.Data[caCertSecretKey])
+}
+
+// ensureCertSecret returns the Secret holding the certificates, and creates it if it does not exist.
+func ensureCertSecret(ctx context.Context, c client.Client, reader client.Reader, key types.NamespacedName, renewBefore time.Duration) (*corev1.Secret, error) {
+	srt := &corev1.Secret{}
+	err := reader.Get(ctx, key, srt)
+	if !apierrors.IsNotFound(err) {
+		return srt, err
+	}
+	data, _, err := renewCertSecretData(nil, time.Now(), renewBefore)
+	if err != nil {
+		return nil, err
+	}
+	srt = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+			Labels: map[string]string{
+				"virtualcluster-webhook": "true",
+			},
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: data,
+	}
+	err = c.Create(ctx, srt)
+	if apierrors.IsAlreadyExists(err) {
+		// another replica created it first
+		srt = &corev1.Secret{}
+		err = reader.Get(ctx, key, srt)
+	}
+	return srt, err
+}
+
+// waitForCertSecret waits for the Secret issued by cert-manager.
+func waitForCertSecret(ctx context.Context, reader client.Reader, key types.NamespacedName) (*corev1.Secret, error) {
+	srt := &corev1.Secret{}
+	err := wait.PollImmediate(5*time.Second, certWaitTimeout, func() (bool, error) {
+		if err := reader.Get(ctx, key, srt); err != nil {
+			return false, client.IgnoreNotFound(err)
+		}
+		return len(srt.Data[corev1.TLSCertKey]) != 0 && len(srt.Data[corev1.TLSPrivateKeyKey]) != 0, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fail to wait for secret %s: %v", key, err)
+	}
+	return srt, nil
+}
+
+// renewCertSecretData returns the certificates renewed if they expire within renewBefore.
+// The previous CA is kept in the CA bundle until it expires, so that the clients trust
+// the replicas that still serve the certificate signed by it.
+func renewCertSecretData(data map[string][]byte, now time.Time, renewBefore time.Duration) (map[string][]byte, bool, error) {
+	deadline := now.Add(renewBefore)
+	caCerts, caKey := parseCA(data)
+	renewed := false
+
+	if len(caCerts) == 0 || caKey == nil || caCerts[0].NotAfter.Before(deadline) {
+		caCert, key, err := pkiutil.NewCertificateAuthority(&pkiutil.CertConfig{
+			Config: certutil.Config{
+				CommonName:   VCWebhookCertCommonName,
+				Organization: []string{VCWebhookCertOrg},
+			},
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		bundle := []*x509.Certificate{caCert}
+		for _, previous := range caCerts {
+			if previous.NotAfter.After(now) {
+				bundle = append(bundle, previous)
+			}
+		}
+		caCerts, caKey = bundle, key
+		renewed = true
+	}
+
+	cert := parseServingCert(data)
+	if renewed || cert == nil || cert.NotAfter.Before(deadline) || cert.CheckSignatureFrom(caCerts[0]) != nil {
+		servingCert, key, err := pkiutil.NewCertAndKey(caCerts[0], caKey, &pkiutil.CertConfig{
+			Config: certutil.Config{
+				CommonName:   VCWebhookServiceName + "." + VCWebhookServiceNs + ".svc",
+				Organization: []string{VCWebhookCertOrg},
+				AltNames: certutil.AltNames{
+					DNSNames: []string{
+						VCWebhookServiceName,
+						VCWebhookServiceName + "." + VCWebhookServiceNs,
+						VCWebhookServiceName + "." + VCWebhookServiceNs + ".svc",
+					},
+				},
+				Usages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			},
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		keyPEM, err := keyutil.MarshalPrivateKeyToPEM(key)
+		if err != nil {
+			return nil, false, err
+		}
+		caKeyPEM, err := keyutil.MarshalPrivateKeyToPEM(caKey)
+		if err != nil {
+			return nil, false, err
+		}
+		var caPEM []byte
+		for _, each := range caCerts {
+			caPEM = append(caPEM, pkiutil.EncodeCertPEM(each)...)
+		}
+		return map[string][]byte{
+			caCertSecretKey:         caPEM,
+			caKeySecretKey:          caKeyPEM,
+			corev1.TLSCertKey:       pkiutil.EncodeCertPEM(servingCert),
+			corev1.TLSPrivateKeyKey: keyPEM,
+		}, true, nil
+	}
+	return data, false, nil
+}
+
+// parseCA returns the CA certificates in the bundle, the first one is the current CA which
+// signs the serving certificate.
+func parseCA(data map[string][]byte) ([]*x509.Certificate, crypto.Signer) {
+	caCerts, err := certutil.ParseCertsPEM(data[caCertSecretKey])
+	if err != nil {
+		return nil, nil
+	}
+	key, err := keyutil.ParsePrivateKeyPEM(data[caKeySecretKey])
+	if err != nil {
+		return nil, nil
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, nil
+	}
+	return caCerts, signer
+}
+
+func parseServingCert(data map[string][]byte) *x509.Certificate {
+	certs, err := certutil.ParseCertsPEM(data[corev1.TLSCertKey])
+	if err != nil || len(certs) == 0 {
+		return nil
+	}
+	return certs[0]
+}
+
+// writeCertFiles writes the serving certificate and key in the Secret to certDir if they changed.
+func writeCertFiles(srt *corev1.Secret, certDir string) error {
+	if err := os.MkdirAll(certDir, 0755); err != nil {
+		return fmt.Errorf("could not create directory %q to store certificates: %v", certDir, err)
+	}
+	for fileName, key := range map[string]string{
+		VCWebhookCertFileName: corev1.TLSCertKey,
+		VCWebhookKeyFileName:  corev1.TLSPrivateKeyKey,
+	} {
+		content, ok := srt.Data[key]
+		if !ok {
+			return fmt.Errorf("secret %s/%s has no key %s", srt.Namespace, srt.Name, key)
+		}
+		path := filepath.Join(certDir, fileName)
+		current, err := ioutil.ReadFile(filepath.Clean(path))
+		if err == nil && bytes.Equal(current, content) {
+			continue
+		}
+		if err := ioutil.WriteFile(path, content, 0600); err != nil {
+			return err
+		}
+		log.Info("updated the webhook certificate file", "path", path)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package virtualcluster
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+const renewBefore = 30 * 24 * time.Hour
+
+func TestRenewCertSecretData(t *testing.T) {
+	now := time.Now()
+	data, renewed, err := renewCertSecretData(nil, now, renewBefore)
+	if err != nil {
+		t.Fatalf("fail to issue the certificates: %v", err)
+	}
+	if !renewed {
+		t.Fatalf("expect the certificates to be issued")
+	}
+	for _, key := range []string{caCertSecretKey, caKeySecretKey, corev1.TLSCertKey, corev1.TLSPrivateKeyKey} {
+		if len(data[key]) == 0 {
+			t.Errorf("expect %s to be set", key)
+		}
+	}
+	caCerts, _ := parseCA(data)
+	cert := parseServingCert(data)
+	if len(caCerts) != 1 || cert == nil {
+		t.Fatalf("expect one ca and a serving cert, got %d ca and %v", len(caCerts), cert)
+	}
+	if err := cert.CheckSignatureFrom(caCerts[0]); err != nil {
+		t.Errorf("expect the serving cert to be signed by the ca: %v", err)
+	}
+
+	// fresh certificates are kept
+	if _, renewed, err := renewCertSecretData(data, now, renewBefore); err != nil || renewed {
+		t.Errorf("expect the certificates to be kept, renewed %v, err %v", renewed, err)
+	}
+
+	// the serving cert is renewed near expiry, the ca is kept
+	certRenewed, renewed, err := renewCertSecretData(data, cert.NotAfter.Add(-time.Hour), renewBefore)
+	if err != nil || !renewed {
+		t.Fatalf("expect the serving cert to be renewed, renewed %v, err %v", renewed, err)
+	}
+	if !bytes.Equal(certRenewed[caCertSecretKey], data[caCertSecretKey]) {
+		t.Errorf("expect the ca to be kept")
+	}
+	if bytes.Equal(certRenewed[corev1.TLSCertKey], data[corev1.TLSCertKey]) {
+		t.Errorf("expect the serving cert to be renewed")
+	}
+
+	// the ca is rotated near expiry, the previous ca stays in the bundle
+	caRenewed, renewed, err := renewCertSecretData(data, caCerts[0].NotAfter.Add(-time.Hour), renewBefore)
+	if err != nil || !renewed {
+		t.Fatalf("expect the ca to be rotated, renewed %v, err %v", renewed, err)
+	}
+	bundle, err := certutil.ParseCertsPEM(caRenewed[caCertSecretKey])
+	if err != nil {
+		t.Fatalf("fail to parse the ca bundle: %v", err)
+	}
+	if len(bundle) != 2 || !bundle[1].Equal(caCerts[0]) {
+		t.Errorf("expect the previous ca to be kept in the bundle, got %d certs", len(bundle))
+	}
+	if err := parseServingCert(caRenewed).CheckSignatureFrom(bundle[0]); err != nil {
+		t.Errorf("expect the serving cert to be signed by the new ca: %v", err)
+	}
+}
+
+func TestWriteCertFiles(t *testing.T) {
+	certDir, err := ioutil.TempDir("", "webhook-certs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(certDir)
+
+	srt := &corev1.Secret{
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       []byte("cert"),
+			corev1.TLSPrivateKeyKey: []byte("key"),
+		},
+	}
+	if err := writeCertFiles(srt, certDir); err != nil {
+		t.Fatalf("fail to write the cert files: %v", err)
+	}
+	for fileName, want := range map[string]string{VCWebhookCertFileName: "cert", VCWebhookKeyFileName: "key"} {
+		got, err := ioutil.ReadFile(filepath.Join(certDir, fileName))
+		if err != nil || string(got) != want {
+			t.Errorf("expect %s to be %q, got %q, err %v", fileName, want, got, err)
+		}
+	}
+
+	delete(srt.Data, corev1.TLSPrivateKeyKey)
+	if err := writeCertFiles(srt, certDir); err == nil {
+		t.Errorf("expect an error for a secret without the key")
+	}
+}
@@ -34,6 +34,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -57,7 +58,14 @@ const (
 
 var (
 	VCWebhookServiceNs string
-	log                = logf.Log.WithName("virtualcluster-webhook")
+	// WebhookCertOptions configures the serving certificate of the webhook server, it
+	// must be set before Add is called.
+	WebhookCertOptions = CertOptions{
+		Mode:        CertModeSelfSigned,
+		SecretName:  VCWebhookCertSecretName,
+		RenewBefore: 720 * time.Hour,
+	}
+	log = logf.Log.WithName("virtualcluster-webhook")
 )
 
 func init() {
@@ -76,21 +84,68 @@ func Add(mgr manager.Manager, certDir string) error {
 		return fmt.Errorf("fail to create virtualcluster webhook service: %s", err)
 	}
 
-	// 2. generate the serving certificate for the webhook server
-	caPEM, genCrtErr := genCertificate(certDir)
-	if genCrtErr != nil {
-		return fmt.Errorf("fail to generate certificates for webhook server: %s", genCrtErr)
+	// 2. provision the serving certificate for the webhook server
+	var (
+		caPEM        []byte
+		injectCAFrom string
+	)
+	secretKey := types.NamespacedName{Namespace: VCWebhookServiceNs, Name: WebhookCertOptions.SecretName}
+	switch WebhookCertOptions.Mode {
+	case CertModeSelfSigned:
+		var genCrtErr error
+		caPEM, genCrtErr = genCertificate(certDir)
+		if genCrtErr != nil {
+			return fmt.Errorf("fail to generate certificates for webhook server: %s", genCrtErr)
+		}
+	case CertModeSecret, CertModeCertManager:
+		var (
+			srt *corev1.Secret
+			err error
+		)
+		if WebhookCertOptions.Mode == CertModeSecret {
+			srt, err = ensureCertSecret(context.TODO(), mgr.GetClient(), mgr.GetAPIReader(), secretKey, WebhookCertOptions.RenewBefore)
+		} else {
+			srt, err = waitForCertSecret(context.TODO(), mgr.GetAPIReader(), secretKey)
+			injectCAFrom = secretKey.String()
+		}
+		if err != nil {
+			return fmt.Errorf("fail to get the certificates for webhook server: %s", err)
+		}
+		if WebhookCertOptions.Mode == CertModeSecret {
+			caPEM = srt.Data[caCertSecretKey]
+		}
+		if err := writeCertFiles(srt, certDir); err != nil {
+			return fmt.Errorf("fail to write certificates for webhook server: %s", err)
+		}
+		if err := mgr.Add(&certSecretSyncer{reader: mgr.GetAPIReader(), secret: secretKey, certDir: certDir}); err != nil {
+			return err
+		}
+		if WebhookCertOptions.Mode == CertModeSecret {
+			if err := mgr.Add(&certRotator{
+				client:      mgr.GetClient(),
+				reader:      mgr.GetAPIReader(),
+				secret:      secretKey,
+				renewBefore: WebhookCertOptions.RenewBefore,
+			}); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unknown webhook certificate mode %s", WebhookCertOptions.Mode)
 	}
 
 	// 3. create the ValidatingWebhookConfiguration
 	log.Info(fmt.Sprintf("will create validatingwebhookconfiguration/%s", VCWebhookCfgName))
-	if err := createValidatingWebhookConfiguration(mgr.GetClient(), caPEM); err != nil {
+	if err := createValidatingWebhookConfiguration(mgr.GetClient(), mgr.GetAPIReader(), caPEM, injectCAFrom); err != nil {
 		return fmt.Errorf("fail to create validating webhook configuration: %s", err)
 	}
 	log.Info(fmt.Sprintf("successfully created validatingwebhookconfiguration/%s", VCWebhookCfgName))
 
-	// 4. register the validating webhook
-	return (&tenancyv1alpha1.VirtualCluster{}).SetupWebhookWithManager(mgr)
+	// 4. register the validating webhooks
+	if err := (&tenancyv1alpha1.VirtualCluster{}).SetupWebhookWithManager(mgr); err != nil {
+		return err
+	}
+	return (&tenancyv1alpha1.ClusterVersion{}).SetupWebhookWithManager(mgr)
 }
 
 // createVirtualClusterWebhookService creates the service for exposing the webhook server
@@ -126,9 +181,11 @@ func createVirtualClusterWebhookService(client client.Client) error {
 	return nil
 }
 
-// createValidatingWebhookConfiguration creates the validatingwebhookconfiguration for the webhook
-func createValidatingWebhookConfiguration(client client.Client, caPEM []byte) error {
+// createValidatingWebhookConfiguration creates the validatingwebhookconfiguration for the webhook.
+// If injectCAFrom is set, the CA bundle is injected by the cert-manager CA injector instead.
+func createValidatingWebhookConfiguration(client client.Client, reader client.Reader, caPEM []byte, injectCAFrom string) error {
 	validatePath := "/validate-tenancy-x-k8s-io-v1alpha1-virtualcluster"
+	cvValidatePath := "/validate-tenancy-x-k8s-io-v1alpha1-clusterversion"
 	svcPort := int32(constants.VirtualClusterWebhookPort)
 	// reject request if the webhook doesn't work
 	failPolicy := admv1.Fail
@@ -170,30 +227,90 @@ func createValidatingWebhookConfiguration(client client.Client, caPEM []byte) er
 					},
 				},
 			},
+			{
+				Name: "clusterversion.validating.webhook",
+				ClientConfig: admv1.WebhookClientConfig{
+					Service: &admv1.ServiceReference{
+						Name:      VCWebhookServiceName,
+						Namespace: VCWebhookServiceNs,
+						Path:      &cvValidatePath,
+						Port:      &svcPort,
+					},
+					CABundle: caPEM,
+				},
+				FailurePolicy:           &failPolicy,
+				SideEffects:             &sideEffortsNone,
+				AdmissionReviewVersions: []string{"v1", "v1beta1"},
+
+				Rules: []admv1.RuleWithOperations{
+					{
+						Operations: []admv1.OperationType{
+							admv1.Create,
+							admv1.Update,
+						},
+						Rule: admv1.Rule{
+							APIGroups:   []string{"tenancy.x-k8s.io"},
+							APIVersions: []string{"v1alpha1"},
+							Resources:   []string{"clusterversions"},
+						},
+					},
+				},
+			},
 		},
 	}
 
+	if injectCAFrom != "" {
+		vwhCfg.Annotations = map[string]string{
+			certManagerInjectCAFromAnnotation: injectCAFrom,
+		}
+	}
+
 	if err := client.Create(context.TODO(), &vwhCfg); err != nil {
 		if !apierrors.IsAlreadyExists(err) {
 			return err
 		}
-		log.Info(fmt.Sprintf("validatingwebhookconfiguration/%s already exist, need remove firstly", VCWebhookCfgName))
-		err := client.Delete(context.TODO(), &vwhCfg)
-		if err != nil {
+		log.Info(fmt.Sprintf("validatingwebhookconfiguration/%s already exist, will update it", VCWebhookCfgName))
+		existing := &admv1.ValidatingWebhookConfiguration{}
+		if err := reader.Get(context.TODO(), types.NamespacedName{Name: VCWebhookCfgName}, existing); err != nil {
 			return err
 		}
-
-		err = client.Create(context.TODO(), &vwhCfg)
-		if err != nil {
-			return err
+		if injectCAFrom != "" {
+			// keep the CA bundle injected by cert-manager
+			injected := make(map[string][]byte, len(existing.Webhooks))
+			for _, wh := range existing.Webhooks {
+				injected[wh.Name] = wh.ClientConfig.CABundle
+			}
+			for i := range vwhCfg.Webhooks {
+				vwhCfg.Webhooks[i].ClientConfig.CABundle = injected[vwhCfg.Webhooks[i].Name]
+			}
 		}
-
-		return nil
+		vwhCfg.ResourceVersion = existing.ResourceVersion
+		return client.Update(context.TODO(), &vwhCfg)
 	}
 	log.Info(fmt.Sprintf("successfully created validatingwebhookconfiguration/%s", VCWebhookCfgName))
 	return nil
 }
 
+// injectCABundle sets the CA bundle of the webhooks in the validatingwebhookconfiguration.
+func injectCABundle(ctx context.Context, c client.Client, reader client.Reader, caPEM []byte) error {
+	vwhCfg := &admv1.ValidatingWebhookConfiguration{}
+	if err := reader.Get(ctx, types.NamespacedName{Name: VCWebhookCfgName}, vwhCfg); err != nil {
+		return err
+	}
+	updated := false
+	for i := range vwhCfg.Webhooks {
+		if !bytes.Equal(vwhCfg.Webhooks[i].ClientConfig.CABundle, caPEM) {
+			vwhCfg.Webhooks[i].ClientConfig.CABundle = caPEM
+			updated = true
+		}
+	}
+	if !updated {
+		return nil
+	}
+	log.Info(fmt.Sprintf("injecting the CA bundle into validatingwebhookconfiguration/%s", VCWebhookCfgName))
+	return c.Update(ctx, vwhCfg)
+}
+
 // genCertificate generates the serving cerficiate for the webhook server
 func genCertificate(certDir string) ([]byte, error) {
 	caPEM, certPEM, keyPEM, err := genSelfSignedCert()